@@ -1,12 +1,16 @@
 package backofficev1
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"github.com/abdelrahman146/digital-wallet/internal/service"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/abdelrahman146/digital-wallet/pkg/validator"
 	"github.com/gofiber/fiber/v2"
+	"time"
 )
 
 type accountHandler struct {
@@ -27,8 +31,14 @@ func (h *accountHandler) Setup(appGroup fiber.Router) {
 	group.Get("/sum", h.GetWalletAccountsSum)
 	group.Get("/:accountId", h.GetAccountByID)
 	group.Delete("/:accountId", h.DeleteAccount)
+	group.Post("/:accountId/freeze", h.FreezeAccount)
+	group.Post("/:accountId/unfreeze", h.UnfreezeAccount)
 	group.Get("/:accountId/transactions", h.GetAccountTransactionsByID)
 	group.Post("/:accountId/transactions/sum", h.GetAccountTransactionsSum)
+	group.Get("/:accountId/statement", h.GetAccountStatement)
+	group.Get("/:accountId/balance", h.GetAccountBalance)
+	group.Get("/:accountId/lots", h.GetAccountLots)
+	group.Get("/:accountId/transactions/:transactionId/consumptions", h.GetTransactionConsumptions)
 }
 
 // GetWalletAccounts retrieves all accounts of a wallet
@@ -134,6 +144,16 @@ func (h *accountHandler) CreateAccount(c *fiber.Ctx) error {
 // @Param accountId path string true "Account ID"
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
+// @Param type query string false "Transaction type"
+// @Param reason query string false "Transaction reason"
+// @Param programId query string false "Program ID"
+// @Param minAmount query int false "Minimum amount"
+// @Param maxAmount query int false "Maximum amount"
+// @Param from query string false "Created from (RFC 3339)"
+// @Param to query string false "Created before (RFC 3339)"
+// @Param metadataKey query string false "Metadata key"
+// @Param metadataValue query string false "Metadata value"
+// @Param cursor query string false "Cursor of the next page, instead of page"
 // @Success 200 {object} api.SuccessResponse{result=[]model.Transaction}
 // @Failure 400 {object} api.ErrorResponse
 func (h *accountHandler) GetAccountTransactionsByID(c *fiber.Ctx) error {
@@ -143,7 +163,11 @@ func (h *accountHandler) GetAccountTransactionsByID(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	transactions, err := h.services.Transaction.GetAccountTransactions(c.Context(), walletId, id, page, limit)
+	filter := new(service.TransactionFilterRequest)
+	if err := c.QueryParser(filter); err != nil {
+		return errs.NewBadRequestError("Invalid query params", "INVALID_QUERY_PARAMS", err)
+	}
+	transactions, err := h.services.Transaction.GetAccountTransactions(c.Context(), walletId, id, filter, page, limit)
 	if err != nil {
 		return err
 	}
@@ -176,6 +200,16 @@ func (h *accountHandler) DeleteAccount(c *fiber.Ctx) error {
 // @Param accountId path string true "Account ID"
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
+// @Param type query string false "Transaction type"
+// @Param reason query string false "Transaction reason"
+// @Param programId query string false "Program ID"
+// @Param minAmount query int false "Minimum amount"
+// @Param maxAmount query int false "Maximum amount"
+// @Param from query string false "Created from (RFC 3339)"
+// @Param to query string false "Created before (RFC 3339)"
+// @Param metadataKey query string false "Metadata key"
+// @Param metadataValue query string false "Metadata value"
+// @Param cursor query string false "Cursor of the next page, instead of page"
 // @Success 200 {object} api.SuccessResponse{result=[]model.Transaction}
 // @Failure 400 {object} api.ErrorResponse
 // @Router /backoffice/wallets/{walletId}/accounts/{accountId}/transactions [get]
@@ -186,7 +220,11 @@ func (h *accountHandler) GetAccountTransactions(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	transactions, err := h.services.Transaction.GetAccountTransactions(c.Context(), walletId, accountId, page, limit)
+	filter := new(service.TransactionFilterRequest)
+	if err := c.QueryParser(filter); err != nil {
+		return errs.NewBadRequestError("Invalid query params", "INVALID_QUERY_PARAMS", err)
+	}
+	transactions, err := h.services.Transaction.GetAccountTransactions(c.Context(), walletId, accountId, filter, page, limit)
 	if err != nil {
 		return err
 	}
@@ -211,3 +249,182 @@ func (h *accountHandler) GetAccountTransactionsSum(c *fiber.Ctx) error {
 	}
 	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(sum))
 }
+
+// GetAccountStatement retrieves the statement of an account over a period
+// @Summary Get the statement of an account
+// @Description Get the opening and closing balances of an account over a period, its transactions with their running balance and its totals by reason, as json, csv or pdf. json and csv statements are streamed and end with an error marker if they fail part way. pdf statements over 2000 transactions are split into several documents streamed in a zip archive
+// @Tags Account
+// @Produce json
+// @Produce text/csv
+// @Produce application/pdf
+// @Produce application/zip
+// @Param walletId path string true "Wallet ID"
+// @Param accountId path string true "Account ID"
+// @Param from query string true "Period start (RFC 3339)"
+// @Param to query string true "Period end, exclusive (RFC 3339)"
+// @Param format query string false "json, csv or pdf"
+// @Success 200 {object} api.SuccessResponse{result=service.AccountStatement}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/accounts/{accountId}/statement [get]
+func (h *accountHandler) GetAccountStatement(c *fiber.Ctx) error {
+	accountId := c.Params("accountId")
+	walletId := c.Params("walletId")
+	req := new(service.StatementRequest)
+	if err := c.QueryParser(req); err != nil {
+		return errs.NewBadRequestError("Invalid query params", "INVALID_QUERY_PARAMS", err)
+	}
+	statement, err := h.services.Account.GetAccountStatement(c.Context(), walletId, accountId, req)
+	if err != nil {
+		return err
+	}
+	extension := statement.Format
+	if statement.Archived {
+		extension = "zip"
+	}
+	filename := fmt.Sprintf(`attachment; filename="statement-%s-%s.%s"`, accountId, statement.From.Format("20060102"), extension)
+	if statement.Format == "pdf" && !statement.Archived {
+		// a single pdf document is bounded and rendered before responding, so a failure still gets its error status
+		var body bytes.Buffer
+		if err := h.services.Account.WriteAccountStatement(c.Context(), statement, &body); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, filename)
+		return c.Status(fiber.StatusOK).Send(body.Bytes())
+	}
+	// json, csv and split pdf statements are streamed, a failure once started ends them with an error marker
+	switch {
+	case statement.Format == "json":
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	case statement.Archived:
+		c.Set(fiber.HeaderContentType, "application/zip")
+		c.Set(fiber.HeaderContentDisposition, filename)
+	default:
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, filename)
+	}
+	ctx := c.Context()
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.services.Account.WriteAccountStatement(ctx, statement, w); err != nil {
+			api.GetLogger(ctx).Error("Failed to stream statement", logger.Field("error", err), logger.Field("accountId", accountId))
+		}
+		if err := w.Flush(); err != nil {
+			api.GetLogger(ctx).Error("Failed to flush statement", logger.Field("error", err), logger.Field("accountId", accountId))
+		}
+	})
+	return nil
+}
+
+// GetAccountBalance retrieves the balance of an account at a given time
+// @Summary Get the balance of an account at a given time
+// @Description Get the balance an account had at the given time, including the transactions made at that time, defaults to now
+// @Tags Account
+// @Produce json
+// @Param walletId path string true "Wallet ID"
+// @Param accountId path string true "Account ID"
+// @Param at query string false "RFC3339 time"
+// @Success 200 {object} api.SuccessResponse{result=service.AccountBalance}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/accounts/{accountId}/balance [get]
+func (h *accountHandler) GetAccountBalance(c *fiber.Ctx) error {
+	at := time.Now()
+	if c.Query("at") != "" {
+		parsed, err := time.Parse(time.RFC3339, c.Query("at"))
+		if err != nil {
+			return errs.NewBadRequestError("invalid at", "INVALID_AT_PARAM", err)
+		}
+		at = parsed
+	}
+	balance, err := h.services.Account.GetBalanceAt(c.Context(), c.Params("walletId"), c.Params("accountId"), at)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(balance))
+}
+
+// GetAccountLots retrieves the open credit lots of an account
+// @Summary Get the open credit lots of an account
+// @Description Get the credits of an account that still have an available amount, with their expiry dates, in the order debits consume them
+// @Tags Account
+// @Produce json
+// @Param walletId path string true "Wallet ID"
+// @Param accountId path string true "Account ID"
+// @Success 200 {object} api.SuccessResponse{result=[]service.CreditLot}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/accounts/{accountId}/lots [get]
+func (h *accountHandler) GetAccountLots(c *fiber.Ctx) error {
+	lots, err := h.services.Transaction.GetAccountLots(c.Context(), c.Params("walletId"), c.Params("accountId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(lots))
+}
+
+// GetTransactionConsumptions retrieves the consumption trace of a transaction
+// @Summary Get the consumption trace of a transaction
+// @Description Get the credit lots a debit drew from, or the debits that drew from a credit, with the amounts
+// @Tags Account
+// @Produce json
+// @Param walletId path string true "Wallet ID"
+// @Param accountId path string true "Account ID"
+// @Param transactionId path string true "Transaction ID"
+// @Success 200 {object} api.SuccessResponse{result=[]model.TransactionConsumption}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/accounts/{accountId}/transactions/{transactionId}/consumptions [get]
+func (h *accountHandler) GetTransactionConsumptions(c *fiber.Ctx) error {
+	consumptions, err := h.services.Transaction.GetTransactionConsumptions(c.Context(), c.Params("walletId"), c.Params("accountId"), c.Params("transactionId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(consumptions))
+}
+
+// FreezeAccount freezes an account
+// @Summary Freeze an account
+// @Description Freeze an account so it can no longer be debited, recording the reason in the audit log
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param walletId path string true "Wallet ID"
+// @Param accountId path string true "Account ID"
+// @Param request body service.AccountStatusRequest true "Account Status Request"
+// @Success 200 {object} api.SuccessResponse{result=model.Account}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/accounts/{accountId}/freeze [post]
+func (h *accountHandler) FreezeAccount(c *fiber.Ctx) error {
+	var req service.AccountStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	account, err := h.services.Account.FreezeAccount(c.Context(), c.Params("accountId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(account))
+}
+
+// UnfreezeAccount unfreezes an account
+// @Summary Unfreeze an account
+// @Description Unfreeze a frozen account, recording the reason in the audit log
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param walletId path string true "Wallet ID"
+// @Param accountId path string true "Account ID"
+// @Param request body service.AccountStatusRequest true "Account Status Request"
+// @Success 200 {object} api.SuccessResponse{result=model.Account}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/accounts/{accountId}/unfreeze [post]
+func (h *accountHandler) UnfreezeAccount(c *fiber.Ctx) error {
+	var req service.AccountStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	account, err := h.services.Account.UnfreezeAccount(c.Context(), c.Params("accountId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(account))
+}
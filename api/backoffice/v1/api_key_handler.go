@@ -0,0 +1,143 @@
+package backofficev1
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/service"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+type apiKeyHandler struct {
+	services *service.Services
+}
+
+func NewApiKeyHandler(appGroup fiber.Router, services *service.Services) {
+	handler := &apiKeyHandler{services: services}
+	handler.Setup(appGroup)
+}
+
+func (h *apiKeyHandler) Setup(appGroup fiber.Router) {
+	group := appGroup.Group("/api-keys")
+	group.Post("/", h.CreateApiKey)
+	group.Get("/", h.GetApiKeys)
+	group.Get("/:apiKeyId", h.GetApiKey)
+	group.Put("/:apiKeyId", h.UpdateApiKey)
+	group.Post("/:apiKeyId/rotate", h.RotateApiKey)
+	group.Delete("/:apiKeyId", h.DeleteApiKey)
+}
+
+// CreateApiKey creates a new API key
+// @Summary Create a new API key
+// @Description Issue an API key for an internal service, scoped to wallets and permissions. The key is only returned once
+// @Tags ApiKey
+// @Accept json
+// @Produce json
+// @Param apiKey body service.CreateApiKeyRequest true "Create API Key Request"
+// @Success 201 {object} api.SuccessResponse{result=service.ApiKeyResponse}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/api-keys [post]
+func (h *apiKeyHandler) CreateApiKey(c *fiber.Ctx) error {
+	var req service.CreateApiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	apiKey, err := h.services.ApiKey.CreateApiKey(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(api.NewSuccessResponse(apiKey))
+}
+
+// GetApiKeys retrieves all API keys
+// @Summary Get all API keys
+// @Description Get all API keys, without the keys themselves
+// @Tags ApiKey
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} api.SuccessResponse{result=api.List[model.ApiKey]}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/api-keys [get]
+func (h *apiKeyHandler) GetApiKeys(c *fiber.Ctx) error {
+	page, limit, err := api.GetPageAndLimit(c)
+	if err != nil {
+		return err
+	}
+	apiKeys, err := h.services.ApiKey.GetApiKeys(c.Context(), page, limit)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(apiKeys))
+}
+
+// GetApiKey retrieves an API key by its ID
+// @Summary Get an API key by its ID
+// @Description Get an API key, its scope and when it was last used
+// @Tags ApiKey
+// @Param apiKeyId path string true "API Key ID"
+// @Success 200 {object} api.SuccessResponse{result=model.ApiKey}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/api-keys/{apiKeyId} [get]
+func (h *apiKeyHandler) GetApiKey(c *fiber.Ctx) error {
+	apiKey, err := h.services.ApiKey.GetApiKey(c.Context(), c.Params("apiKeyId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(apiKey))
+}
+
+// UpdateApiKey updates an API key
+// @Summary Update an API key
+// @Description Update the name, scope, rate limit or status of an API key
+// @Tags ApiKey
+// @Accept json
+// @Produce json
+// @Param apiKeyId path string true "API Key ID"
+// @Param apiKey body service.UpdateApiKeyRequest true "Update API Key Request"
+// @Success 200 {object} api.SuccessResponse{result=model.ApiKey}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/api-keys/{apiKeyId} [put]
+func (h *apiKeyHandler) UpdateApiKey(c *fiber.Ctx) error {
+	var req service.UpdateApiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	apiKey, err := h.services.ApiKey.UpdateApiKey(c.Context(), c.Params("apiKeyId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(apiKey))
+}
+
+// RotateApiKey rotates an API key
+// @Summary Rotate an API key
+// @Description Replace the key of an API key, keeping its scope. The previous key stops working immediately
+// @Tags ApiKey
+// @Param apiKeyId path string true "API Key ID"
+// @Success 200 {object} api.SuccessResponse{result=service.ApiKeyResponse}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/api-keys/{apiKeyId}/rotate [post]
+func (h *apiKeyHandler) RotateApiKey(c *fiber.Ctx) error {
+	apiKey, err := h.services.ApiKey.RotateApiKey(c.Context(), c.Params("apiKeyId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(apiKey))
+}
+
+// DeleteApiKey deletes an API key
+// @Summary Delete an API key
+// @Description Delete an API key, revoking it immediately
+// @Tags ApiKey
+// @Param apiKeyId path string true "API Key ID"
+// @Success 202 {object} api.SuccessResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/api-keys/{apiKeyId} [delete]
+func (h *apiKeyHandler) DeleteApiKey(c *fiber.Ctx) error {
+	if err := h.services.ApiKey.DeleteApiKey(c.Context(), c.Params("apiKeyId")); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
+}
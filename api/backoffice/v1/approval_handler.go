@@ -0,0 +1,114 @@
+package backofficev1
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/service"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+type approvalHandler struct {
+	services *service.Services
+}
+
+func NewApprovalHandler(appGroup fiber.Router, services *service.Services) {
+	handler := &approvalHandler{services: services}
+	handler.Setup(appGroup)
+}
+
+func (h *approvalHandler) Setup(appGroup fiber.Router) {
+	group := appGroup.Group("/approvals")
+	group.Get("/", h.GetApprovals)
+	group.Get("/:approvalId", h.GetApproval)
+	group.Post("/:approvalId/approve", h.Approve)
+	group.Post("/:approvalId/reject", h.Reject)
+}
+
+// GetApprovals retrieves all approvals
+// @Summary Get all approvals
+// @Description Get the operations held for approval, optionally filtered by status
+// @Tags Approval
+// @Param status query string false "Status" Enums(PENDING, APPROVED, REJECTED)
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} api.SuccessResponse{result=api.List[model.Approval]}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/approvals [get]
+func (h *approvalHandler) GetApprovals(c *fiber.Ctx) error {
+	page, limit, err := api.GetPageAndLimit(c)
+	if err != nil {
+		return err
+	}
+	approvals, err := h.services.Approval.GetApprovals(c.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(approvals))
+}
+
+// GetApproval retrieves an approval by its ID
+// @Summary Get an approval by its ID
+// @Description Get an approval and the payload of the operation it holds
+// @Tags Approval
+// @Param approvalId path string true "Approval ID"
+// @Success 200 {object} api.SuccessResponse{result=model.Approval}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/approvals/{approvalId} [get]
+func (h *approvalHandler) GetApproval(c *fiber.Ctx) error {
+	approval, err := h.services.Approval.GetApproval(c.Context(), c.Params("approvalId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(approval))
+}
+
+// Approve approves an operation
+// @Summary Approve an operation
+// @Description Carry out the operation held by a pending approval. The approver must not be the actor who requested it
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param approvalId path string true "Approval ID"
+// @Param decision body service.ApprovalDecisionRequest false "Approval Decision Request"
+// @Success 200 {object} api.SuccessResponse{result=model.Approval}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/approvals/{approvalId}/approve [post]
+func (h *approvalHandler) Approve(c *fiber.Ctx) error {
+	var req service.ApprovalDecisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+			return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+		}
+	}
+	approval, err := h.services.Approval.Approve(c.Context(), c.Params("approvalId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(approval))
+}
+
+// Reject rejects an operation
+// @Summary Reject an operation
+// @Description Discard the operation held by a pending approval, giving a reason
+// @Tags Approval
+// @Accept json
+// @Produce json
+// @Param approvalId path string true "Approval ID"
+// @Param decision body service.ApprovalDecisionRequest true "Approval Decision Request"
+// @Success 200 {object} api.SuccessResponse{result=model.Approval}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/approvals/{approvalId}/reject [post]
+func (h *approvalHandler) Reject(c *fiber.Ctx) error {
+	var req service.ApprovalDecisionRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	approval, err := h.services.Approval.Reject(c.Context(), c.Params("approvalId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(approval))
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/gofiber/fiber/v2"
-	"github.com/shopspring/decimal"
+	"time"
 )
 
 type exchangeRateHandler struct {
@@ -24,9 +24,11 @@ func (h *exchangeRateHandler) Setup(appGroup fiber.Router) {
 	group := appGroup.Group("exchange-rates")
 	group.Post("/", h.CreateExchangeRate)
 	group.Get("/", h.GetExchangeRates)
+	group.Get("/versions", h.GetExchangeRateVersions)
+	group.Get("/effective", h.GetEffectiveExchangeRate)
 	group.Get("/wallets/:walletId", h.GetExchangeRatesByWalletID)
-	group.Put("/wallets/:walletId", h.UpdateExchangeRate)
-	group.Delete("/wallets/:walletId", h.DeleteExchangeRate)
+	group.Put("/:exchangeRateId", h.UpdateExchangeRate)
+	group.Delete("/:exchangeRateId", h.DeleteExchangeRate)
 }
 
 // CreateExchangeRate creates a new exchange rate
@@ -95,36 +97,95 @@ func (h *exchangeRateHandler) GetExchangeRatesByWalletID(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(exchangeRates))
 }
 
-// UpdateExchangeRate updates an exchange rate
+// GetExchangeRateVersions retrieves the version history of an exchange rate pair
+// @Summary Get the version history of an exchange rate pair
+// @Description Get all versions of an exchange rate pair, including versions scheduled for the future
+// @Tags Exchange Rate
+// @Param fromWalletId query string true "From Wallet ID"
+// @Param toWalletId query string true "To Wallet ID"
+// @Param tierId query string false "Tier ID, omit for the default rate"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} api.SuccessResponse{result=[]model.ExchangeRate}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/exchange-rates/versions [get]
+func (h *exchangeRateHandler) GetExchangeRateVersions(c *fiber.Ctx) error {
+	page, limit, err := api.GetPageAndLimit(c)
+	if err != nil {
+		return err
+	}
+	var tierId *string
+	if c.Query("tierId") != "" {
+		tier := c.Query("tierId")
+		tierId = &tier
+	}
+	exchangeRates, err := h.services.ExchangeRate.GetExchangeRateVersions(c.Context(), c.Query("fromWalletId"), c.Query("toWalletId"), tierId, page, limit)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(exchangeRates))
+}
+
+// GetEffectiveExchangeRate retrieves the exchange rate version that applied at a given time
+// @Summary Get the exchange rate that applied at a given time
+// @Description Get the exchange rate version of a pair that was active at the given time, defaults to now
+// @Tags Exchange Rate
+// @Param fromWalletId query string true "From Wallet ID"
+// @Param toWalletId query string true "To Wallet ID"
+// @Param tierId query string false "Tier ID, omit for the default rate"
+// @Param at query string false "RFC3339 time"
+// @Success 200 {object} api.SuccessResponse{result=model.ExchangeRate}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/exchange-rates/effective [get]
+func (h *exchangeRateHandler) GetEffectiveExchangeRate(c *fiber.Ctx) error {
+	at := time.Now()
+	if c.Query("at") != "" {
+		parsed, err := time.Parse(time.RFC3339, c.Query("at"))
+		if err != nil {
+			return errs.NewBadRequestError("invalid at", "INVALID_AT_PARAM", err)
+		}
+		at = parsed
+	}
+	var tierId *string
+	if c.Query("tierId") != "" {
+		tier := c.Query("tierId")
+		tierId = &tier
+	}
+	exchangeRate, err := h.services.ExchangeRate.GetEffectiveExchangeRate(c.Context(), c.Query("fromWalletId"), c.Query("toWalletId"), tierId, at)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(exchangeRate))
+}
+
+// UpdateExchangeRate schedules a new version of an exchange rate
 // @Summary Update an exchange rate
-// @Description Update an exchange rate based on the provided request
+// @Description Create a new version of the exchange rate pair, effective from validFrom (defaults to now)
 // @Tags Exchange Rate
 // @Accept json
 // @Produce json
 // @Param exchangeRateId path string true "Exchange Rate ID"
-// @Param exchangeRate body object true "Update Exchange Rate Request"
+// @Param exchangeRate body service.UpdateExchangeRateRequest true "Update Exchange Rate Request"
 // @Success 200 {object} api.SuccessResponse{result=model.ExchangeRate}
 // @Failure 400 {object} api.ErrorResponse
 // @Router /backoffice/exchange-rates/{exchangeRateId} [put]
 func (h *exchangeRateHandler) UpdateExchangeRate(c *fiber.Ctx) error {
 	exchangeRateId := c.Params("exchangeRateId")
-	var req struct {
-		ExchangeRate decimal.Decimal `json:"exchangeRate" validate:"required"`
-	}
+	var req service.UpdateExchangeRateRequest
 	if err := c.BodyParser(&req); err != nil {
 		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
 		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
 	}
-	exchangeRate, err := h.services.ExchangeRate.UpdateExchangeRate(c.Context(), exchangeRateId, req.ExchangeRate)
+	exchangeRate, err := h.services.ExchangeRate.UpdateExchangeRate(c.Context(), exchangeRateId, &req)
 	if err != nil {
 		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(exchangeRate))
 }
 
-// DeleteExchangeRate deletes an exchange rate
+// DeleteExchangeRate closes an exchange rate version, or deletes it if it has not started yet
 // @Summary Delete an exchange rate
-// @Description Delete an exchange rate by its ID
+// @Description Close the active exchange rate version now, or delete a version scheduled in the future. Past versions are kept as history
 // @Tags Exchange Rate
 // @Produce json
 // @Param exchangeRateId path string true "Exchange Rate ID"
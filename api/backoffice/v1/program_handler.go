@@ -24,6 +24,12 @@ func (h *programHandler) Setup(appGroup fiber.Router) {
 	group.Delete("/:programId", h.DeleteProgram)
 	group.Get("/:programId", h.GetProgram)
 	group.Get("/", h.GetPrograms)
+	group.Post("/:programId/publish", h.PublishProgram)
+	group.Post("/:programId/rollback", h.RollbackProgram)
+	group.Delete("/:programId/draft", h.DiscardProgramDraft)
+	group.Post("/:programId/simulate", h.SimulateProgram)
+	group.Get("/:programId/versions", h.GetProgramVersions)
+	group.Get("/:programId/versions/:version", h.GetProgramVersion)
 }
 
 // CreateProgram creates a new program
@@ -50,7 +56,8 @@ func (h *programHandler) CreateProgram(c *fiber.Ctx) error {
 
 // UpdateProgram updates a program
 // @Summary Update a program
-// @Description Update a program based on the provided request
+// @Description Edit the draft of a program, which the program runs once it is published. Switching the program on or
+// @Description off applies at once.
 // @Tags Program
 // @Accept json
 // @Produce json
@@ -135,3 +142,149 @@ func (h *programHandler) GetPrograms(c *fiber.Ctx) error {
 	}
 	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(programs))
 }
+
+// PublishProgram publishes the draft of a program
+// @Summary Publish the draft of a program
+// @Description Publish the draft of a program as its next version, which the program runs from then on
+// @Tags Program
+// @Param programId path string true "Program ID"
+// @Success 200 {object} api.SuccessResponse{result=model.Program}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/programs/{programId}/publish [post]
+func (h *programHandler) PublishProgram(c *fiber.Ctx) error {
+	programID, err := strconv.ParseUint(c.Params("programId"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program ID", "INVALID_PROGRAM_ID", err)
+	}
+	program, err := h.services.Program.PublishProgram(c.Context(), programID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(program))
+}
+
+// RollbackProgram rolls a program back to an earlier version
+// @Summary Roll a program back
+// @Description Publish an earlier version of a program again as its next version
+// @Tags Program
+// @Accept json
+// @Produce json
+// @Param programId path string true "Program ID"
+// @Param req body service.RollbackProgramRequest true "Rollback Program Request"
+// @Success 200 {object} api.SuccessResponse{result=model.Program}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/programs/{programId}/rollback [post]
+func (h *programHandler) RollbackProgram(c *fiber.Ctx) error {
+	programID, err := strconv.ParseUint(c.Params("programId"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program ID", "INVALID_PROGRAM_ID", err)
+	}
+	var req service.RollbackProgramRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	program, err := h.services.Program.RollbackProgram(c.Context(), programID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(program))
+}
+
+// DiscardProgramDraft discards the draft of a program
+// @Summary Discard the draft of a program
+// @Description Discard the edits of a program that are not published
+// @Tags Program
+// @Param programId path string true "Program ID"
+// @Success 200 {object} api.SuccessResponse{result=model.Program}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/programs/{programId}/draft [delete]
+func (h *programHandler) DiscardProgramDraft(c *fiber.Ctx) error {
+	programID, err := strconv.ParseUint(c.Params("programId"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program ID", "INVALID_PROGRAM_ID", err)
+	}
+	program, err := h.services.Program.DiscardProgramDraft(c.Context(), programID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(program))
+}
+
+// SimulateProgram simulates a program
+// @Summary Simulate a program
+// @Description Run the draft of a program, or one of its versions, against a trigger fired for a user without
+// @Description recording or rewarding anything
+// @Tags Program
+// @Accept json
+// @Produce json
+// @Param programId path string true "Program ID"
+// @Param req body service.SimulateProgramRequest true "Simulate Program Request"
+// @Success 200 {object} api.SuccessResponse{result=service.SimulationResult}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/programs/{programId}/simulate [post]
+func (h *programHandler) SimulateProgram(c *fiber.Ctx) error {
+	programID, err := strconv.ParseUint(c.Params("programId"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program ID", "INVALID_PROGRAM_ID", err)
+	}
+	var req service.SimulateProgramRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	result, err := h.services.Program.SimulateProgram(c.Context(), programID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(result))
+}
+
+// GetProgramVersions retrieves the versions of a program
+// @Summary Get the versions of a program
+// @Description Get the published versions of a program, the latest first
+// @Tags Program
+// @Param programId path string true "Program ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} api.SuccessResponse{result=api.List[model.ProgramVersion]}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/programs/{programId}/versions [get]
+func (h *programHandler) GetProgramVersions(c *fiber.Ctx) error {
+	programID, err := strconv.ParseUint(c.Params("programId"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program ID", "INVALID_PROGRAM_ID", err)
+	}
+	page, limit, err := api.GetPageAndLimit(c)
+	if err != nil {
+		return err
+	}
+	versions, err := h.services.Program.ListProgramVersions(c.Context(), programID, page, limit)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(versions))
+}
+
+// GetProgramVersion retrieves a version of a program
+// @Summary Get a version of a program
+// @Description Get a published version of a program by its number
+// @Tags Program
+// @Param programId path string true "Program ID"
+// @Param version path int true "Version"
+// @Success 200 {object} api.SuccessResponse{result=model.ProgramVersion}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/programs/{programId}/versions/{version} [get]
+func (h *programHandler) GetProgramVersion(c *fiber.Ctx) error {
+	programID, err := strconv.ParseUint(c.Params("programId"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program ID", "INVALID_PROGRAM_ID", err)
+	}
+	version, err := strconv.ParseUint(c.Params("version"), 10, 64)
+	if err != nil {
+		return errs.NewBadRequestError("Invalid program version", "INVALID_PROGRAM_VERSION", err)
+	}
+	programVersion, err := h.services.Program.GetProgramVersion(c.Context(), programID, version)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(programVersion))
+}
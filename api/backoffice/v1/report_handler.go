@@ -0,0 +1,57 @@
+package backofficev1
+
+import (
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/service"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+type reportHandler struct {
+	services *service.Services
+}
+
+func NewReportHandler(appGroup fiber.Router, services *service.Services) {
+	handler := &reportHandler{
+		services: services,
+	}
+	handler.Setup(appGroup)
+}
+
+func (h *reportHandler) Setup(appGroup fiber.Router) {
+	group := appGroup.Group("reports")
+	group.Get("/wallets/:walletId/liability", h.GetLiabilityReport)
+}
+
+// GetLiabilityReport retrieves the monthly liability and breakage report of a wallet
+// @Summary Get the liability report of a wallet
+// @Description Get the points a wallet issued, had redeemed, had expire and otherwise debited every month of a period, with its outstanding liability, valued at the exchange rate to a monetary wallet when one is given, as json or csv
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param walletId path string true "Wallet ID"
+// @Param from query string true "First month (YYYY-MM)"
+// @Param to query string true "Last month, inclusive (YYYY-MM)"
+// @Param monetaryWalletId query string false "Monetary wallet to value the liability in"
+// @Param format query string false "json or csv"
+// @Success 200 {object} api.SuccessResponse{result=service.LiabilityReport}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/reports/wallets/{walletId}/liability [get]
+func (h *reportHandler) GetLiabilityReport(c *fiber.Ctx) error {
+	walletId := c.Params("walletId")
+	req := new(service.LiabilityReportRequest)
+	if err := c.QueryParser(req); err != nil {
+		return errs.NewBadRequestError("Invalid query params", "INVALID_QUERY_PARAMS", err)
+	}
+	report, err := h.services.Report.GetLiabilityReport(c.Context(), walletId, req)
+	if err != nil {
+		return err
+	}
+	if report.Format == "json" {
+		return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(report))
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="liability-%s-%s-%s.csv"`, walletId, report.From, report.To))
+	return h.services.Report.WriteLiabilityReport(c.Context(), report, c.Response().BodyWriter())
+}
@@ -0,0 +1,194 @@
+package backofficev1
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/service"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+type roleHandler struct {
+	services *service.Services
+}
+
+func NewRoleHandler(appGroup fiber.Router, services *service.Services) {
+	handler := &roleHandler{services: services}
+	handler.Setup(appGroup)
+}
+
+func (h *roleHandler) Setup(appGroup fiber.Router) {
+	group := appGroup.Group("/roles")
+	group.Post("/", h.CreateRole)
+	group.Get("/", h.GetRoles)
+	group.Get("/permissions", h.GetPermissions)
+	group.Get("/actors/:actorId", h.GetActorRoles)
+	group.Get("/:roleId", h.GetRole)
+	group.Put("/:roleId", h.UpdateRole)
+	group.Delete("/:roleId", h.DeleteRole)
+	group.Put("/:roleId/actors/:actorId", h.AssignRole)
+	group.Delete("/:roleId/actors/:actorId", h.RevokeRole)
+}
+
+// CreateRole creates a new role
+// @Summary Create a new role
+// @Description Create a role granting a set of backoffice permissions
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param role body service.CreateRoleRequest true "Create Role Request"
+// @Success 201 {object} api.SuccessResponse{result=model.Role}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles [post]
+func (h *roleHandler) CreateRole(c *fiber.Ctx) error {
+	var req service.CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	role, err := h.services.Role.CreateRole(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(api.NewSuccessResponse(role))
+}
+
+// GetRoles retrieves all roles
+// @Summary Get all roles
+// @Description Get all roles and their permissions
+// @Tags Role
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} api.SuccessResponse{result=api.List[model.Role]}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles [get]
+func (h *roleHandler) GetRoles(c *fiber.Ctx) error {
+	page, limit, err := api.GetPageAndLimit(c)
+	if err != nil {
+		return err
+	}
+	roles, err := h.services.Role.GetRoles(c.Context(), page, limit)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(roles))
+}
+
+// GetPermissions retrieves all permissions
+// @Summary Get all permissions
+// @Description Get every permission a role can be granted
+// @Tags Role
+// @Success 200 {object} api.SuccessResponse{result=[]string}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/permissions [get]
+func (h *roleHandler) GetPermissions(c *fiber.Ctx) error {
+	permissions, err := h.services.Role.GetPermissions(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(permissions))
+}
+
+// GetActorRoles retrieves the roles of a backoffice actor
+// @Summary Get the roles of a backoffice actor
+// @Description Get the roles assigned to a backoffice actor
+// @Tags Role
+// @Param actorId path string true "Actor ID"
+// @Success 200 {object} api.SuccessResponse{result=[]model.Role}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/actors/{actorId} [get]
+func (h *roleHandler) GetActorRoles(c *fiber.Ctx) error {
+	roles, err := h.services.Role.GetActorRoles(c.Context(), c.Params("actorId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(roles))
+}
+
+// GetRole retrieves a role by its ID
+// @Summary Get a role by its ID
+// @Description Get a role and its permissions
+// @Tags Role
+// @Param roleId path string true "Role ID"
+// @Success 200 {object} api.SuccessResponse{result=model.Role}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/{roleId} [get]
+func (h *roleHandler) GetRole(c *fiber.Ctx) error {
+	role, err := h.services.Role.GetRole(c.Context(), c.Params("roleId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(role))
+}
+
+// UpdateRole updates a role
+// @Summary Update a role
+// @Description Update a role, replacing its permissions when provided
+// @Tags Role
+// @Accept json
+// @Produce json
+// @Param roleId path string true "Role ID"
+// @Param role body service.UpdateRoleRequest true "Update Role Request"
+// @Success 200 {object} api.SuccessResponse{result=model.Role}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/{roleId} [put]
+func (h *roleHandler) UpdateRole(c *fiber.Ctx) error {
+	var req service.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	role, err := h.services.Role.UpdateRole(c.Context(), c.Params("roleId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(role))
+}
+
+// DeleteRole deletes a role
+// @Summary Delete a role
+// @Description Delete a role, revoking it from every actor it is assigned to
+// @Tags Role
+// @Param roleId path string true "Role ID"
+// @Success 202 {object} api.SuccessResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/{roleId} [delete]
+func (h *roleHandler) DeleteRole(c *fiber.Ctx) error {
+	if err := h.services.Role.DeleteRole(c.Context(), c.Params("roleId")); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
+}
+
+// AssignRole assigns a role to a backoffice actor
+// @Summary Assign a role to a backoffice actor
+// @Description Assign a role to a backoffice actor
+// @Tags Role
+// @Param roleId path string true "Role ID"
+// @Param actorId path string true "Actor ID"
+// @Success 201 {object} api.SuccessResponse{result=model.ActorRole}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/{roleId}/actors/{actorId} [put]
+func (h *roleHandler) AssignRole(c *fiber.Ctx) error {
+	actorRole, err := h.services.Role.AssignRole(c.Context(), c.Params("roleId"), c.Params("actorId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(api.NewSuccessResponse(actorRole))
+}
+
+// RevokeRole revokes a role from a backoffice actor
+// @Summary Revoke a role from a backoffice actor
+// @Description Revoke a role from a backoffice actor
+// @Tags Role
+// @Param roleId path string true "Role ID"
+// @Param actorId path string true "Actor ID"
+// @Success 202 {object} api.SuccessResponse
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/roles/{roleId}/actors/{actorId} [delete]
+func (h *roleHandler) RevokeRole(c *fiber.Ctx) error {
+	if err := h.services.Role.RevokeRole(c.Context(), c.Params("roleId"), c.Params("actorId")); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
+}
@@ -10,6 +10,7 @@ func New(app *fiber.App, services *service.Services) {
 	group := app.Group("api/v1/backoffice/")
 	group.Use(api.AdminAuthenticationMiddleware())
 	group.Use(api.CreateAppContextMiddleware(api.AppActorAdmin))
+	group.Use(api.AuthorizationMiddleware(services.Role.GetActorPermissions))
 	NewAuditHandler(group, services)
 	NewAccountHandler(group, services)
 	NewExchangeRateHandler(group, services)
@@ -19,4 +20,8 @@ func New(app *fiber.App, services *service.Services) {
 	NewTransactionHandler(group, services)
 	NewTriggerHandler(group, services)
 	NewProgramHandler(group, services)
+	NewRoleHandler(group, services)
+	NewApprovalHandler(group, services)
+	NewApiKeyHandler(group, services)
+	NewReportHandler(group, services)
 }
@@ -21,8 +21,15 @@ func (h *tierHandler) Setup(appGroup fiber.Router) {
 	group := appGroup.Group("tiers")
 	group.Post("/", h.CreateTier)
 	group.Get("/", h.GetTiers)
+	group.Post("/evaluate", h.EvaluateTiers)
 	group.Get("/:tierId", h.GetTierByID)
 	group.Delete("/:tierId", h.DeleteTier)
+	group.Get("/:tierId/policies", h.GetTierPolicies)
+	group.Put("/:tierId/policies/:walletId", h.SetTierPolicy)
+	group.Delete("/:tierId/policies/:walletId", h.DeleteTierPolicy)
+	group.Get("/:tierId/rule", h.GetTierRule)
+	group.Put("/:tierId/rule", h.SetTierRule)
+	group.Delete("/:tierId/rule", h.DeleteTierRule)
 }
 
 func (h *tierHandler) CreateTier(c *fiber.Ctx) error {
@@ -67,3 +74,70 @@ func (h *tierHandler) DeleteTier(c *fiber.Ctx) error {
 	}
 	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
 }
+
+func (h *tierHandler) GetTierPolicies(c *fiber.Ctx) error {
+	id := c.Params("tierId")
+	policies, err := h.services.Tier.GetTierPolicies(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(policies))
+}
+
+func (h *tierHandler) SetTierPolicy(c *fiber.Ctx) error {
+	var req service.SetTierPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	policy, err := h.services.Tier.SetTierPolicy(c.Context(), c.Params("tierId"), c.Params("walletId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(policy))
+}
+
+func (h *tierHandler) DeleteTierPolicy(c *fiber.Ctx) error {
+	err := h.services.Tier.DeleteTierPolicy(c.Context(), c.Params("tierId"), c.Params("walletId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
+}
+
+func (h *tierHandler) GetTierRule(c *fiber.Ctx) error {
+	rule, err := h.services.Tier.GetTierRule(c.Context(), c.Params("tierId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(rule))
+}
+
+func (h *tierHandler) SetTierRule(c *fiber.Ctx) error {
+	var req service.SetTierRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	rule, err := h.services.Tier.SetTierRule(c.Context(), c.Params("tierId"), &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(rule))
+}
+
+func (h *tierHandler) DeleteTierRule(c *fiber.Ctx) error {
+	err := h.services.Tier.DeleteTierRule(c.Context(), c.Params("tierId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
+}
+
+func (h *tierHandler) EvaluateTiers(c *fiber.Ctx) error {
+	err := h.services.Tier.EvaluateTiers(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
+}
@@ -35,6 +35,16 @@ func (h *transactionHandler) Setup(appGroup fiber.Router) {
 // @Param walletId path string true "Wallet ID"
 // @Param page query int false "Page"
 // @Param limit query int false "Limit"
+// @Param type query string false "Transaction type"
+// @Param reason query string false "Transaction reason"
+// @Param programId query string false "Program ID"
+// @Param minAmount query int false "Minimum amount"
+// @Param maxAmount query int false "Maximum amount"
+// @Param from query string false "Created from (RFC 3339)"
+// @Param to query string false "Created before (RFC 3339)"
+// @Param metadataKey query string false "Metadata key"
+// @Param metadataValue query string false "Metadata value"
+// @Param cursor query string false "Cursor of the next page, instead of page"
 // @Success 200 {object} api.SuccessResponse{result=[]model.Transaction}
 // @Failure 400 {object} api.ErrorResponse
 // @Router /backoffice/wallets/{walletId}/transactions [get]
@@ -44,7 +54,11 @@ func (h *transactionHandler) GetTransactions(c *fiber.Ctx) error {
 		return err
 	}
 	walletId := c.Params("walletId")
-	transactions, err := h.services.Transaction.GetWalletTransactions(c.Context(), walletId, page, limit)
+	filter := new(service.TransactionFilterRequest)
+	if err := c.QueryParser(filter); err != nil {
+		return errs.NewBadRequestError("Invalid query params", "INVALID_QUERY_PARAMS", err)
+	}
+	transactions, err := h.services.Transaction.GetWalletTransactions(c.Context(), walletId, filter, page, limit)
 	if err != nil {
 		return err
 	}
@@ -21,6 +21,8 @@ func (h *userHandler) Setup(appGroup fiber.Router) {
 	group.Get("/", h.GetUsers)
 	group.Get("/:userId", h.GetUserByID)
 	group.Put("/:userId/tier", h.SetUserTier)
+	group.Post("/:userId/tier/evaluate", h.EvaluateUserTier)
+	group.Get("/:userId/tier/history", h.GetUserTierTimeline)
 	group.Delete("/:userId", h.DeleteUser)
 }
 
@@ -119,3 +121,42 @@ func (h *userHandler) DeleteUser(c *fiber.Ctx) error {
 	}
 	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(nil))
 }
+
+// EvaluateUserTier evaluates a user against the tier qualification rules
+// @Summary Evaluate the tier of a user
+// @Description Evaluate a user against the tier qualification rules, applying any upgrade or downgrade
+// @Tags User
+// @Param userId path string true "User ID"
+// @Success 200 {object} api.SuccessResponse{result=model.User}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/users/{userId}/tier/evaluate [post]
+func (h *userHandler) EvaluateUserTier(c *fiber.Ctx) error {
+	userId := c.Params("userId")
+	user, err := h.services.Tier.EvaluateUserTier(c.Context(), userId)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(user))
+}
+
+// GetUserTierTimeline retrieves the tier timeline of a user
+// @Summary Get the tier timeline of a user
+// @Description Get the tier changes of a user, latest first
+// @Tags User
+// @Param userId path string true "User ID"
+// @Param page query int false "Page"
+// @Param limit query int false "Limit"
+// @Success 200 {object} api.SuccessResponse{result=[]model.UserTierHistory}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/users/{userId}/tier/history [get]
+func (h *userHandler) GetUserTierTimeline(c *fiber.Ctx) error {
+	page, limit, err := api.GetPageAndLimit(c)
+	if err != nil {
+		return err
+	}
+	history, err := h.services.User.GetUserTierTimeline(c.Context(), c.Params("userId"), page, limit)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(history))
+}
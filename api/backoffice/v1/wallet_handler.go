@@ -7,6 +7,7 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"math"
+	"time"
 )
 
 type walletHandler struct {
@@ -25,6 +26,7 @@ func (h *walletHandler) Setup(appGroup fiber.Router) {
 	group.Post("/", h.CreateWallet)
 	group.Get("/", h.GetWallets)
 	group.Get("/:walletId/check-integrity", h.CheckWalletIntegrity)
+	group.Get("/:walletId/liability", h.GetWalletLiability)
 	group.Get("/:walletId", h.GetWalletByID)
 	group.Put("/:walletId", h.UpdateWallet)
 	group.Delete("/:walletId", h.DeleteWallet)
@@ -163,3 +165,29 @@ func (h *walletHandler) CheckWalletIntegrity(c *fiber.Ctx) error {
 		"diff":            diff,
 	}))
 }
+
+// GetWalletLiability retrieves the liability of a wallet at a given time
+// @Summary Get the liability of a wallet at a given time
+// @Description Get the sum of the balances of the accounts of a wallet at the given time, defaults to now
+// @Tags Wallet
+// @Produce json
+// @Param walletId path string true "Wallet ID"
+// @Param at query string false "RFC3339 time"
+// @Success 200 {object} api.SuccessResponse{result=service.WalletLiability}
+// @Failure 400 {object} api.ErrorResponse
+// @Router /backoffice/wallets/{walletId}/liability [get]
+func (h *walletHandler) GetWalletLiability(c *fiber.Ctx) error {
+	at := time.Now()
+	if c.Query("at") != "" {
+		parsed, err := time.Parse(time.RFC3339, c.Query("at"))
+		if err != nil {
+			return errs.NewBadRequestError("invalid at", "INVALID_AT_PARAM", err)
+		}
+		at = parsed
+	}
+	liability, err := h.services.Wallet.GetLiabilityAt(c.Context(), c.Params("walletId"), at)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NewSuccessResponse(liability))
+}
@@ -0,0 +1,17 @@
+package systemv1
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/service"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// New sets up the routes internal services call as the SYSTEM actor, authenticated with an API key
+func New(app *fiber.App, services *service.Services) {
+	group := app.Group("api/v1/system/")
+	group.Use(api.ApiKeyAuthenticationMiddleware(services.ApiKey.Authenticate))
+	group.Use(api.ActorRateLimitMiddleware(time.Minute))
+	NewTransactionHandler(group, services)
+	NewTriggerHandler(group, services)
+}
@@ -0,0 +1,54 @@
+package systemv1
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/service"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type triggerHandler struct {
+	services *service.Services
+}
+
+func NewTriggerHandler(appGroup fiber.Router, services *service.Services) {
+	handler := &triggerHandler{services: services}
+	handler.Setup(appGroup)
+}
+
+func (h *triggerHandler) Setup(appGroup fiber.Router) {
+	group := appGroup.Group("triggers")
+	group.Post("/:triggerSlug/fire", h.FireTrigger)
+}
+
+// FireTrigger fires a trigger for a user
+// @Summary Fire a trigger
+// @Description Invoke the programs of a trigger for a user, rewarding them in the wallets the API key is scoped to.
+// @Description The result lists the programs that fired and the ones that were suppressed, with the reason.
+// @Tags System
+// @Accept json
+// @Param triggerSlug path string true "Trigger Slug"
+// @Param req body service.FireTriggerRequest true "Fire Trigger Request"
+// @Success 202 {object} api.SuccessResponse{result=service.InvocationResult}
+// @Failure 400 {object} api.ErrorResponse
+// @Failure 404 {object} api.ErrorResponse
+// @Router /system/triggers/{triggerSlug}/fire [post]
+func (h *triggerHandler) FireTrigger(c *fiber.Ctx) error {
+	var req service.FireTriggerRequest
+	if err := c.BodyParser(&req); err != nil {
+		api.GetLogger(c.Context()).Error("Invalid body request", logger.Field("error", err))
+		return errs.NewBadRequestError("Invalid body request", "INVALID_BODY_REQUEST", err)
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(c.Context()).Error("Invalid request", logger.Field("fields", fields))
+		return errs.NewValidationError("Invalid request", "", fields)
+	}
+	result, err := h.services.Program.InvokePrograms(c.Context(), c.Params("triggerSlug"), req.UserID, req.Data)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(api.NewSuccessResponse(result))
+}
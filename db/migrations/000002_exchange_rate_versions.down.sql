@@ -0,0 +1,21 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS exchange_rate_id;
+
+DROP INDEX IF EXISTS exchange_rates_pair_valid_from_idx;
+
+ALTER TABLE exchange_rates
+    DROP CONSTRAINT IF EXISTS exclude_overlapping_exchange_rate_versions,
+    DROP CONSTRAINT IF EXISTS check_exchange_rate_valid_period;
+
+-- Keep only the versions that are active now so the pair uniqueness can be restored
+DELETE
+FROM exchange_rates
+WHERE valid_from > NOW()
+   OR (valid_until IS NOT NULL AND valid_until <= NOW());
+
+ALTER TABLE exchange_rates
+    ADD CONSTRAINT unique_from_to_tier UNIQUE (from_wallet_id, to_wallet_id, tier_id);
+
+ALTER TABLE exchange_rates
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS valid_from;
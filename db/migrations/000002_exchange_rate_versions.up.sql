@@ -0,0 +1,27 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Every exchange_rates row is now an effective-dated version of a (from, to, tier) pair
+ALTER TABLE exchange_rates
+    ADD COLUMN IF NOT EXISTS valid_from  TIMESTAMP DEFAULT NOW() NOT NULL,
+    ADD COLUMN IF NOT EXISTS valid_until TIMESTAMP; -- NULL means the version is open ended
+
+UPDATE exchange_rates
+SET valid_from = created_at;
+
+ALTER TABLE exchange_rates
+    DROP CONSTRAINT IF EXISTS unique_from_to_tier;
+
+ALTER TABLE exchange_rates
+    ADD CONSTRAINT check_exchange_rate_valid_period CHECK (valid_until IS NULL OR valid_until > valid_from),
+    ADD CONSTRAINT exclude_overlapping_exchange_rate_versions EXCLUDE USING gist (
+        from_wallet_id WITH =,
+        to_wallet_id WITH =,
+        (COALESCE(tier_id, '')) WITH =,
+        tsrange(valid_from, valid_until) WITH &&
+        );
+
+CREATE INDEX IF NOT EXISTS exchange_rates_pair_valid_from_idx ON exchange_rates (from_wallet_id, to_wallet_id, tier_id, valid_from);
+
+-- The exact exchange rate version used by an exchange transaction
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS exchange_rate_id INT REFERENCES exchange_rates (id) ON DELETE SET NULL;
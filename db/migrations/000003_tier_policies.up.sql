@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS tier_policies
+(
+    wallet_id              TEXT REFERENCES wallets (id) ON DELETE CASCADE NOT NULL,
+    tier_id                TEXT REFERENCES tiers (id) ON DELETE CASCADE   NOT NULL,
+    max_balance            BIGINT CHECK (max_balance >= 0),                       -- NULL means the wallet limit per user
+    earn_multiplier        NUMERIC   DEFAULT 1                            NOT NULL CHECK (earn_multiplier >= 0),
+    max_transaction_amount BIGINT CHECK (max_transaction_amount >= 0),            -- NULL means no limit
+    daily_debit_limit      BIGINT CHECK (daily_debit_limit >= 0),                 -- NULL means no limit
+    created_at             TIMESTAMP DEFAULT NOW()                        NOT NULL,
+    updated_at             TIMESTAMP DEFAULT NOW()                        NOT NULL,
+    PRIMARY KEY (wallet_id, tier_id)
+);
+
+CREATE INDEX IF NOT EXISTS tier_policies_tier_id_idx ON tier_policies (tier_id);
+CREATE INDEX IF NOT EXISTS transactions_account_type_created_at_idx ON transactions (account_id, type, created_at);
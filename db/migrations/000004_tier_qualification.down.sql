@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_tier_history;
+DROP TABLE IF EXISTS tier_rules;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS tier_grace_until,
+    DROP COLUMN IF EXISTS tier_achieved_at;
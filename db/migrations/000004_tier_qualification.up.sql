@@ -0,0 +1,37 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tier_achieved_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS tier_grace_until TIMESTAMP; -- NULL means the user still qualifies for the tier
+
+UPDATE users
+SET tier_achieved_at = updated_at
+WHERE tier_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS tier_rules
+(
+    tier_id              TEXT PRIMARY KEY REFERENCES tiers (id) ON DELETE CASCADE,
+    rank                 INT UNIQUE                                     NOT NULL,
+    wallet_id            TEXT REFERENCES wallets (id) ON DELETE CASCADE NOT NULL,
+    metric               TEXT                                           NOT NULL,
+    threshold            BIGINT                                         NOT NULL CHECK (threshold >= 0),
+    qualification_window INTERVAL                                       NOT NULL,
+    retention_period     INTERVAL, -- NULL means no minimum time in the tier
+    grace_period         INTERVAL, -- NULL means users are downgraded as soon as they stop qualifying
+    is_active            BOOLEAN   DEFAULT TRUE                         NOT NULL,
+    created_at           TIMESTAMP DEFAULT NOW()                        NOT NULL,
+    updated_at           TIMESTAMP DEFAULT NOW()                        NOT NULL,
+    CONSTRAINT check_tier_rule_metric CHECK (metric IN ('EARNED', 'SPENT'))
+);
+
+CREATE TABLE IF NOT EXISTS user_tier_history
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      TEXT REFERENCES users (id) ON DELETE CASCADE NOT NULL,
+    from_tier_id TEXT REFERENCES tiers (id) ON DELETE SET NULL,
+    to_tier_id   TEXT REFERENCES tiers (id) ON DELETE SET NULL,
+    actor        TEXT                                         NOT NULL,
+    actor_id     TEXT,
+    remarks      TEXT,
+    created_at   TIMESTAMP DEFAULT NOW()                      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS user_tier_history_user_id_created_at_idx ON user_tier_history (user_id, created_at);
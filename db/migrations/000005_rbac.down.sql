@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS actor_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+
+DELETE
+FROM audit
+WHERE operation = 'ACCESS_DENIED';
+
+ALTER TABLE audit
+    DROP CONSTRAINT IF EXISTS check_audit_valid_operation,
+    ADD CONSTRAINT check_audit_valid_operation CHECK (operation IN ('CREATE', 'UPDATE', 'DELETE'));
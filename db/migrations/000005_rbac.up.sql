@@ -0,0 +1,54 @@
+ALTER TABLE audit
+    DROP CONSTRAINT IF EXISTS check_audit_valid_operation,
+    ADD CONSTRAINT check_audit_valid_operation CHECK (operation IN ('CREATE', 'UPDATE', 'DELETE', 'ACCESS_DENIED'));
+
+CREATE TABLE IF NOT EXISTS roles
+(
+    id          TEXT PRIMARY KEY CHECK (id ~ '^[a-z]+(?:[-_][a-z]+)*$'),
+    name        TEXT UNIQUE             NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at  TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role_id    TEXT REFERENCES roles (id) ON DELETE CASCADE NOT NULL,
+    permission TEXT                                         NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE IF NOT EXISTS actor_roles
+(
+    actor_id   TEXT                                         NOT NULL,
+    role_id    TEXT REFERENCES roles (id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()                      NOT NULL,
+    PRIMARY KEY (actor_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS actor_roles_role_id_idx ON actor_roles (role_id);
+
+-- Grant every permission to the default backoffice actor so existing setups keep working
+INSERT INTO roles (id, name, description)
+VALUES ('admin', 'Administrator', 'Every backoffice permission')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT 'admin', permission
+FROM unnest(ARRAY [
+    'audit:read',
+    'wallet:read', 'wallet:write',
+    'account:read', 'account:write', 'account:freeze',
+    'transaction:read', 'transaction:credit', 'transaction:debit', 'transaction:exchange',
+    'exchange_rate:read', 'exchange_rate:write',
+    'tier:read', 'tier:write',
+    'user:read', 'user:write',
+    'trigger:read', 'trigger:write',
+    'program:read', 'program:write', 'program:publish', 'program:invoke',
+    'role:read', 'role:write'
+    ]) AS permission
+ON CONFLICT DO NOTHING;
+
+INSERT INTO actor_roles (actor_id, role_id)
+VALUES ('admin_id', 'admin')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+DELETE
+FROM role_permissions
+WHERE permission IN ('approval:read', 'approval:decide');
+
+DROP TABLE IF EXISTS approvals;
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS approval_threshold;
@@ -0,0 +1,32 @@
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS approval_threshold BIGINT CHECK (approval_threshold > 0);
+
+CREATE TABLE IF NOT EXISTS approvals
+(
+    id            UUID      DEFAULT uuid_generate_v4() PRIMARY KEY,
+    operation     TEXT                    NOT NULL CHECK (operation IN
+                                                          ('CREATE_TRANSACTION', 'CREATE_EXCHANGE_RATE',
+                                                           'UPDATE_EXCHANGE_RATE', 'DELETE_EXCHANGE_RATE',
+                                                           'DELETE_WALLET', 'CREATE_PROGRAM', 'UPDATE_PROGRAM')),
+    permission    TEXT                    NOT NULL,
+    status        TEXT      DEFAULT 'PENDING' NOT NULL CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    payload       JSONB                   NOT NULL,
+    result        JSONB,
+    maker_actor   TEXT                    NOT NULL,
+    maker_id      TEXT                    NOT NULL,
+    checker_actor TEXT,
+    checker_id    TEXT,
+    reason        TEXT,
+    decided_at    TIMESTAMP,
+    created_at    TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at    TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT check_approvals_checker_differs CHECK (checker_id IS NULL OR checker_id <> maker_id)
+);
+
+CREATE INDEX IF NOT EXISTS approvals_status_created_at_idx ON approvals (status, created_at);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT 'admin', permission
+FROM unnest(ARRAY ['approval:read', 'approval:decide']) AS permission
+WHERE EXISTS (SELECT 1 FROM roles WHERE id = 'admin')
+ON CONFLICT DO NOTHING;
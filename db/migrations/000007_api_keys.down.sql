@@ -0,0 +1,5 @@
+DELETE
+FROM role_permissions
+WHERE permission IN ('api_key:read', 'api_key:write');
+
+DROP TABLE IF EXISTS api_keys;
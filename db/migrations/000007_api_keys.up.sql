@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           UUID      DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name         TEXT                    NOT NULL,
+    prefix       TEXT                    NOT NULL,
+    key_hash     TEXT UNIQUE             NOT NULL,
+    wallet_ids   JSONB     DEFAULT '[]'  NOT NULL,
+    permissions  JSONB     DEFAULT '[]'  NOT NULL,
+    rate_limit   INT                     NOT NULL CHECK (rate_limit > 0),
+    is_active    BOOLEAN   DEFAULT TRUE  NOT NULL,
+    last_used_at TIMESTAMP,
+    rotated_at   TIMESTAMP,
+    created_at   TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at   TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT 'admin', permission
+FROM unnest(ARRAY ['api_key:read', 'api_key:write']) AS permission
+WHERE EXISTS (SELECT 1 FROM roles WHERE id = 'admin')
+ON CONFLICT DO NOTHING;
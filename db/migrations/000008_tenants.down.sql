@@ -0,0 +1,187 @@
+-- Rows of other tenants cannot be folded back into single tenant keys
+DELETE
+FROM audit
+WHERE tenant_id <> 'default';
+DELETE
+FROM transactions
+WHERE tenant_id <> 'default';
+DELETE
+FROM approvals
+WHERE tenant_id <> 'default';
+DELETE
+FROM api_keys
+WHERE tenant_id <> 'default';
+DELETE
+FROM actor_roles
+WHERE tenant_id <> 'default';
+DELETE
+FROM roles
+WHERE tenant_id <> 'default';
+DELETE
+FROM users
+WHERE tenant_id <> 'default';
+DELETE
+FROM triggers
+WHERE tenant_id <> 'default';
+DELETE
+FROM tiers
+WHERE tenant_id <> 'default';
+DELETE
+FROM wallets
+WHERE tenant_id <> 'default';
+
+DROP INDEX IF EXISTS approvals_tenant_id_status_created_at_idx;
+DROP INDEX IF EXISTS user_tier_history_tenant_id_idx;
+DROP INDEX IF EXISTS triggers_tenant_id_idx;
+DROP INDEX IF EXISTS programs_tenant_id_idx;
+DROP INDEX IF EXISTS exchange_rates_tenant_id_idx;
+DROP INDEX IF EXISTS transactions_tenant_id_idx;
+DROP INDEX IF EXISTS audit_tenant_id_idx;
+
+ALTER TABLE actor_roles
+    DROP CONSTRAINT IF EXISTS actor_roles_role_id_fkey,
+    DROP CONSTRAINT IF EXISTS actor_roles_pkey;
+ALTER TABLE role_permissions
+    DROP CONSTRAINT IF EXISTS role_permissions_role_id_fkey,
+    DROP CONSTRAINT IF EXISTS role_permissions_pkey;
+ALTER TABLE user_tier_history
+    DROP CONSTRAINT IF EXISTS user_tier_history_user_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_tier_history_from_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_tier_history_to_tier_id_fkey;
+ALTER TABLE tier_rules
+    DROP CONSTRAINT IF EXISTS tier_rules_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_rules_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS unique_tenant_tier_rule_rank,
+    DROP CONSTRAINT IF EXISTS tier_rules_pkey;
+ALTER TABLE tier_policies
+    DROP CONSTRAINT IF EXISTS tier_policies_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_policies_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_policies_pkey;
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS transactions_account_id_fkey,
+    DROP CONSTRAINT IF EXISTS transactions_program_id_fkey,
+    DROP CONSTRAINT IF EXISTS transactions_exchange_rate_id_fkey;
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS accounts_user_id_fkey,
+    DROP CONSTRAINT IF EXISTS unique_wallet_user,
+    DROP CONSTRAINT IF EXISTS accounts_pkey;
+ALTER TABLE programs
+    DROP CONSTRAINT IF EXISTS programs_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS programs_trigger_slug_fkey,
+    DROP CONSTRAINT IF EXISTS unique_tenant_program;
+ALTER TABLE exchange_rates
+    DROP CONSTRAINT IF EXISTS exchange_rates_from_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS exchange_rates_to_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS exchange_rates_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS exclude_overlapping_exchange_rate_versions,
+    DROP CONSTRAINT IF EXISTS unique_tenant_exchange_rate;
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS users_pkey;
+ALTER TABLE triggers
+    DROP CONSTRAINT IF EXISTS unique_tenant_trigger_slug;
+ALTER TABLE roles
+    DROP CONSTRAINT IF EXISTS unique_tenant_role_name,
+    DROP CONSTRAINT IF EXISTS roles_pkey;
+ALTER TABLE tiers
+    DROP CONSTRAINT IF EXISTS unique_tenant_tier_name,
+    DROP CONSTRAINT IF EXISTS tiers_pkey;
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS unique_tenant_wallet_name,
+    DROP CONSTRAINT IF EXISTS wallets_pkey;
+
+ALTER TABLE wallets
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT wallets_name_key UNIQUE (name);
+ALTER TABLE tiers
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT tiers_name_key UNIQUE (name);
+ALTER TABLE roles
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT roles_name_key UNIQUE (name);
+ALTER TABLE users
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT users_tier_id_fkey FOREIGN KEY (tier_id) REFERENCES tiers (id) ON DELETE SET NULL;
+ALTER TABLE triggers
+    ADD CONSTRAINT triggers_slug_key UNIQUE (slug);
+ALTER TABLE exchange_rates
+    ADD CONSTRAINT exchange_rates_from_wallet_id_fkey FOREIGN KEY (from_wallet_id) REFERENCES wallets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT exchange_rates_to_wallet_id_fkey FOREIGN KEY (to_wallet_id) REFERENCES wallets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT exchange_rates_tier_id_fkey FOREIGN KEY (tier_id) REFERENCES tiers (id) ON DELETE CASCADE,
+    ADD CONSTRAINT exclude_overlapping_exchange_rate_versions EXCLUDE USING gist (
+        from_wallet_id WITH =,
+        to_wallet_id WITH =,
+        (COALESCE(tier_id, '')) WITH =,
+        tsrange(valid_from, valid_until) WITH &&
+        );
+ALTER TABLE programs
+    ADD CONSTRAINT programs_wallet_id_fkey FOREIGN KEY (wallet_id) REFERENCES wallets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT programs_trigger_slug_fkey FOREIGN KEY (trigger_slug) REFERENCES triggers (slug) ON DELETE CASCADE;
+ALTER TABLE accounts
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT accounts_wallet_id_fkey FOREIGN KEY (wallet_id) REFERENCES wallets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT accounts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT unique_wallet_user UNIQUE (wallet_id, user_id);
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_wallet_id_fkey FOREIGN KEY (wallet_id) REFERENCES wallets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT transactions_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts (id) ON DELETE CASCADE,
+    ADD CONSTRAINT transactions_program_id_fkey FOREIGN KEY (program_id) REFERENCES programs (id) ON DELETE SET NULL,
+    ADD CONSTRAINT transactions_exchange_rate_id_fkey FOREIGN KEY (exchange_rate_id) REFERENCES exchange_rates (id) ON DELETE SET NULL;
+ALTER TABLE tier_policies
+    ADD PRIMARY KEY (wallet_id, tier_id),
+    ADD CONSTRAINT tier_policies_wallet_id_fkey FOREIGN KEY (wallet_id) REFERENCES wallets (id) ON DELETE CASCADE,
+    ADD CONSTRAINT tier_policies_tier_id_fkey FOREIGN KEY (tier_id) REFERENCES tiers (id) ON DELETE CASCADE;
+ALTER TABLE tier_rules
+    ADD PRIMARY KEY (tier_id),
+    ADD CONSTRAINT tier_rules_rank_key UNIQUE (rank),
+    ADD CONSTRAINT tier_rules_tier_id_fkey FOREIGN KEY (tier_id) REFERENCES tiers (id) ON DELETE CASCADE,
+    ADD CONSTRAINT tier_rules_wallet_id_fkey FOREIGN KEY (wallet_id) REFERENCES wallets (id) ON DELETE CASCADE;
+ALTER TABLE user_tier_history
+    ADD CONSTRAINT user_tier_history_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT user_tier_history_from_tier_id_fkey FOREIGN KEY (from_tier_id) REFERENCES tiers (id) ON DELETE SET NULL,
+    ADD CONSTRAINT user_tier_history_to_tier_id_fkey FOREIGN KEY (to_tier_id) REFERENCES tiers (id) ON DELETE SET NULL;
+ALTER TABLE role_permissions
+    ADD PRIMARY KEY (role_id, permission),
+    ADD CONSTRAINT role_permissions_role_id_fkey FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE;
+ALTER TABLE actor_roles
+    ADD PRIMARY KEY (actor_id, role_id),
+    ADD CONSTRAINT actor_roles_role_id_fkey FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE approvals
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE actor_roles
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE role_permissions
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE roles
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE user_tier_history
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tier_rules
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tier_policies
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE programs
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE triggers
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE exchange_rates
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tiers
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE audit
+    DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,201 @@
+CREATE TABLE IF NOT EXISTS tenants
+(
+    id         TEXT PRIMARY KEY CHECK (id ~ '^[a-z0-9]+(?:[-_][a-z0-9]+)*$'),
+    name       TEXT                    NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+-- Everything created before tenants existed belongs to the default tenant
+INSERT INTO tenants (id, name)
+VALUES ('default', 'Default')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE audit
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE tiers
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE exchange_rates
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE triggers
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE programs
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE tier_policies
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE tier_rules
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE user_tier_history
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE roles
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE role_permissions
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE actor_roles
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE approvals
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT DEFAULT 'default' NOT NULL REFERENCES tenants (id);
+
+-- Drop the references to keys that become unique per tenant only
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_tier_id_fkey;
+ALTER TABLE exchange_rates
+    DROP CONSTRAINT IF EXISTS exchange_rates_from_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS exchange_rates_to_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS exchange_rates_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS exclude_overlapping_exchange_rate_versions;
+ALTER TABLE programs
+    DROP CONSTRAINT IF EXISTS programs_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS programs_trigger_slug_fkey;
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS accounts_user_id_fkey,
+    DROP CONSTRAINT IF EXISTS unique_wallet_user;
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS transactions_account_id_fkey,
+    DROP CONSTRAINT IF EXISTS transactions_program_id_fkey,
+    DROP CONSTRAINT IF EXISTS transactions_exchange_rate_id_fkey;
+ALTER TABLE tier_policies
+    DROP CONSTRAINT IF EXISTS tier_policies_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_policies_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_policies_pkey;
+ALTER TABLE tier_rules
+    DROP CONSTRAINT IF EXISTS tier_rules_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_rules_wallet_id_fkey,
+    DROP CONSTRAINT IF EXISTS tier_rules_rank_key,
+    DROP CONSTRAINT IF EXISTS tier_rules_pkey;
+ALTER TABLE user_tier_history
+    DROP CONSTRAINT IF EXISTS user_tier_history_user_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_tier_history_from_tier_id_fkey,
+    DROP CONSTRAINT IF EXISTS user_tier_history_to_tier_id_fkey;
+ALTER TABLE role_permissions
+    DROP CONSTRAINT IF EXISTS role_permissions_role_id_fkey,
+    DROP CONSTRAINT IF EXISTS role_permissions_pkey;
+ALTER TABLE actor_roles
+    DROP CONSTRAINT IF EXISTS actor_roles_role_id_fkey,
+    DROP CONSTRAINT IF EXISTS actor_roles_pkey;
+
+-- Identifiers chosen by a tenant are unique within the tenant
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS wallets_name_key,
+    DROP CONSTRAINT IF EXISTS wallets_pkey,
+    ADD PRIMARY KEY (tenant_id, id),
+    ADD CONSTRAINT unique_tenant_wallet_name UNIQUE (tenant_id, name);
+ALTER TABLE tiers
+    DROP CONSTRAINT IF EXISTS tiers_name_key,
+    DROP CONSTRAINT IF EXISTS tiers_pkey,
+    ADD PRIMARY KEY (tenant_id, id),
+    ADD CONSTRAINT unique_tenant_tier_name UNIQUE (tenant_id, name);
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_pkey,
+    ADD PRIMARY KEY (tenant_id, id);
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_pkey,
+    ADD PRIMARY KEY (tenant_id, id);
+ALTER TABLE roles
+    DROP CONSTRAINT IF EXISTS roles_name_key,
+    DROP CONSTRAINT IF EXISTS roles_pkey,
+    ADD PRIMARY KEY (tenant_id, id),
+    ADD CONSTRAINT unique_tenant_role_name UNIQUE (tenant_id, name);
+ALTER TABLE triggers
+    DROP CONSTRAINT IF EXISTS triggers_slug_key,
+    ADD CONSTRAINT unique_tenant_trigger_slug UNIQUE (tenant_id, slug);
+
+-- Generated identifiers stay global, but are referenced together with their tenant.
+-- API key hashes stay globally unique too, as a key is looked up before its tenant is known
+ALTER TABLE exchange_rates
+    ADD CONSTRAINT unique_tenant_exchange_rate UNIQUE (tenant_id, id);
+ALTER TABLE programs
+    ADD CONSTRAINT unique_tenant_program UNIQUE (tenant_id, id);
+
+ALTER TABLE tier_policies
+    ADD PRIMARY KEY (tenant_id, wallet_id, tier_id);
+ALTER TABLE tier_rules
+    ADD PRIMARY KEY (tenant_id, tier_id),
+    ADD CONSTRAINT unique_tenant_tier_rule_rank UNIQUE (tenant_id, rank);
+ALTER TABLE role_permissions
+    ADD PRIMARY KEY (tenant_id, role_id, permission);
+ALTER TABLE actor_roles
+    ADD PRIMARY KEY (tenant_id, actor_id, role_id);
+ALTER TABLE accounts
+    ADD CONSTRAINT unique_wallet_user UNIQUE (tenant_id, wallet_id, user_id);
+ALTER TABLE exchange_rates
+    ADD CONSTRAINT exclude_overlapping_exchange_rate_versions EXCLUDE USING gist (
+        tenant_id WITH =,
+        from_wallet_id WITH =,
+        to_wallet_id WITH =,
+        (COALESCE(tier_id, '')) WITH =,
+        tsrange(valid_from, valid_until) WITH &&
+        );
+
+-- A row can only reference rows of its own tenant. Nulling a single column of a composite key needs PostgreSQL 15
+ALTER TABLE users
+    ADD CONSTRAINT users_tier_id_fkey FOREIGN KEY (tenant_id, tier_id)
+        REFERENCES tiers (tenant_id, id) ON DELETE SET NULL (tier_id);
+ALTER TABLE exchange_rates
+    ADD CONSTRAINT exchange_rates_from_wallet_id_fkey FOREIGN KEY (tenant_id, from_wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT exchange_rates_to_wallet_id_fkey FOREIGN KEY (tenant_id, to_wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT exchange_rates_tier_id_fkey FOREIGN KEY (tenant_id, tier_id)
+        REFERENCES tiers (tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE programs
+    ADD CONSTRAINT programs_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT programs_trigger_slug_fkey FOREIGN KEY (tenant_id, trigger_slug)
+        REFERENCES triggers (tenant_id, slug) ON DELETE CASCADE;
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT accounts_user_id_fkey FOREIGN KEY (tenant_id, user_id)
+        REFERENCES users (tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT transactions_account_id_fkey FOREIGN KEY (tenant_id, account_id)
+        REFERENCES accounts (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT transactions_program_id_fkey FOREIGN KEY (tenant_id, program_id)
+        REFERENCES programs (tenant_id, id) ON DELETE SET NULL (program_id),
+    ADD CONSTRAINT transactions_exchange_rate_id_fkey FOREIGN KEY (tenant_id, exchange_rate_id)
+        REFERENCES exchange_rates (tenant_id, id) ON DELETE SET NULL (exchange_rate_id);
+ALTER TABLE tier_policies
+    ADD CONSTRAINT tier_policies_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT tier_policies_tier_id_fkey FOREIGN KEY (tenant_id, tier_id)
+        REFERENCES tiers (tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE tier_rules
+    ADD CONSTRAINT tier_rules_tier_id_fkey FOREIGN KEY (tenant_id, tier_id)
+        REFERENCES tiers (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT tier_rules_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE user_tier_history
+    ADD CONSTRAINT user_tier_history_user_id_fkey FOREIGN KEY (tenant_id, user_id)
+        REFERENCES users (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT user_tier_history_from_tier_id_fkey FOREIGN KEY (tenant_id, from_tier_id)
+        REFERENCES tiers (tenant_id, id) ON DELETE SET NULL (from_tier_id),
+    ADD CONSTRAINT user_tier_history_to_tier_id_fkey FOREIGN KEY (tenant_id, to_tier_id)
+        REFERENCES tiers (tenant_id, id) ON DELETE SET NULL (to_tier_id);
+ALTER TABLE role_permissions
+    ADD CONSTRAINT role_permissions_role_id_fkey FOREIGN KEY (tenant_id, role_id)
+        REFERENCES roles (tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE actor_roles
+    ADD CONSTRAINT actor_roles_role_id_fkey FOREIGN KEY (tenant_id, role_id)
+        REFERENCES roles (tenant_id, id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS audit_tenant_id_idx ON audit (tenant_id);
+CREATE INDEX IF NOT EXISTS transactions_tenant_id_idx ON transactions (tenant_id);
+CREATE INDEX IF NOT EXISTS exchange_rates_tenant_id_idx ON exchange_rates (tenant_id);
+CREATE INDEX IF NOT EXISTS programs_tenant_id_idx ON programs (tenant_id);
+CREATE INDEX IF NOT EXISTS triggers_tenant_id_idx ON triggers (tenant_id);
+CREATE INDEX IF NOT EXISTS user_tier_history_tenant_id_idx ON user_tier_history (tenant_id);
+CREATE INDEX IF NOT EXISTS approvals_tenant_id_status_created_at_idx ON approvals (tenant_id, status, created_at);
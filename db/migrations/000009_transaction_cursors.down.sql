@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transactions_wallet_created_at_id_idx;
+DROP INDEX IF EXISTS transactions_account_created_at_id_idx;
@@ -0,0 +1,3 @@
+-- Transaction listings are paged by (created_at, id), newest first
+CREATE INDEX IF NOT EXISTS transactions_account_created_at_id_idx ON transactions (tenant_id, account_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_wallet_created_at_id_idx ON transactions (tenant_id, wallet_id, created_at DESC, id DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS new_balance,
+    DROP COLUMN IF EXISTS previous_balance;
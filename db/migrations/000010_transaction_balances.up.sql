@@ -0,0 +1,17 @@
+-- The balance of the account before and after each transaction, for statements and running balances
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS previous_balance BIGINT DEFAULT 0 NOT NULL CHECK (previous_balance >= 0),
+    ADD COLUMN IF NOT EXISTS new_balance      BIGINT DEFAULT 0 NOT NULL CHECK (new_balance >= 0);
+
+-- Replay the existing transactions of every account to backfill their balances
+UPDATE transactions t
+SET new_balance      = replayed.balance,
+    previous_balance = replayed.balance - replayed.change
+FROM (SELECT id,
+             wallet_id,
+             CASE WHEN type = 'CREDIT' THEN amount ELSE -amount END AS change,
+             SUM(CASE WHEN type = 'CREDIT' THEN amount ELSE -amount END)
+             OVER (PARTITION BY tenant_id, account_id ORDER BY created_at, id) AS balance
+      FROM transactions) replayed
+WHERE t.id = replayed.id
+  AND t.wallet_id = replayed.wallet_id;
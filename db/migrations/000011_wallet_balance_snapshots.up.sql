@@ -0,0 +1,12 @@
+-- The liability of every wallet at the start of each day, so point in time liabilities only sum the transactions since
+CREATE TABLE IF NOT EXISTS wallet_balance_snapshots
+(
+    tenant_id  TEXT      DEFAULT 'default' NOT NULL REFERENCES tenants (id),
+    wallet_id  TEXT                        NOT NULL,
+    balance_at TIMESTAMP                   NOT NULL,
+    liability  BIGINT                      NOT NULL CHECK (liability >= 0),
+    created_at TIMESTAMP DEFAULT NOW()     NOT NULL,
+    PRIMARY KEY (tenant_id, wallet_id, balance_at),
+    CONSTRAINT wallet_balance_snapshots_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE
+);
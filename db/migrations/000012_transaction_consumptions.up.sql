@@ -0,0 +1,58 @@
+-- How much of each credit lot every debit drew from
+CREATE TABLE IF NOT EXISTS transaction_consumptions
+(
+    tenant_id             TEXT      DEFAULT 'default' NOT NULL REFERENCES tenants (id),
+    debit_transaction_id  TEXT                        NOT NULL,
+    credit_transaction_id TEXT                        NOT NULL,
+    wallet_id             TEXT                        NOT NULL,
+    account_id            TEXT                        NOT NULL,
+    amount                BIGINT                      NOT NULL CHECK (amount > 0),
+    created_at            TIMESTAMP DEFAULT NOW()     NOT NULL,
+    PRIMARY KEY (tenant_id, debit_transaction_id, credit_transaction_id),
+    CONSTRAINT transaction_consumptions_debit_transaction_id_fkey FOREIGN KEY (debit_transaction_id, wallet_id)
+        REFERENCES transactions (id, wallet_id) ON DELETE CASCADE,
+    CONSTRAINT transaction_consumptions_credit_transaction_id_fkey FOREIGN KEY (credit_transaction_id, wallet_id)
+        REFERENCES transactions (id, wallet_id) ON DELETE CASCADE,
+    CONSTRAINT transaction_consumptions_account_id_fkey FOREIGN KEY (tenant_id, account_id)
+        REFERENCES accounts (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS transaction_consumptions_credit_transaction_id_idx
+    ON transaction_consumptions (tenant_id, credit_transaction_id);
+CREATE INDEX IF NOT EXISTS transaction_consumptions_account_id_idx
+    ON transaction_consumptions (tenant_id, account_id, created_at);
+
+-- Replay the existing debits of every account against its credits in FIFO order. Credits and debits are laid out as
+-- consecutive ranges of their running totals, a debit consuming the part of every credit its range overlaps
+WITH credits AS (SELECT tenant_id,
+                        id,
+                        account_id,
+                        SUM(amount) OVER (PARTITION BY tenant_id, account_id ORDER BY created_at, id) - amount AS range_start,
+                        SUM(amount) OVER (PARTITION BY tenant_id, account_id ORDER BY created_at, id)          AS range_end
+                 FROM transactions
+                 WHERE type = 'CREDIT'),
+     debits AS (SELECT tenant_id,
+                       id,
+                       wallet_id,
+                       account_id,
+                       created_at,
+                       SUM(amount) OVER (PARTITION BY tenant_id, account_id ORDER BY created_at, id) - amount AS range_start,
+                       SUM(amount) OVER (PARTITION BY tenant_id, account_id ORDER BY created_at, id)          AS range_end
+                FROM transactions
+                WHERE type = 'DEBIT')
+INSERT
+INTO transaction_consumptions (tenant_id, debit_transaction_id, credit_transaction_id, wallet_id, account_id, amount,
+                               created_at)
+SELECT d.tenant_id,
+       d.id,
+       c.id,
+       d.wallet_id,
+       d.account_id,
+       LEAST(c.range_end, d.range_end) - GREATEST(c.range_start, d.range_start),
+       d.created_at
+FROM debits d
+         JOIN credits c ON c.tenant_id = d.tenant_id
+    AND c.account_id = d.account_id
+    AND c.range_start < d.range_end
+    AND d.range_start < c.range_end
+ON CONFLICT DO NOTHING;
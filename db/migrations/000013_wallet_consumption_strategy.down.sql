@@ -0,0 +1,4 @@
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS check_wallet_consumption_strategy,
+    DROP COLUMN IF EXISTS consumption_priority,
+    DROP COLUMN IF EXISTS consumption_strategy;
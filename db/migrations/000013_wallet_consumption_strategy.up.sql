@@ -0,0 +1,6 @@
+-- The order the credit lots of an account are consumed in when it is debited
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS consumption_strategy TEXT  DEFAULT 'FIFO' NOT NULL,
+    ADD COLUMN IF NOT EXISTS consumption_priority JSONB DEFAULT '[]'   NOT NULL,
+    ADD CONSTRAINT check_wallet_consumption_strategy CHECK (consumption_strategy IN
+                                                            ('FIFO', 'EXPIRING_FIRST', 'LIFO', 'PRIORITY'));
@@ -0,0 +1,5 @@
+DELETE
+FROM role_permissions
+WHERE permission = 'report:read';
+
+DROP TABLE IF EXISTS wallet_liability_summaries;
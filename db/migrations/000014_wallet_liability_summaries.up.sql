@@ -0,0 +1,20 @@
+-- The points every wallet issued, had redeemed and had expire each month, refreshed from the transactions by a job
+CREATE TABLE IF NOT EXISTS wallet_liability_summaries
+(
+    tenant_id    TEXT      DEFAULT 'default' NOT NULL REFERENCES tenants (id),
+    wallet_id    TEXT                        NOT NULL,
+    month        DATE                        NOT NULL,
+    issued       BIGINT    DEFAULT 0         NOT NULL CHECK (issued >= 0),
+    redeemed     BIGINT    DEFAULT 0         NOT NULL CHECK (redeemed >= 0),
+    expired      BIGINT    DEFAULT 0         NOT NULL CHECK (expired >= 0),
+    refreshed_at TIMESTAMP DEFAULT NOW()     NOT NULL,
+    PRIMARY KEY (tenant_id, wallet_id, month),
+    CONSTRAINT wallet_liability_summaries_wallet_id_fkey FOREIGN KEY (tenant_id, wallet_id)
+        REFERENCES wallets (tenant_id, id) ON DELETE CASCADE
+);
+
+INSERT INTO role_permissions (tenant_id, role_id, permission)
+SELECT tenant_id, id, 'report:read'
+FROM roles
+WHERE id = 'admin'
+ON CONFLICT DO NOTHING;
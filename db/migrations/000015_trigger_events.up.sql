@@ -0,0 +1,14 @@
+-- Every trigger fired for a user, aggregated by program conditions such as count(purchase, month)
+CREATE TABLE IF NOT EXISTS trigger_events
+(
+    tenant_id    TEXT      DEFAULT 'default' NOT NULL REFERENCES tenants (id),
+    id           BIGSERIAL PRIMARY KEY,
+    trigger_slug TEXT                        NOT NULL,
+    user_id      TEXT                        NOT NULL,
+    data         JSONB     DEFAULT '{}'      NOT NULL,
+    created_at   TIMESTAMP DEFAULT NOW()     NOT NULL,
+    CONSTRAINT trigger_events_user_id_fkey FOREIGN KEY (tenant_id, user_id)
+        REFERENCES users (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_trigger_events_user_trigger ON trigger_events (tenant_id, user_id, trigger_slug, created_at);
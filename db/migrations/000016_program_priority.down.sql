@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_programs_trigger_priority;
+
+ALTER TABLE programs
+    DROP COLUMN IF EXISTS max_reward_per_event,
+    DROP COLUMN IF EXISTS stop_processing,
+    DROP COLUMN IF EXISTS exclusive_group,
+    DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,9 @@
+-- How programs of the same trigger combine: the order they run in, the group of which only one can fire for an event,
+-- whether they stop the programs after them and the most a program can reward for a single event
+ALTER TABLE programs
+    ADD COLUMN IF NOT EXISTS priority             INT     DEFAULT 0     NOT NULL,
+    ADD COLUMN IF NOT EXISTS exclusive_group      TEXT,
+    ADD COLUMN IF NOT EXISTS stop_processing      BOOLEAN DEFAULT FALSE NOT NULL,
+    ADD COLUMN IF NOT EXISTS max_reward_per_event BIGINT CHECK (max_reward_per_event > 0);
+
+CREATE INDEX IF NOT EXISTS idx_programs_trigger_priority ON programs (tenant_id, trigger_slug, priority DESC);
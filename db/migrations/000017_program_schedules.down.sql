@@ -0,0 +1,2 @@
+ALTER TABLE programs
+    DROP COLUMN IF EXISTS schedule;
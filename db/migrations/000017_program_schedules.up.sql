@@ -0,0 +1,3 @@
+-- The recurring windows and blackout dates a program runs in, any time when it has none
+ALTER TABLE programs
+    ADD COLUMN IF NOT EXISTS schedule JSONB;
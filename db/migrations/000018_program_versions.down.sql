@@ -0,0 +1,8 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS program_version;
+
+DROP TABLE IF EXISTS program_versions;
+
+ALTER TABLE programs
+    DROP COLUMN IF EXISTS draft,
+    DROP COLUMN IF EXISTS version;
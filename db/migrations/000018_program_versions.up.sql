@@ -0,0 +1,52 @@
+-- Programs run their published version, while edits wait in a draft until they are published as the next version
+ALTER TABLE programs
+    ADD COLUMN IF NOT EXISTS version BIGINT DEFAULT 1 NOT NULL,
+    ADD COLUMN IF NOT EXISTS draft   JSONB;
+
+-- Every published version of a program, which is never updated
+CREATE TABLE IF NOT EXISTS program_versions
+(
+    tenant_id        TEXT      DEFAULT 'default' NOT NULL REFERENCES tenants (id),
+    id               BIGSERIAL PRIMARY KEY,
+    program_id       INT                         NOT NULL,
+    version          BIGINT                      NOT NULL CHECK (version > 0),
+    definition       JSONB                       NOT NULL,
+    rolled_back_from BIGINT, -- the earlier version a rollback published again
+    created_at       TIMESTAMP DEFAULT NOW()     NOT NULL,
+    CONSTRAINT program_versions_program_id_fkey FOREIGN KEY (tenant_id, program_id)
+        REFERENCES programs (tenant_id, id) ON DELETE CASCADE,
+    CONSTRAINT unique_program_version UNIQUE (tenant_id, program_id, version)
+);
+
+-- Existing programs start at version 1, holding their current definition
+INSERT INTO program_versions (tenant_id, program_id, version, definition, created_at)
+SELECT tenant_id,
+       id,
+       1,
+       jsonb_build_object(
+               'name', name,
+               'walletId', wallet_id,
+               'triggerSlug', trigger_slug,
+               'condition', condition,
+               'effect', effect,
+               'validFrom', to_char(valid_from, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+               'validUntil', to_char(valid_until, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+               'limitPerUser', limit_per_user,
+               'limitGlobal', limit_global,
+               'priority', priority,
+               'exclusiveGroup', exclusive_group,
+               'stopProcessing', stop_processing,
+               'maxRewardPerEvent', max_reward_per_event,
+               'schedule', schedule
+       ),
+       updated_at
+FROM programs
+ON CONFLICT DO NOTHING;
+
+-- The program version that granted a reward
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS program_version BIGINT;
+
+UPDATE transactions
+SET program_version = 1
+WHERE program_id IS NOT NULL;
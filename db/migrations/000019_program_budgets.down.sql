@@ -0,0 +1,3 @@
+ALTER TABLE programs
+    DROP COLUMN IF EXISTS budget_consumed,
+    DROP COLUMN IF EXISTS budget;
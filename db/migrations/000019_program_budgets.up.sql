@@ -0,0 +1,4 @@
+-- The total of points a program can reward, with its optional monetary value, and the points it rewarded so far
+ALTER TABLE programs
+    ADD COLUMN IF NOT EXISTS budget          JSONB,
+    ADD COLUMN IF NOT EXISTS budget_consumed BIGINT DEFAULT 0 NOT NULL CHECK (budget_consumed >= 0);
@@ -0,0 +1,16 @@
+UPDATE approvals
+SET status = 'PENDING'
+WHERE status = 'APPROVING';
+
+DELETE
+FROM approvals
+WHERE operation = 'UPDATE_WALLET';
+
+ALTER TABLE approvals
+    DROP CONSTRAINT IF EXISTS approvals_status_check,
+    ADD CONSTRAINT approvals_status_check CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    DROP CONSTRAINT IF EXISTS approvals_operation_check,
+    ADD CONSTRAINT approvals_operation_check CHECK (operation IN
+                                                    ('CREATE_TRANSACTION', 'CREATE_EXCHANGE_RATE',
+                                                     'UPDATE_EXCHANGE_RATE', 'DELETE_EXCHANGE_RATE',
+                                                     'DELETE_WALLET', 'CREATE_PROGRAM', 'UPDATE_PROGRAM'));
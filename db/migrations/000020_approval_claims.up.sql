@@ -0,0 +1,11 @@
+-- A checker claims an approval while its operation is carried out, so concurrent approvals carry it out only once.
+-- Changing the approval threshold of a wallet needs approval too, so a single actor can't lift it
+ALTER TABLE approvals
+    DROP CONSTRAINT IF EXISTS approvals_status_check,
+    ADD CONSTRAINT approvals_status_check CHECK (status IN ('PENDING', 'APPROVING', 'APPROVED', 'REJECTED')),
+    DROP CONSTRAINT IF EXISTS approvals_operation_check,
+    ADD CONSTRAINT approvals_operation_check CHECK (operation IN
+                                                    ('CREATE_TRANSACTION', 'CREATE_EXCHANGE_RATE',
+                                                     'UPDATE_EXCHANGE_RATE', 'DELETE_EXCHANGE_RATE',
+                                                     'DELETE_WALLET', 'UPDATE_WALLET', 'CREATE_PROGRAM',
+                                                     'UPDATE_PROGRAM'));
@@ -0,0 +1,11 @@
+DELETE
+FROM approvals
+WHERE operation IN ('PUBLISH_PROGRAM', 'ROLLBACK_PROGRAM');
+
+ALTER TABLE approvals
+    DROP CONSTRAINT IF EXISTS approvals_operation_check,
+    ADD CONSTRAINT approvals_operation_check CHECK (operation IN
+                                                    ('CREATE_TRANSACTION', 'CREATE_EXCHANGE_RATE',
+                                                     'UPDATE_EXCHANGE_RATE', 'DELETE_EXCHANGE_RATE',
+                                                     'DELETE_WALLET', 'UPDATE_WALLET', 'CREATE_PROGRAM',
+                                                     'UPDATE_PROGRAM'));
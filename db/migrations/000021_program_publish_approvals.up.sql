@@ -0,0 +1,8 @@
+-- Publishing a draft or rolling back an active program changes what it rewards, so it needs approval like activating it
+ALTER TABLE approvals
+    DROP CONSTRAINT IF EXISTS approvals_operation_check,
+    ADD CONSTRAINT approvals_operation_check CHECK (operation IN
+                                                    ('CREATE_TRANSACTION', 'CREATE_EXCHANGE_RATE',
+                                                     'UPDATE_EXCHANGE_RATE', 'DELETE_EXCHANGE_RATE',
+                                                     'DELETE_WALLET', 'UPDATE_WALLET', 'CREATE_PROGRAM',
+                                                     'UPDATE_PROGRAM', 'PUBLISH_PROGRAM', 'ROLLBACK_PROGRAM'));
@@ -0,0 +1,5 @@
+UPDATE wallet_liability_summaries
+SET redeemed = redeemed + other_debits;
+
+ALTER TABLE wallet_liability_summaries
+    DROP COLUMN IF EXISTS other_debits;
@@ -0,0 +1,19 @@
+-- Only redeemed and purchased points are redemptions. Withdrawals, exchanges to other wallets and penalties are kept
+-- apart so they no longer inflate the redemptions, and the months summarized so far are summed up again
+ALTER TABLE wallet_liability_summaries
+    ADD COLUMN IF NOT EXISTS other_debits BIGINT DEFAULT 0 NOT NULL CHECK (other_debits >= 0);
+
+UPDATE wallet_liability_summaries s
+SET redeemed     = t.redeemed,
+    other_debits = t.other_debits
+FROM (SELECT tenant_id,
+             wallet_id,
+             date_trunc('month', created_at)::date                                                          AS month,
+             COALESCE(SUM(amount) FILTER (WHERE type = 'DEBIT' AND reason IN ('REDEEM', 'PURCHASE')), 0)    AS redeemed,
+             COALESCE(SUM(amount)
+                      FILTER (WHERE type = 'DEBIT' AND reason NOT IN ('REDEEM', 'PURCHASE', 'EXPIRED')), 0) AS other_debits
+      FROM transactions
+      GROUP BY tenant_id, wallet_id, date_trunc('month', created_at)) t
+WHERE s.tenant_id = t.tenant_id
+  AND s.wallet_id = t.wallet_id
+  AND s.month = t.month;
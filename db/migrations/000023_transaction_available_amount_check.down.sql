@@ -0,0 +1 @@
+-- The corrected check is kept, the original one rejected every credit with a positive available amount
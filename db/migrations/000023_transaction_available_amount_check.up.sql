@@ -0,0 +1,6 @@
+-- The available amount of a credit lot goes down from its amount to 0 as it is consumed. The check created with the
+-- transactions table had its bounds swapped (BETWEEN amount AND 0), which only holds for empty transactions, so it
+-- rejected every credit with a positive available amount once lots started being tracked
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_available_amount_check,
+    ADD CONSTRAINT transactions_available_amount_check CHECK (available_amount BETWEEN 0 AND amount);
@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_exchange_rate_id_fkey,
+    ADD CONSTRAINT transactions_exchange_rate_id_fkey FOREIGN KEY (tenant_id, exchange_rate_id)
+        REFERENCES exchange_rates (tenant_id, id) ON DELETE SET NULL (exchange_rate_id);
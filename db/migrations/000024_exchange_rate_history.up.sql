@@ -0,0 +1,7 @@
+-- Exchange rate versions referenced by transactions can no longer be deleted
+-- Transactions keep the exchange rate version they were converted with. Versions are closed instead of deleted, so
+-- deleting one that a transaction references is rejected rather than clearing the reference
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_exchange_rate_id_fkey,
+    ADD CONSTRAINT transactions_exchange_rate_id_fkey FOREIGN KEY (tenant_id, exchange_rate_id)
+        REFERENCES exchange_rates (tenant_id, id) ON DELETE RESTRICT;
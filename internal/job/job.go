@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"time"
+)
+
+// Schedule runs a job at every interval until the context is cancelled.
+// Each run gets its own SYSTEM actor context, so the changes it makes are audited under the job name.
+func Schedule(ctx context.Context, name string, interval time.Duration, run func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			runCtx := api.CreateAppContext(ctx, api.AppActorSystem, name, fmt.Sprintf("%s-%d", name, t.Unix()))
+			api.GetLogger(runCtx).Info("Running job", logger.Field("job", name))
+			if err := run(runCtx); err != nil {
+				api.GetLogger(runCtx).Error("Job failed", logger.Field("job", name), logger.Field("error", err))
+			}
+		}
+	}
+}
+
+// ForEachTenant runs a job once for every tenant, confining each run to the tenant it is run for.
+// A failing tenant does not stop the others; their errors are joined.
+func ForEachTenant(fetchTenantIds func(ctx context.Context) ([]string, error), run func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		tenantIds, err := fetchTenantIds(ctx)
+		if err != nil {
+			return err
+		}
+		var errList []error
+		for _, tenantId := range tenantIds {
+			if err := run(api.WithTenant(ctx, tenantId)); err != nil {
+				api.GetLogger(ctx).Error("Job failed for tenant", logger.Field("tenantId", tenantId), logger.Field("error", err))
+				errList = append(errList, err)
+			}
+		}
+		return errors.Join(errList...)
+	}
+}
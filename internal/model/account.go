@@ -7,6 +7,7 @@ import (
 
 type Account struct {
 	Auditable
+	Tenanted
 	ID        string    `gorm:"column:id;primaryKey" json:"id"`
 	WalletID  string    `gorm:"column:wallet_id" json:"walletId"`
 	UserID    string    `gorm:"column:user_id" json:"userId"`
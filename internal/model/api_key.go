@@ -0,0 +1,58 @@
+package model
+
+import (
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ApiKey authenticates an internal service as the SYSTEM actor, within the wallets and permissions it is scoped to.
+// Only a hash of the key is stored, the key itself is shown once when it is created or rotated.
+type ApiKey struct {
+	Auditable
+	Tenanted
+	ID   string `gorm:"column:id;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Name string `gorm:"column:name" json:"name"`
+	// Prefix is the beginning of the key, enough to recognize it without revealing it
+	Prefix  string `gorm:"column:prefix" json:"prefix"`
+	KeyHash string `gorm:"column:key_hash" json:"-"`
+	// @swaggertype array,string
+	WalletIDs types.StringList `gorm:"column:wallet_ids" json:"walletIds"`
+	// @swaggertype array,string
+	Permissions types.StringList `gorm:"column:permissions" json:"permissions"`
+	// RateLimit is the number of requests the key can make per minute
+	RateLimit  int        `gorm:"column:rate_limit" json:"rateLimit"`
+	IsActive   bool       `gorm:"column:is_active;default:true" json:"isActive"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"lastUsedAt"`
+	RotatedAt  *time.Time `gorm:"column:rotated_at" json:"rotatedAt"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (m *ApiKey) TableName() string {
+	return "api_keys"
+}
+
+func (m *ApiKey) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *ApiKey) AfterUpdate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationUpdate, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *ApiKey) AfterDelete(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationDelete, m.ID, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
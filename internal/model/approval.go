@@ -0,0 +1,75 @@
+package model
+
+import (
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	ApprovalStatusPending = "PENDING"
+	// ApprovalStatusApproving is held by the checker carrying out the approved operation
+	ApprovalStatusApproving = "APPROVING"
+	ApprovalStatusApproved  = "APPROVED"
+	ApprovalStatusRejected  = "REJECTED"
+)
+
+// Sensitive operations a backoffice actor can't perform alone
+const (
+	ApprovalOperationCreateTransaction  = "CREATE_TRANSACTION"
+	ApprovalOperationCreateExchangeRate = "CREATE_EXCHANGE_RATE"
+	ApprovalOperationUpdateExchangeRate = "UPDATE_EXCHANGE_RATE"
+	ApprovalOperationDeleteExchangeRate = "DELETE_EXCHANGE_RATE"
+	ApprovalOperationDeleteWallet       = "DELETE_WALLET"
+	ApprovalOperationUpdateWallet       = "UPDATE_WALLET"
+	ApprovalOperationCreateProgram      = "CREATE_PROGRAM"
+	ApprovalOperationUpdateProgram      = "UPDATE_PROGRAM"
+	ApprovalOperationPublishProgram     = "PUBLISH_PROGRAM"
+	ApprovalOperationRollbackProgram    = "ROLLBACK_PROGRAM"
+)
+
+// Approval holds a sensitive operation requested by a backoffice actor (the maker)
+// until a second backoffice actor (the checker) approves or rejects it
+type Approval struct {
+	Auditable
+	Tenanted
+	ID        string `gorm:"column:id;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Operation string `gorm:"column:operation" json:"operation"`
+	// Permission is the permission the checker needs to approve the operation
+	Permission string `gorm:"column:permission" json:"permission"`
+	Status     string `gorm:"column:status" json:"status"`
+	// Payload is a snapshot of the request, replayed once the operation is approved
+	// @swaggertype object
+	Payload types.JSONB `gorm:"column:payload" json:"payload"`
+	// Result is a snapshot of the outcome of the approved operation
+	// @swaggertype object
+	Result       *types.JSONB `gorm:"column:result" json:"result"`
+	MakerActor   string       `gorm:"column:maker_actor" json:"makerActor"`
+	MakerID      string       `gorm:"column:maker_id" json:"makerId"`
+	CheckerActor *string      `gorm:"column:checker_actor" json:"checkerActor"`
+	CheckerID    *string      `gorm:"column:checker_id" json:"checkerId"`
+	Reason       *string      `gorm:"column:reason" json:"reason"`
+	DecidedAt    *time.Time   `gorm:"column:decided_at" json:"decidedAt"`
+	CreatedAt    time.Time    `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt    time.Time    `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (m *Approval) TableName() string {
+	return "approvals"
+}
+
+func (m *Approval) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *Approval) AfterUpdate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationUpdate, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
@@ -9,9 +9,12 @@ const (
 	AuditOperationCreate = "CREATE"
 	AuditOperationUpdate = "UPDATE"
 	AuditOperationDelete = "DELETE"
+	// AuditOperationAccessDenied records an attempt denied for lack of permission
+	AuditOperationAccessDenied = "ACCESS_DENIED"
 )
 
 type Audit struct {
+	Tenanted
 	ID        string  `gorm:"column:id;primaryKey;default:uuid_generate_v4()" json:"id"`
 	Operation string  `gorm:"column:operation" json:"operation"`
 	Table     string  `gorm:"column:table_name" json:"table"`
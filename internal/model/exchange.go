@@ -9,6 +9,7 @@ import (
 
 type ExchangeRate struct {
 	Auditable
+	Tenanted
 	ID           uint64  `gorm:"column:id;primary_key" json:"id"`
 	FromWalletID string  `gorm:"column:from_wallet_id" json:"fromWalletId"`
 	ToWalletID   string  `gorm:"column:to_wallet_id" json:"toWalletId"`
@@ -16,6 +17,8 @@ type ExchangeRate struct {
 	// @swaggertype number
 	ExchangeRate  decimal.Decimal `gorm:"column:exchange_rate" json:"exchangeRate"`
 	MinimumAmount *uint64         `gorm:"column:minimum_amount" json:"minimumAmount"`
+	ValidFrom     time.Time       `gorm:"column:valid_from" json:"validFrom"`
+	ValidUntil    *time.Time      `gorm:"column:valid_until" json:"validUntil"`
 	CreatedAt     time.Time       `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt     time.Time       `gorm:"column:updated_at" json:"updatedAt"`
 }
@@ -24,6 +27,11 @@ func (m *ExchangeRate) TableName() string {
 	return "exchange_rates"
 }
 
+// IsActiveAt reports whether this exchange rate version applies at the given time
+func (m *ExchangeRate) IsActiveAt(at time.Time) bool {
+	return !m.ValidFrom.After(at) && (m.ValidUntil == nil || m.ValidUntil.After(at))
+}
+
 func (m *ExchangeRate) AfterCreate(tx *gorm.DB) error {
 	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, strconv.FormatUint(m.ID, 10), m)
 	if err != nil {
@@ -10,25 +10,86 @@ import (
 
 type Program struct {
 	Auditable
-	ID           uint64           `json:"id" gorm:"column:id;primaryKey"`
-	Name         string           `json:"name" gorm:"column:name"`
-	WalletID     string           `json:"walletId" gorm:"column:wallet_id"`
-	TriggerSlug  string           `json:"triggerSlug" gorm:"column:trigger_slug"`
-	Condition    rule_engine.Rule `json:"condition" gorm:"column:condition"`
-	Effect       types.JSONB      `json:"effect" gorm:"column:effect"`
-	ValidFrom    time.Time        `json:"validFrom" gorm:"column:valid_from"`
-	ValidUntil   *time.Time       `json:"validUntil" gorm:"column:valid_until"`
-	IsActive     bool             `json:"isActive;default:false" gorm:"column:is_active"`
-	LimitPerUser *uint64          `json:"limitPerUser" gorm:"column:limit_per_user"`
-	LimitGlobal  *uint64          `json:"limitGlobal" gorm:"column:limit_global"`
-	CreatedAt    time.Time        `json:"createdAt" gorm:"column:created_at"`
-	UpdatedAt    time.Time        `json:"updatedAt" gorm:"column:updated_at"`
+	Tenanted
+	ID                  uint64           `json:"id" gorm:"column:id;primaryKey"`
+	Name                string           `json:"name" gorm:"column:name"`
+	WalletID            string           `json:"walletId" gorm:"column:wallet_id"`
+	TriggerSlug         string           `json:"triggerSlug" gorm:"column:trigger_slug"`
+	Condition           rule_engine.Rule `json:"condition" gorm:"column:condition"`
+	ConditionExpression string           `json:"conditionExpression" gorm:"-"`
+	Effect              types.JSONB      `json:"effect" gorm:"column:effect"`
+	ValidFrom           time.Time        `json:"validFrom" gorm:"column:valid_from"`
+	ValidUntil          *time.Time       `json:"validUntil" gorm:"column:valid_until"`
+	IsActive            bool             `json:"isActive;default:false" gorm:"column:is_active"`
+	LimitPerUser        *uint64          `json:"limitPerUser" gorm:"column:limit_per_user"`
+	LimitGlobal         *uint64          `json:"limitGlobal" gorm:"column:limit_global"`
+	Priority            int              `json:"priority" gorm:"column:priority"`
+	ExclusiveGroup      *string          `json:"exclusiveGroup" gorm:"column:exclusive_group"`
+	StopProcessing      bool             `json:"stopProcessing" gorm:"column:stop_processing"`
+	MaxRewardPerEvent   *uint64          `json:"maxRewardPerEvent" gorm:"column:max_reward_per_event"`
+	Schedule            *ProgramSchedule `json:"schedule" gorm:"column:schedule"`
+	// Budget is the total of points the program rewards, BudgetConsumed the points it rewarded so far. Budgets are not
+	// versioned, and the consumption is only ever changed along with the rewards posted
+	Budget         *ProgramBudget `json:"budget" gorm:"column:budget"`
+	BudgetConsumed uint64         `json:"budgetConsumed" gorm:"column:budget_consumed;->"`
+	// Version is the published version the program runs
+	Version uint64 `json:"version" gorm:"column:version"`
+	// Draft holds the edits that are not published yet
+	Draft     *ProgramDefinition `json:"draft" gorm:"column:draft"`
+	CreatedAt time.Time          `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time          `json:"updatedAt" gorm:"column:updated_at"`
 }
 
 func (m *Program) TableName() string {
 	return "programs"
 }
 
+// AfterFind writes the condition in the rule language
+func (m *Program) AfterFind(tx *gorm.DB) error {
+	m.ConditionExpression = rule_engine.Format(m.Condition)
+	return nil
+}
+
+// Definition returns the definition of the published version the program runs
+func (m *Program) Definition() ProgramDefinition {
+	return ProgramDefinition{
+		Name:                m.Name,
+		WalletID:            m.WalletID,
+		TriggerSlug:         m.TriggerSlug,
+		Condition:           m.Condition,
+		ConditionExpression: m.ConditionExpression,
+		Effect:              m.Effect,
+		ValidFrom:           m.ValidFrom,
+		ValidUntil:          m.ValidUntil,
+		LimitPerUser:        m.LimitPerUser,
+		LimitGlobal:         m.LimitGlobal,
+		Priority:            m.Priority,
+		ExclusiveGroup:      m.ExclusiveGroup,
+		StopProcessing:      m.StopProcessing,
+		MaxRewardPerEvent:   m.MaxRewardPerEvent,
+		Schedule:            m.Schedule,
+	}
+}
+
+// ApplyDefinition makes the program run the definition, as when a version is published
+func (m *Program) ApplyDefinition(definition ProgramDefinition) {
+	m.Name = definition.Name
+	m.WalletID = definition.WalletID
+	m.TriggerSlug = definition.TriggerSlug
+	m.Condition = definition.Condition
+	m.ConditionExpression = rule_engine.Format(definition.Condition)
+	m.Effect = definition.Effect
+	m.ValidFrom = definition.ValidFrom
+	m.ValidUntil = definition.ValidUntil
+	m.LimitPerUser = definition.LimitPerUser
+	m.LimitGlobal = definition.LimitGlobal
+	m.Priority = definition.Priority
+	m.ExclusiveGroup = definition.ExclusiveGroup
+	m.StopProcessing = definition.StopProcessing
+	m.MaxRewardPerEvent = definition.MaxRewardPerEvent
+	m.Schedule = definition.Schedule
+}
+
 func (m *Program) AfterCreate(tx *gorm.DB) error {
 	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, strconv.FormatUint(m.ID, 10), m)
 	if err != nil {
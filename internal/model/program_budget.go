@@ -0,0 +1,127 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"sort"
+	"time"
+)
+
+// ProgramBudget is the total of points a campaign can reward, set by finance besides the count limits of the program.
+// The program switches itself off once its budget runs out
+type ProgramBudget struct {
+	Points uint64 `json:"points"`
+	// MonetaryValue is the optional value of the points in Currency, for finance to report on
+	// @swaggertype number
+	MonetaryValue *decimal.Decimal `json:"monetaryValue,omitempty"`
+	Currency      string           `json:"currency,omitempty"`
+	// AlertThresholds are the percentages of the points consumed at which an alert is published, the configured
+	// thresholds when there are none. Running out of the budget always alerts
+	AlertThresholds []int `json:"alertThresholds,omitempty"`
+	// AllowPartial grants what remains of the budget to a reward exceeding it, which is otherwise not granted at all
+	AllowPartial bool `json:"allowPartial"`
+}
+
+// Validate returns the invalid parts of the budget keyed by their path, e.g. "alertThresholds[0]".
+func (b ProgramBudget) Validate() map[string]string {
+	fields := make(map[string]string)
+	if b.Points == 0 {
+		fields["points"] = "must be greater than 0"
+	}
+	if b.MonetaryValue != nil && b.MonetaryValue.IsNegative() {
+		fields["monetaryValue"] = "must not be negative"
+	}
+	if b.MonetaryValue != nil && len(b.Currency) != 3 {
+		fields["currency"] = "must be an ISO 4217 currency code such as USD"
+	}
+	for i, threshold := range b.AlertThresholds {
+		if threshold <= 0 || threshold > 100 {
+			fields[fmt.Sprintf("alertThresholds[%d]", i)] = "must be a percentage between 1 and 100"
+		}
+	}
+	return fields
+}
+
+// Remaining returns the points left of the budget once the consumed points are taken out
+func (b ProgramBudget) Remaining(consumed uint64) uint64 {
+	if consumed >= b.Points {
+		return 0
+	}
+	return b.Points - consumed
+}
+
+// Grant returns how much of a reward the budget grants once the consumed points are taken out: all of it when it fits,
+// what remains of the budget when partial rewards are allowed, and nothing otherwise
+func (b ProgramBudget) Grant(consumed uint64, reward uint64) uint64 {
+	remaining := b.Remaining(consumed)
+	if reward <= remaining || b.AllowPartial {
+		return min(reward, remaining)
+	}
+	return 0
+}
+
+// CrossedThresholds returns the alert thresholds that consuming the budget from before to after points crosses, from
+// the lowest, using the default thresholds when the budget has none
+func (b ProgramBudget) CrossedThresholds(before, after uint64, defaults []int) []int {
+	thresholds := b.AlertThresholds
+	if len(thresholds) == 0 {
+		thresholds = defaults
+	}
+	thresholds = append(append([]int(nil), thresholds...), 100)
+	sort.Ints(thresholds)
+	var crossed []int
+	for i, threshold := range thresholds {
+		if i > 0 && threshold == thresholds[i-1] {
+			continue
+		}
+		// Consumption crosses a threshold once consumed/points reaches threshold/100
+		limit := decimal.NewFromUint64(b.Points).Mul(decimal.NewFromInt(int64(threshold))).Div(decimal.NewFromInt(100))
+		if decimal.NewFromUint64(before).LessThan(limit) && !decimal.NewFromUint64(after).LessThan(limit) {
+			crossed = append(crossed, threshold)
+		}
+	}
+	return crossed
+}
+
+// MonetaryValueOf returns the monetary value of some points of the budget, nil when the budget has no monetary value
+func (b ProgramBudget) MonetaryValueOf(points uint64) *decimal.Decimal {
+	if b.MonetaryValue == nil || b.Points == 0 {
+		return nil
+	}
+	value := b.MonetaryValue.Mul(decimal.NewFromUint64(points)).Div(decimal.NewFromUint64(b.Points)).Round(2)
+	return &value
+}
+
+// Value Marshal
+func (b ProgramBudget) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan Unmarshal
+func (b *ProgramBudget) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, b)
+}
+
+// ProgramBudgetAlert is published to the broker when the consumption of a program budget crosses an alert threshold
+type ProgramBudgetAlert struct {
+	TenantID  string `json:"tenantId"`
+	ProgramID uint64 `json:"programId"`
+	Name      string `json:"name"`
+	// Threshold is the percentage of the budget crossed
+	Threshold int    `json:"threshold"`
+	Points    uint64 `json:"points"`
+	Consumed  uint64 `json:"consumed"`
+	// @swaggertype number
+	ConsumedValue *decimal.Decimal `json:"consumedValue,omitempty"`
+	Currency      string           `json:"currency,omitempty"`
+	// Exhausted tells the budget ran out and the program switched itself off
+	Exhausted bool      `json:"exhausted"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	rule_engine "github.com/abdelrahman146/digital-wallet/pkg/rules_engine"
+	"sync"
+	"time"
+)
+
+// ProgramSchedule restricts a program to recurring windows, such as happy hours every Friday from 18:00 to 22:00 or
+// weekends only, outside of blackout dates. Times and dates are read in the timezone of the schedule, or in the
+// default rules timezone when it has none.
+type ProgramSchedule struct {
+	Timezone string `json:"timezone,omitempty"`
+	// Windows are the times the program runs at, any time when there are none
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+	// BlackoutDates are days the program never runs on, as YYYY-MM-DD
+	BlackoutDates []string `json:"blackoutDates,omitempty"`
+}
+
+// ScheduleWindow is a range of times on some days of the week. A window ending before it starts spans midnight and
+// belongs to the day it starts on, e.g. Friday from 22:00 until 02:00.
+type ScheduleWindow struct {
+	// Weekdays are the days the window starts on (MON to SUN), every day when there are none
+	Weekdays []string `json:"weekdays,omitempty"`
+	// From is the time the window opens at as HH:MM, midnight when empty
+	From string `json:"from,omitempty"`
+	// Until is the time the window closes at as HH:MM, the end of the day when empty
+	Until string `json:"until,omitempty"`
+}
+
+// scheduleLocations caches the timezones of the schedules by name, so they are only loaded once
+var scheduleLocations sync.Map
+
+const minutesPerDay = 24 * 60
+
+// Validate returns the invalid parts of the schedule keyed by their path, e.g. "windows[0].from".
+func (s ProgramSchedule) Validate() map[string]string {
+	fields := make(map[string]string)
+	if s.Timezone != "" {
+		if _, err := loadScheduleLocation(s.Timezone); err != nil {
+			fields["timezone"] = fmt.Sprintf("unknown timezone %q", s.Timezone)
+		}
+	}
+	for i, window := range s.Windows {
+		path := fmt.Sprintf("windows[%d].", i)
+		for _, weekday := range window.Weekdays {
+			if _, ok := rule_engine.ParseWeekday(weekday); !ok {
+				fields[path+"weekdays"] = fmt.Sprintf("unknown weekday %q, use MON to SUN", weekday)
+			}
+		}
+		from, fromErr := parseTimeOfDay(window.From, 0)
+		if fromErr != nil {
+			fields[path+"from"] = fromErr.Error()
+		}
+		until, untilErr := parseTimeOfDay(window.Until, minutesPerDay)
+		if untilErr != nil {
+			fields[path+"until"] = untilErr.Error()
+		}
+		if fromErr == nil && untilErr == nil && from == until {
+			fields[path+"until"] = "must differ from the start of the window"
+		}
+	}
+	for i, date := range s.BlackoutDates {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			fields[fmt.Sprintf("blackoutDates[%d]", i)] = "must be a date such as 2024-12-25"
+		}
+	}
+	return fields
+}
+
+// IsOpenAt reports whether the program runs at the given time. Schedules are validated when they are saved, so invalid
+// parts are ignored here.
+func (s ProgramSchedule) IsOpenAt(at time.Time, defaultLocation *time.Location) bool {
+	location := defaultLocation
+	if s.Timezone != "" {
+		if loaded, err := loadScheduleLocation(s.Timezone); err == nil {
+			location = loaded
+		}
+	}
+	local := at.In(location)
+	today := local.Format(time.DateOnly)
+	for _, date := range s.BlackoutDates {
+		if date == today {
+			return false
+		}
+	}
+	if len(s.Windows) == 0 {
+		return true
+	}
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range s.Windows {
+		if window.isOpenAt(local.Weekday(), minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOpenAt reports whether the window is open at the minute of the day on the given weekday.
+func (w ScheduleWindow) isOpenAt(weekday time.Weekday, minute int) bool {
+	from, fromErr := parseTimeOfDay(w.From, 0)
+	until, untilErr := parseTimeOfDay(w.Until, minutesPerDay)
+	if fromErr != nil || untilErr != nil {
+		return false
+	}
+	if from < until {
+		return w.startsOn(weekday) && minute >= from && minute < until
+	}
+	// The window spans midnight: it opened today, or opened yesterday and is not over yet
+	yesterday := (weekday + 6) % 7
+	return (w.startsOn(weekday) && minute >= from) || (w.startsOn(yesterday) && minute < until)
+}
+
+func (w ScheduleWindow) startsOn(weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, name := range w.Weekdays {
+		if day, ok := rule_engine.ParseWeekday(name); ok && day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// loadScheduleLocation loads a timezone by name, from the cache once it has been loaded.
+func loadScheduleLocation(name string) (*time.Location, error) {
+	if cached, ok := scheduleLocations.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	scheduleLocations.Store(name, location)
+	return location, nil
+}
+
+// parseTimeOfDay reads a time of day as HH:MM into minutes since midnight, returning the fallback when it is empty.
+func parseTimeOfDay(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a time such as 18:00")
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// Value Marshal
+func (s ProgramSchedule) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan Unmarshal
+func (s *ProgramSchedule) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, s)
+}
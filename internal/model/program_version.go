@@ -0,0 +1,78 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	rule_engine "github.com/abdelrahman146/digital-wallet/pkg/rules_engine"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// ProgramDefinition holds the parts of a program that decide when it fires and what it rewards, which drafts edit and
+// versions fix. Whether the program is active is not part of it, so a program is switched on and off without a new
+// version
+type ProgramDefinition struct {
+	Name                string           `json:"name"`
+	WalletID            string           `json:"walletId"`
+	TriggerSlug         string           `json:"triggerSlug"`
+	Condition           rule_engine.Rule `json:"condition"`
+	ConditionExpression string           `json:"conditionExpression,omitempty"`
+	Effect              types.JSONB      `json:"effect"`
+	ValidFrom           time.Time        `json:"validFrom"`
+	ValidUntil          *time.Time       `json:"validUntil"`
+	LimitPerUser        *uint64          `json:"limitPerUser"`
+	LimitGlobal         *uint64          `json:"limitGlobal"`
+	Priority            int              `json:"priority"`
+	ExclusiveGroup      *string          `json:"exclusiveGroup"`
+	StopProcessing      bool             `json:"stopProcessing"`
+	MaxRewardPerEvent   *uint64          `json:"maxRewardPerEvent"`
+	Schedule            *ProgramSchedule `json:"schedule"`
+}
+
+// Value Marshal, leaving out the condition expression, which is written from the condition when it is read
+func (d ProgramDefinition) Value() (driver.Value, error) {
+	d.ConditionExpression = ""
+	return json.Marshal(d)
+}
+
+// Scan Unmarshal
+func (d *ProgramDefinition) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	if err := json.Unmarshal(b, d); err != nil {
+		return err
+	}
+	d.ConditionExpression = rule_engine.Format(d.Condition)
+	return nil
+}
+
+// ProgramVersion is a published definition of a program. Versions are numbered from 1 and never change, a rollback
+// publishes an earlier version again as a new one
+type ProgramVersion struct {
+	Auditable
+	Tenanted
+	ID         uint64            `json:"id" gorm:"column:id;primaryKey"`
+	ProgramID  uint64            `json:"programId" gorm:"column:program_id"`
+	Version    uint64            `json:"version" gorm:"column:version"`
+	Definition ProgramDefinition `json:"definition" gorm:"column:definition"`
+	// RolledBackFrom is the earlier version a rollback published again
+	RolledBackFrom *uint64   `json:"rolledBackFrom" gorm:"column:rolled_back_from"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (m *ProgramVersion) TableName() string {
+	return "program_versions"
+}
+
+func (m *ProgramVersion) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, strconv.FormatUint(m.ID, 10), m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
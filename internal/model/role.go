@@ -0,0 +1,90 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Role groups the backoffice permissions granted to the actors it is assigned to
+type Role struct {
+	Auditable
+	Tenanted
+	ID          string    `gorm:"column:id;primaryKey" json:"id"`
+	Name        string    `gorm:"column:name" json:"name"`
+	Description *string   `gorm:"column:description" json:"description"`
+	Permissions []string  `gorm:"-" json:"permissions"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (m *Role) TableName() string {
+	return "roles"
+}
+
+func (m *Role) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *Role) AfterUpdate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationUpdate, m.ID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *Role) AfterDelete(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationDelete, m.ID, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+// RolePermission is a permission granted by a role
+type RolePermission struct {
+	Tenanted
+	RoleID     string `gorm:"column:role_id;primaryKey" json:"roleId"`
+	Permission string `gorm:"column:permission;primaryKey" json:"permission"`
+}
+
+func (m *RolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// ActorRole assigns a role to a backoffice actor
+type ActorRole struct {
+	Auditable
+	Tenanted
+	ActorID   string    `gorm:"column:actor_id;primaryKey" json:"actorId"`
+	RoleID    string    `gorm:"column:role_id;primaryKey" json:"roleId"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (m *ActorRole) TableName() string {
+	return "actor_roles"
+}
+
+func (m *ActorRole) recordId() string {
+	return m.ActorID + ":" + m.RoleID
+}
+
+func (m *ActorRole) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, m.recordId(), m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *ActorRole) AfterDelete(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationDelete, m.recordId(), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
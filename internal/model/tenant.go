@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Tenant is a brand served by the deployment. Everything a tenant owns is invisible to the other tenants.
+type Tenant struct {
+	ID        string    `gorm:"column:id;primaryKey" json:"id"`
+	Name      string    `gorm:"column:name" json:"name"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (m *Tenant) TableName() string {
+	return "tenants"
+}
+
+// Tenanted is embedded by the models owned by a tenant. The tenant is stamped on create
+// and filtered on by every query, from the tenant of the context.
+type Tenanted struct {
+	TenantID string `gorm:"column:tenant_id" json:"-"`
+}
@@ -7,6 +7,7 @@ import (
 
 type Tier struct {
 	Auditable
+	Tenanted
 	ID          string    `gorm:"column:id;primaryKey;" json:"id"`
 	Name        string    `gorm:"column:name" json:"name"`
 	Description *string   `gorm:"column:description" json:"description"`
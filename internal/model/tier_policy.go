@@ -0,0 +1,55 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TierPolicy overrides the limits of a wallet for the users of a specific tier.
+// A nil limit falls back to the wallet default.
+type TierPolicy struct {
+	Auditable
+	Tenanted
+	WalletID   string  `gorm:"column:wallet_id;primaryKey" json:"walletId"`
+	TierID     string  `gorm:"column:tier_id;primaryKey" json:"tierId"`
+	MaxBalance *uint64 `gorm:"column:max_balance" json:"maxBalance"`
+	// @swaggertype number
+	EarnMultiplier       decimal.Decimal `gorm:"column:earn_multiplier" json:"earnMultiplier"`
+	MaxTransactionAmount *uint64         `gorm:"column:max_transaction_amount" json:"maxTransactionAmount"`
+	DailyDebitLimit      *uint64         `gorm:"column:daily_debit_limit" json:"dailyDebitLimit"`
+	CreatedAt            time.Time       `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt            time.Time       `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (m *TierPolicy) TableName() string {
+	return "tier_policies"
+}
+
+func (m *TierPolicy) recordId() string {
+	return m.WalletID + ":" + m.TierID
+}
+
+func (m *TierPolicy) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, m.recordId(), m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *TierPolicy) AfterUpdate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationUpdate, m.recordId(), m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *TierPolicy) AfterDelete(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationDelete, m.recordId(), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	// TierRuleMetricEarned qualifies users by the points credited to them as rewards or deposits
+	TierRuleMetricEarned = "EARNED"
+	// TierRuleMetricSpent qualifies users by the points they spent on purchases or redemptions
+	TierRuleMetricSpent = "SPENT"
+)
+
+// TierRule defines how users qualify for a tier, e.g. earning 10,000 points in a wallet within the last 12 months.
+// When users qualify for several tiers they get the one with the highest rank.
+type TierRule struct {
+	Auditable
+	Tenanted
+	TierID    string `gorm:"column:tier_id;primaryKey" json:"tierId"`
+	Rank      int    `gorm:"column:rank" json:"rank"`
+	WalletID  string `gorm:"column:wallet_id" json:"walletId"`
+	Metric    string `gorm:"column:metric" json:"metric"`
+	Threshold uint64 `gorm:"column:threshold" json:"threshold"`
+	// @swaggertype string
+	Window types.Interval `gorm:"column:qualification_window" json:"window"`
+	// RetentionPeriod is the minimum time users keep the tier after reaching it
+	// @swaggertype string
+	RetentionPeriod *types.Interval `gorm:"column:retention_period" json:"retentionPeriod"`
+	// GracePeriod is how long users keep the tier after they stop qualifying for it
+	// @swaggertype string
+	GracePeriod *types.Interval `gorm:"column:grace_period" json:"gracePeriod"`
+	IsActive    bool            `gorm:"column:is_active;default:true" json:"isActive"`
+	CreatedAt   time.Time       `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time       `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (m *TierRule) TableName() string {
+	return "tier_rules"
+}
+
+func (m *TierRule) AfterCreate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationCreate, m.TierID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *TierRule) AfterUpdate(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationUpdate, m.TierID, m)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
+
+func (m *TierRule) AfterDelete(tx *gorm.DB) error {
+	audit, err := m.CreateAudit(m.TableName(), AuditOperationDelete, m.TierID, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Create(audit).Error
+}
@@ -19,10 +19,12 @@ const (
 	TransactionReasonRedeem     = "REDEEM"
 	TransactionReasonPenalty    = "PENALTY"
 	TransactionReasonExpired    = "EXPIRED"
+	TransactionReasonReward     = "REWARD"
 )
 
 type Transaction struct {
 	Auditable
+	Tenanted
 	ID              string      `gorm:"column:id;primaryKey" json:"id"`
 	Type            string      `gorm:"column:type" json:"type"`
 	WalletID        string      `gorm:"column:wallet_id" json:"walletId"`
@@ -30,6 +32,8 @@ type Transaction struct {
 	Reason          string      `gorm:"column:reason" json:"reason"`
 	Metadata        types.JSONB `gorm:"column:metadata;type:jsonb" json:"metadata"`
 	ProgramID       *string     `gorm:"column:program_id" json:"programId"`
+	ProgramVersion  *uint64     `gorm:"column:program_version" json:"programVersion"`
+	ExchangeRateID  *uint64     `gorm:"column:exchange_rate_id" json:"exchangeRateId"`
 	Amount          uint64      `gorm:"column:amount" json:"amount"`
 	AvailableAmount uint64      `gorm:"column:available_amount" json:"availableAmount"`
 	ExpireAt        *time.Time  `gorm:"column:expire_at" json:"expireAt"`
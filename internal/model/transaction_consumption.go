@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TransactionConsumption records how much of a credit lot a debit drew from.
+// A debit consumes one or more credits of its account, each credit being consumed by one or more debits over time
+type TransactionConsumption struct {
+	Tenanted
+	DebitTransactionID  string    `gorm:"column:debit_transaction_id;primaryKey" json:"debitTransactionId"`
+	CreditTransactionID string    `gorm:"column:credit_transaction_id;primaryKey" json:"creditTransactionId"`
+	WalletID            string    `gorm:"column:wallet_id" json:"walletId"`
+	AccountID           string    `gorm:"column:account_id" json:"accountId"`
+	Amount              uint64    `gorm:"column:amount" json:"amount"`
+	CreatedAt           time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (m *TransactionConsumption) TableName() string {
+	return "transaction_consumptions"
+}
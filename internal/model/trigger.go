@@ -9,6 +9,7 @@ import (
 
 type Trigger struct {
 	Auditable
+	Tenanted
 	ID         uint64      `json:"id" gorm:"column:id;primaryKey"`
 	Name       string      `json:"name" gorm:"column:name"`
 	Slug       string      `json:"slug" gorm:"column:slug"`
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"time"
+)
+
+// TriggerEvent records a trigger fired for a user, so program conditions can aggregate the past events of the user.
+// Events are history and are not audited
+type TriggerEvent struct {
+	Tenanted
+	ID          uint64      `gorm:"column:id;primaryKey" json:"id"`
+	TriggerSlug string      `gorm:"column:trigger_slug" json:"triggerSlug"`
+	UserID      string      `gorm:"column:user_id" json:"userId"`
+	Data        types.JSONB `gorm:"column:data" json:"data"`
+	CreatedAt   time.Time   `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (m *TriggerEvent) TableName() string {
+	return "trigger_events"
+}
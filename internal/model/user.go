@@ -7,12 +7,17 @@ import (
 
 type User struct {
 	Auditable
-	ID        string    `json:"id" gorm:"column:id;primaryKey"`
-	TierID    *string   `json:"tierId" gorm:"column:tier_id"`
-	IsActive  bool      `json:"isActive;default:true" gorm:"column:is_active"`
-	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
-	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
-	Accounts  []Account `json:"accounts,omitempty" gorm:"foreignKey:UserID;references:ID"`
+	Tenanted
+	ID     string  `json:"id" gorm:"column:id;primaryKey"`
+	TierID *string `json:"tierId" gorm:"column:tier_id"`
+	// TierAchievedAt is when the user reached their current tier
+	TierAchievedAt *time.Time `json:"tierAchievedAt" gorm:"column:tier_achieved_at"`
+	// TierGraceUntil is when the user loses their current tier, set once they stop qualifying for it
+	TierGraceUntil *time.Time `json:"tierGraceUntil" gorm:"column:tier_grace_until"`
+	IsActive       bool       `json:"isActive" gorm:"column:is_active;default:true"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"column:updated_at"`
+	Accounts       []Account  `json:"accounts,omitempty" gorm:"foreignKey:UserID;references:ID"`
 }
 
 func (m *User) TableName() string {
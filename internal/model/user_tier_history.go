@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// UserTierHistory records a change of a user's tier, forming the user's tier timeline
+type UserTierHistory struct {
+	Tenanted
+	ID         uint64    `gorm:"column:id;primaryKey" json:"id"`
+	UserID     string    `gorm:"column:user_id" json:"userId"`
+	FromTierID *string   `gorm:"column:from_tier_id" json:"fromTierId"`
+	ToTierID   *string   `gorm:"column:to_tier_id" json:"toTierId"`
+	Actor      string    `gorm:"column:actor" json:"actor"`
+	ActorID    string    `gorm:"column:actor_id" json:"actorId"`
+	Remarks    *string   `gorm:"column:remarks" json:"remarks"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (m *UserTierHistory) TableName() string {
+	return "user_tier_history"
+}
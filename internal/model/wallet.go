@@ -6,8 +6,25 @@ import (
 	"time"
 )
 
+// The strategies a wallet consumes the credit lots of an account with when it is debited
+const (
+	// ConsumptionStrategyFIFO consumes the oldest credits first
+	ConsumptionStrategyFIFO = "FIFO"
+	// ConsumptionStrategyExpiringFirst consumes the credits expiring soonest first, the ones that never expire last
+	ConsumptionStrategyExpiringFirst = "EXPIRING_FIRST"
+	// ConsumptionStrategyLIFO consumes the newest credits first
+	ConsumptionStrategyLIFO = "LIFO"
+	// ConsumptionStrategyPriority consumes credits in the order of the consumption priority of the wallet, then oldest first
+	ConsumptionStrategyPriority = "PRIORITY"
+)
+
+// ConsumptionPriorityProgramPrefix prefixes a program ID in the consumption priority of a wallet, as in "program:12".
+// The other entries of the priority are transaction reasons
+const ConsumptionPriorityProgramPrefix = "program:"
+
 type Wallet struct {
 	Auditable
+	Tenanted
 	ID          string  `gorm:"column:id;primaryKey" json:"id"`
 	Name        string  `gorm:"column:name" json:"name"`
 	Description *string `gorm:"column:description" json:"description"`
@@ -17,11 +34,16 @@ type Wallet struct {
 	LimitPerUser      *uint64         `gorm:"column:limit_per_user" json:"limitPerUser"`
 	LimitGlobal       *uint64         `gorm:"column:limit_global" json:"limitGlobal"`
 	MinimumWithdrawal *uint64         `gorm:"column:minimum_withdrawal" json:"minimumWithdrawal"`
-	IsMonetary        bool            `gorm:"column:is_monetary;default:false" json:"isMonetary"`
-	IsActive          bool            `gorm:"column:is_active;default:true" json:"isActive"`
-	IsArchived        bool            `gorm:"column:is_archived;default:false" json:"isArchived"`
-	CreatedAt         time.Time       `gorm:"column:created_at" json:"createdAt"`
-	UpdatedAt         time.Time       `gorm:"column:updated_at" json:"updatedAt"`
+	ApprovalThreshold *uint64         `gorm:"column:approval_threshold" json:"approvalThreshold"`
+	// ConsumptionStrategy is the order the credit lots of an account are consumed in, FIFO by default
+	ConsumptionStrategy string `gorm:"column:consumption_strategy;default:FIFO" json:"consumptionStrategy"`
+	// ConsumptionPriority ranks the reasons and programs credits are consumed by with the PRIORITY strategy
+	ConsumptionPriority types.StringList `gorm:"column:consumption_priority" json:"consumptionPriority"`
+	IsMonetary          bool             `gorm:"column:is_monetary;default:false" json:"isMonetary"`
+	IsActive            bool             `gorm:"column:is_active;default:true" json:"isActive"`
+	IsArchived          bool             `gorm:"column:is_archived;default:false" json:"isArchived"`
+	CreatedAt           time.Time        `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt           time.Time        `gorm:"column:updated_at" json:"updatedAt"`
 }
 
 func (m *Wallet) TableName() string {
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// WalletBalanceSnapshot is the liability of a wallet, the sum of the balances of its accounts, at the end of a day.
+// Snapshots are derived from the transactions and are not audited
+type WalletBalanceSnapshot struct {
+	Tenanted
+	WalletID  string    `gorm:"column:wallet_id;primaryKey" json:"walletId"`
+	BalanceAt time.Time `gorm:"column:balance_at;primaryKey" json:"balanceAt"`
+	Liability uint64    `gorm:"column:liability" json:"liability"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (m *WalletBalanceSnapshot) TableName() string {
+	return "wallet_balance_snapshots"
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// WalletLiabilitySummary sums up the points a wallet issued, had redeemed, had expire and otherwise debited in a month.
+// Summaries are materialized from the transactions by a job and are not audited
+type WalletLiabilitySummary struct {
+	Tenanted
+	WalletID string    `gorm:"column:wallet_id;primaryKey" json:"walletId"`
+	Month    time.Time `gorm:"column:month;primaryKey" json:"month"`
+	Issued   uint64    `gorm:"column:issued" json:"issued"`
+	Redeemed uint64    `gorm:"column:redeemed" json:"redeemed"`
+	Expired  uint64    `gorm:"column:expired" json:"expired"`
+	// OtherDebits are the points withdrawn, exchanged to other wallets or taken as penalties
+	OtherDebits uint64    `gorm:"column:other_debits" json:"otherDebits"`
+	RefreshedAt time.Time `gorm:"column:refreshed_at" json:"refreshedAt"`
+}
+
+func (m *WalletLiabilitySummary) TableName() string {
+	return "wallet_liability_summaries"
+}
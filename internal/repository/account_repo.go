@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
 	"github.com/abdelrahman146/digital-wallet/internal/resource"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm"
 )
 
 type AccountRepo interface {
@@ -23,6 +26,8 @@ type AccountRepo interface {
 	CountWalletAccounts(ctx context.Context, walletId string) (int64, error)
 	// SumWalletAccounts Retrieves the sum of account balances for a wallet
 	SumWalletAccounts(ctx context.Context, walletId string) (uint64, error)
+	// UpdateAccountStatus Saves whether an account is active, bumping its version so in-flight postings are rejected
+	UpdateAccountStatus(ctx context.Context, account *model.Account) error
 }
 
 type accountRepo struct {
@@ -37,12 +42,12 @@ func NewAccountRepo(resources *resource.Resources) AccountRepo {
 // CreateAccount creates a new account and generates an account ID
 func (r *accountRepo) CreateAccount(ctx context.Context, account *model.Account) error {
 	// Generate account ID based on the wallet ID
-	if err := r.resources.DB.Raw("SELECT generate_account_id(?);", account.WalletID).Scan(&account.ID).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Raw("SELECT generate_account_id(?);", account.WalletID).Scan(&account.ID).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to generate account ID", logger.Field("error", err), logger.Field("account", account))
 		return err
 	}
 	// Create the account
-	if err := r.resources.DB.Create(account).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Create(account).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to create account", logger.Field("error", err), logger.Field("account", account))
 		return err
 	}
@@ -52,7 +57,7 @@ func (r *accountRepo) CreateAccount(ctx context.Context, account *model.Account)
 // FetchAccountByUserID retrieves an account by wallet ID and user ID
 func (r *accountRepo) FetchAccountByUserID(ctx context.Context, walletId, userId string) (*model.Account, error) {
 	var account model.Account
-	err := r.resources.DB.Where("wallet_id = ? AND user_id = ?", walletId, userId).First(&account).Error
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ? AND user_id = ?", walletId, userId).First(&account).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve account by user ID", logger.Field("error", err), logger.Field("userId", userId), logger.Field("walletId", walletId))
 		return nil, err
@@ -63,7 +68,7 @@ func (r *accountRepo) FetchAccountByUserID(ctx context.Context, walletId, userId
 // FetchAccountByID retrieves an account by its account ID
 func (r *accountRepo) FetchAccountByID(ctx context.Context, accountId string) (*model.Account, error) {
 	var account model.Account
-	err := r.resources.DB.Where("id = ?", accountId).First(&account).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", accountId).First(&account).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve account by ID", logger.Field("error", err), logger.Field("accountId", accountId))
 		return nil, err
@@ -74,7 +79,7 @@ func (r *accountRepo) FetchAccountByID(ctx context.Context, accountId string) (*
 // SumWalletAccounts retrieves the sum of balances for accounts in a wallet
 func (r *accountRepo) SumWalletAccounts(ctx context.Context, walletId string) (uint64, error) {
 	var sum uint64
-	err := r.resources.DB.Model(&model.Account{}).Select("COALESCE(SUM(balance), 0)").Where("wallet_id = ?", walletId).Scan(&sum).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Account{}).Select("COALESCE(SUM(balance), 0)").Where("wallet_id = ?", walletId).Scan(&sum).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve account balance sum", logger.Field("error", err), logger.Field("walletId", walletId))
 		return 0, err
@@ -85,7 +90,7 @@ func (r *accountRepo) SumWalletAccounts(ctx context.Context, walletId string) (u
 // FetchWalletAccounts retrieves a paginated list of accounts for a wallet
 func (r *accountRepo) FetchWalletAccounts(ctx context.Context, walletId string, page int, limit int) ([]model.Account, error) {
 	var accounts []model.Account
-	err := r.resources.DB.Where("wallet_id = ?", walletId).Order("created_at desc").
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ?", walletId).Order("created_at desc").
 		Offset((page - 1) * limit).Limit(limit).Find(&accounts).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve accounts", logger.Field("error", err), logger.Field("walletId", walletId))
@@ -97,7 +102,7 @@ func (r *accountRepo) FetchWalletAccounts(ctx context.Context, walletId string,
 // CountWalletAccounts retrieves the total number of accounts in a wallet
 func (r *accountRepo) CountWalletAccounts(ctx context.Context, walletId string) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Account{}).Where("wallet_id = ?", walletId).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Account{}).Where("wallet_id = ?", walletId).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve total accounts", logger.Field("error", err), logger.Field("walletId", walletId))
 		return 0, err
@@ -105,9 +110,29 @@ func (r *accountRepo) CountWalletAccounts(ctx context.Context, walletId string)
 	return total, nil
 }
 
+// UpdateAccountStatus saves whether an account is active.
+// The account version is bumped under optimistic locking, so postings prepared against the previous status are rejected.
+func (r *accountRepo) UpdateAccountStatus(ctx context.Context, account *model.Account) error {
+	version := account.Version
+	account.Version++
+	return r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(account).Where("version = ?", version).Select("is_active", "version", "updated_at").Updates(account)
+		if result.Error != nil {
+			api.GetLogger(ctx).Error("Failed to update account status", logger.Field("error", result.Error), logger.Field("account", account))
+			return result.Error
+		}
+		// Rolling back also discards the audit written by the update hook
+		if result.RowsAffected == 0 {
+			api.GetLogger(ctx).Error("Account version conflict", logger.Field("account", account), logger.Field("accountVersion", version))
+			return errs.NewConflictError(fmt.Sprintf("Account %s has been modified by another transaction", account.ID), "ACCOUNT_VERSION_MODIFIED", nil)
+		}
+		return nil
+	})
+}
+
 // DeleteAccount deletes an account from the database
 func (r *accountRepo) DeleteAccount(ctx context.Context, account *model.Account) error {
-	if err := r.resources.DB.Delete(account).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Delete(account).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to delete account", logger.Field("error", err), logger.Field("account", account))
 		return err
 	}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/resource"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"time"
+)
+
+type ApiKeyRepo interface {
+	// CreateApiKey stores a new API key
+	CreateApiKey(ctx context.Context, apiKey *model.ApiKey) error
+	// UpdateApiKey updates an API key, including its hash when it is rotated
+	UpdateApiKey(ctx context.Context, apiKey *model.ApiKey) error
+	// DeleteApiKey deletes an API key
+	DeleteApiKey(ctx context.Context, apiKey *model.ApiKey) error
+	// FetchApiKeyByID retrieves an API key by its ID
+	FetchApiKeyByID(ctx context.Context, apiKeyId string) (*model.ApiKey, error)
+	// FetchApiKeyByHash retrieves the API key matching a key hash, or nil if none does
+	FetchApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error)
+	// FetchApiKeys retrieves a paginated list of API keys
+	FetchApiKeys(ctx context.Context, page int, limit int) ([]model.ApiKey, error)
+	// CountApiKeys retrieves the total number of API keys
+	CountApiKeys(ctx context.Context) (int64, error)
+	// TouchApiKey records when an API key was last used, without auditing it
+	TouchApiKey(ctx context.Context, apiKeyId string, usedAt time.Time) error
+}
+
+type apiKeyRepo struct {
+	resources *resource.Resources
+}
+
+func NewApiKeyRepo(resources *resource.Resources) ApiKeyRepo {
+	return &apiKeyRepo{resources: resources}
+}
+
+func (r *apiKeyRepo) CreateApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	if err := r.resources.DB.WithContext(ctx).Create(apiKey).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to create api key", logger.Field("error", err), logger.Field("name", apiKey.Name))
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) UpdateApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	if err := r.resources.DB.WithContext(ctx).Save(apiKey).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to update api key", logger.Field("error", err), logger.Field("apiKeyId", apiKey.ID))
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) DeleteApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	if err := r.resources.DB.WithContext(ctx).Delete(apiKey).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to delete api key", logger.Field("error", err), logger.Field("apiKeyId", apiKey.ID))
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) FetchApiKeyByID(ctx context.Context, apiKeyId string) (*model.ApiKey, error) {
+	var apiKey model.ApiKey
+	if err := r.resources.DB.WithContext(ctx).Where("id = ?", apiKeyId).First(&apiKey).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to get api key by id", logger.Field("error", err), logger.Field("apiKeyId", apiKeyId))
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (r *apiKeyRepo) FetchApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error) {
+	var apiKeys []model.ApiKey
+	if err := r.resources.DB.WithContext(ctx).Where("key_hash = ?", keyHash).Limit(1).Find(&apiKeys).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to get api key by hash", logger.Field("error", err))
+		return nil, err
+	}
+	if len(apiKeys) == 0 {
+		return nil, nil
+	}
+	return &apiKeys[0], nil
+}
+
+func (r *apiKeyRepo) FetchApiKeys(ctx context.Context, page int, limit int) ([]model.ApiKey, error) {
+	var apiKeys []model.ApiKey
+	if err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&apiKeys).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to get api keys", logger.Field("error", err))
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepo) CountApiKeys(ctx context.Context) (int64, error) {
+	var total int64
+	if err := r.resources.DB.WithContext(ctx).Model(&model.ApiKey{}).Count(&total).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to get total api keys", logger.Field("error", err))
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *apiKeyRepo) TouchApiKey(ctx context.Context, apiKeyId string, usedAt time.Time) error {
+	err := r.resources.DB.WithContext(ctx).Model(&model.ApiKey{}).Where("id = ?", apiKeyId).UpdateColumn("last_used_at", usedAt).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to touch api key", logger.Field("error", err), logger.Field("apiKeyId", apiKeyId))
+		return err
+	}
+	return nil
+}
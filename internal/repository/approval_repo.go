@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/resource"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type ApprovalRepo interface {
+	// CreateApproval stores a pending approval
+	CreateApproval(ctx context.Context, approval *model.Approval) error
+	// ClaimApproval moves a pending approval to APPROVING, so a single checker carries out its operation. It fails if
+	// the approval was already claimed or decided
+	ClaimApproval(ctx context.Context, approvalId string) error
+	// ReleaseApproval moves a claimed approval back to PENDING once its operation failed
+	ReleaseApproval(ctx context.Context, approvalId string) error
+	// DecideApproval saves the decision on an approval, failing if it is no longer in the status it was decided from
+	DecideApproval(ctx context.Context, approval *model.Approval, from string) error
+	// FetchApprovalByID retrieves an approval by its ID
+	FetchApprovalByID(ctx context.Context, approvalId string) (*model.Approval, error)
+	// FetchApprovals retrieves a paginated list of approvals, optionally filtered by status
+	FetchApprovals(ctx context.Context, status string, page int, limit int) ([]model.Approval, error)
+	// CountApprovals retrieves the total number of approvals, optionally filtered by status
+	CountApprovals(ctx context.Context, status string) (int64, error)
+}
+
+type approvalRepo struct {
+	resources *resource.Resources
+}
+
+func NewApprovalRepo(resources *resource.Resources) ApprovalRepo {
+	return &approvalRepo{resources: resources}
+}
+
+func (r *approvalRepo) CreateApproval(ctx context.Context, approval *model.Approval) error {
+	if err := r.resources.DB.WithContext(ctx).Create(approval).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to create approval", logger.Field("error", err), logger.Field("approval", approval))
+		return err
+	}
+	return nil
+}
+
+func (r *approvalRepo) ClaimApproval(ctx context.Context, approvalId string) error {
+	result := r.resources.DB.WithContext(ctx).Model(&model.Approval{}).
+		Where("id = ? AND status = ?", approvalId, model.ApprovalStatusPending).
+		UpdateColumn("status", model.ApprovalStatusApproving)
+	if result.Error != nil {
+		api.GetLogger(ctx).Error("failed to claim approval", logger.Field("error", result.Error), logger.Field("approvalId", approvalId))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		api.GetLogger(ctx).Error("approval already claimed or decided", logger.Field("approvalId", approvalId))
+		return errs.NewConflictError("Approval already decided", "APPROVAL_ALREADY_DECIDED", nil)
+	}
+	return nil
+}
+
+func (r *approvalRepo) ReleaseApproval(ctx context.Context, approvalId string) error {
+	err := r.resources.DB.WithContext(ctx).Model(&model.Approval{}).
+		Where("id = ? AND status = ?", approvalId, model.ApprovalStatusApproving).
+		UpdateColumn("status", model.ApprovalStatusPending).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to release approval", logger.Field("error", err), logger.Field("approvalId", approvalId))
+		return err
+	}
+	return nil
+}
+
+func (r *approvalRepo) DecideApproval(ctx context.Context, approval *model.Approval, from string) error {
+	return r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(approval).Where("status = ?", from).
+			Select("status", "result", "checker_actor", "checker_id", "reason", "decided_at", "updated_at").Updates(approval)
+		if result.Error != nil {
+			api.GetLogger(ctx).Error("failed to decide approval", logger.Field("error", result.Error), logger.Field("approval", approval))
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			api.GetLogger(ctx).Error("approval already decided", logger.Field("approvalId", approval.ID))
+			return errs.NewConflictError("Approval already decided", "APPROVAL_ALREADY_DECIDED", nil)
+		}
+		return nil
+	})
+}
+
+func (r *approvalRepo) FetchApprovalByID(ctx context.Context, approvalId string) (*model.Approval, error) {
+	var approval model.Approval
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", approvalId).First(&approval).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get approval by id", logger.Field("error", err), logger.Field("approvalId", approvalId))
+		return nil, err
+	}
+	return &approval, nil
+}
+
+func (r *approvalRepo) FetchApprovals(ctx context.Context, status string, page int, limit int) ([]model.Approval, error) {
+	var approvals []model.Approval
+	query := r.resources.DB.WithContext(ctx).Order("created_at desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Offset((page - 1) * limit).Limit(limit).Find(&approvals).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get approvals", logger.Field("error", err), logger.Field("status", status))
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func (r *approvalRepo) CountApprovals(ctx context.Context, status string) (int64, error) {
+	var total int64
+	query := r.resources.DB.WithContext(ctx).Model(&model.Approval{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to get total approvals", logger.Field("error", err), logger.Field("status", status))
+		return 0, err
+	}
+	return total, nil
+}
@@ -9,6 +9,8 @@ import (
 )
 
 type AuditRepo interface {
+	// CreateAudit records an audit log that isn't tied to a record change, such as a denied access attempt
+	CreateAudit(ctx context.Context, audit *model.Audit) error
 	// FetchTableAuditLogs retrieves a paginated list of audit logs for a table
 	FetchTableAuditLogs(ctx context.Context, tableName string, page int, limit int) ([]model.Audit, error)
 	// CountTableAuditLogs retrieves the total number of audit logs for a table
@@ -31,10 +33,19 @@ func NewAuditRepo(resources *resource.Resources) AuditRepo {
 	return &auditRepo{resources: resources}
 }
 
+// CreateAudit records an audit log that isn't tied to a record change, such as a denied access attempt
+func (r *auditRepo) CreateAudit(ctx context.Context, audit *model.Audit) error {
+	if err := r.resources.DB.WithContext(ctx).Create(audit).Error; err != nil {
+		api.GetLogger(ctx).Error("Failed to create audit log", logger.Field("error", err), logger.Field("audit", audit))
+		return err
+	}
+	return nil
+}
+
 // FetchTableAuditLogs retrieves a paginated list of audit logs for a table
 func (r *auditRepo) FetchTableAuditLogs(ctx context.Context, tableName string, page int, limit int) ([]model.Audit, error) {
 	var audits []model.Audit
-	err := r.resources.DB.Where("table_name = ?", tableName).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&audits).Error
+	err := r.resources.DB.WithContext(ctx).Where("table_name = ?", tableName).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&audits).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to fetch table audit logs", logger.Field("error", err), logger.Field("tableName", tableName))
 		return nil, err
@@ -45,7 +56,7 @@ func (r *auditRepo) FetchTableAuditLogs(ctx context.Context, tableName string, p
 // CountTableAuditLogs retrieves the total number of audit logs for a table
 func (r *auditRepo) CountTableAuditLogs(ctx context.Context, tableName string) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Audit{}).Where("table_name = ?", tableName).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Audit{}).Where("table_name = ?", tableName).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to count table audit logs", logger.Field("error", err), logger.Field("tableName", tableName))
 		return 0, err
@@ -56,7 +67,7 @@ func (r *auditRepo) CountTableAuditLogs(ctx context.Context, tableName string) (
 // FetchRecordAuditLogs retrieves a paginated list of audit logs for a record
 func (r *auditRepo) FetchRecordAuditLogs(ctx context.Context, tableName, recordId string, page int, limit int) ([]model.Audit, error) {
 	var audits []model.Audit
-	err := r.resources.DB.Where("table_name = ? AND record_id = ?", tableName, recordId).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&audits).Error
+	err := r.resources.DB.WithContext(ctx).Where("table_name = ? AND record_id = ?", tableName, recordId).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&audits).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to fetch record audit logs", logger.Field("error", err), logger.Field("tableName", tableName), logger.Field("recordId", recordId))
 		return nil, err
@@ -67,7 +78,7 @@ func (r *auditRepo) FetchRecordAuditLogs(ctx context.Context, tableName, recordI
 // CountRecordAuditLogs retrieves the total number of audit logs for a record
 func (r *auditRepo) CountRecordAuditLogs(ctx context.Context, tableName, recordId string) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Audit{}).Where("table_name = ? AND record_id = ?", tableName, recordId).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Audit{}).Where("table_name = ? AND record_id = ?", tableName, recordId).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to count record audit logs", logger.Field("error", err), logger.Field("tableName", tableName), logger.Field("recordId", recordId))
 		return 0, err
@@ -78,7 +89,7 @@ func (r *auditRepo) CountRecordAuditLogs(ctx context.Context, tableName, recordI
 // FetchActorAuditLogs retrieves a paginated list of audit logs for an actor
 func (r *auditRepo) FetchActorAuditLogs(ctx context.Context, actor, actorId string, page int, limit int) ([]model.Audit, error) {
 	var audits []model.Audit
-	err := r.resources.DB.Where("actor = ? AND actor_id = ?", actor, actorId).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&audits).Error
+	err := r.resources.DB.WithContext(ctx).Where("actor = ? AND actor_id = ?", actor, actorId).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&audits).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to fetch actor audit logs", logger.Field("error", err), logger.Field("actorType", actor), logger.Field("actorId", actorId))
 		return nil, err
@@ -89,7 +100,7 @@ func (r *auditRepo) FetchActorAuditLogs(ctx context.Context, actor, actorId stri
 // CountActorAuditLogs retrieves the total number of audit logs for an actor
 func (r *auditRepo) CountActorAuditLogs(ctx context.Context, actor, actorId string) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Audit{}).Where("actor = ? AND actor_id = ?", actor, actorId).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Audit{}).Where("actor = ? AND actor_id = ?", actor, actorId).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to count actor audit logs", logger.Field("error", err), logger.Field("actorType", actor), logger.Field("actorId", actorId))
 		return 0, err
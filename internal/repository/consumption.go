@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"cmp"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"slices"
+)
+
+// lotOrder compares two credit lots, the one to be consumed first being the lesser
+type lotOrder func(a, b model.Transaction) int
+
+// consumptionStrategies builds the lot order of each consumption strategy for a wallet
+var consumptionStrategies = map[string]func(wallet *model.Wallet) lotOrder{
+	model.ConsumptionStrategyFIFO: func(*model.Wallet) lotOrder {
+		return oldestFirst
+	},
+	model.ConsumptionStrategyLIFO: func(*model.Wallet) lotOrder {
+		return func(a, b model.Transaction) int {
+			return oldestFirst(b, a)
+		}
+	},
+	model.ConsumptionStrategyExpiringFirst: func(*model.Wallet) lotOrder {
+		return func(a, b model.Transaction) int {
+			switch {
+			case a.ExpireAt == nil && b.ExpireAt == nil:
+			case a.ExpireAt == nil:
+				return 1
+			case b.ExpireAt == nil:
+				return -1
+			default:
+				if c := a.ExpireAt.Compare(*b.ExpireAt); c != 0 {
+					return c
+				}
+			}
+			return oldestFirst(a, b)
+		}
+	},
+	model.ConsumptionStrategyPriority: func(wallet *model.Wallet) lotOrder {
+		ranks := make(map[string]int, len(wallet.ConsumptionPriority))
+		for i, key := range wallet.ConsumptionPriority {
+			if _, ok := ranks[key]; !ok {
+				ranks[key] = i
+			}
+		}
+		rank := func(lot model.Transaction) int {
+			best := len(ranks)
+			if lot.ProgramID != nil {
+				if r, ok := ranks[model.ConsumptionPriorityProgramPrefix+*lot.ProgramID]; ok {
+					best = r
+				}
+			}
+			if r, ok := ranks[lot.Reason]; ok && r < best {
+				best = r
+			}
+			return best
+		}
+		return func(a, b model.Transaction) int {
+			if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+				return c
+			}
+			return oldestFirst(a, b)
+		}
+	},
+}
+
+// sortLots sorts the open credit lots of an account in the order the wallet consumes them, falling back to FIFO
+func sortLots(wallet *model.Wallet, lots []model.Transaction) {
+	strategy, ok := consumptionStrategies[wallet.ConsumptionStrategy]
+	if !ok {
+		strategy = consumptionStrategies[model.ConsumptionStrategyFIFO]
+	}
+	slices.SortStableFunc(lots, strategy(wallet))
+}
+
+func oldestFirst(a, b model.Transaction) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortLots(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time {
+		value := day.AddDate(0, 0, days)
+		return &value
+	}
+	promo := "12"
+	lots := []model.Transaction{
+		{ID: "TX-1", Reason: model.TransactionReasonDeposit, CreatedAt: day, ExpireAt: nil},
+		{ID: "TX-2", Reason: model.TransactionReasonReward, CreatedAt: day.Add(time.Hour), ExpireAt: at(30)},
+		{ID: "TX-3", Reason: model.TransactionReasonReward, ProgramID: &promo, CreatedAt: day.Add(2 * time.Hour), ExpireAt: at(10)},
+		{ID: "TX-4", Reason: model.TransactionReasonDeposit, CreatedAt: day.Add(2 * time.Hour), ExpireAt: at(10)},
+	}
+	testcases := []struct {
+		name     string
+		wallet   *model.Wallet
+		expected []string
+	}{
+		{
+			name:     "FIFO consumes the oldest credits first",
+			wallet:   &model.Wallet{ConsumptionStrategy: model.ConsumptionStrategyFIFO},
+			expected: []string{"TX-1", "TX-2", "TX-3", "TX-4"},
+		},
+		{
+			name:     "LIFO consumes the newest credits first",
+			wallet:   &model.Wallet{ConsumptionStrategy: model.ConsumptionStrategyLIFO},
+			expected: []string{"TX-4", "TX-3", "TX-2", "TX-1"},
+		},
+		{
+			name:     "EXPIRING_FIRST consumes the credits expiring soonest first and the ones never expiring last",
+			wallet:   &model.Wallet{ConsumptionStrategy: model.ConsumptionStrategyExpiringFirst},
+			expected: []string{"TX-3", "TX-4", "TX-2", "TX-1"},
+		},
+		{
+			name: "PRIORITY consumes the ranked programs and reasons first, then the oldest credits",
+			wallet: &model.Wallet{
+				ConsumptionStrategy: model.ConsumptionStrategyPriority,
+				ConsumptionPriority: []string{model.ConsumptionPriorityProgramPrefix + promo, model.TransactionReasonReward},
+			},
+			expected: []string{"TX-3", "TX-2", "TX-1", "TX-4"},
+		},
+		{
+			name:     "An unknown strategy falls back to FIFO",
+			wallet:   &model.Wallet{},
+			expected: []string{"TX-1", "TX-2", "TX-3", "TX-4"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted := make([]model.Transaction, len(lots))
+			copy(sorted, lots)
+			// the lots arrive oldest first from the database, start from another order to make sure they are sorted
+			sorted[0], sorted[3] = sorted[3], sorted[0]
+			sortLots(tc.wallet, sorted)
+			ids := make([]string, len(sorted))
+			for i, lot := range sorted {
+				ids[i] = lot.ID
+			}
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Errorf("expected lots %v, got %v", tc.expected, ids)
+			}
+		})
+	}
+}
@@ -1,9 +1,51 @@
 package repository
 
-import "github.com/abdelrahman146/digital-wallet/internal/model"
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"time"
+)
 
 type ExchangeRequest struct {
 	WalletID       string
 	Transaction    *model.Transaction
 	AccountVersion uint64
 }
+
+// ExchangeLeg is a single conversion step of an exchange, debiting one account and crediting another
+type ExchangeLeg struct {
+	From *ExchangeRequest
+	To   *ExchangeRequest
+}
+
+// BudgetConsumption is what a program reward consumed from the budget of the program
+type BudgetConsumption struct {
+	// Program is the rewarding program as it is after the reward
+	Program *model.Program
+	// Before and After are the points of the budget consumed before and after the reward
+	Before uint64
+	After  uint64
+}
+
+// TransactionFilter narrows down a transaction listing. Empty fields do not filter
+type TransactionFilter struct {
+	Type          string
+	Reason        string
+	ProgramID     *string
+	MinAmount     *uint64
+	MaxAmount     *uint64
+	From          *time.Time
+	To            *time.Time
+	MetadataKey   string
+	MetadataValue string
+	// Cursor lists the transactions following it, instead of the page
+	Cursor *api.Cursor
+}
+
+// ReasonTotal is the total amount and number of transactions of a type and reason
+type ReasonTotal struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	Amount uint64 `json:"amount"`
+	Count  int64  `json:"count"`
+}
@@ -2,23 +2,34 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
 	"github.com/abdelrahman146/digital-wallet/internal/resource"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type ExchangeRateRepo interface {
-	// CreateExchangeRate Creates a new exchange rate
+	// CreateExchangeRate Creates a new exchange rate version, closing the version it supersedes
 	CreateExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error
-	// UpdateExchangeRate Updates an existing exchange rate
-	UpdateExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error
-	// DeleteExchangeRate Deletes an exchange rate by its ID
+	// CloseExchangeRate Closes an exchange rate version at a given time, keeping it as history
+	CloseExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate, at time.Time) error
+	// DeleteExchangeRate Deletes a scheduled exchange rate version, handing its period back to the version before it
 	DeleteExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error
 	// FetchExchangeRateByID Retrieves an exchange rate by its ID
 	FetchExchangeRateByID(ctx context.Context, exchangeRateId string) (*model.ExchangeRate, error)
-	// FetchExchangeRate Retrieves an exchange rate by the source and destination wallet IDs, with an optional tier ID
-	FetchExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string) (*model.ExchangeRate, error)
+	// FetchExchangeRate Retrieves the exchange rate version active at a given time by the source and destination wallet IDs, with an optional tier ID, or nil if there is none
+	FetchExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string, at time.Time) (*model.ExchangeRate, error)
+	// FetchActiveExchangeRates Retrieves all exchange rate versions active at a given time for an optional tier ID
+	FetchActiveExchangeRates(ctx context.Context, tierId *string, at time.Time) ([]model.ExchangeRate, error)
+	// FetchExchangeRateVersions Retrieves a paginated list of all versions of an exchange rate pair
+	FetchExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string, page int, limit int) ([]model.ExchangeRate, error)
+	// CountExchangeRateVersions Retrieves the total number of versions of an exchange rate pair
+	CountExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string) (int64, error)
 	// FetchExchangeRates Retrieves a paginated list of exchange rates
 	FetchExchangeRates(ctx context.Context, page int, limit int) ([]model.ExchangeRate, error)
 	// CountExchangeRates Retrieves the total number of exchange rates
@@ -41,7 +52,7 @@ func NewExchangeRateRepo(resources *resource.Resources) ExchangeRateRepo {
 // FetchExchangeRateByID retrieves an exchange rate by its ID
 func (r *exchangeRateRepo) FetchExchangeRateByID(ctx context.Context, exchangeRateId string) (*model.ExchangeRate, error) {
 	var exchangeRate model.ExchangeRate
-	err := r.resources.DB.Where("id = ?", exchangeRateId).First(&exchangeRate).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", exchangeRateId).First(&exchangeRate).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve exchange rate by ID", logger.Field("error", err), logger.Field("exchangeRateId", exchangeRateId))
 		return nil, err
@@ -49,9 +60,45 @@ func (r *exchangeRateRepo) FetchExchangeRateByID(ctx context.Context, exchangeRa
 	return &exchangeRate, nil
 }
 
-// CreateExchangeRate creates a new exchange rate in the database
+// CreateExchangeRate creates a new exchange rate version in the database.
+// The version active at the new version's valid from is closed at that time, and the new version
+// is closed at the start of the next scheduled version if there is one.
 func (r *exchangeRateRepo) CreateExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error {
-	if err := r.resources.DB.Create(exchangeRate).Error; err != nil {
+	if exchangeRate.ValidFrom.IsZero() {
+		exchangeRate.ValidFrom = time.Now()
+	}
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var versions []model.ExchangeRate
+		err := whereTier(tx.Clauses(clause.Locking{Strength: "UPDATE"}), exchangeRate.TierID).
+			Where("from_wallet_id = ? AND to_wallet_id = ?", exchangeRate.FromWalletID, exchangeRate.ToWalletID).
+			Order("valid_from asc").Find(&versions).Error
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			if version.ValidFrom.Equal(exchangeRate.ValidFrom) {
+				return errs.NewConflictError("An exchange rate version already starts at this time", "EXCHANGE_RATE_VERSION_EXISTS", nil)
+			}
+			if version.ValidFrom.After(exchangeRate.ValidFrom) {
+				// the new version ends where the next scheduled version starts
+				validUntil := version.ValidFrom
+				exchangeRate.ValidUntil = &validUntil
+				break
+			}
+			if version.IsActiveAt(exchangeRate.ValidFrom) {
+				version.SetActor(exchangeRate.GetActor())
+				version.SetRemarks("Exchange rate version superseded")
+				version.SetOldRecord(version)
+				validUntil := exchangeRate.ValidFrom
+				version.ValidUntil = &validUntil
+				if err := tx.Save(&version).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Create(exchangeRate).Error
+	})
+	if err != nil {
 		api.GetLogger(ctx).Error("Failed to create exchange rate", logger.Field("error", err), logger.Field("exchangeRate", exchangeRate))
 		return err
 	}
@@ -61,7 +108,7 @@ func (r *exchangeRateRepo) CreateExchangeRate(ctx context.Context, exchangeRate
 // FetchExchangeRates retrieves a paginated list of exchange rates
 func (r *exchangeRateRepo) FetchExchangeRates(ctx context.Context, page int, limit int) ([]model.ExchangeRate, error) {
 	var exchangeRates []model.ExchangeRate
-	err := r.resources.DB.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&exchangeRates).Error
+	err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&exchangeRates).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve exchange rates", logger.Field("error", err))
 		return nil, err
@@ -72,7 +119,7 @@ func (r *exchangeRateRepo) FetchExchangeRates(ctx context.Context, page int, lim
 // CountExchangeRates retrieves the total number of exchange rates
 func (r *exchangeRateRepo) CountExchangeRates(ctx context.Context) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.ExchangeRate{}).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.ExchangeRate{}).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve total exchange rates count", logger.Field("error", err))
 		return 0, err
@@ -83,7 +130,7 @@ func (r *exchangeRateRepo) CountExchangeRates(ctx context.Context) (int64, error
 // FetchWalletExchangeRates retrieves exchange rates by wallet ID with pagination
 func (r *exchangeRateRepo) FetchWalletExchangeRates(ctx context.Context, walletId string, page int, limit int) ([]model.ExchangeRate, error) {
 	var exchangeRates []model.ExchangeRate
-	err := r.resources.DB.Where("from_wallet_id = ?", walletId).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&exchangeRates).Error
+	err := r.resources.DB.WithContext(ctx).Where("from_wallet_id = ?", walletId).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&exchangeRates).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve exchange rates by wallet ID", logger.Field("error", err), logger.Field("walletId", walletId))
 		return nil, err
@@ -94,7 +141,7 @@ func (r *exchangeRateRepo) FetchWalletExchangeRates(ctx context.Context, walletI
 // CountWalletExchangeRates retrieves the total number of exchange rates for a specific wallet
 func (r *exchangeRateRepo) CountWalletExchangeRates(ctx context.Context, walletId string) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.ExchangeRate{}).Where("from_wallet_id = ?", walletId).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.ExchangeRate{}).Where("from_wallet_id = ?", walletId).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve total exchange rates by wallet ID", logger.Field("error", err), logger.Field("walletId", walletId))
 		return 0, err
@@ -102,31 +149,107 @@ func (r *exchangeRateRepo) CountWalletExchangeRates(ctx context.Context, walletI
 	return total, nil
 }
 
-// UpdateExchangeRate updates an existing exchange rate in the database
-func (r *exchangeRateRepo) UpdateExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error {
-	if err := r.resources.DB.Save(exchangeRate).Error; err != nil {
-		api.GetLogger(ctx).Error("Failed to update exchange rate", logger.Field("error", err), logger.Field("exchangeRate", exchangeRate))
+// CloseExchangeRate closes an exchange rate version at a given time.
+// The version stays in the database so the transactions converted with it keep their rate.
+func (r *exchangeRateRepo) CloseExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate, at time.Time) error {
+	exchangeRate.ValidUntil = &at
+	if err := r.resources.DB.WithContext(ctx).Save(exchangeRate).Error; err != nil {
+		api.GetLogger(ctx).Error("Failed to close exchange rate", logger.Field("error", err), logger.Field("exchangeRate", exchangeRate))
 		return err
 	}
 	return nil
 }
 
-// DeleteExchangeRate deletes an exchange rate
+// DeleteExchangeRate deletes an exchange rate version that has not started yet.
+// The version that was closed at its valid from is extended to its valid until so the pair is left without a gap.
 func (r *exchangeRateRepo) DeleteExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error {
-	if err := r.resources.DB.Delete(exchangeRate).Error; err != nil {
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var versions []model.ExchangeRate
+		err := whereTier(tx.Clauses(clause.Locking{Strength: "UPDATE"}), exchangeRate.TierID).
+			Where("from_wallet_id = ? AND to_wallet_id = ?", exchangeRate.FromWalletID, exchangeRate.ToWalletID).
+			Where("valid_until = ?", exchangeRate.ValidFrom).
+			Find(&versions).Error
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			version.SetActor(exchangeRate.GetActor())
+			version.SetRemarks(fmt.Sprintf("Exchange rate version %d deleted before it started", exchangeRate.ID))
+			version.SetOldRecord(version)
+			version.ValidUntil = exchangeRate.ValidUntil
+			if err := tx.Save(&version).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(exchangeRate).Error
+	})
+	if err != nil {
 		api.GetLogger(ctx).Error("Failed to delete exchange rate", logger.Field("error", err), logger.Field("exchangeRate", exchangeRate))
 		return err
 	}
 	return nil
 }
 
-// FetchExchangeRate retrieves an exchange rate by source wallet ID, destination wallet ID, and optionally a tier ID
-func (r *exchangeRateRepo) FetchExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string) (*model.ExchangeRate, error) {
-	var exchangeRate model.ExchangeRate
-	err := r.resources.DB.Where("from_wallet_id = ? AND to_wallet_id = ? AND tier_id = ?", fromWalletId, toWalletId, tierId).First(&exchangeRate).Error
+// FetchExchangeRate retrieves the exchange rate version active at a given time by source wallet ID, destination wallet ID, and optionally a tier ID
+func (r *exchangeRateRepo) FetchExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string, at time.Time) (*model.ExchangeRate, error) {
+	var exchangeRates []model.ExchangeRate
+	err := whereTier(r.resources.DB.WithContext(ctx), tierId).
+		Where("from_wallet_id = ? AND to_wallet_id = ?", fromWalletId, toWalletId).
+		Where("valid_from <= ? AND (valid_until IS NULL OR valid_until > ?)", at, at).
+		Order("id").Limit(1).Find(&exchangeRates).Error
 	if err != nil {
-		api.GetLogger(ctx).Error("Failed to retrieve exchange rate by wallet IDs and tier ID", logger.Field("error", err), logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", tierId))
+		api.GetLogger(ctx).Error("Failed to retrieve exchange rate by wallet IDs and tier ID", logger.Field("error", err), logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", tierId), logger.Field("at", at))
 		return nil, err
 	}
-	return &exchangeRate, nil
+	if len(exchangeRates) == 0 {
+		return nil, nil
+	}
+	return &exchangeRates[0], nil
+}
+
+// FetchActiveExchangeRates retrieves all exchange rate versions active at a given time, forming the exchange graph for a tier
+func (r *exchangeRateRepo) FetchActiveExchangeRates(ctx context.Context, tierId *string, at time.Time) ([]model.ExchangeRate, error) {
+	var exchangeRates []model.ExchangeRate
+	err := whereTier(r.resources.DB.WithContext(ctx), tierId).
+		Where("valid_from <= ? AND (valid_until IS NULL OR valid_until > ?)", at, at).
+		Find(&exchangeRates).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve active exchange rates", logger.Field("error", err), logger.Field("tierId", tierId), logger.Field("at", at))
+		return nil, err
+	}
+	return exchangeRates, nil
+}
+
+// FetchExchangeRateVersions retrieves a paginated list of all versions of an exchange rate pair, latest first
+func (r *exchangeRateRepo) FetchExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string, page int, limit int) ([]model.ExchangeRate, error) {
+	var exchangeRates []model.ExchangeRate
+	err := whereTier(r.resources.DB.WithContext(ctx), tierId).
+		Where("from_wallet_id = ? AND to_wallet_id = ?", fromWalletId, toWalletId).
+		Order("valid_from desc").Offset((page - 1) * limit).Limit(limit).Find(&exchangeRates).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve exchange rate versions", logger.Field("error", err), logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", tierId))
+		return nil, err
+	}
+	return exchangeRates, nil
+}
+
+// CountExchangeRateVersions retrieves the total number of versions of an exchange rate pair
+func (r *exchangeRateRepo) CountExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string) (int64, error) {
+	var total int64
+	err := whereTier(r.resources.DB.WithContext(ctx).Model(&model.ExchangeRate{}), tierId).
+		Where("from_wallet_id = ? AND to_wallet_id = ?", fromWalletId, toWalletId).
+		Count(&total).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to count exchange rate versions", logger.Field("error", err), logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", tierId))
+		return 0, err
+	}
+	return total, nil
+}
+
+// whereTier filters by tier ID, where a nil tier ID matches the default (tier-less) rate
+func whereTier(db *gorm.DB, tierId *string) *gorm.DB {
+	if tierId == nil {
+		return db.Where("tier_id IS NULL")
+	}
+	return db.Where("tier_id = ?", *tierId)
 }
@@ -142,3 +142,17 @@ func (mr *MockAccountRepoMockRecorder) SumWalletAccounts(ctx, walletId any) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumWalletAccounts", reflect.TypeOf((*MockAccountRepo)(nil).SumWalletAccounts), ctx, walletId)
 }
+
+// UpdateAccountStatus mocks base method.
+func (m *MockAccountRepo) UpdateAccountStatus(ctx context.Context, account *model.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockAccountRepoMockRecorder) UpdateAccountStatus(ctx, account any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockAccountRepo)(nil).UpdateAccountStatus), ctx, account)
+}
@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/api_key_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/api_key_repo.go -destination=internal/repository/mocks/api_key_repo_mock.go -package=repository_mock
+//
+
+// Package repository_mock is a generated GoMock package.
+package repository_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApiKeyRepo is a mock of ApiKeyRepo interface.
+type MockApiKeyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyRepoMockRecorder
+}
+
+// MockApiKeyRepoMockRecorder is the mock recorder for MockApiKeyRepo.
+type MockApiKeyRepoMockRecorder struct {
+	mock *MockApiKeyRepo
+}
+
+// NewMockApiKeyRepo creates a new mock instance.
+func NewMockApiKeyRepo(ctrl *gomock.Controller) *MockApiKeyRepo {
+	mock := &MockApiKeyRepo{ctrl: ctrl}
+	mock.recorder = &MockApiKeyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyRepo) EXPECT() *MockApiKeyRepoMockRecorder {
+	return m.recorder
+}
+
+// CountApiKeys mocks base method.
+func (m *MockApiKeyRepo) CountApiKeys(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountApiKeys", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountApiKeys indicates an expected call of CountApiKeys.
+func (mr *MockApiKeyRepoMockRecorder) CountApiKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountApiKeys", reflect.TypeOf((*MockApiKeyRepo)(nil).CountApiKeys), ctx)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKeyRepo) CreateApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", ctx, apiKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeyRepoMockRecorder) CreateApiKey(ctx, apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).CreateApiKey), ctx, apiKey)
+}
+
+// DeleteApiKey mocks base method.
+func (m *MockApiKeyRepo) DeleteApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApiKey", ctx, apiKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApiKey indicates an expected call of DeleteApiKey.
+func (mr *MockApiKeyRepoMockRecorder) DeleteApiKey(ctx, apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).DeleteApiKey), ctx, apiKey)
+}
+
+// FetchApiKeyByHash mocks base method.
+func (m *MockApiKeyRepo) FetchApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchApiKeyByHash", ctx, keyHash)
+	ret0, _ := ret[0].(*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchApiKeyByHash indicates an expected call of FetchApiKeyByHash.
+func (mr *MockApiKeyRepoMockRecorder) FetchApiKeyByHash(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchApiKeyByHash", reflect.TypeOf((*MockApiKeyRepo)(nil).FetchApiKeyByHash), ctx, keyHash)
+}
+
+// FetchApiKeyByID mocks base method.
+func (m *MockApiKeyRepo) FetchApiKeyByID(ctx context.Context, apiKeyId string) (*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchApiKeyByID", ctx, apiKeyId)
+	ret0, _ := ret[0].(*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchApiKeyByID indicates an expected call of FetchApiKeyByID.
+func (mr *MockApiKeyRepoMockRecorder) FetchApiKeyByID(ctx, apiKeyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchApiKeyByID", reflect.TypeOf((*MockApiKeyRepo)(nil).FetchApiKeyByID), ctx, apiKeyId)
+}
+
+// FetchApiKeys mocks base method.
+func (m *MockApiKeyRepo) FetchApiKeys(ctx context.Context, page, limit int) ([]model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchApiKeys", ctx, page, limit)
+	ret0, _ := ret[0].([]model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchApiKeys indicates an expected call of FetchApiKeys.
+func (mr *MockApiKeyRepoMockRecorder) FetchApiKeys(ctx, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchApiKeys", reflect.TypeOf((*MockApiKeyRepo)(nil).FetchApiKeys), ctx, page, limit)
+}
+
+// TouchApiKey mocks base method.
+func (m *MockApiKeyRepo) TouchApiKey(ctx context.Context, apiKeyId string, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchApiKey", ctx, apiKeyId, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchApiKey indicates an expected call of TouchApiKey.
+func (mr *MockApiKeyRepoMockRecorder) TouchApiKey(ctx, apiKeyId, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).TouchApiKey), ctx, apiKeyId, usedAt)
+}
+
+// UpdateApiKey mocks base method.
+func (m *MockApiKeyRepo) UpdateApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApiKey", ctx, apiKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateApiKey indicates an expected call of UpdateApiKey.
+func (mr *MockApiKeyRepoMockRecorder) UpdateApiKey(ctx, apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).UpdateApiKey), ctx, apiKey)
+}
@@ -0,0 +1,142 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/approval_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/approval_repo.go -destination=internal/repository/mocks/approval_repo_mock.go -package=repository_mock
+//
+
+// Package repository_mock is a generated GoMock package.
+package repository_mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApprovalRepo is a mock of ApprovalRepo interface.
+type MockApprovalRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockApprovalRepoMockRecorder
+}
+
+// MockApprovalRepoMockRecorder is the mock recorder for MockApprovalRepo.
+type MockApprovalRepoMockRecorder struct {
+	mock *MockApprovalRepo
+}
+
+// NewMockApprovalRepo creates a new mock instance.
+func NewMockApprovalRepo(ctrl *gomock.Controller) *MockApprovalRepo {
+	mock := &MockApprovalRepo{ctrl: ctrl}
+	mock.recorder = &MockApprovalRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApprovalRepo) EXPECT() *MockApprovalRepoMockRecorder {
+	return m.recorder
+}
+
+// ClaimApproval mocks base method.
+func (m *MockApprovalRepo) ClaimApproval(ctx context.Context, approvalId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimApproval", ctx, approvalId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimApproval indicates an expected call of ClaimApproval.
+func (mr *MockApprovalRepoMockRecorder) ClaimApproval(ctx, approvalId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimApproval", reflect.TypeOf((*MockApprovalRepo)(nil).ClaimApproval), ctx, approvalId)
+}
+
+// CountApprovals mocks base method.
+func (m *MockApprovalRepo) CountApprovals(ctx context.Context, status string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountApprovals", ctx, status)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountApprovals indicates an expected call of CountApprovals.
+func (mr *MockApprovalRepoMockRecorder) CountApprovals(ctx, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountApprovals", reflect.TypeOf((*MockApprovalRepo)(nil).CountApprovals), ctx, status)
+}
+
+// CreateApproval mocks base method.
+func (m *MockApprovalRepo) CreateApproval(ctx context.Context, approval *model.Approval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApproval", ctx, approval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateApproval indicates an expected call of CreateApproval.
+func (mr *MockApprovalRepoMockRecorder) CreateApproval(ctx, approval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApproval", reflect.TypeOf((*MockApprovalRepo)(nil).CreateApproval), ctx, approval)
+}
+
+// DecideApproval mocks base method.
+func (m *MockApprovalRepo) DecideApproval(ctx context.Context, approval *model.Approval, from string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideApproval", ctx, approval, from)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecideApproval indicates an expected call of DecideApproval.
+func (mr *MockApprovalRepoMockRecorder) DecideApproval(ctx, approval, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideApproval", reflect.TypeOf((*MockApprovalRepo)(nil).DecideApproval), ctx, approval, from)
+}
+
+// FetchApprovalByID mocks base method.
+func (m *MockApprovalRepo) FetchApprovalByID(ctx context.Context, approvalId string) (*model.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchApprovalByID", ctx, approvalId)
+	ret0, _ := ret[0].(*model.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchApprovalByID indicates an expected call of FetchApprovalByID.
+func (mr *MockApprovalRepoMockRecorder) FetchApprovalByID(ctx, approvalId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchApprovalByID", reflect.TypeOf((*MockApprovalRepo)(nil).FetchApprovalByID), ctx, approvalId)
+}
+
+// FetchApprovals mocks base method.
+func (m *MockApprovalRepo) FetchApprovals(ctx context.Context, status string, page, limit int) ([]model.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchApprovals", ctx, status, page, limit)
+	ret0, _ := ret[0].([]model.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchApprovals indicates an expected call of FetchApprovals.
+func (mr *MockApprovalRepoMockRecorder) FetchApprovals(ctx, status, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchApprovals", reflect.TypeOf((*MockApprovalRepo)(nil).FetchApprovals), ctx, status, page, limit)
+}
+
+// ReleaseApproval mocks base method.
+func (m *MockApprovalRepo) ReleaseApproval(ctx context.Context, approvalId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseApproval", ctx, approvalId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseApproval indicates an expected call of ReleaseApproval.
+func (mr *MockApprovalRepoMockRecorder) ReleaseApproval(ctx, approvalId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseApproval", reflect.TypeOf((*MockApprovalRepo)(nil).ReleaseApproval), ctx, approvalId)
+}
@@ -85,6 +85,20 @@ func (mr *MockAuditRepoMockRecorder) CountTableAuditLogs(ctx, tableName any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTableAuditLogs", reflect.TypeOf((*MockAuditRepo)(nil).CountTableAuditLogs), ctx, tableName)
 }
 
+// CreateAudit mocks base method.
+func (m *MockAuditRepo) CreateAudit(ctx context.Context, audit *model.Audit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAudit", ctx, audit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAudit indicates an expected call of CreateAudit.
+func (mr *MockAuditRepoMockRecorder) CreateAudit(ctx, audit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAudit", reflect.TypeOf((*MockAuditRepo)(nil).CreateAudit), ctx, audit)
+}
+
 // FetchActorAuditLogs mocks base method.
 func (m *MockAuditRepo) FetchActorAuditLogs(ctx context.Context, actor, actorId string, page, limit int) ([]model.Audit, error) {
 	m.ctrl.T.Helper()
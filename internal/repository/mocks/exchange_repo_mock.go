@@ -12,6 +12,7 @@ package repository_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,35 @@ func (m *MockExchangeRateRepo) EXPECT() *MockExchangeRateRepoMockRecorder {
 	return m.recorder
 }
 
+// CloseExchangeRate mocks base method.
+func (m *MockExchangeRateRepo) CloseExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseExchangeRate", ctx, exchangeRate, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseExchangeRate indicates an expected call of CloseExchangeRate.
+func (mr *MockExchangeRateRepoMockRecorder) CloseExchangeRate(ctx, exchangeRate, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseExchangeRate", reflect.TypeOf((*MockExchangeRateRepo)(nil).CloseExchangeRate), ctx, exchangeRate, at)
+}
+
+// CountExchangeRateVersions mocks base method.
+func (m *MockExchangeRateRepo) CountExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountExchangeRateVersions", ctx, fromWalletId, toWalletId, tierId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountExchangeRateVersions indicates an expected call of CountExchangeRateVersions.
+func (mr *MockExchangeRateRepoMockRecorder) CountExchangeRateVersions(ctx, fromWalletId, toWalletId, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountExchangeRateVersions", reflect.TypeOf((*MockExchangeRateRepo)(nil).CountExchangeRateVersions), ctx, fromWalletId, toWalletId, tierId)
+}
+
 // CountExchangeRates mocks base method.
 func (m *MockExchangeRateRepo) CountExchangeRates(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
@@ -98,19 +128,34 @@ func (mr *MockExchangeRateRepoMockRecorder) DeleteExchangeRate(ctx, exchangeRate
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExchangeRate", reflect.TypeOf((*MockExchangeRateRepo)(nil).DeleteExchangeRate), ctx, exchangeRate)
 }
 
+// FetchActiveExchangeRates mocks base method.
+func (m *MockExchangeRateRepo) FetchActiveExchangeRates(ctx context.Context, tierId *string, at time.Time) ([]model.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchActiveExchangeRates", ctx, tierId, at)
+	ret0, _ := ret[0].([]model.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchActiveExchangeRates indicates an expected call of FetchActiveExchangeRates.
+func (mr *MockExchangeRateRepoMockRecorder) FetchActiveExchangeRates(ctx, tierId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchActiveExchangeRates", reflect.TypeOf((*MockExchangeRateRepo)(nil).FetchActiveExchangeRates), ctx, tierId, at)
+}
+
 // FetchExchangeRate mocks base method.
-func (m *MockExchangeRateRepo) FetchExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string) (*model.ExchangeRate, error) {
+func (m *MockExchangeRateRepo) FetchExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string, at time.Time) (*model.ExchangeRate, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchExchangeRate", ctx, fromWalletId, toWalletId, tierId)
+	ret := m.ctrl.Call(m, "FetchExchangeRate", ctx, fromWalletId, toWalletId, tierId, at)
 	ret0, _ := ret[0].(*model.ExchangeRate)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchExchangeRate indicates an expected call of FetchExchangeRate.
-func (mr *MockExchangeRateRepoMockRecorder) FetchExchangeRate(ctx, fromWalletId, toWalletId, tierId any) *gomock.Call {
+func (mr *MockExchangeRateRepoMockRecorder) FetchExchangeRate(ctx, fromWalletId, toWalletId, tierId, at any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchExchangeRate", reflect.TypeOf((*MockExchangeRateRepo)(nil).FetchExchangeRate), ctx, fromWalletId, toWalletId, tierId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchExchangeRate", reflect.TypeOf((*MockExchangeRateRepo)(nil).FetchExchangeRate), ctx, fromWalletId, toWalletId, tierId, at)
 }
 
 // FetchExchangeRateByID mocks base method.
@@ -128,6 +173,21 @@ func (mr *MockExchangeRateRepoMockRecorder) FetchExchangeRateByID(ctx, exchangeR
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchExchangeRateByID", reflect.TypeOf((*MockExchangeRateRepo)(nil).FetchExchangeRateByID), ctx, exchangeRateId)
 }
 
+// FetchExchangeRateVersions mocks base method.
+func (m *MockExchangeRateRepo) FetchExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string, page, limit int) ([]model.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchExchangeRateVersions", ctx, fromWalletId, toWalletId, tierId, page, limit)
+	ret0, _ := ret[0].([]model.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchExchangeRateVersions indicates an expected call of FetchExchangeRateVersions.
+func (mr *MockExchangeRateRepoMockRecorder) FetchExchangeRateVersions(ctx, fromWalletId, toWalletId, tierId, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchExchangeRateVersions", reflect.TypeOf((*MockExchangeRateRepo)(nil).FetchExchangeRateVersions), ctx, fromWalletId, toWalletId, tierId, page, limit)
+}
+
 // FetchExchangeRates mocks base method.
 func (m *MockExchangeRateRepo) FetchExchangeRates(ctx context.Context, page, limit int) ([]model.ExchangeRate, error) {
 	m.ctrl.T.Helper()
@@ -157,17 +217,3 @@ func (mr *MockExchangeRateRepoMockRecorder) FetchWalletExchangeRates(ctx, wallet
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWalletExchangeRates", reflect.TypeOf((*MockExchangeRateRepo)(nil).FetchWalletExchangeRates), ctx, walletId, page, limit)
 }
-
-// UpdateExchangeRate mocks base method.
-func (m *MockExchangeRateRepo) UpdateExchangeRate(ctx context.Context, exchangeRate *model.ExchangeRate) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateExchangeRate", ctx, exchangeRate)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// UpdateExchangeRate indicates an expected call of UpdateExchangeRate.
-func (mr *MockExchangeRateRepoMockRecorder) UpdateExchangeRate(ctx, exchangeRate any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExchangeRate", reflect.TypeOf((*MockExchangeRateRepo)(nil).UpdateExchangeRate), ctx, exchangeRate)
-}
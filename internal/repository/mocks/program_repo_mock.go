@@ -12,6 +12,7 @@ package repository_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,21 @@ func (m *MockProgramRepo) EXPECT() *MockProgramRepoMockRecorder {
 	return m.recorder
 }
 
+// CountProgramVersions mocks base method.
+func (m *MockProgramRepo) CountProgramVersions(ctx context.Context, programId uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountProgramVersions", ctx, programId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountProgramVersions indicates an expected call of CountProgramVersions.
+func (mr *MockProgramRepoMockRecorder) CountProgramVersions(ctx, programId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountProgramVersions", reflect.TypeOf((*MockProgramRepo)(nil).CountProgramVersions), ctx, programId)
+}
+
 // CountPrograms mocks base method.
 func (m *MockProgramRepo) CountPrograms(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
@@ -113,6 +129,36 @@ func (mr *MockProgramRepoMockRecorder) FetchProgramByID(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchProgramByID", reflect.TypeOf((*MockProgramRepo)(nil).FetchProgramByID), ctx, id)
 }
 
+// FetchProgramVersion mocks base method.
+func (m *MockProgramRepo) FetchProgramVersion(ctx context.Context, programId, version uint64) (*model.ProgramVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchProgramVersion", ctx, programId, version)
+	ret0, _ := ret[0].(*model.ProgramVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchProgramVersion indicates an expected call of FetchProgramVersion.
+func (mr *MockProgramRepoMockRecorder) FetchProgramVersion(ctx, programId, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchProgramVersion", reflect.TypeOf((*MockProgramRepo)(nil).FetchProgramVersion), ctx, programId, version)
+}
+
+// FetchProgramVersions mocks base method.
+func (m *MockProgramRepo) FetchProgramVersions(ctx context.Context, programId uint64, page, limit int) ([]model.ProgramVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchProgramVersions", ctx, programId, page, limit)
+	ret0, _ := ret[0].([]model.ProgramVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchProgramVersions indicates an expected call of FetchProgramVersions.
+func (mr *MockProgramRepoMockRecorder) FetchProgramVersions(ctx, programId, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchProgramVersions", reflect.TypeOf((*MockProgramRepo)(nil).FetchProgramVersions), ctx, programId, page, limit)
+}
+
 // FetchPrograms mocks base method.
 func (m *MockProgramRepo) FetchPrograms(ctx context.Context, page, limit int) ([]model.Program, error) {
 	m.ctrl.T.Helper()
@@ -144,18 +190,46 @@ func (mr *MockProgramRepoMockRecorder) FetchProgramsByWalletID(ctx, walletID any
 }
 
 // FetchTriggerPrograms mocks base method.
-func (m *MockProgramRepo) FetchTriggerPrograms(ctx context.Context, triggerSlug string) ([]*model.Program, error) {
+func (m *MockProgramRepo) FetchTriggerPrograms(ctx context.Context, triggerSlug string, at time.Time) ([]*model.Program, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchTriggerPrograms", ctx, triggerSlug)
+	ret := m.ctrl.Call(m, "FetchTriggerPrograms", ctx, triggerSlug, at)
 	ret0, _ := ret[0].([]*model.Program)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchTriggerPrograms indicates an expected call of FetchTriggerPrograms.
-func (mr *MockProgramRepoMockRecorder) FetchTriggerPrograms(ctx, triggerSlug any) *gomock.Call {
+func (mr *MockProgramRepoMockRecorder) FetchTriggerPrograms(ctx, triggerSlug, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTriggerPrograms", reflect.TypeOf((*MockProgramRepo)(nil).FetchTriggerPrograms), ctx, triggerSlug, at)
+}
+
+// PublishBudgetAlert mocks base method.
+func (m *MockProgramRepo) PublishBudgetAlert(ctx context.Context, alert *model.ProgramBudgetAlert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishBudgetAlert", ctx, alert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishBudgetAlert indicates an expected call of PublishBudgetAlert.
+func (mr *MockProgramRepoMockRecorder) PublishBudgetAlert(ctx, alert any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishBudgetAlert", reflect.TypeOf((*MockProgramRepo)(nil).PublishBudgetAlert), ctx, alert)
+}
+
+// PublishProgram mocks base method.
+func (m *MockProgramRepo) PublishProgram(ctx context.Context, program *model.Program, version *model.ProgramVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishProgram", ctx, program, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishProgram indicates an expected call of PublishProgram.
+func (mr *MockProgramRepoMockRecorder) PublishProgram(ctx, program, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTriggerPrograms", reflect.TypeOf((*MockProgramRepo)(nil).FetchTriggerPrograms), ctx, triggerSlug)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishProgram", reflect.TypeOf((*MockProgramRepo)(nil).PublishProgram), ctx, program, version)
 }
 
 // UpdateProgram mocks base method.
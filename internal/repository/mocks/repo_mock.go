@@ -18,6 +18,11 @@ func NewRepoMock(t *testing.T) (*repository.Repos, *gomock.Controller) {
 		ExchangeRate: NewMockExchangeRateRepo(ctrl),
 		Program:      NewMockProgramRepo(ctrl),
 		Trigger:      NewMockTriggerRepo(ctrl),
+		Role:         NewMockRoleRepo(ctrl),
+		Approval:     NewMockApprovalRepo(ctrl),
+		ApiKey:       NewMockApiKeyRepo(ctrl),
+		Tenant:       NewMockTenantRepo(ctrl),
+		Report:       NewMockReportRepo(ctrl),
 	}, ctrl
 }
 
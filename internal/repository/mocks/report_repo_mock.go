@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/report_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/report_repo.go -destination=internal/repository/mocks/report_repo_mock.go -package=repository_mock
+//
+
+// Package repository_mock is a generated GoMock package.
+package repository_mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReportRepo is a mock of ReportRepo interface.
+type MockReportRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportRepoMockRecorder
+}
+
+// MockReportRepoMockRecorder is the mock recorder for MockReportRepo.
+type MockReportRepoMockRecorder struct {
+	mock *MockReportRepo
+}
+
+// NewMockReportRepo creates a new mock instance.
+func NewMockReportRepo(ctrl *gomock.Controller) *MockReportRepo {
+	mock := &MockReportRepo{ctrl: ctrl}
+	mock.recorder = &MockReportRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportRepo) EXPECT() *MockReportRepoMockRecorder {
+	return m.recorder
+}
+
+// AggregateLiabilitySummaries mocks base method.
+func (m *MockReportRepo) AggregateLiabilitySummaries(ctx context.Context, walletId string, from time.Time) ([]model.WalletLiabilitySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateLiabilitySummaries", ctx, walletId, from)
+	ret0, _ := ret[0].([]model.WalletLiabilitySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateLiabilitySummaries indicates an expected call of AggregateLiabilitySummaries.
+func (mr *MockReportRepoMockRecorder) AggregateLiabilitySummaries(ctx, walletId, from any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateLiabilitySummaries", reflect.TypeOf((*MockReportRepo)(nil).AggregateLiabilitySummaries), ctx, walletId, from)
+}
+
+// FetchLatestLiabilitySummary mocks base method.
+func (m *MockReportRepo) FetchLatestLiabilitySummary(ctx context.Context, walletId string) (*model.WalletLiabilitySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLatestLiabilitySummary", ctx, walletId)
+	ret0, _ := ret[0].(*model.WalletLiabilitySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLatestLiabilitySummary indicates an expected call of FetchLatestLiabilitySummary.
+func (mr *MockReportRepoMockRecorder) FetchLatestLiabilitySummary(ctx, walletId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLatestLiabilitySummary", reflect.TypeOf((*MockReportRepo)(nil).FetchLatestLiabilitySummary), ctx, walletId)
+}
+
+// FetchLiabilitySummaries mocks base method.
+func (m *MockReportRepo) FetchLiabilitySummaries(ctx context.Context, walletId string, from, to time.Time) ([]model.WalletLiabilitySummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLiabilitySummaries", ctx, walletId, from, to)
+	ret0, _ := ret[0].([]model.WalletLiabilitySummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLiabilitySummaries indicates an expected call of FetchLiabilitySummaries.
+func (mr *MockReportRepoMockRecorder) FetchLiabilitySummaries(ctx, walletId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLiabilitySummaries", reflect.TypeOf((*MockReportRepo)(nil).FetchLiabilitySummaries), ctx, walletId, from, to)
+}
+
+// SaveLiabilitySummaries mocks base method.
+func (m *MockReportRepo) SaveLiabilitySummaries(ctx context.Context, summaries []model.WalletLiabilitySummary) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLiabilitySummaries", ctx, summaries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLiabilitySummaries indicates an expected call of SaveLiabilitySummaries.
+func (mr *MockReportRepoMockRecorder) SaveLiabilitySummaries(ctx, summaries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLiabilitySummaries", reflect.TypeOf((*MockReportRepo)(nil).SaveLiabilitySummaries), ctx, summaries)
+}
+
+// SumLiabilityBefore mocks base method.
+func (m *MockReportRepo) SumLiabilityBefore(ctx context.Context, walletId string, month time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumLiabilityBefore", ctx, walletId, month)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumLiabilityBefore indicates an expected call of SumLiabilityBefore.
+func (mr *MockReportRepoMockRecorder) SumLiabilityBefore(ctx, walletId, month any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumLiabilityBefore", reflect.TypeOf((*MockReportRepo)(nil).SumLiabilityBefore), ctx, walletId, month)
+}
@@ -0,0 +1,201 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/role_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/role_repo.go -destination=internal/repository/mocks/role_repo_mock.go -package=repository_mock
+//
+
+// Package repository_mock is a generated GoMock package.
+package repository_mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoleRepo is a mock of RoleRepo interface.
+type MockRoleRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepoMockRecorder
+}
+
+// MockRoleRepoMockRecorder is the mock recorder for MockRoleRepo.
+type MockRoleRepoMockRecorder struct {
+	mock *MockRoleRepo
+}
+
+// NewMockRoleRepo creates a new mock instance.
+func NewMockRoleRepo(ctrl *gomock.Controller) *MockRoleRepo {
+	mock := &MockRoleRepo{ctrl: ctrl}
+	mock.recorder = &MockRoleRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepo) EXPECT() *MockRoleRepoMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockRoleRepo) AssignRole(ctx context.Context, actorRole *model.ActorRole) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, actorRole)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRoleRepoMockRecorder) AssignRole(ctx, actorRole any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRoleRepo)(nil).AssignRole), ctx, actorRole)
+}
+
+// CountRoles mocks base method.
+func (m *MockRoleRepo) CountRoles(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRoles", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRoles indicates an expected call of CountRoles.
+func (mr *MockRoleRepoMockRecorder) CountRoles(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRoles", reflect.TypeOf((*MockRoleRepo)(nil).CountRoles), ctx)
+}
+
+// CreateRole mocks base method.
+func (m *MockRoleRepo) CreateRole(ctx context.Context, role *model.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockRoleRepoMockRecorder) CreateRole(ctx, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockRoleRepo)(nil).CreateRole), ctx, role)
+}
+
+// DeleteRole mocks base method.
+func (m *MockRoleRepo) DeleteRole(ctx context.Context, role *model.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockRoleRepoMockRecorder) DeleteRole(ctx, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockRoleRepo)(nil).DeleteRole), ctx, role)
+}
+
+// FetchActorPermissions mocks base method.
+func (m *MockRoleRepo) FetchActorPermissions(ctx context.Context, actorId string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchActorPermissions", ctx, actorId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchActorPermissions indicates an expected call of FetchActorPermissions.
+func (mr *MockRoleRepoMockRecorder) FetchActorPermissions(ctx, actorId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchActorPermissions", reflect.TypeOf((*MockRoleRepo)(nil).FetchActorPermissions), ctx, actorId)
+}
+
+// FetchActorRole mocks base method.
+func (m *MockRoleRepo) FetchActorRole(ctx context.Context, actorId, roleId string) (*model.ActorRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchActorRole", ctx, actorId, roleId)
+	ret0, _ := ret[0].(*model.ActorRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchActorRole indicates an expected call of FetchActorRole.
+func (mr *MockRoleRepoMockRecorder) FetchActorRole(ctx, actorId, roleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchActorRole", reflect.TypeOf((*MockRoleRepo)(nil).FetchActorRole), ctx, actorId, roleId)
+}
+
+// FetchActorRoles mocks base method.
+func (m *MockRoleRepo) FetchActorRoles(ctx context.Context, actorId string) ([]model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchActorRoles", ctx, actorId)
+	ret0, _ := ret[0].([]model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchActorRoles indicates an expected call of FetchActorRoles.
+func (mr *MockRoleRepoMockRecorder) FetchActorRoles(ctx, actorId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchActorRoles", reflect.TypeOf((*MockRoleRepo)(nil).FetchActorRoles), ctx, actorId)
+}
+
+// FetchRoleByID mocks base method.
+func (m *MockRoleRepo) FetchRoleByID(ctx context.Context, roleId string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchRoleByID", ctx, roleId)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchRoleByID indicates an expected call of FetchRoleByID.
+func (mr *MockRoleRepoMockRecorder) FetchRoleByID(ctx, roleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchRoleByID", reflect.TypeOf((*MockRoleRepo)(nil).FetchRoleByID), ctx, roleId)
+}
+
+// FetchRoles mocks base method.
+func (m *MockRoleRepo) FetchRoles(ctx context.Context, page, limit int) ([]model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchRoles", ctx, page, limit)
+	ret0, _ := ret[0].([]model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchRoles indicates an expected call of FetchRoles.
+func (mr *MockRoleRepoMockRecorder) FetchRoles(ctx, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchRoles", reflect.TypeOf((*MockRoleRepo)(nil).FetchRoles), ctx, page, limit)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRoleRepo) RevokeRole(ctx context.Context, actorRole *model.ActorRole) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, actorRole)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRoleRepoMockRecorder) RevokeRole(ctx, actorRole any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRoleRepo)(nil).RevokeRole), ctx, actorRole)
+}
+
+// UpdateRole mocks base method.
+func (m *MockRoleRepo) UpdateRole(ctx context.Context, role *model.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", ctx, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockRoleRepoMockRecorder) UpdateRole(ctx, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockRoleRepo)(nil).UpdateRole), ctx, role)
+}
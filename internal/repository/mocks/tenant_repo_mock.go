@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/tenant_repo.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/tenant_repo.go -destination=internal/repository/mocks/tenant_repo_mock.go -package=repository_mock
+//
+
+// Package repository_mock is a generated GoMock package.
+package repository_mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTenantRepo is a mock of TenantRepo interface.
+type MockTenantRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantRepoMockRecorder
+}
+
+// MockTenantRepoMockRecorder is the mock recorder for MockTenantRepo.
+type MockTenantRepoMockRecorder struct {
+	mock *MockTenantRepo
+}
+
+// NewMockTenantRepo creates a new mock instance.
+func NewMockTenantRepo(ctrl *gomock.Controller) *MockTenantRepo {
+	mock := &MockTenantRepo{ctrl: ctrl}
+	mock.recorder = &MockTenantRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTenantRepo) EXPECT() *MockTenantRepoMockRecorder {
+	return m.recorder
+}
+
+// FetchTenantIDs mocks base method.
+func (m *MockTenantRepo) FetchTenantIDs(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTenantIDs", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchTenantIDs indicates an expected call of FetchTenantIDs.
+func (mr *MockTenantRepoMockRecorder) FetchTenantIDs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTenantIDs", reflect.TypeOf((*MockTenantRepo)(nil).FetchTenantIDs), ctx)
+}
@@ -69,6 +69,34 @@ func (mr *MockTierRepoMockRecorder) CreateTier(ctx, tier any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTier", reflect.TypeOf((*MockTierRepo)(nil).CreateTier), ctx, tier)
 }
 
+// CreateTierPolicy mocks base method.
+func (m *MockTierRepo) CreateTierPolicy(ctx context.Context, policy *model.TierPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTierPolicy", ctx, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTierPolicy indicates an expected call of CreateTierPolicy.
+func (mr *MockTierRepoMockRecorder) CreateTierPolicy(ctx, policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTierPolicy", reflect.TypeOf((*MockTierRepo)(nil).CreateTierPolicy), ctx, policy)
+}
+
+// CreateTierRule mocks base method.
+func (m *MockTierRepo) CreateTierRule(ctx context.Context, rule *model.TierRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTierRule", ctx, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTierRule indicates an expected call of CreateTierRule.
+func (mr *MockTierRepoMockRecorder) CreateTierRule(ctx, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTierRule", reflect.TypeOf((*MockTierRepo)(nil).CreateTierRule), ctx, rule)
+}
+
 // DeleteTier mocks base method.
 func (m *MockTierRepo) DeleteTier(ctx context.Context, tier *model.Tier) error {
 	m.ctrl.T.Helper()
@@ -83,6 +111,34 @@ func (mr *MockTierRepoMockRecorder) DeleteTier(ctx, tier any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTier", reflect.TypeOf((*MockTierRepo)(nil).DeleteTier), ctx, tier)
 }
 
+// DeleteTierPolicy mocks base method.
+func (m *MockTierRepo) DeleteTierPolicy(ctx context.Context, policy *model.TierPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTierPolicy", ctx, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTierPolicy indicates an expected call of DeleteTierPolicy.
+func (mr *MockTierRepoMockRecorder) DeleteTierPolicy(ctx, policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTierPolicy", reflect.TypeOf((*MockTierRepo)(nil).DeleteTierPolicy), ctx, policy)
+}
+
+// DeleteTierRule mocks base method.
+func (m *MockTierRepo) DeleteTierRule(ctx context.Context, rule *model.TierRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTierRule", ctx, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTierRule indicates an expected call of DeleteTierRule.
+func (mr *MockTierRepoMockRecorder) DeleteTierRule(ctx, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTierRule", reflect.TypeOf((*MockTierRepo)(nil).DeleteTierRule), ctx, rule)
+}
+
 // FetchTierByID mocks base method.
 func (m *MockTierRepo) FetchTierByID(ctx context.Context, tierId string) (*model.Tier, error) {
 	m.ctrl.T.Helper()
@@ -98,6 +154,66 @@ func (mr *MockTierRepoMockRecorder) FetchTierByID(ctx, tierId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTierByID", reflect.TypeOf((*MockTierRepo)(nil).FetchTierByID), ctx, tierId)
 }
 
+// FetchTierPolicies mocks base method.
+func (m *MockTierRepo) FetchTierPolicies(ctx context.Context, tierId string) ([]model.TierPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTierPolicies", ctx, tierId)
+	ret0, _ := ret[0].([]model.TierPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchTierPolicies indicates an expected call of FetchTierPolicies.
+func (mr *MockTierRepoMockRecorder) FetchTierPolicies(ctx, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTierPolicies", reflect.TypeOf((*MockTierRepo)(nil).FetchTierPolicies), ctx, tierId)
+}
+
+// FetchTierPolicy mocks base method.
+func (m *MockTierRepo) FetchTierPolicy(ctx context.Context, walletId, tierId string) (*model.TierPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTierPolicy", ctx, walletId, tierId)
+	ret0, _ := ret[0].(*model.TierPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchTierPolicy indicates an expected call of FetchTierPolicy.
+func (mr *MockTierRepoMockRecorder) FetchTierPolicy(ctx, walletId, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTierPolicy", reflect.TypeOf((*MockTierRepo)(nil).FetchTierPolicy), ctx, walletId, tierId)
+}
+
+// FetchTierRule mocks base method.
+func (m *MockTierRepo) FetchTierRule(ctx context.Context, tierId string) (*model.TierRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTierRule", ctx, tierId)
+	ret0, _ := ret[0].(*model.TierRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchTierRule indicates an expected call of FetchTierRule.
+func (mr *MockTierRepoMockRecorder) FetchTierRule(ctx, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTierRule", reflect.TypeOf((*MockTierRepo)(nil).FetchTierRule), ctx, tierId)
+}
+
+// FetchTierRules mocks base method.
+func (m *MockTierRepo) FetchTierRules(ctx context.Context) ([]model.TierRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTierRules", ctx)
+	ret0, _ := ret[0].([]model.TierRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchTierRules indicates an expected call of FetchTierRules.
+func (mr *MockTierRepoMockRecorder) FetchTierRules(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTierRules", reflect.TypeOf((*MockTierRepo)(nil).FetchTierRules), ctx)
+}
+
 // FetchTiers mocks base method.
 func (m *MockTierRepo) FetchTiers(ctx context.Context, page, limit int) ([]model.Tier, error) {
 	m.ctrl.T.Helper()
@@ -112,3 +228,31 @@ func (mr *MockTierRepoMockRecorder) FetchTiers(ctx, page, limit any) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTiers", reflect.TypeOf((*MockTierRepo)(nil).FetchTiers), ctx, page, limit)
 }
+
+// UpdateTierPolicy mocks base method.
+func (m *MockTierRepo) UpdateTierPolicy(ctx context.Context, policy *model.TierPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTierPolicy", ctx, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTierPolicy indicates an expected call of UpdateTierPolicy.
+func (mr *MockTierRepoMockRecorder) UpdateTierPolicy(ctx, policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTierPolicy", reflect.TypeOf((*MockTierRepo)(nil).UpdateTierPolicy), ctx, policy)
+}
+
+// UpdateTierRule mocks base method.
+func (m *MockTierRepo) UpdateTierRule(ctx context.Context, rule *model.TierRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTierRule", ctx, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTierRule indicates an expected call of UpdateTierRule.
+func (mr *MockTierRepoMockRecorder) UpdateTierRule(ctx, rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTierRule", reflect.TypeOf((*MockTierRepo)(nil).UpdateTierRule), ctx, rule)
+}
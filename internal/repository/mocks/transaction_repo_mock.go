@@ -12,6 +12,7 @@ package repository_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	repository "github.com/abdelrahman146/digital-wallet/internal/repository"
@@ -43,33 +44,48 @@ func (m *MockTransactionRepo) EXPECT() *MockTransactionRepoMockRecorder {
 }
 
 // CountAccountTransactions mocks base method.
-func (m *MockTransactionRepo) CountAccountTransactions(ctx context.Context, accountId string) (int64, error) {
+func (m *MockTransactionRepo) CountAccountTransactions(ctx context.Context, accountId string, filter repository.TransactionFilter) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CountAccountTransactions", ctx, accountId)
+	ret := m.ctrl.Call(m, "CountAccountTransactions", ctx, accountId, filter)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CountAccountTransactions indicates an expected call of CountAccountTransactions.
-func (mr *MockTransactionRepoMockRecorder) CountAccountTransactions(ctx, accountId any) *gomock.Call {
+func (mr *MockTransactionRepoMockRecorder) CountAccountTransactions(ctx, accountId, filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAccountTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).CountAccountTransactions), ctx, accountId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAccountTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).CountAccountTransactions), ctx, accountId, filter)
 }
 
 // CountWalletTransactions mocks base method.
-func (m *MockTransactionRepo) CountWalletTransactions(ctx context.Context, walletId string) (int64, error) {
+func (m *MockTransactionRepo) CountWalletTransactions(ctx context.Context, walletId string, filter repository.TransactionFilter) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CountWalletTransactions", ctx, walletId)
+	ret := m.ctrl.Call(m, "CountWalletTransactions", ctx, walletId, filter)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CountWalletTransactions indicates an expected call of CountWalletTransactions.
-func (mr *MockTransactionRepoMockRecorder) CountWalletTransactions(ctx, walletId any) *gomock.Call {
+func (mr *MockTransactionRepoMockRecorder) CountWalletTransactions(ctx, walletId, filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountWalletTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).CountWalletTransactions), ctx, walletId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountWalletTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).CountWalletTransactions), ctx, walletId, filter)
+}
+
+// CreateRewardTransaction mocks base method.
+func (m *MockTransactionRepo) CreateRewardTransaction(ctx context.Context, transaction *model.Transaction, accountVersion, programId uint64) (*repository.BudgetConsumption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRewardTransaction", ctx, transaction, accountVersion, programId)
+	ret0, _ := ret[0].(*repository.BudgetConsumption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRewardTransaction indicates an expected call of CreateRewardTransaction.
+func (mr *MockTransactionRepoMockRecorder) CreateRewardTransaction(ctx, transaction, accountVersion, programId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRewardTransaction", reflect.TypeOf((*MockTransactionRepo)(nil).CreateRewardTransaction), ctx, transaction, accountVersion, programId)
 }
 
 // CreateTransaction mocks base method.
@@ -87,18 +103,32 @@ func (mr *MockTransactionRepoMockRecorder) CreateTransaction(ctx, transaction, a
 }
 
 // FetchAccountTransactions mocks base method.
-func (m *MockTransactionRepo) FetchAccountTransactions(ctx context.Context, accountId string, page, limit int) ([]model.Transaction, error) {
+func (m *MockTransactionRepo) FetchAccountTransactions(ctx context.Context, accountId string, filter repository.TransactionFilter, page, limit int) ([]model.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchAccountTransactions", ctx, accountId, page, limit)
+	ret := m.ctrl.Call(m, "FetchAccountTransactions", ctx, accountId, filter, page, limit)
 	ret0, _ := ret[0].([]model.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchAccountTransactions indicates an expected call of FetchAccountTransactions.
-func (mr *MockTransactionRepoMockRecorder) FetchAccountTransactions(ctx, accountId, page, limit any) *gomock.Call {
+func (mr *MockTransactionRepoMockRecorder) FetchAccountTransactions(ctx, accountId, filter, page, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAccountTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).FetchAccountTransactions), ctx, accountId, page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAccountTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).FetchAccountTransactions), ctx, accountId, filter, page, limit)
+}
+
+// FetchAccountTransactionsInBatches mocks base method.
+func (m *MockTransactionRepo) FetchAccountTransactionsInBatches(ctx context.Context, accountId string, from, to time.Time, batchSize int, process func([]model.Transaction) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchAccountTransactionsInBatches", ctx, accountId, from, to, batchSize, process)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchAccountTransactionsInBatches indicates an expected call of FetchAccountTransactionsInBatches.
+func (mr *MockTransactionRepoMockRecorder) FetchAccountTransactionsInBatches(ctx, accountId, from, to, batchSize, process any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAccountTransactionsInBatches", reflect.TypeOf((*MockTransactionRepo)(nil).FetchAccountTransactionsInBatches), ctx, accountId, from, to, batchSize, process)
 }
 
 // FetchExpiredWalletTransactions mocks base method.
@@ -116,33 +146,78 @@ func (mr *MockTransactionRepoMockRecorder) FetchExpiredWalletTransactions(ctx, w
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchExpiredWalletTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).FetchExpiredWalletTransactions), ctx, walletId)
 }
 
+// FetchLastAccountTransaction mocks base method.
+func (m *MockTransactionRepo) FetchLastAccountTransaction(ctx context.Context, accountId string, before time.Time) (*model.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLastAccountTransaction", ctx, accountId, before)
+	ret0, _ := ret[0].(*model.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLastAccountTransaction indicates an expected call of FetchLastAccountTransaction.
+func (mr *MockTransactionRepoMockRecorder) FetchLastAccountTransaction(ctx, accountId, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLastAccountTransaction", reflect.TypeOf((*MockTransactionRepo)(nil).FetchLastAccountTransaction), ctx, accountId, before)
+}
+
+// FetchOpenAccountLots mocks base method.
+func (m *MockTransactionRepo) FetchOpenAccountLots(ctx context.Context, wallet *model.Wallet, accountId string) ([]model.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchOpenAccountLots", ctx, wallet, accountId)
+	ret0, _ := ret[0].([]model.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchOpenAccountLots indicates an expected call of FetchOpenAccountLots.
+func (mr *MockTransactionRepoMockRecorder) FetchOpenAccountLots(ctx, wallet, accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchOpenAccountLots", reflect.TypeOf((*MockTransactionRepo)(nil).FetchOpenAccountLots), ctx, wallet, accountId)
+}
+
+// FetchTransactionConsumptions mocks base method.
+func (m *MockTransactionRepo) FetchTransactionConsumptions(ctx context.Context, accountId, transactionId string) ([]model.TransactionConsumption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTransactionConsumptions", ctx, accountId, transactionId)
+	ret0, _ := ret[0].([]model.TransactionConsumption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchTransactionConsumptions indicates an expected call of FetchTransactionConsumptions.
+func (mr *MockTransactionRepoMockRecorder) FetchTransactionConsumptions(ctx, accountId, transactionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTransactionConsumptions", reflect.TypeOf((*MockTransactionRepo)(nil).FetchTransactionConsumptions), ctx, accountId, transactionId)
+}
+
 // FetchWalletTransactions mocks base method.
-func (m *MockTransactionRepo) FetchWalletTransactions(ctx context.Context, walletId string, page, limit int) ([]model.Transaction, error) {
+func (m *MockTransactionRepo) FetchWalletTransactions(ctx context.Context, walletId string, filter repository.TransactionFilter, page, limit int) ([]model.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchWalletTransactions", ctx, walletId, page, limit)
+	ret := m.ctrl.Call(m, "FetchWalletTransactions", ctx, walletId, filter, page, limit)
 	ret0, _ := ret[0].([]model.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchWalletTransactions indicates an expected call of FetchWalletTransactions.
-func (mr *MockTransactionRepoMockRecorder) FetchWalletTransactions(ctx, walletId, page, limit any) *gomock.Call {
+func (mr *MockTransactionRepoMockRecorder) FetchWalletTransactions(ctx, walletId, filter, page, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWalletTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).FetchWalletTransactions), ctx, walletId, page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchWalletTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).FetchWalletTransactions), ctx, walletId, filter, page, limit)
 }
 
 // PerformExchange mocks base method.
-func (m *MockTransactionRepo) PerformExchange(ctx context.Context, from, to *repository.ExchangeRequest) error {
+func (m *MockTransactionRepo) PerformExchange(ctx context.Context, legs []repository.ExchangeLeg) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PerformExchange", ctx, from, to)
+	ret := m.ctrl.Call(m, "PerformExchange", ctx, legs)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PerformExchange indicates an expected call of PerformExchange.
-func (mr *MockTransactionRepoMockRecorder) PerformExchange(ctx, from, to any) *gomock.Call {
+func (mr *MockTransactionRepoMockRecorder) PerformExchange(ctx, legs any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PerformExchange", reflect.TypeOf((*MockTransactionRepo)(nil).PerformExchange), ctx, from, to)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PerformExchange", reflect.TypeOf((*MockTransactionRepo)(nil).PerformExchange), ctx, legs)
 }
 
 // SumAccountTransactions mocks base method.
@@ -160,6 +235,36 @@ func (mr *MockTransactionRepoMockRecorder) SumAccountTransactions(ctx, accountId
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumAccountTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).SumAccountTransactions), ctx, accountId)
 }
 
+// SumAccountTransactionsByReason mocks base method.
+func (m *MockTransactionRepo) SumAccountTransactionsByReason(ctx context.Context, accountId string, from, to time.Time) ([]repository.ReasonTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumAccountTransactionsByReason", ctx, accountId, from, to)
+	ret0, _ := ret[0].([]repository.ReasonTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumAccountTransactionsByReason indicates an expected call of SumAccountTransactionsByReason.
+func (mr *MockTransactionRepoMockRecorder) SumAccountTransactionsByReason(ctx, accountId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumAccountTransactionsByReason", reflect.TypeOf((*MockTransactionRepo)(nil).SumAccountTransactionsByReason), ctx, accountId, from, to)
+}
+
+// SumAccountTransactionsSince mocks base method.
+func (m *MockTransactionRepo) SumAccountTransactionsSince(ctx context.Context, accountId, transactionType string, reasons []string, since time.Time) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumAccountTransactionsSince", ctx, accountId, transactionType, reasons, since)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumAccountTransactionsSince indicates an expected call of SumAccountTransactionsSince.
+func (mr *MockTransactionRepoMockRecorder) SumAccountTransactionsSince(ctx, accountId, transactionType, reasons, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumAccountTransactionsSince", reflect.TypeOf((*MockTransactionRepo)(nil).SumAccountTransactionsSince), ctx, accountId, transactionType, reasons, since)
+}
+
 // SumExpiringAccountTransactions mocks base method.
 func (m *MockTransactionRepo) SumExpiringAccountTransactions(ctx context.Context, accountId string, expireInterval types.Interval) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -175,6 +280,21 @@ func (mr *MockTransactionRepoMockRecorder) SumExpiringAccountTransactions(ctx, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumExpiringAccountTransactions", reflect.TypeOf((*MockTransactionRepo)(nil).SumExpiringAccountTransactions), ctx, accountId, expireInterval)
 }
 
+// SumWalletBalanceChange mocks base method.
+func (m *MockTransactionRepo) SumWalletBalanceChange(ctx context.Context, walletId string, from, to time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumWalletBalanceChange", ctx, walletId, from, to)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumWalletBalanceChange indicates an expected call of SumWalletBalanceChange.
+func (mr *MockTransactionRepoMockRecorder) SumWalletBalanceChange(ctx, walletId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumWalletBalanceChange", reflect.TypeOf((*MockTransactionRepo)(nil).SumWalletBalanceChange), ctx, walletId, from, to)
+}
+
 // SumWalletTransactions mocks base method.
 func (m *MockTransactionRepo) SumWalletTransactions(ctx context.Context, walletId string) (uint64, error) {
 	m.ctrl.T.Helper()
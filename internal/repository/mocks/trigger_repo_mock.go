@@ -12,6 +12,7 @@ package repository_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,21 @@ func (m *MockTriggerRepo) EXPECT() *MockTriggerRepoMockRecorder {
 	return m.recorder
 }
 
+// CountTriggerEvents mocks base method.
+func (m *MockTriggerRepo) CountTriggerEvents(ctx context.Context, userId, triggerSlug string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTriggerEvents", ctx, userId, triggerSlug, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTriggerEvents indicates an expected call of CountTriggerEvents.
+func (mr *MockTriggerRepoMockRecorder) CountTriggerEvents(ctx, userId, triggerSlug, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTriggerEvents", reflect.TypeOf((*MockTriggerRepo)(nil).CountTriggerEvents), ctx, userId, triggerSlug, since)
+}
+
 // CountTriggers mocks base method.
 func (m *MockTriggerRepo) CountTriggers(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
@@ -69,6 +85,20 @@ func (mr *MockTriggerRepoMockRecorder) CreateTrigger(ctx, trigger any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTrigger", reflect.TypeOf((*MockTriggerRepo)(nil).CreateTrigger), ctx, trigger)
 }
 
+// CreateTriggerEvent mocks base method.
+func (m *MockTriggerRepo) CreateTriggerEvent(ctx context.Context, event *model.TriggerEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTriggerEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTriggerEvent indicates an expected call of CreateTriggerEvent.
+func (mr *MockTriggerRepoMockRecorder) CreateTriggerEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTriggerEvent", reflect.TypeOf((*MockTriggerRepo)(nil).CreateTriggerEvent), ctx, event)
+}
+
 // DeleteTrigger mocks base method.
 func (m *MockTriggerRepo) DeleteTrigger(ctx context.Context, trigger *model.Trigger) error {
 	m.ctrl.T.Helper()
@@ -128,6 +158,21 @@ func (mr *MockTriggerRepoMockRecorder) FetchTriggers(ctx, page, limit any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTriggers", reflect.TypeOf((*MockTriggerRepo)(nil).FetchTriggers), ctx, page, limit)
 }
 
+// SumTriggerEvents mocks base method.
+func (m *MockTriggerRepo) SumTriggerEvents(ctx context.Context, userId, triggerSlug, field string, since time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumTriggerEvents", ctx, userId, triggerSlug, field, since)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumTriggerEvents indicates an expected call of SumTriggerEvents.
+func (mr *MockTriggerRepoMockRecorder) SumTriggerEvents(ctx, userId, triggerSlug, field, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumTriggerEvents", reflect.TypeOf((*MockTriggerRepo)(nil).SumTriggerEvents), ctx, userId, triggerSlug, field, since)
+}
+
 // UpdateTrigger mocks base method.
 func (m *MockTriggerRepo) UpdateTrigger(ctx context.Context, trigger *model.Trigger) error {
 	m.ctrl.T.Helper()
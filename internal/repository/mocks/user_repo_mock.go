@@ -55,6 +55,21 @@ func (mr *MockUserRepoMockRecorder) CountTotalUsers(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTotalUsers", reflect.TypeOf((*MockUserRepo)(nil).CountTotalUsers), ctx)
 }
 
+// CountUserTierHistory mocks base method.
+func (m *MockUserRepo) CountUserTierHistory(ctx context.Context, userId string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserTierHistory", ctx, userId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserTierHistory indicates an expected call of CountUserTierHistory.
+func (mr *MockUserRepoMockRecorder) CountUserTierHistory(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserTierHistory", reflect.TypeOf((*MockUserRepo)(nil).CountUserTierHistory), ctx, userId)
+}
+
 // CountUsersByTierID mocks base method.
 func (m *MockUserRepo) CountUsersByTierID(ctx context.Context, tierId string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -113,6 +128,21 @@ func (mr *MockUserRepoMockRecorder) FetchUserByID(ctx, userId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchUserByID", reflect.TypeOf((*MockUserRepo)(nil).FetchUserByID), ctx, userId)
 }
 
+// FetchUserTierHistory mocks base method.
+func (m *MockUserRepo) FetchUserTierHistory(ctx context.Context, userId string, page, limit int) ([]model.UserTierHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchUserTierHistory", ctx, userId, page, limit)
+	ret0, _ := ret[0].([]model.UserTierHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchUserTierHistory indicates an expected call of FetchUserTierHistory.
+func (mr *MockUserRepoMockRecorder) FetchUserTierHistory(ctx, userId, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchUserTierHistory", reflect.TypeOf((*MockUserRepo)(nil).FetchUserTierHistory), ctx, userId, page, limit)
+}
+
 // FetchUsers mocks base method.
 func (m *MockUserRepo) FetchUsers(ctx context.Context, page, limit int) ([]model.User, error) {
 	m.ctrl.T.Helper()
@@ -128,6 +158,21 @@ func (mr *MockUserRepoMockRecorder) FetchUsers(ctx, page, limit any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchUsers", reflect.TypeOf((*MockUserRepo)(nil).FetchUsers), ctx, page, limit)
 }
 
+// FetchUsersAfter mocks base method.
+func (m *MockUserRepo) FetchUsersAfter(ctx context.Context, afterId string, limit int) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchUsersAfter", ctx, afterId, limit)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchUsersAfter indicates an expected call of FetchUsersAfter.
+func (mr *MockUserRepoMockRecorder) FetchUsersAfter(ctx, afterId, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchUsersAfter", reflect.TypeOf((*MockUserRepo)(nil).FetchUsersAfter), ctx, afterId, limit)
+}
+
 // FetchUsersByTierID mocks base method.
 func (m *MockUserRepo) FetchUsersByTierID(ctx context.Context, tierId string, page, limit int) ([]model.User, error) {
 	m.ctrl.T.Helper()
@@ -144,15 +189,15 @@ func (mr *MockUserRepoMockRecorder) FetchUsersByTierID(ctx, tierId, page, limit
 }
 
 // UpdateUserTier mocks base method.
-func (m *MockUserRepo) UpdateUserTier(ctx context.Context, userId, tierId string) error {
+func (m *MockUserRepo) UpdateUserTier(ctx context.Context, user *model.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUserTier", ctx, userId, tierId)
+	ret := m.ctrl.Call(m, "UpdateUserTier", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUserTier indicates an expected call of UpdateUserTier.
-func (mr *MockUserRepoMockRecorder) UpdateUserTier(ctx, userId, tierId any) *gomock.Call {
+func (mr *MockUserRepoMockRecorder) UpdateUserTier(ctx, user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTier", reflect.TypeOf((*MockUserRepo)(nil).UpdateUserTier), ctx, userId, tierId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTier", reflect.TypeOf((*MockUserRepo)(nil).UpdateUserTier), ctx, user)
 }
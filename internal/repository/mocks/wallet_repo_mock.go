@@ -12,6 +12,7 @@ package repository_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -55,6 +56,20 @@ func (mr *MockWalletRepoMockRecorder) CountTotalWallets(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTotalWallets", reflect.TypeOf((*MockWalletRepo)(nil).CountTotalWallets), ctx)
 }
 
+// CreateBalanceSnapshot mocks base method.
+func (m *MockWalletRepo) CreateBalanceSnapshot(ctx context.Context, snapshot *model.WalletBalanceSnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBalanceSnapshot", ctx, snapshot)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBalanceSnapshot indicates an expected call of CreateBalanceSnapshot.
+func (mr *MockWalletRepoMockRecorder) CreateBalanceSnapshot(ctx, snapshot any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalanceSnapshot", reflect.TypeOf((*MockWalletRepo)(nil).CreateBalanceSnapshot), ctx, snapshot)
+}
+
 // CreateWallet mocks base method.
 func (m *MockWalletRepo) CreateWallet(ctx context.Context, wallet *model.Wallet) error {
 	m.ctrl.T.Helper()
@@ -83,6 +98,21 @@ func (mr *MockWalletRepoMockRecorder) DeleteWallet(ctx, wallet any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWallet", reflect.TypeOf((*MockWalletRepo)(nil).DeleteWallet), ctx, wallet)
 }
 
+// FetchLatestBalanceSnapshot mocks base method.
+func (m *MockWalletRepo) FetchLatestBalanceSnapshot(ctx context.Context, walletId string, at time.Time) (*model.WalletBalanceSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLatestBalanceSnapshot", ctx, walletId, at)
+	ret0, _ := ret[0].(*model.WalletBalanceSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLatestBalanceSnapshot indicates an expected call of FetchLatestBalanceSnapshot.
+func (mr *MockWalletRepoMockRecorder) FetchLatestBalanceSnapshot(ctx, walletId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLatestBalanceSnapshot", reflect.TypeOf((*MockWalletRepo)(nil).FetchLatestBalanceSnapshot), ctx, walletId, at)
+}
+
 // FetchWalletByID mocks base method.
 func (m *MockWalletRepo) FetchWalletByID(ctx context.Context, walletId string) (*model.Wallet, error) {
 	m.ctrl.T.Helper()
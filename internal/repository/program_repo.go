@@ -2,14 +2,17 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
 	"github.com/abdelrahman146/digital-wallet/internal/resource"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm"
+	"time"
 )
 
 type ProgramRepo interface {
-	// CreateProgram creates a new program
+	// CreateProgram creates a new program along with its first version
 	CreateProgram(ctx context.Context, program *model.Program) error
 	// UpdateProgram updates an existing program
 	UpdateProgram(ctx context.Context, program *model.Program) error
@@ -17,8 +20,8 @@ type ProgramRepo interface {
 	DeleteProgram(ctx context.Context, program *model.Program) error
 	// FetchProgramByID retrieves a program by its ID
 	FetchProgramByID(ctx context.Context, id uint64) (*model.Program, error)
-	// FetchTriggerPrograms retrieves programs for a specific trigger
-	FetchTriggerPrograms(ctx context.Context, triggerSlug string) ([]*model.Program, error)
+	// FetchTriggerPrograms retrieves the active programs of a trigger that are valid at the given time, by descending priority
+	FetchTriggerPrograms(ctx context.Context, triggerSlug string, at time.Time) ([]*model.Program, error)
 	// FetchProgramsByWalletID retrieves programs for a specific wallet
 	FetchProgramsByWalletID(ctx context.Context, walletID uint64) ([]model.Program, error)
 	// CountProgramsByWalletID retrieves the total number of programs for a specific wallet
@@ -27,6 +30,16 @@ type ProgramRepo interface {
 	FetchPrograms(ctx context.Context, page int, limit int) ([]model.Program, error)
 	// CountPrograms retrieves the total number of programs
 	CountPrograms(ctx context.Context) (int64, error)
+	// PublishProgram saves a program along with the version it now runs
+	PublishProgram(ctx context.Context, program *model.Program, version *model.ProgramVersion) error
+	// FetchProgramVersion retrieves a version of a program by its number
+	FetchProgramVersion(ctx context.Context, programId uint64, version uint64) (*model.ProgramVersion, error)
+	// FetchProgramVersions retrieves a paginated list of the versions of a program, the latest first
+	FetchProgramVersions(ctx context.Context, programId uint64, page int, limit int) ([]model.ProgramVersion, error)
+	// CountProgramVersions retrieves the total number of versions of a program
+	CountProgramVersions(ctx context.Context, programId uint64) (int64, error)
+	// PublishBudgetAlert publishes an alert about the budget of a program to the budget alerts topic of its tenant
+	PublishBudgetAlert(ctx context.Context, alert *model.ProgramBudgetAlert) error
 }
 
 type programRepo struct {
@@ -38,7 +51,16 @@ func NewProgramRepo(resources *resource.Resources) ProgramRepo {
 }
 
 func (r *programRepo) CreateProgram(ctx context.Context, program *model.Program) error {
-	if err := r.resources.DB.Create(program).Error; err != nil {
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(program).Error; err != nil {
+			return err
+		}
+		version := &model.ProgramVersion{ProgramID: program.ID, Version: program.Version, Definition: program.Definition()}
+		version.SetActor(program.GetActor())
+		version.SetRemarks("Program version published")
+		return tx.Create(version).Error
+	})
+	if err != nil {
 		api.GetLogger(ctx).Error("Failed to create program", logger.Field("error", err), logger.Field("program", program))
 		return err
 	}
@@ -46,7 +68,7 @@ func (r *programRepo) CreateProgram(ctx context.Context, program *model.Program)
 }
 
 func (r *programRepo) UpdateProgram(ctx context.Context, program *model.Program) error {
-	if err := r.resources.DB.Save(program).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Save(program).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to update program", logger.Field("error", err), logger.Field("program", program))
 		return err
 	}
@@ -54,7 +76,7 @@ func (r *programRepo) UpdateProgram(ctx context.Context, program *model.Program)
 }
 
 func (r *programRepo) DeleteProgram(ctx context.Context, program *model.Program) error {
-	if err := r.resources.DB.Delete(program).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Delete(program).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to delete program", logger.Field("error", err), logger.Field("program", program))
 		return err
 	}
@@ -63,7 +85,7 @@ func (r *programRepo) DeleteProgram(ctx context.Context, program *model.Program)
 
 func (r *programRepo) FetchProgramByID(ctx context.Context, id uint64) (*model.Program, error) {
 	var program model.Program
-	err := r.resources.DB.Where("id = ?", id).First(&program).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", id).First(&program).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve program by ID", logger.Field("error", err), logger.Field("id", id))
 		return nil, err
@@ -71,9 +93,13 @@ func (r *programRepo) FetchProgramByID(ctx context.Context, id uint64) (*model.P
 	return &program, nil
 }
 
-func (r *programRepo) FetchTriggerPrograms(ctx context.Context, triggerSlug string) ([]*model.Program, error) {
+func (r *programRepo) FetchTriggerPrograms(ctx context.Context, triggerSlug string, at time.Time) ([]*model.Program, error) {
 	var programs []*model.Program
-	err := r.resources.DB.Where("trigger_slug = ?", triggerSlug).Find(&programs).Error
+	err := r.resources.DB.WithContext(ctx).
+		Where("trigger_slug = ? AND is_active = TRUE", triggerSlug).
+		Where("valid_from <= ? AND (valid_until IS NULL OR valid_until > ?)", at, at).
+		Order("priority DESC, id ASC").
+		Find(&programs).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve programs of a trigger", logger.Field("error", err), logger.Field("triggerSlug", triggerSlug))
 		return nil, err
@@ -83,7 +109,7 @@ func (r *programRepo) FetchTriggerPrograms(ctx context.Context, triggerSlug stri
 
 func (r *programRepo) FetchProgramsByWalletID(ctx context.Context, walletID uint64) ([]model.Program, error) {
 	var programs []model.Program
-	err := r.resources.DB.Where("wallet_id = ?", walletID).Find(&programs).Error
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ?", walletID).Find(&programs).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve programs by wallet ID", logger.Field("error", err), logger.Field("walletId", walletID))
 		return nil, err
@@ -93,7 +119,7 @@ func (r *programRepo) FetchProgramsByWalletID(ctx context.Context, walletID uint
 
 func (r *programRepo) CountProgramsByWalletID(ctx context.Context, walletID uint64) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Program{}).Where("wallet_id = ?", walletID).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Program{}).Where("wallet_id = ?", walletID).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve total programs count by wallet ID", logger.Field("error", err), logger.Field("walletId", walletID))
 		return 0, err
@@ -103,7 +129,7 @@ func (r *programRepo) CountProgramsByWalletID(ctx context.Context, walletID uint
 
 func (r *programRepo) FetchPrograms(ctx context.Context, page int, limit int) ([]model.Program, error) {
 	var programs []model.Program
-	err := r.resources.DB.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&programs).Error
+	err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&programs).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve programs", logger.Field("error", err))
 		return nil, err
@@ -113,10 +139,72 @@ func (r *programRepo) FetchPrograms(ctx context.Context, page int, limit int) ([
 
 func (r *programRepo) CountPrograms(ctx context.Context) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Program{}).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Program{}).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve total programs count", logger.Field("error", err))
 		return 0, err
 	}
 	return total, nil
 }
+
+func (r *programRepo) PublishProgram(ctx context.Context, program *model.Program, version *model.ProgramVersion) error {
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return tx.Save(program).Error
+	})
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to publish program", logger.Field("error", err), logger.Field("programId", program.ID), logger.Field("version", version.Version))
+		return err
+	}
+	return nil
+}
+
+func (r *programRepo) FetchProgramVersion(ctx context.Context, programId uint64, version uint64) (*model.ProgramVersion, error) {
+	var programVersion model.ProgramVersion
+	err := r.resources.DB.WithContext(ctx).Where("program_id = ? AND version = ?", programId, version).First(&programVersion).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve program version", logger.Field("error", err), logger.Field("programId", programId), logger.Field("version", version))
+		return nil, err
+	}
+	return &programVersion, nil
+}
+
+func (r *programRepo) FetchProgramVersions(ctx context.Context, programId uint64, page int, limit int) ([]model.ProgramVersion, error) {
+	var versions []model.ProgramVersion
+	err := r.resources.DB.WithContext(ctx).Where("program_id = ?", programId).Order("version desc").Offset((page - 1) * limit).Limit(limit).Find(&versions).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve program versions", logger.Field("error", err), logger.Field("programId", programId))
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *programRepo) CountProgramVersions(ctx context.Context, programId uint64) (int64, error) {
+	var total int64
+	err := r.resources.DB.WithContext(ctx).Model(&model.ProgramVersion{}).Where("program_id = ?", programId).Count(&total).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve total program versions count", logger.Field("error", err), logger.Field("programId", programId))
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *programRepo) PublishBudgetAlert(ctx context.Context, alert *model.ProgramBudgetAlert) error {
+	message, err := json.Marshal(alert)
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to encode program budget alert", logger.Field("error", err), logger.Field("alert", alert))
+		return err
+	}
+	if err := r.resources.Broker.Publish(ctx, budgetAlertTopic(alert.TenantID), message); err != nil {
+		api.GetLogger(ctx).Error("Failed to publish program budget alert", logger.Field("error", err), logger.Field("alert", alert))
+		return err
+	}
+	return nil
+}
+
+// budgetAlertTopic is the topic the program budget alerts of a tenant are published to
+func budgetAlertTopic(tenantId string) string {
+	return tenantId + ".program-budget-alerts"
+}
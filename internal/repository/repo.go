@@ -10,4 +10,9 @@ type Repos struct {
 	ExchangeRate ExchangeRateRepo
 	Program      ProgramRepo
 	Trigger      TriggerRepo
+	Role         RoleRepo
+	Approval     ApprovalRepo
+	ApiKey       ApiKeyRepo
+	Tenant       TenantRepo
+	Report       ReportRepo
 }
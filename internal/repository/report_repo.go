@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/resource"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ReportRepo interface {
+	// AggregateLiabilitySummaries Sums up the transactions of a wallet by month, from the month of a given time
+	AggregateLiabilitySummaries(ctx context.Context, walletId string, from time.Time) ([]model.WalletLiabilitySummary, error)
+	// SaveLiabilitySummaries Stores monthly liability summaries, replacing the ones already stored for their month
+	SaveLiabilitySummaries(ctx context.Context, summaries []model.WalletLiabilitySummary) error
+	// FetchLatestLiabilitySummary Retrieves the summary of the latest month of a wallet, or nil if there is none
+	FetchLatestLiabilitySummary(ctx context.Context, walletId string) (*model.WalletLiabilitySummary, error)
+	// FetchLiabilitySummaries Retrieves the summaries of a wallet for the months in a period, oldest first
+	FetchLiabilitySummaries(ctx context.Context, walletId string, from time.Time, to time.Time) ([]model.WalletLiabilitySummary, error)
+	// SumLiabilityBefore Retrieves the liability of a wallet at the start of a month, from the summaries of the months before it
+	SumLiabilityBefore(ctx context.Context, walletId string, month time.Time) (int64, error)
+}
+
+type reportRepo struct {
+	resources *resource.Resources
+}
+
+// NewReportRepo initializes the report repository
+func NewReportRepo(resources *resource.Resources) ReportRepo {
+	return &reportRepo{resources: resources}
+}
+
+// redemptionReasons are the debits the points of a wallet are redeemed with
+var redemptionReasons = []string{model.TransactionReasonRedeem, model.TransactionReasonPurchase}
+
+// AggregateLiabilitySummaries sums up the transactions of a wallet by month, from the month of a given time.
+// Expired points are the breakage, redeemed and purchased points the redemptions, and the other debits are kept apart
+func (r *reportRepo) AggregateLiabilitySummaries(ctx context.Context, walletId string, from time.Time) ([]model.WalletLiabilitySummary, error) {
+	var summaries []model.WalletLiabilitySummary
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).
+		Select(`date_trunc('month', created_at) AS month,
+			COALESCE(SUM(amount) FILTER (WHERE type = ?), 0) AS issued,
+			COALESCE(SUM(amount) FILTER (WHERE type = ? AND reason IN ?), 0) AS redeemed,
+			COALESCE(SUM(amount) FILTER (WHERE type = ? AND reason = ?), 0) AS expired,
+			COALESCE(SUM(amount) FILTER (WHERE type = ? AND reason NOT IN ?), 0) AS other_debits`,
+			model.TransactionTypeCredit,
+			model.TransactionTypeDebit, redemptionReasons,
+			model.TransactionTypeDebit, model.TransactionReasonExpired,
+			model.TransactionTypeDebit, append([]string{model.TransactionReasonExpired}, redemptionReasons...)).
+		Where("wallet_id = ? AND created_at >= date_trunc('month', ?::timestamp)", walletId, from).
+		Group("month").Order("month").Scan(&summaries).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error aggregating liability summaries", logger.Field("error", err), logger.Field("walletId", walletId), logger.Field("from", from))
+		return nil, err
+	}
+	for i := range summaries {
+		summaries[i].WalletID = walletId
+	}
+	return summaries, nil
+}
+
+// SaveLiabilitySummaries stores monthly liability summaries, replacing the ones already stored for their month
+func (r *reportRepo) SaveLiabilitySummaries(ctx context.Context, summaries []model.WalletLiabilitySummary) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+	err := r.resources.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "wallet_id"}, {Name: "month"}},
+		DoUpdates: clause.AssignmentColumns([]string{"issued", "redeemed", "expired", "other_debits", "refreshed_at"}),
+	}).Create(&summaries).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error saving liability summaries", logger.Field("error", err), logger.Field("walletId", summaries[0].WalletID))
+		return err
+	}
+	return nil
+}
+
+// FetchLatestLiabilitySummary retrieves the summary of the latest month of a wallet, or nil if there is none
+func (r *reportRepo) FetchLatestLiabilitySummary(ctx context.Context, walletId string) (*model.WalletLiabilitySummary, error) {
+	var summaries []model.WalletLiabilitySummary
+	if err := r.resources.DB.WithContext(ctx).Where("wallet_id = ?", walletId).Order("month desc").Limit(1).Find(&summaries).Error; err != nil {
+		api.GetLogger(ctx).Error("Error fetching latest liability summary", logger.Field("error", err), logger.Field("walletId", walletId))
+		return nil, err
+	}
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+	return &summaries[0], nil
+}
+
+// FetchLiabilitySummaries retrieves the summaries of a wallet for the months in a period, oldest first
+func (r *reportRepo) FetchLiabilitySummaries(ctx context.Context, walletId string, from time.Time, to time.Time) ([]model.WalletLiabilitySummary, error) {
+	var summaries []model.WalletLiabilitySummary
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ? AND month >= ? AND month < ?", walletId, from, to).Order("month").Find(&summaries).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching liability summaries", logger.Field("error", err), logger.Field("walletId", walletId), logger.Field("from", from), logger.Field("to", to))
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// SumLiabilityBefore retrieves the liability of a wallet at the start of a month, from the summaries of the months before it
+func (r *reportRepo) SumLiabilityBefore(ctx context.Context, walletId string, month time.Time) (int64, error) {
+	var liability int64
+	err := r.resources.DB.WithContext(ctx).Model(&model.WalletLiabilitySummary{}).
+		Select("COALESCE(SUM(issued - redeemed - expired - other_debits), 0)").
+		Where("wallet_id = ? AND month < ?", walletId, month).Scan(&liability).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error summing liability", logger.Field("error", err), logger.Field("walletId", walletId), logger.Field("month", month))
+		return 0, err
+	}
+	return liability, nil
+}
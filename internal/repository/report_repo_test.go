@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportRepo_AggregateRedemptions(t *testing.T) {
+	rec, repos := setupRecorder(t)
+	if _, err := repos.Report.AggregateLiabilitySummaries(createTenantContext(test_tenantA), "points", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statements := rec.reset()
+	if len(statements) != 1 {
+		t.Fatalf("expected a single query, got %+v", statements)
+	}
+	query := statements[0].query
+	if !strings.Contains(query, "reason IN ($3,$4)") || !strings.Contains(query, "reason NOT IN ($8,$9,$10)") {
+		t.Errorf("expected the redemptions to be kept apart from the other debits: %s", query)
+	}
+	for _, reason := range []string{"REDEEM", "PURCHASE", "EXPIRED"} {
+		if !hasArg(statements[0].args, reason) {
+			t.Errorf("expected the query to be given %s, got %v", reason, statements[0].args)
+		}
+	}
+}
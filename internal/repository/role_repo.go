@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/resource"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type RoleRepo interface {
+	// CreateRole creates a new role along with its permissions
+	CreateRole(ctx context.Context, role *model.Role) error
+	// UpdateRole updates a role and replaces its permissions
+	UpdateRole(ctx context.Context, role *model.Role) error
+	// DeleteRole deletes a role, revoking it from every actor it is assigned to
+	DeleteRole(ctx context.Context, role *model.Role) error
+	// FetchRoleByID retrieves a role and its permissions by its ID
+	FetchRoleByID(ctx context.Context, roleId string) (*model.Role, error)
+	// FetchRoles retrieves a paginated list of roles and their permissions
+	FetchRoles(ctx context.Context, page int, limit int) ([]model.Role, error)
+	// CountRoles retrieves the total number of roles
+	CountRoles(ctx context.Context) (int64, error)
+	// AssignRole assigns a role to a backoffice actor
+	AssignRole(ctx context.Context, actorRole *model.ActorRole) error
+	// RevokeRole revokes a role from a backoffice actor
+	RevokeRole(ctx context.Context, actorRole *model.ActorRole) error
+	// FetchActorRole retrieves the assignment of a role to an actor, or nil if the actor doesn't have the role
+	FetchActorRole(ctx context.Context, actorId, roleId string) (*model.ActorRole, error)
+	// FetchActorRoles retrieves the roles assigned to an actor and their permissions
+	FetchActorRoles(ctx context.Context, actorId string) ([]model.Role, error)
+	// FetchActorPermissions retrieves the permissions granted to an actor by all of its roles
+	FetchActorPermissions(ctx context.Context, actorId string) ([]string, error)
+}
+
+type roleRepo struct {
+	resources *resource.Resources
+}
+
+func NewRoleRepo(resources *resource.Resources) RoleRepo {
+	return &roleRepo{resources: resources}
+}
+
+func (r *roleRepo) CreateRole(ctx context.Context, role *model.Role) error {
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return r.savePermissions(tx, role)
+	})
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to create role", logger.Field("error", err), logger.Field("role", role))
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepo) UpdateRole(ctx context.Context, role *model.Role) error {
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(role).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return r.savePermissions(tx, role)
+	})
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to update role", logger.Field("error", err), logger.Field("role", role))
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepo) savePermissions(tx *gorm.DB, role *model.Role) error {
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+	permissions := make([]model.RolePermission, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = model.RolePermission{RoleID: role.ID, Permission: permission}
+	}
+	return tx.Create(&permissions).Error
+}
+
+func (r *roleRepo) DeleteRole(ctx context.Context, role *model.Role) error {
+	if err := r.resources.DB.WithContext(ctx).Delete(role).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to delete role", logger.Field("error", err), logger.Field("role", role))
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepo) FetchRoleByID(ctx context.Context, roleId string) (*model.Role, error) {
+	var role model.Role
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", roleId).First(&role).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get role by id", logger.Field("error", err), logger.Field("roleId", roleId))
+		return nil, err
+	}
+	roles := []model.Role{role}
+	if err := r.loadPermissions(ctx, roles); err != nil {
+		return nil, err
+	}
+	return &roles[0], nil
+}
+
+func (r *roleRepo) FetchRoles(ctx context.Context, page int, limit int) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&roles).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get roles", logger.Field("error", err))
+		return nil, err
+	}
+	if err := r.loadPermissions(ctx, roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepo) CountRoles(ctx context.Context) (int64, error) {
+	var total int64
+	err := r.resources.DB.WithContext(ctx).Model(&model.Role{}).Count(&total).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get total roles", logger.Field("error", err))
+		return 0, err
+	}
+	return total, nil
+}
+
+// loadPermissions fills in the permissions of the given roles
+func (r *roleRepo) loadPermissions(ctx context.Context, roles []model.Role) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	roleIds := make([]string, len(roles))
+	for i, role := range roles {
+		roleIds[i] = role.ID
+	}
+	var permissions []model.RolePermission
+	err := r.resources.DB.WithContext(ctx).Where("role_id IN ?", roleIds).Order("permission").Find(&permissions).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get role permissions", logger.Field("error", err), logger.Field("roleIds", roleIds))
+		return err
+	}
+	byRole := make(map[string][]string, len(roles))
+	for _, permission := range permissions {
+		byRole[permission.RoleID] = append(byRole[permission.RoleID], permission.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].ID]
+	}
+	return nil
+}
+
+func (r *roleRepo) AssignRole(ctx context.Context, actorRole *model.ActorRole) error {
+	if err := r.resources.DB.WithContext(ctx).Create(actorRole).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to assign role", logger.Field("error", err), logger.Field("actorRole", actorRole))
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepo) RevokeRole(ctx context.Context, actorRole *model.ActorRole) error {
+	if err := r.resources.DB.WithContext(ctx).Delete(actorRole).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to revoke role", logger.Field("error", err), logger.Field("actorRole", actorRole))
+		return err
+	}
+	return nil
+}
+
+func (r *roleRepo) FetchActorRole(ctx context.Context, actorId, roleId string) (*model.ActorRole, error) {
+	var actorRoles []model.ActorRole
+	err := r.resources.DB.WithContext(ctx).Where("actor_id = ? AND role_id = ?", actorId, roleId).Limit(1).Find(&actorRoles).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get actor role", logger.Field("error", err), logger.Field("actorId", actorId), logger.Field("roleId", roleId))
+		return nil, err
+	}
+	if len(actorRoles) == 0 {
+		return nil, nil
+	}
+	return &actorRoles[0], nil
+}
+
+func (r *roleRepo) FetchActorRoles(ctx context.Context, actorId string) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.resources.DB.WithContext(ctx).Joins("JOIN actor_roles ON actor_roles.tenant_id = roles.tenant_id AND actor_roles.role_id = roles.id").Where("actor_roles.actor_id = ?", actorId).Order("roles.id").Find(&roles).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get actor roles", logger.Field("error", err), logger.Field("actorId", actorId))
+		return nil, err
+	}
+	if err := r.loadPermissions(ctx, roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepo) FetchActorPermissions(ctx context.Context, actorId string) ([]string, error) {
+	var permissions []string
+	err := r.resources.DB.WithContext(ctx).Model(&model.RolePermission{}).
+		Joins("JOIN actor_roles ON actor_roles.tenant_id = role_permissions.tenant_id AND actor_roles.role_id = role_permissions.role_id").
+		Where("actor_roles.actor_id = ?", actorId).
+		Distinct().Order("permission").Pluck("permission", &permissions).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get actor permissions", logger.Field("error", err), logger.Field("actorId", actorId))
+		return nil, err
+	}
+	return permissions, nil
+}
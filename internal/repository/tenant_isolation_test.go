@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/resource"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	test_tenantA = "tenant-a"
+	test_tenantB = "tenant-b"
+)
+
+// statement is a SQL statement sent to the database, with its arguments
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// recorder is a database/sql driver that records the statements sent to it instead of running them.
+// Queries return no rows and every write affects a single row.
+type recorder struct {
+	mu         sync.Mutex
+	statements []statement
+}
+
+func (r *recorder) record(query string, args []driver.NamedValue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	r.statements = append(r.statements, statement{query: query, args: values})
+}
+
+func (r *recorder) reset() []statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements := r.statements
+	r.statements = nil
+	return statements
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) {
+	return &recorderConn{recorder: r}, nil
+}
+func (r *recorder) Driver() driver.Driver { return recorderDriver{recorder: r} }
+
+type recorderDriver struct{ recorder *recorder }
+
+func (d recorderDriver) Open(string) (driver.Conn, error) {
+	return &recorderConn{recorder: d.recorder}, nil
+}
+
+type recorderConn struct{ recorder *recorder }
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return &recorderStmt{recorder: c.recorder, query: query}, nil
+}
+func (c *recorderConn) Close() error                             { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error)                { return recorderTx{}, nil }
+func (c *recorderConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+func (c *recorderConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.recorder.record(query, args)
+	return driver.RowsAffected(1), nil
+}
+func (c *recorderConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.recorder.record(query, args)
+	return recorderRows{}, nil
+}
+
+type recorderStmt struct {
+	recorder *recorder
+	query    string
+}
+
+func (s *recorderStmt) Close() error  { return nil }
+func (s *recorderStmt) NumInput() int { return -1 }
+func (s *recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.recorder.record(s.query, namedValues(args))
+	return driver.RowsAffected(1), nil
+}
+func (s *recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.recorder.record(s.query, namedValues(args))
+	return recorderRows{}, nil
+}
+
+type recorderTx struct{}
+
+func (recorderTx) Commit() error   { return nil }
+func (recorderTx) Rollback() error { return nil }
+
+type recorderRows struct{}
+
+func (recorderRows) Columns() []string         { return nil }
+func (recorderRows) Close() error              { return nil }
+func (recorderRows) Next([]driver.Value) error { return io.EOF }
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+type noopBroker struct{}
+
+func (noopBroker) CreateTopic(context.Context, string, sarama.TopicDetail) error { return nil }
+func (noopBroker) DeleteTopic(context.Context, string) error                     { return nil }
+func (noopBroker) Publish(context.Context, string, []byte) error                 { return nil }
+func (noopBroker) Close()                                                        {}
+
+func setupRecorder(t *testing.T) (*recorder, *Repos) {
+	rec := &recorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(rec)}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := resource.RegisterTenantScope(db); err != nil {
+		t.Fatalf("failed to register tenant scope: %v", err)
+	}
+	resources := &resource.Resources{DB: db, Broker: noopBroker{}}
+	return rec, &Repos{
+		Audit:        NewAuditRepo(resources),
+		Transaction:  NewTransactionRepo(resources),
+		Account:      NewAccountRepo(resources),
+		Wallet:       NewWalletRepo(resources),
+		Tier:         NewTierRepo(resources),
+		User:         NewUserRepo(resources),
+		ExchangeRate: NewExchangeRateRepo(resources),
+		Program:      NewProgramRepo(resources),
+		Trigger:      NewTriggerRepo(resources),
+		Role:         NewRoleRepo(resources),
+		Approval:     NewApprovalRepo(resources),
+		ApiKey:       NewApiKeyRepo(resources),
+		Report:       NewReportRepo(resources),
+	}
+}
+
+func createTenantContext(tenantId string) context.Context {
+	ctx := api.CreateAppContext(context.Background(), api.AppActorAdmin, "admin-123", "request-123")
+	if tenantId == "" {
+		return ctx
+	}
+	return api.WithTenant(ctx, tenantId)
+}
+
+// callMethod calls a repository method with the given context and placeholder arguments, returning its error
+func callMethod(t *testing.T, method reflect.Method, repo reflect.Value, ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s panicked: %v", method.Name, r)
+		}
+	}()
+	methodType := method.Type
+	args := []reflect.Value{repo, reflect.ValueOf(ctx)}
+	for i := 2; i < methodType.NumIn(); i++ {
+		args = append(args, placeholder(methodType.In(i)))
+	}
+	results := method.Func.Call(args)
+	last := results[len(results)-1]
+	if last.Type() == reflect.TypeOf((*error)(nil)).Elem() && !last.IsNil() {
+		return last.Interface().(error)
+	}
+	return nil
+}
+
+// placeholder returns a value to call a repository method with
+func placeholder(typ reflect.Type) reflect.Value {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		value := reflect.New(typ.Elem())
+		value.Elem().Set(placeholder(typ.Elem()))
+		return value
+	case reflect.String:
+		return reflect.ValueOf("x").Convert(typ)
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return reflect.ValueOf(1).Convert(typ)
+	case reflect.Struct:
+		if typ == reflect.TypeOf(time.Time{}) {
+			return reflect.ValueOf(time.Now())
+		}
+	}
+	return reflect.Zero(typ)
+}
+
+// isTenantFree reports whether a statement does not touch tenant data, like the id generators
+func isTenantFree(query string) bool {
+	return strings.HasPrefix(query, "SELECT generate_")
+}
+
+func hasArg(args []interface{}, value string) bool {
+	for _, arg := range args {
+		if fmt.Sprint(arg) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRepos_TenantIsolation(t *testing.T) {
+	rec, repos := setupRecorder(t)
+	reposValue := reflect.ValueOf(repos).Elem()
+	for i := 0; i < reposValue.NumField(); i++ {
+		if reposValue.Field(i).IsNil() {
+			continue
+		}
+		repo := reposValue.Field(i).Elem()
+		repoName := reposValue.Type().Field(i).Name
+		for j := 0; j < repo.Type().NumMethod(); j++ {
+			method := repo.Type().Method(j)
+			t.Run(repoName+"/"+method.Name, func(t *testing.T) {
+				rec.reset()
+				_ = callMethod(t, method, repo, createTenantContext(test_tenantA))
+				statements := rec.reset()
+				scoped := 0
+				for _, stmt := range statements {
+					if isTenantFree(stmt.query) {
+						continue
+					}
+					scoped++
+					if !strings.Contains(stmt.query, "tenant_id") || !hasArg(stmt.args, test_tenantA) {
+						t.Errorf("statement is not confined to the tenant: %s %v", stmt.query, stmt.args)
+					}
+					if hasArg(stmt.args, test_tenantB) {
+						t.Errorf("statement reaches another tenant: %s %v", stmt.query, stmt.args)
+					}
+				}
+				if scoped == 0 {
+					return
+				}
+
+				err := callMethod(t, method, repo, createTenantContext(""))
+				if err == nil {
+					t.Errorf("expected %s to fail without a tenant", method.Name)
+				}
+				for _, stmt := range rec.reset() {
+					if !isTenantFree(stmt.query) {
+						t.Errorf("statement ran without a tenant: %s %v", stmt.query, stmt.args)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRepos_TenantIsolation_AllTenants(t *testing.T) {
+	rec, repos := setupRecorder(t)
+	ctx := api.WithAllTenants(createTenantContext(""))
+	if _, err := repos.ApiKey.FetchApiKeyByHash(ctx, "hash"); err != nil {
+		t.Fatalf("expected the key lookup across tenants to succeed, got %v", err)
+	}
+	for _, stmt := range rec.reset() {
+		if strings.Contains(stmt.query, "tenant_id") {
+			t.Errorf("expected the lookup not to be confined to a tenant: %s", stmt.query)
+		}
+	}
+	if err := repos.Wallet.CreateWallet(ctx, &model.Wallet{ID: "points"}); err == nil {
+		t.Errorf("expected creating without a tenant to fail")
+	}
+}
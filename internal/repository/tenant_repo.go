@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/resource"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+)
+
+type TenantRepo interface {
+	// FetchTenantIDs retrieves the IDs of all tenants
+	FetchTenantIDs(ctx context.Context) ([]string, error)
+}
+
+type tenantRepo struct {
+	resources *resource.Resources
+}
+
+func NewTenantRepo(resources *resource.Resources) TenantRepo {
+	return &tenantRepo{resources: resources}
+}
+
+func (r *tenantRepo) FetchTenantIDs(ctx context.Context) ([]string, error) {
+	var tenantIds []string
+	if err := r.resources.DB.WithContext(ctx).Model(&model.Tenant{}).Order("id").Pluck("id", &tenantIds).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to fetch tenants", logger.Field("error", err))
+		return nil, err
+	}
+	return tenantIds, nil
+}
@@ -19,6 +19,26 @@ type TierRepo interface {
 	FetchTiers(ctx context.Context, page int, limit int) ([]model.Tier, error)
 	// CountTiers retrieves the total number of tiers
 	CountTiers(ctx context.Context) (int64, error)
+	// CreateTierPolicy creates the policy of a tier for a wallet
+	CreateTierPolicy(ctx context.Context, policy *model.TierPolicy) error
+	// UpdateTierPolicy updates the policy of a tier for a wallet
+	UpdateTierPolicy(ctx context.Context, policy *model.TierPolicy) error
+	// DeleteTierPolicy deletes the policy of a tier for a wallet
+	DeleteTierPolicy(ctx context.Context, policy *model.TierPolicy) error
+	// FetchTierPolicy retrieves the policy of a tier for a wallet, or nil if the tier has no policy for the wallet
+	FetchTierPolicy(ctx context.Context, walletId, tierId string) (*model.TierPolicy, error)
+	// FetchTierPolicies retrieves the policies of a tier across all wallets
+	FetchTierPolicies(ctx context.Context, tierId string) ([]model.TierPolicy, error)
+	// CreateTierRule creates the qualification rule of a tier
+	CreateTierRule(ctx context.Context, rule *model.TierRule) error
+	// UpdateTierRule updates the qualification rule of a tier
+	UpdateTierRule(ctx context.Context, rule *model.TierRule) error
+	// DeleteTierRule deletes the qualification rule of a tier
+	DeleteTierRule(ctx context.Context, rule *model.TierRule) error
+	// FetchTierRule retrieves the qualification rule of a tier, or nil if the tier has none
+	FetchTierRule(ctx context.Context, tierId string) (*model.TierRule, error)
+	// FetchTierRules retrieves all tier qualification rules, highest rank first
+	FetchTierRules(ctx context.Context) ([]model.TierRule, error)
 }
 
 type tierRepo struct {
@@ -30,7 +50,7 @@ func NewTierRepo(resources *resource.Resources) TierRepo {
 }
 
 func (r *tierRepo) CreateTier(ctx context.Context, tier *model.Tier) error {
-	if err := r.resources.DB.Create(tier).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Create(tier).Error; err != nil {
 		api.GetLogger(ctx).Error("failed to create tier", logger.Field("error", err), logger.Field("tier", tier))
 		return err
 	}
@@ -39,7 +59,7 @@ func (r *tierRepo) CreateTier(ctx context.Context, tier *model.Tier) error {
 
 func (r *tierRepo) FetchTierByID(ctx context.Context, tierId string) (*model.Tier, error) {
 	var tier model.Tier
-	err := r.resources.DB.Where("id = ?", tierId).First(&tier).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", tierId).First(&tier).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("failed to get tier by id", logger.Field("error", err), logger.Field("tierId", tierId))
 		return nil, err
@@ -49,7 +69,7 @@ func (r *tierRepo) FetchTierByID(ctx context.Context, tierId string) (*model.Tie
 
 func (r *tierRepo) FetchTiers(ctx context.Context, page int, limit int) ([]model.Tier, error) {
 	var tiers []model.Tier
-	err := r.resources.DB.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&tiers).Error
+	err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&tiers).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("failed to get tiers", logger.Field("error", err))
 		return nil, err
@@ -59,7 +79,7 @@ func (r *tierRepo) FetchTiers(ctx context.Context, page int, limit int) ([]model
 
 func (r *tierRepo) CountTiers(ctx context.Context) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Tier{}).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Tier{}).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("failed to get total tiers", logger.Field("error", err))
 		return 0, err
@@ -68,9 +88,103 @@ func (r *tierRepo) CountTiers(ctx context.Context) (int64, error) {
 }
 
 func (r *tierRepo) DeleteTier(ctx context.Context, tier *model.Tier) error {
-	if err := r.resources.DB.Delete(tier).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Delete(tier).Error; err != nil {
 		api.GetLogger(ctx).Error("failed to delete tier", logger.Field("error", err), logger.Field("tier", tier))
 		return err
 	}
 	return nil
 }
+
+func (r *tierRepo) CreateTierPolicy(ctx context.Context, policy *model.TierPolicy) error {
+	if err := r.resources.DB.WithContext(ctx).Create(policy).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to create tier policy", logger.Field("error", err), logger.Field("policy", policy))
+		return err
+	}
+	return nil
+}
+
+func (r *tierRepo) UpdateTierPolicy(ctx context.Context, policy *model.TierPolicy) error {
+	if err := r.resources.DB.WithContext(ctx).Save(policy).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to update tier policy", logger.Field("error", err), logger.Field("policy", policy))
+		return err
+	}
+	return nil
+}
+
+func (r *tierRepo) DeleteTierPolicy(ctx context.Context, policy *model.TierPolicy) error {
+	if err := r.resources.DB.WithContext(ctx).Delete(policy).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to delete tier policy", logger.Field("error", err), logger.Field("policy", policy))
+		return err
+	}
+	return nil
+}
+
+func (r *tierRepo) FetchTierPolicy(ctx context.Context, walletId, tierId string) (*model.TierPolicy, error) {
+	var policies []model.TierPolicy
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ? AND tier_id = ?", walletId, tierId).Limit(1).Find(&policies).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get tier policy", logger.Field("error", err), logger.Field("walletId", walletId), logger.Field("tierId", tierId))
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return &policies[0], nil
+}
+
+func (r *tierRepo) FetchTierPolicies(ctx context.Context, tierId string) ([]model.TierPolicy, error) {
+	var policies []model.TierPolicy
+	err := r.resources.DB.WithContext(ctx).Where("tier_id = ?", tierId).Order("wallet_id").Find(&policies).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get tier policies", logger.Field("error", err), logger.Field("tierId", tierId))
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *tierRepo) CreateTierRule(ctx context.Context, rule *model.TierRule) error {
+	if err := r.resources.DB.WithContext(ctx).Create(rule).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to create tier rule", logger.Field("error", err), logger.Field("rule", rule))
+		return err
+	}
+	return nil
+}
+
+func (r *tierRepo) UpdateTierRule(ctx context.Context, rule *model.TierRule) error {
+	if err := r.resources.DB.WithContext(ctx).Save(rule).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to update tier rule", logger.Field("error", err), logger.Field("rule", rule))
+		return err
+	}
+	return nil
+}
+
+func (r *tierRepo) DeleteTierRule(ctx context.Context, rule *model.TierRule) error {
+	if err := r.resources.DB.WithContext(ctx).Delete(rule).Error; err != nil {
+		api.GetLogger(ctx).Error("failed to delete tier rule", logger.Field("error", err), logger.Field("rule", rule))
+		return err
+	}
+	return nil
+}
+
+func (r *tierRepo) FetchTierRule(ctx context.Context, tierId string) (*model.TierRule, error) {
+	var rules []model.TierRule
+	err := r.resources.DB.WithContext(ctx).Where("tier_id = ?", tierId).Limit(1).Find(&rules).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get tier rule", logger.Field("error", err), logger.Field("tierId", tierId))
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return &rules[0], nil
+}
+
+func (r *tierRepo) FetchTierRules(ctx context.Context) ([]model.TierRule, error) {
+	var rules []model.TierRule
+	err := r.resources.DB.WithContext(ctx).Order("rank desc").Find(&rules).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("failed to get tier rules", logger.Field("error", err))
+		return nil, err
+	}
+	return rules, nil
+}
@@ -15,14 +15,22 @@ import (
 )
 
 type TransactionRepo interface {
-	// FetchAccountTransactions Retrieves a list of transactions by account ID with pagination
-	FetchAccountTransactions(ctx context.Context, accountId string, page int, limit int) ([]model.Transaction, error)
-	// CountAccountTransactions Retrieves the total count of transactions by account ID
-	CountAccountTransactions(ctx context.Context, accountId string) (int64, error)
-	// FetchWalletTransactions Retrieves a list of transactions by wallet ID with pagination
-	FetchWalletTransactions(ctx context.Context, walletId string, page int, limit int) ([]model.Transaction, error)
-	// CountWalletTransactions Retrieves the total count of transactions by wallet ID
-	CountWalletTransactions(ctx context.Context, walletId string) (int64, error)
+	// FetchAccountTransactions Retrieves a filtered list of transactions by account ID, newest first, by cursor or page
+	FetchAccountTransactions(ctx context.Context, accountId string, filter TransactionFilter, page int, limit int) ([]model.Transaction, error)
+	// CountAccountTransactions Retrieves the total count of filtered transactions by account ID
+	CountAccountTransactions(ctx context.Context, accountId string, filter TransactionFilter) (int64, error)
+	// FetchLastAccountTransaction Retrieves the last transaction made by an account before a given time, or nil if there is none
+	FetchLastAccountTransaction(ctx context.Context, accountId string, before time.Time) (*model.Transaction, error)
+	// FetchAccountTransactionsInBatches Passes the transactions made by an account in a period to process, oldest first, a batch at a time
+	FetchAccountTransactionsInBatches(ctx context.Context, accountId string, from time.Time, to time.Time, batchSize int, process func(transactions []model.Transaction) error) error
+	// SumAccountTransactionsByReason Retrieves the totals of the transactions made by an account in a period, by type and reason
+	SumAccountTransactionsByReason(ctx context.Context, accountId string, from time.Time, to time.Time) ([]ReasonTotal, error)
+	// SumWalletBalanceChange Retrieves how much the transactions of a wallet made in a period changed its liability, credits minus debits
+	SumWalletBalanceChange(ctx context.Context, walletId string, from time.Time, to time.Time) (int64, error)
+	// FetchWalletTransactions Retrieves a filtered list of transactions by wallet ID, newest first, by cursor or page
+	FetchWalletTransactions(ctx context.Context, walletId string, filter TransactionFilter, page int, limit int) ([]model.Transaction, error)
+	// CountWalletTransactions Retrieves the total count of filtered transactions by wallet ID
+	CountWalletTransactions(ctx context.Context, walletId string, filter TransactionFilter) (int64, error)
 	// SumAccountTransactions Retrieves the sum of transactions for a specific account ID
 	SumAccountTransactions(ctx context.Context, accountId string) (uint64, error)
 	// SumWalletTransactions Retrieves the sum of transactions for a specific wallet ID
@@ -31,10 +39,21 @@ type TransactionRepo interface {
 	FetchExpiredWalletTransactions(ctx context.Context, walletId string) ([]model.Transaction, error)
 	// SumExpiringAccountTransactions Retrieves the sum of transactions about to expire for a specific account ID
 	SumExpiringAccountTransactions(ctx context.Context, accountId string, expireInterval types.Interval) (uint64, error)
+	// SumAccountTransactionsSince Retrieves the sum of transactions of a type and any of the given reasons made by an account since a given time
+	SumAccountTransactionsSince(ctx context.Context, accountId string, transactionType string, reasons []string, since time.Time) (uint64, error)
+	// FetchOpenAccountLots Retrieves the credits of an account that still have an available amount, in the order its wallet consumes them
+	FetchOpenAccountLots(ctx context.Context, wallet *model.Wallet, accountId string) ([]model.Transaction, error)
+	// FetchTransactionConsumptions Retrieves what a debit of an account consumed, or what consumed a credit of it
+	FetchTransactionConsumptions(ctx context.Context, accountId string, transactionId string) ([]model.TransactionConsumption, error)
 	// CreateTransaction Creates a new transaction
 	CreateTransaction(ctx context.Context, transaction *model.Transaction, accountVersion uint64) error
-	// PerformExchange Performs an exchange between two accounts
-	PerformExchange(ctx context.Context, from *ExchangeRequest, to *ExchangeRequest) error
+	// CreateRewardTransaction Credits the reward of a program with a budget and consumes it from the budget atomically.
+	// A reward exceeding what remains of the budget is reduced to the remainder, with the full reward recorded as
+	// "partialFrom" in its metadata, when the budget allows partial rewards and rejected otherwise. The program is
+	// switched off once its budget runs out
+	CreateRewardTransaction(ctx context.Context, transaction *model.Transaction, accountVersion uint64, programId uint64) (*BudgetConsumption, error)
+	// PerformExchange Performs an exchange through one or more legs atomically
+	PerformExchange(ctx context.Context, legs []ExchangeLeg) error
 }
 
 type transactionRepo struct {
@@ -46,11 +65,11 @@ func NewTransactionRepo(resources *resource.Resources) TransactionRepo {
 	return &transactionRepo{resources: resources}
 }
 
-// FetchAccountTransactions retrieves transactions by account ID with pagination
-func (r *transactionRepo) FetchAccountTransactions(ctx context.Context, accountId string, page int, limit int) ([]model.Transaction, error) {
+// FetchAccountTransactions retrieves filtered transactions by account ID, newest first, by cursor or page
+func (r *transactionRepo) FetchAccountTransactions(ctx context.Context, accountId string, filter TransactionFilter, page int, limit int) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	err := r.resources.DB.Where("account_id = ?", accountId).Order("created_at desc").
-		Offset((page - 1) * limit).Limit(limit).Find(&transactions).Error
+	query := filterTransactions(r.resources.DB.WithContext(ctx).Where("account_id = ?", accountId), filter)
+	err := pageTransactions(query, filter.Cursor, page, limit).Find(&transactions).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching transactions by account ID", logger.Field("error", err), logger.Field("accountId", accountId))
 		return nil, err
@@ -58,10 +77,10 @@ func (r *transactionRepo) FetchAccountTransactions(ctx context.Context, accountI
 	return transactions, nil
 }
 
-// CountAccountTransactions retrieves the total number of transactions by account ID
-func (r *transactionRepo) CountAccountTransactions(ctx context.Context, accountId string) (int64, error) {
+// CountAccountTransactions retrieves the total number of filtered transactions by account ID
+func (r *transactionRepo) CountAccountTransactions(ctx context.Context, accountId string, filter TransactionFilter) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Transaction{}).Where("account_id = ?", accountId).Count(&total).Error
+	err := filterTransactions(r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).Where("account_id = ?", accountId), filter).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching total transactions by account ID", logger.Field("error", err), logger.Field("accountId", accountId))
 		return 0, err
@@ -69,10 +88,67 @@ func (r *transactionRepo) CountAccountTransactions(ctx context.Context, accountI
 	return total, nil
 }
 
+// FetchLastAccountTransaction retrieves the last transaction made by an account before a given time, or nil if there is none
+func (r *transactionRepo) FetchLastAccountTransaction(ctx context.Context, accountId string, before time.Time) (*model.Transaction, error) {
+	var transactions []model.Transaction
+	err := r.resources.DB.WithContext(ctx).Where("account_id = ? AND created_at < ?", accountId, before).
+		Order("created_at desc, id desc").Limit(1).Find(&transactions).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching last account transaction", logger.Field("error", err), logger.Field("accountId", accountId), logger.Field("before", before))
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	return &transactions[0], nil
+}
+
+// FetchAccountTransactionsInBatches passes the transactions made by an account in a period to process, oldest first, a batch at a time.
+// Batches are fetched by keyset on (created_at, id) so long periods are never loaded at once
+func (r *transactionRepo) FetchAccountTransactionsInBatches(ctx context.Context, accountId string, from time.Time, to time.Time, batchSize int, process func(transactions []model.Transaction) error) error {
+	var cursor *api.Cursor
+	for {
+		var transactions []model.Transaction
+		query := r.resources.DB.WithContext(ctx).Where("account_id = ? AND created_at >= ? AND created_at < ?", accountId, from, to)
+		if cursor != nil {
+			query = query.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		if err := query.Order("created_at asc, id asc").Limit(batchSize).Find(&transactions).Error; err != nil {
+			api.GetLogger(ctx).Error("Error fetching account transactions batch", logger.Field("error", err), logger.Field("accountId", accountId))
+			return err
+		}
+		if len(transactions) == 0 {
+			return nil
+		}
+		if err := process(transactions); err != nil {
+			return err
+		}
+		if len(transactions) < batchSize {
+			return nil
+		}
+		last := transactions[len(transactions)-1]
+		cursor = &api.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// SumAccountTransactionsByReason retrieves the totals of the transactions made by an account in a period, by type and reason
+func (r *transactionRepo) SumAccountTransactionsByReason(ctx context.Context, accountId string, from time.Time, to time.Time) ([]ReasonTotal, error) {
+	var totals []ReasonTotal
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).
+		Select("type, reason, COALESCE(SUM(amount), 0) as amount, COUNT(*) as count").
+		Where("account_id = ? AND created_at >= ? AND created_at < ?", accountId, from, to).
+		Group("type, reason").Order("type, reason").Scan(&totals).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching account transaction totals", logger.Field("error", err), logger.Field("accountId", accountId))
+		return nil, err
+	}
+	return totals, nil
+}
+
 // SumAccountTransactions retrieves the sum of transaction amounts for a specific account ID
 func (r *transactionRepo) SumAccountTransactions(ctx context.Context, accountId string) (uint64, error) {
 	var sum uint64
-	err := r.resources.DB.Model(&model.Transaction{}).Select("COALESCE(SUM(amount), 0)").Where("account_id = ?", accountId).Scan(&sum).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).Select("COALESCE(SUM(amount), 0)").Where("account_id = ?", accountId).Scan(&sum).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching transaction sum by account ID", logger.Field("error", err), logger.Field("accountId", accountId))
 		return 0, err
@@ -83,7 +159,7 @@ func (r *transactionRepo) SumAccountTransactions(ctx context.Context, accountId
 // SumExpiringAccountTransactions retrieves the sum of transactions that are about to expire for an account ID
 func (r *transactionRepo) SumExpiringAccountTransactions(ctx context.Context, accountId string, expireInterval types.Interval) (uint64, error) {
 	var sum uint64
-	err := r.resources.DB.Model(&model.Transaction{}).
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).
 		Select("COALESCE(SUM(amount), 0)").
 		Where("account_id = ? AND expire_at < ?", accountId, time.Now().Add(expireInterval.Duration()).Format(time.DateOnly)).
 		Scan(&sum).Error
@@ -94,11 +170,25 @@ func (r *transactionRepo) SumExpiringAccountTransactions(ctx context.Context, ac
 	return sum, nil
 }
 
-// FetchWalletTransactions retrieves transactions by wallet ID with pagination
-func (r *transactionRepo) FetchWalletTransactions(ctx context.Context, walletId string, page int, limit int) ([]model.Transaction, error) {
+// SumAccountTransactionsSince retrieves the sum of transactions of a type and any of the given reasons made by an account since a given time
+func (r *transactionRepo) SumAccountTransactionsSince(ctx context.Context, accountId string, transactionType string, reasons []string, since time.Time) (uint64, error) {
+	var sum uint64
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("account_id = ? AND type = ? AND reason IN ? AND created_at >= ?", accountId, transactionType, reasons, since).
+		Scan(&sum).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching sum of account transactions", logger.Field("error", err), logger.Field("accountId", accountId), logger.Field("type", transactionType), logger.Field("since", since))
+		return 0, err
+	}
+	return sum, nil
+}
+
+// FetchWalletTransactions retrieves filtered transactions by wallet ID, newest first, by cursor or page
+func (r *transactionRepo) FetchWalletTransactions(ctx context.Context, walletId string, filter TransactionFilter, page int, limit int) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	err := r.resources.DB.Where("wallet_id = ?", walletId).Order("created_at desc").
-		Offset((page - 1) * limit).Limit(limit).Find(&transactions).Error
+	query := filterTransactions(r.resources.DB.WithContext(ctx).Where("wallet_id = ?", walletId), filter)
+	err := pageTransactions(query, filter.Cursor, page, limit).Find(&transactions).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching transactions by wallet ID", logger.Field("error", err))
 		return nil, err
@@ -106,10 +196,10 @@ func (r *transactionRepo) FetchWalletTransactions(ctx context.Context, walletId
 	return transactions, nil
 }
 
-// CountWalletTransactions retrieves the total number of transactions by wallet ID
-func (r *transactionRepo) CountWalletTransactions(ctx context.Context, walletId string) (int64, error) {
+// CountWalletTransactions retrieves the total number of filtered transactions by wallet ID
+func (r *transactionRepo) CountWalletTransactions(ctx context.Context, walletId string, filter TransactionFilter) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Transaction{}).Where("wallet_id = ?", walletId).Count(&total).Error
+	err := filterTransactions(r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).Where("wallet_id = ?", walletId), filter).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching total transactions by wallet ID", logger.Field("error", err), logger.Field("walletId", walletId))
 		return 0, err
@@ -117,13 +207,69 @@ func (r *transactionRepo) CountWalletTransactions(ctx context.Context, walletId
 	return total, nil
 }
 
+// SumWalletBalanceChange retrieves how much the transactions of a wallet made in a period changed its liability, credits minus debits
+func (r *transactionRepo) SumWalletBalanceChange(ctx context.Context, walletId string, from time.Time, to time.Time) (int64, error) {
+	var change int64
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", model.TransactionTypeCredit).
+		Where("wallet_id = ? AND created_at >= ? AND created_at < ?", walletId, from, to).
+		Scan(&change).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching wallet balance change", logger.Field("error", err), logger.Field("walletId", walletId), logger.Field("from", from), logger.Field("to", to))
+		return 0, err
+	}
+	return change, nil
+}
+
+// filterTransactions narrows down a transaction query to the transactions matching the filter
+func filterTransactions(query *gorm.DB, filter TransactionFilter) *gorm.DB {
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if filter.ProgramID != nil {
+		query = query.Where("program_id = ?", *filter.ProgramID)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("amount <= ?", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.MetadataKey != "" && filter.MetadataValue != "" {
+		query = query.Where("metadata ->> ? = ?", filter.MetadataKey, filter.MetadataValue)
+	} else if filter.MetadataKey != "" {
+		// jsonb_exists is the ? operator, which gorm would take for a placeholder
+		query = query.Where("jsonb_exists(metadata, ?)", filter.MetadataKey)
+	}
+	return query
+}
+
+// pageTransactions orders a transaction query newest first and limits it to the transactions following the cursor,
+// or to the page when there is no cursor. The id breaks ties between transactions created at the same time
+func pageTransactions(query *gorm.DB, cursor *api.Cursor, page int, limit int) *gorm.DB {
+	query = query.Order("created_at desc, id desc").Limit(limit)
+	if cursor != nil {
+		return query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	return query.Offset((page - 1) * limit)
+}
+
 // SumWalletTransactions retrieves the sum of transaction amounts for a specific wallet ID
 func (r *transactionRepo) SumWalletTransactions(ctx context.Context, walletId string) (uint64, error) {
 	var res []struct {
 		Type string
 		Sum  uint64
 	}
-	err := r.resources.DB.Model(&model.Transaction{}).Where("wallet_id = ?", walletId).
+	err := r.resources.DB.WithContext(ctx).Model(&model.Transaction{}).Where("wallet_id = ?", walletId).
 		Select("type, COALESCE(SUM(amount), 0) as sum").Group("type").Scan(&res).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching transaction sums by wallet ID", logger.Field("error", err), logger.Field("walletId", walletId))
@@ -145,7 +291,7 @@ func (r *transactionRepo) SumWalletTransactions(ctx context.Context, walletId st
 // FetchExpiredWalletTransactions retrieves expired transactions by wallet ID
 func (r *transactionRepo) FetchExpiredWalletTransactions(ctx context.Context, walletId string) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	err := r.resources.DB.Where("wallet_id = ? AND expire_at < ? AND available_amount > 0", walletId, time.Now().Format(time.DateOnly)).Find(&transactions).Error
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ? AND expire_at < ? AND available_amount > 0", walletId, time.Now().Format(time.DateOnly)).Find(&transactions).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching expired transactions", logger.Field("error", err))
 		return nil, err
@@ -153,9 +299,35 @@ func (r *transactionRepo) FetchExpiredWalletTransactions(ctx context.Context, wa
 	return transactions, nil
 }
 
+// FetchOpenAccountLots retrieves the credits of an account that still have an available amount, in the order its wallet consumes them
+func (r *transactionRepo) FetchOpenAccountLots(ctx context.Context, wallet *model.Wallet, accountId string) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	err := r.resources.DB.WithContext(ctx).Where("account_id = ? AND type = ? AND available_amount > 0", accountId, model.TransactionTypeCredit).
+		Order("created_at asc, id asc").Find(&transactions).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching open lots", logger.Field("error", err), logger.Field("accountId", accountId))
+		return nil, err
+	}
+	sortLots(wallet, transactions)
+	return transactions, nil
+}
+
+// FetchTransactionConsumptions retrieves what a debit of an account consumed, or what consumed a credit of it, oldest first
+func (r *transactionRepo) FetchTransactionConsumptions(ctx context.Context, accountId string, transactionId string) ([]model.TransactionConsumption, error) {
+	var consumptions []model.TransactionConsumption
+	err := r.resources.DB.WithContext(ctx).
+		Where("account_id = ? AND (debit_transaction_id = ? OR credit_transaction_id = ?)", accountId, transactionId, transactionId).
+		Order("created_at asc, debit_transaction_id asc").Find(&consumptions).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Error fetching transaction consumptions", logger.Field("error", err), logger.Field("accountId", accountId), logger.Field("transactionId", transactionId))
+		return nil, err
+	}
+	return consumptions, nil
+}
+
 // CreateTransaction creates a new transaction for the specified account
 func (r *transactionRepo) CreateTransaction(ctx context.Context, transaction *model.Transaction, accountVersion uint64) error {
-	return r.resources.DB.Transaction(func(tx *gorm.DB) error {
+	return r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		account, err := r.lockAndFetchAccount(ctx, tx, transaction.AccountID, accountVersion)
 		if err != nil {
 			return err
@@ -164,21 +336,90 @@ func (r *transactionRepo) CreateTransaction(ctx context.Context, transaction *mo
 	})
 }
 
-// PerformExchange performs a transaction exchange between two accounts
-func (r *transactionRepo) PerformExchange(ctx context.Context, from *ExchangeRequest, to *ExchangeRequest) error {
-	return r.resources.DB.Transaction(func(tx *gorm.DB) error {
-		fromAccount, err := r.lockAndFetchAccount(ctx, tx, from.Transaction.AccountID, from.AccountVersion)
+// CreateRewardTransaction credits a program reward and consumes it from the program budget within a single database
+// transaction. The program is locked first, so concurrent rewards of a program consume its budget one at a time.
+func (r *transactionRepo) CreateRewardTransaction(ctx context.Context, transaction *model.Transaction, accountVersion uint64, programId uint64) (*BudgetConsumption, error) {
+	var consumption *BudgetConsumption
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var program model.Program
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", programId).First(&program).Error; err != nil {
+			api.GetLogger(ctx).Error("Error fetching program by ID", logger.Field("error", err), logger.Field("programId", programId))
+			return err
+		}
+		consumption = &BudgetConsumption{Program: &program, Before: program.BudgetConsumed, After: program.BudgetConsumed}
+		if program.Budget != nil {
+			granted := program.Budget.Grant(program.BudgetConsumed, transaction.Amount)
+			if granted == 0 && program.Budget.Remaining(program.BudgetConsumed) == 0 {
+				return errs.NewPaymentRequiredError("Program budget is exhausted", "PROGRAM_BUDGET_EXHAUSTED", nil)
+			}
+			if granted == 0 {
+				return errs.NewPaymentRequiredError("Reward exceeds the remaining program budget", "PROGRAM_BUDGET_INSUFFICIENT", nil)
+			}
+			if granted < transaction.Amount {
+				if transaction.Metadata == nil {
+					transaction.Metadata = types.JSONB{}
+				}
+				transaction.Metadata["partialFrom"] = transaction.Amount
+				transaction.Amount = granted
+			}
+		}
+		account, err := r.lockAndFetchAccount(ctx, tx, transaction.AccountID, accountVersion)
 		if err != nil {
 			return err
 		}
-		if err := r.createTransaction(ctx, tx, from.Transaction, fromAccount); err != nil {
+		if err := r.createTransaction(ctx, tx, transaction, account); err != nil {
 			return err
 		}
-		toAccount, err := r.lockAndFetchAccount(ctx, tx, to.Transaction.AccountID, to.AccountVersion)
-		if err != nil {
+		if program.Budget == nil {
+			return nil
+		}
+		// The consumption is only ever incremented here, so it is never overwritten by a stale program
+		if err := tx.Model(&program).UpdateColumn("budget_consumed", gorm.Expr("budget_consumed + ?", transaction.Amount)).Error; err != nil {
+			api.GetLogger(ctx).Error("Error consuming program budget", logger.Field("error", err), logger.Field("programId", programId))
 			return err
 		}
-		return r.createTransaction(ctx, tx, to.Transaction, toAccount)
+		program.BudgetConsumed += transaction.Amount
+		consumption.After = program.BudgetConsumed
+		if program.IsActive && program.Budget.Remaining(program.BudgetConsumed) == 0 {
+			program.SetOldRecord(program)
+			program.IsActive = false
+			program.SetActor(transaction.GetActor())
+			program.SetRemarks("Program paused as its budget ran out")
+			if err := tx.Save(&program).Error; err != nil {
+				api.GetLogger(ctx).Error("Error pausing program", logger.Field("error", err), logger.Field("programId", programId))
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return consumption, nil
+}
+
+// PerformExchange performs all legs of an exchange within a single database transaction.
+// Accounts visited by more than one leg are locked once, so an intermediate account can be credited and then debited.
+func (r *transactionRepo) PerformExchange(ctx context.Context, legs []ExchangeLeg) error {
+	return r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		accounts := make(map[string]*model.Account)
+		for _, leg := range legs {
+			for _, req := range []*ExchangeRequest{leg.From, leg.To} {
+				account, ok := accounts[req.Transaction.AccountID]
+				if !ok {
+					var err error
+					account, err = r.lockAndFetchAccount(ctx, tx, req.Transaction.AccountID, req.AccountVersion)
+					if err != nil {
+						return err
+					}
+					accounts[req.Transaction.AccountID] = account
+				}
+				if err := r.createTransaction(ctx, tx, req.Transaction, account); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	})
 }
 
@@ -191,10 +432,12 @@ func (r *transactionRepo) createTransaction(ctx context.Context, tx *gorm.DB, tr
 	}
 
 	transaction.PreviousBalance = account.Balance
+	var consumptions []model.TransactionConsumption
 	switch transaction.Type {
 	case model.TransactionTypeDebit:
 		account.Balance -= transaction.Amount
-		if err := r.applyDebitFIFO(ctx, tx, transaction); err != nil {
+		var err error
+		if consumptions, err = r.applyDebit(ctx, tx, transaction); err != nil {
 			return err
 		}
 	case model.TransactionTypeCredit:
@@ -217,6 +460,16 @@ func (r *transactionRepo) createTransaction(ctx context.Context, tx *gorm.DB, tr
 		api.GetLogger(ctx).Error("Error creating transaction", logger.Field("error", err))
 		return err
 	}
+	if len(consumptions) > 0 {
+		for i := range consumptions {
+			consumptions[i].DebitTransactionID = transaction.ID
+			consumptions[i].CreatedAt = transaction.CreatedAt
+		}
+		if err := tx.Create(&consumptions).Error; err != nil {
+			api.GetLogger(ctx).Error("Error recording consumed credits", logger.Field("error", err), logger.Field("transactionId", transaction.ID))
+			return err
+		}
+	}
 	if err := tx.Save(&account).Error; err != nil {
 		api.GetLogger(ctx).Error("Error saving account", logger.Field("error", err))
 		return err
@@ -240,42 +493,57 @@ func (r *transactionRepo) lockAndFetchAccount(ctx context.Context, tx *gorm.DB,
 	return &account, nil
 }
 
-// applyDebitFIFO applies FIFO (first-in, first-out) logic for debiting transactions from available balance
-func (r *transactionRepo) applyDebitFIFO(ctx context.Context, tx *gorm.DB, transaction *model.Transaction) error {
+// applyDebit consumes the available amount of the credits of the account in the order of the consumption strategy of
+// its wallet, returning how much the debit consumed from each credit
+func (r *transactionRepo) applyDebit(ctx context.Context, tx *gorm.DB, transaction *model.Transaction) ([]model.TransactionConsumption, error) {
+	var wallet model.Wallet
 	var transactions []model.Transaction
 	var modifiedTransactions []model.Transaction
+	var consumptions []model.TransactionConsumption
 
+	if err := tx.Where("id = ?", transaction.WalletID).First(&wallet).Error; err != nil {
+		api.GetLogger(ctx).Error("Error fetching wallet by ID", logger.Field("error", err), logger.Field("walletId", transaction.WalletID))
+		return nil, err
+	}
 	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("account_id = ? AND type = 'CREDIT' AND available_amount > 0", transaction.AccountID).
-		Order("created_at asc").Find(&transactions).Error
+		Order("created_at asc, id asc").Find(&transactions).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Error fetching credit transactions", logger.Field("error", err))
-		return err
+		return nil, err
 	}
+	sortLots(&wallet, transactions)
 
 	amount := transaction.Amount
 	for _, t := range transactions {
+		consumed := t.AvailableAmount
+		if consumed > amount {
+			consumed = amount
+		}
+		t.AvailableAmount -= consumed
+		amount -= consumed
 		modifiedTransactions = append(modifiedTransactions, t)
-		if t.AvailableAmount >= amount {
-			t.AvailableAmount -= amount
-			amount = 0
+		consumptions = append(consumptions, model.TransactionConsumption{
+			CreditTransactionID: t.ID,
+			WalletID:            t.WalletID,
+			AccountID:           t.AccountID,
+			Amount:              consumed,
+		})
+		if amount == 0 {
 			break
-		} else {
-			amount -= t.AvailableAmount
-			t.AvailableAmount = 0
 		}
 	}
 
 	if amount > 0 {
 		api.GetLogger(ctx).Error("Insufficient balance for debit", logger.Field("account_id", transaction.AccountID))
-		return errs.NewPaymentRequiredError("insufficient balance", "INSUFFICIENT_BALANCE", nil)
+		return nil, errs.NewPaymentRequiredError("insufficient balance", "INSUFFICIENT_BALANCE", nil)
 	}
 
 	if len(modifiedTransactions) > 0 {
 		if err := tx.Save(&modifiedTransactions).Error; err != nil {
 			api.GetLogger(ctx).Error("Error saving modified transactions", logger.Field("error", err))
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return consumptions, nil
 }
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransactionRepo_FilterByMetadata(t *testing.T) {
+	testcases := []struct {
+		name     string
+		filter   TransactionFilter
+		expected string
+		args     []string
+	}{
+		{
+			name:     "Matches the value of a metadata key",
+			filter:   TransactionFilter{MetadataKey: "orderId", MetadataValue: "123"},
+			expected: "metadata ->> $",
+			args:     []string{"orderId", "123"},
+		},
+		{
+			name:     "Matches the transactions holding a metadata key",
+			filter:   TransactionFilter{MetadataKey: "orderId"},
+			expected: "jsonb_exists(metadata, $",
+			args:     []string{"orderId"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, repos := setupRecorder(t)
+			if _, err := repos.Transaction.FetchWalletTransactions(createTenantContext(test_tenantA), "wallet", tc.filter, 1, 10); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			statements := rec.reset()
+			if len(statements) != 1 || !strings.Contains(statements[0].query, tc.expected) {
+				t.Fatalf("expected the query to filter with %q, got %+v", tc.expected, statements)
+			}
+			for _, arg := range tc.args {
+				if !hasArg(statements[0].args, arg) {
+					t.Errorf("expected the query to be given %q, got %v", arg, statements[0].args)
+				}
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
 type TriggerRepo interface {
@@ -25,6 +27,13 @@ type TriggerRepo interface {
 	FetchTriggers(ctx context.Context, page int, limit int) ([]model.Trigger, error)
 	// CountTriggers retrieves the total number of triggers
 	CountTriggers(ctx context.Context) (int64, error)
+	// CreateTriggerEvent records a trigger fired for a user
+	CreateTriggerEvent(ctx context.Context, event *model.TriggerEvent) error
+	// CountTriggerEvents retrieves the number of times a trigger fired for a user since a time
+	CountTriggerEvents(ctx context.Context, userId string, triggerSlug string, since time.Time) (int64, error)
+	// SumTriggerEvents retrieves the sum of a numeric field of the data of a trigger fired for a user since a time.
+	// The field uses dot notation, and events where it is missing or not a number are ignored
+	SumTriggerEvents(ctx context.Context, userId string, triggerSlug string, field string, since time.Time) (float64, error)
 }
 
 type triggerRepo struct {
@@ -38,7 +47,7 @@ func NewTriggerRepo(resources *resource.Resources) TriggerRepo {
 
 // CreateTrigger creates a new trigger in the database
 func (r *triggerRepo) CreateTrigger(ctx context.Context, trigger *model.Trigger) error {
-	if err := r.resources.DB.Transaction(func(tx *gorm.DB) error {
+	if err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(trigger).Error; err != nil {
 			return err
 		}
@@ -46,7 +55,7 @@ func (r *triggerRepo) CreateTrigger(ctx context.Context, trigger *model.Trigger)
 			NumPartitions:     1,
 			ReplicationFactor: 1,
 		}
-		if err := r.resources.Broker.CreateTopic(ctx, trigger.Slug, topicDetail); err != nil {
+		if err := r.resources.Broker.CreateTopic(ctx, triggerTopic(trigger), topicDetail); err != nil {
 			return err
 		}
 		return nil
@@ -61,7 +70,7 @@ func (r *triggerRepo) CreateTrigger(ctx context.Context, trigger *model.Trigger)
 // UpdateTrigger updates an existing trigger in the database
 func (r *triggerRepo) UpdateTrigger(ctx context.Context, trigger *model.Trigger) error {
 	// TODO: if slug is changed then update the topic name in broker
-	if err := r.resources.DB.Save(trigger).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Save(trigger).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to update trigger", logger.Field("error", err), logger.Field("trigger", trigger))
 		return err
 	}
@@ -70,11 +79,11 @@ func (r *triggerRepo) UpdateTrigger(ctx context.Context, trigger *model.Trigger)
 
 // DeleteTrigger deletes a trigger from the database
 func (r *triggerRepo) DeleteTrigger(ctx context.Context, trigger *model.Trigger) error {
-	if err := r.resources.DB.Transaction(func(tx *gorm.DB) error {
+	if err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Delete(trigger).Error; err != nil {
 			return err
 		}
-		if err := r.resources.Broker.DeleteTopic(ctx, trigger.Slug); err != nil {
+		if err := r.resources.Broker.DeleteTopic(ctx, triggerTopic(trigger)); err != nil {
 			return err
 		}
 		return nil
@@ -88,7 +97,7 @@ func (r *triggerRepo) DeleteTrigger(ctx context.Context, trigger *model.Trigger)
 // FetchTriggerByID retrieves a trigger by its ID
 func (r *triggerRepo) FetchTriggerByID(ctx context.Context, id uint64) (*model.Trigger, error) {
 	var trigger model.Trigger
-	err := r.resources.DB.Where("id = ?", id).First(&trigger).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", id).First(&trigger).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve trigger by ID", logger.Field("error", err), logger.Field("triggerId", id))
 		return nil, err
@@ -99,7 +108,7 @@ func (r *triggerRepo) FetchTriggerByID(ctx context.Context, id uint64) (*model.T
 // FetchTriggerBySlug retrieves a trigger by its slug
 func (r *triggerRepo) FetchTriggerBySlug(ctx context.Context, slug string) (*model.Trigger, error) {
 	var trigger model.Trigger
-	err := r.resources.DB.Where("slug = ?", slug).First(&trigger).Error
+	err := r.resources.DB.WithContext(ctx).Where("slug = ?", slug).First(&trigger).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve trigger by slug", logger.Field("error", err), logger.Field("triggerSlug", slug))
 		return nil, err
@@ -110,7 +119,7 @@ func (r *triggerRepo) FetchTriggerBySlug(ctx context.Context, slug string) (*mod
 // FetchTriggers retrieves a paginated list of triggers
 func (r *triggerRepo) FetchTriggers(ctx context.Context, page int, limit int) ([]model.Trigger, error) {
 	var triggers []model.Trigger
-	err := r.resources.DB.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&triggers).Error
+	err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&triggers).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve triggers", logger.Field("error", err))
 		return nil, err
@@ -121,10 +130,52 @@ func (r *triggerRepo) FetchTriggers(ctx context.Context, page int, limit int) ([
 // CountTriggers retrieves the total number of triggers
 func (r *triggerRepo) CountTriggers(ctx context.Context) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Trigger{}).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Trigger{}).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve total triggers count", logger.Field("error", err))
 		return 0, err
 	}
 	return total, nil
 }
+
+// CreateTriggerEvent records a trigger fired for a user
+func (r *triggerRepo) CreateTriggerEvent(ctx context.Context, event *model.TriggerEvent) error {
+	if err := r.resources.DB.WithContext(ctx).Create(event).Error; err != nil {
+		api.GetLogger(ctx).Error("Failed to create trigger event", logger.Field("error", err), logger.Field("triggerSlug", event.TriggerSlug), logger.Field("userId", event.UserID))
+		return err
+	}
+	return nil
+}
+
+// CountTriggerEvents retrieves the number of times a trigger fired for a user since a time
+func (r *triggerRepo) CountTriggerEvents(ctx context.Context, userId string, triggerSlug string, since time.Time) (int64, error) {
+	var total int64
+	err := r.resources.DB.WithContext(ctx).Model(&model.TriggerEvent{}).
+		Where("user_id = ? AND trigger_slug = ? AND created_at >= ?", userId, triggerSlug, since).
+		Count(&total).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to count trigger events", logger.Field("error", err), logger.Field("userId", userId), logger.Field("triggerSlug", triggerSlug), logger.Field("since", since))
+		return 0, err
+	}
+	return total, nil
+}
+
+// SumTriggerEvents retrieves the sum of a numeric field of the data of a trigger fired for a user since a time
+func (r *triggerRepo) SumTriggerEvents(ctx context.Context, userId string, triggerSlug string, field string, since time.Time) (float64, error) {
+	var total float64
+	path := "{" + strings.Join(strings.Split(field, "."), ",") + "}"
+	err := r.resources.DB.WithContext(ctx).Model(&model.TriggerEvent{}).
+		Select("COALESCE(SUM(CASE WHEN jsonb_typeof(data #> ?::text[]) = 'number' THEN (data #>> ?::text[])::numeric END), 0)", path, path).
+		Where("user_id = ? AND trigger_slug = ? AND created_at >= ?", userId, triggerSlug, since).
+		Scan(&total).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to sum trigger events", logger.Field("error", err), logger.Field("userId", userId), logger.Field("triggerSlug", triggerSlug), logger.Field("field", field), logger.Field("since", since))
+		return 0, err
+	}
+	return total, nil
+}
+
+// triggerTopic returns the broker topic of a trigger, which is namespaced by its tenant as slugs are only unique within a tenant
+func triggerTopic(trigger *model.Trigger) string {
+	return trigger.TenantID + "." + trigger.Slug
+}
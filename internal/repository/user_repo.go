@@ -6,13 +6,20 @@ import (
 	"github.com/abdelrahman146/digital-wallet/internal/resource"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepo interface {
 	// CreateUser Creates a new user
 	CreateUser(ctx context.Context, user *model.User) error
-	// UpdateUserTier Sets the user's tier by user ID and tier ID
-	UpdateUserTier(ctx context.Context, userId string, tierId string) error
+	// UpdateUserTier Saves the user's tier and its qualification dates, recording the change in the user's tier history
+	UpdateUserTier(ctx context.Context, user *model.User) error
+	// FetchUserTierHistory Retrieves the tier timeline of a user with pagination, latest first
+	FetchUserTierHistory(ctx context.Context, userId string, page int, limit int) ([]model.UserTierHistory, error)
+	// CountUserTierHistory Retrieves the total number of tier changes of a user
+	CountUserTierHistory(ctx context.Context, userId string) (int64, error)
 	// DeleteUser Deletes a user by user ID
 	DeleteUser(ctx context.Context, user *model.User) error
 	// FetchUserByID Retrieves a user by user ID
@@ -23,6 +30,8 @@ type UserRepo interface {
 	CountUsersByTierID(ctx context.Context, tierId string) (int64, error)
 	// FetchUsers Retrieves all users with pagination
 	FetchUsers(ctx context.Context, page int, limit int) ([]model.User, error)
+	// FetchUsersAfter Retrieves the users following a user ID, in ID order, to go through all users in batches
+	FetchUsersAfter(ctx context.Context, afterId string, limit int) ([]model.User, error)
 	// CountTotalUsers Retrieves the total number of users
 	CountTotalUsers(ctx context.Context) (int64, error)
 }
@@ -38,7 +47,7 @@ func NewUserRepo(resources *resource.Resources) UserRepo {
 
 // CreateUser creates a new user in the database
 func (r *userRepo) CreateUser(ctx context.Context, user *model.User) error {
-	if err := r.resources.DB.Create(user).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Create(user).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to create user", logger.Field("error", err), logger.Field("user", user))
 		return err
 	}
@@ -48,7 +57,7 @@ func (r *userRepo) CreateUser(ctx context.Context, user *model.User) error {
 // FetchUserByID retrieves a user by user ID and preloads related accounts
 func (r *userRepo) FetchUserByID(ctx context.Context, userId string) (*model.User, error) {
 	var user model.User
-	err := r.resources.DB.Where("id = ?", userId).Preload("Accounts").First(&user).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", userId).Preload("Accounts").First(&user).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve user by ID", logger.Field("error", err), logger.Field("userId", userId))
 		return nil, err
@@ -56,19 +65,65 @@ func (r *userRepo) FetchUserByID(ctx context.Context, userId string) (*model.Use
 	return &user, nil
 }
 
-// UpdateUserTier sets the user's tier by user ID and tier ID
-func (r *userRepo) UpdateUserTier(ctx context.Context, userId string, tierId string) error {
-	if err := r.resources.DB.Model(&model.User{}).Where("id = ?", userId).Update("tier_id", tierId).Error; err != nil {
-		api.GetLogger(ctx).Error("Failed to update user tier", logger.Field("error", err), logger.Field("userId", userId), logger.Field("tierId", tierId))
+// UpdateUserTier saves the user's tier and its qualification dates.
+// A tier history entry is recorded within the same transaction whenever the tier changes.
+func (r *userRepo) UpdateUserTier(ctx context.Context, user *model.User) error {
+	err := r.resources.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", user.ID).First(&current).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Select("tier_id", "tier_achieved_at", "tier_grace_until", "updated_at").Updates(user).Error; err != nil {
+			return err
+		}
+		if utils.EqualPtr(current.TierID, user.TierID) {
+			return nil
+		}
+		actor, actorId := user.GetActor()
+		history := &model.UserTierHistory{
+			UserID:     user.ID,
+			FromTierID: current.TierID,
+			ToTierID:   user.TierID,
+			Actor:      actor,
+			ActorID:    actorId,
+			Remarks:    user.GetRemarks(),
+		}
+		return tx.Create(history).Error
+	})
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to update user tier", logger.Field("error", err), logger.Field("userId", user.ID), logger.Field("tierId", user.TierID))
 		return err
 	}
 	return nil
 }
 
+// FetchUserTierHistory retrieves the tier timeline of a user with pagination, latest first
+func (r *userRepo) FetchUserTierHistory(ctx context.Context, userId string, page int, limit int) ([]model.UserTierHistory, error) {
+	var history []model.UserTierHistory
+	err := r.resources.DB.WithContext(ctx).Where("user_id = ?", userId).Order("created_at desc, id desc").
+		Offset((page - 1) * limit).Limit(limit).Find(&history).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve user tier history", logger.Field("error", err), logger.Field("userId", userId))
+		return nil, err
+	}
+	return history, nil
+}
+
+// CountUserTierHistory retrieves the total number of tier changes of a user
+func (r *userRepo) CountUserTierHistory(ctx context.Context, userId string) (int64, error) {
+	var count int64
+	err := r.resources.DB.WithContext(ctx).Model(&model.UserTierHistory{}).Where("user_id = ?", userId).Count(&count).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to count user tier history", logger.Field("error", err), logger.Field("userId", userId))
+		return 0, err
+	}
+	return count, nil
+}
+
 // FetchUsersByTierID retrieves users by their tier ID with pagination and preloads related accounts
 func (r *userRepo) FetchUsersByTierID(ctx context.Context, tierId string, page int, limit int) ([]model.User, error) {
 	var users []model.User
-	err := r.resources.DB.Where("tier_id = ?", tierId).Offset((page - 1) * limit).Limit(limit).Preload("Accounts").Find(&users).Error
+	err := r.resources.DB.WithContext(ctx).Where("tier_id = ?", tierId).Order("id").Offset((page - 1) * limit).Limit(limit).Preload("Accounts").Find(&users).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve users by tier ID", logger.Field("error", err), logger.Field("tierId", tierId))
 		return nil, err
@@ -79,7 +134,7 @@ func (r *userRepo) FetchUsersByTierID(ctx context.Context, tierId string, page i
 // CountUsersByTierID retrieves the total number of users in a given tier
 func (r *userRepo) CountUsersByTierID(ctx context.Context, tierId string) (int64, error) {
 	var count int64
-	err := r.resources.DB.Model(&model.User{}).Where("tier_id = ?", tierId).Count(&count).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.User{}).Where("tier_id = ?", tierId).Count(&count).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to count users by tier ID", logger.Field("error", err), logger.Field("tierId", tierId))
 		return 0, err
@@ -90,7 +145,7 @@ func (r *userRepo) CountUsersByTierID(ctx context.Context, tierId string) (int64
 // FetchUsers retrieves all users with pagination and preloads related accounts
 func (r *userRepo) FetchUsers(ctx context.Context, page int, limit int) ([]model.User, error) {
 	var users []model.User
-	err := r.resources.DB.Offset((page - 1) * limit).Limit(limit).Preload("Accounts").Find(&users).Error
+	err := r.resources.DB.WithContext(ctx).Order("id").Offset((page - 1) * limit).Limit(limit).Preload("Accounts").Find(&users).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to retrieve users", logger.Field("error", err))
 		return nil, err
@@ -98,10 +153,22 @@ func (r *userRepo) FetchUsers(ctx context.Context, page int, limit int) ([]model
 	return users, nil
 }
 
+// FetchUsersAfter retrieves the users following a user ID and preloads related accounts. Unlike pages, batches
+// stay stable while users are created or deleted between them
+func (r *userRepo) FetchUsersAfter(ctx context.Context, afterId string, limit int) ([]model.User, error) {
+	var users []model.User
+	err := r.resources.DB.WithContext(ctx).Where("id > ?", afterId).Order("id").Limit(limit).Preload("Accounts").Find(&users).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to retrieve users", logger.Field("error", err), logger.Field("afterId", afterId))
+		return nil, err
+	}
+	return users, nil
+}
+
 // CountTotalUsers retrieves the total number of users
 func (r *userRepo) CountTotalUsers(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.resources.DB.Model(&model.User{}).Count(&count).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.User{}).Count(&count).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to count total users", logger.Field("error", err))
 		return 0, err
@@ -111,7 +178,7 @@ func (r *userRepo) CountTotalUsers(ctx context.Context) (int64, error) {
 
 // DeleteUser deletes a user by user ID
 func (r *userRepo) DeleteUser(ctx context.Context, user *model.User) error {
-	if err := r.resources.DB.Delete(user).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Delete(user).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to delete user", logger.Field("error", err), logger.Field("user", user))
 		return err
 	}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserRepo_PagesInIDOrder(t *testing.T) {
+	rec, repos := setupRecorder(t)
+	ctx := createTenantContext(test_tenantA)
+	if _, err := repos.User.FetchUsers(ctx, 2, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repos.User.FetchUsersByTierID(ctx, "gold", 2, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range rec.reset() {
+		if !strings.Contains(stmt.query, "ORDER BY id") {
+			t.Errorf("expected the users to be paged in id order: %s", stmt.query)
+		}
+	}
+
+	if _, err := repos.User.FetchUsersAfter(ctx, "USER-099", 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statements := rec.reset()
+	if len(statements) != 1 || !strings.Contains(statements[0].query, "id > $") || strings.Contains(statements[0].query, "OFFSET") || !hasArg(statements[0].args, "USER-099") {
+		t.Errorf("expected the users to follow the last user ID, got %+v", statements)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/abdelrahman146/digital-wallet/internal/resource"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type WalletRepo interface {
@@ -21,6 +23,10 @@ type WalletRepo interface {
 	FetchWallets(ctx context.Context, page int, limit int) ([]model.Wallet, error)
 	// CountTotalWallets Retrieves the total number of wallets
 	CountTotalWallets(ctx context.Context) (int64, error)
+	// CreateBalanceSnapshot Stores the liability of a wallet at a point in time, keeping the existing snapshot if there is one
+	CreateBalanceSnapshot(ctx context.Context, snapshot *model.WalletBalanceSnapshot) error
+	// FetchLatestBalanceSnapshot Retrieves the latest snapshot of a wallet taken at or before a given time, or nil if there is none
+	FetchLatestBalanceSnapshot(ctx context.Context, walletId string, at time.Time) (*model.WalletBalanceSnapshot, error)
 }
 
 type walletRepo struct {
@@ -35,7 +41,7 @@ func NewWalletRepo(resources *resource.Resources) WalletRepo {
 // CreateWallet creates a new wallet and its associated schema
 func (r *walletRepo) CreateWallet(ctx context.Context, wallet *model.Wallet) error {
 	// Create the wallet in the database
-	if err := r.resources.DB.Create(wallet).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Create(wallet).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to create wallet", logger.Field("error", err), logger.Field("wallet", wallet))
 		return err
 	}
@@ -45,7 +51,7 @@ func (r *walletRepo) CreateWallet(ctx context.Context, wallet *model.Wallet) err
 // FetchWalletByID retrieves a wallet by its wallet ID
 func (r *walletRepo) FetchWalletByID(ctx context.Context, walletId string) (*model.Wallet, error) {
 	var wallet model.Wallet
-	err := r.resources.DB.Where("id = ?", walletId).First(&wallet).Error
+	err := r.resources.DB.WithContext(ctx).Where("id = ?", walletId).First(&wallet).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to fetch wallet by ID", logger.Field("error", err), logger.Field("walletId", walletId))
 		return nil, err
@@ -55,7 +61,7 @@ func (r *walletRepo) FetchWalletByID(ctx context.Context, walletId string) (*mod
 
 // UpdateWallet updates an existing wallet in the database
 func (r *walletRepo) UpdateWallet(ctx context.Context, wallet *model.Wallet) error {
-	if err := r.resources.DB.Save(wallet).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Save(wallet).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to update wallet", logger.Field("error", err), logger.Field("wallet", wallet))
 		return err
 	}
@@ -65,7 +71,7 @@ func (r *walletRepo) UpdateWallet(ctx context.Context, wallet *model.Wallet) err
 // FetchWallets retrieves a paginated list of wallets from the database
 func (r *walletRepo) FetchWallets(ctx context.Context, page int, limit int) ([]model.Wallet, error) {
 	var wallets []model.Wallet
-	err := r.resources.DB.Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&wallets).Error
+	err := r.resources.DB.WithContext(ctx).Order("created_at desc").Offset((page - 1) * limit).Limit(limit).Find(&wallets).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to fetch wallets", logger.Field("error", err))
 		return nil, err
@@ -76,7 +82,7 @@ func (r *walletRepo) FetchWallets(ctx context.Context, page int, limit int) ([]m
 // CountTotalWallets retrieves the total number of wallets in the database
 func (r *walletRepo) CountTotalWallets(ctx context.Context) (int64, error) {
 	var total int64
-	err := r.resources.DB.Model(&model.Wallet{}).Count(&total).Error
+	err := r.resources.DB.WithContext(ctx).Model(&model.Wallet{}).Count(&total).Error
 	if err != nil {
 		api.GetLogger(ctx).Error("Failed to count total wallets", logger.Field("error", err))
 		return 0, err
@@ -86,9 +92,32 @@ func (r *walletRepo) CountTotalWallets(ctx context.Context) (int64, error) {
 
 // DeleteWallet deletes a wallet from the database by its wallet ID
 func (r *walletRepo) DeleteWallet(ctx context.Context, wallet *model.Wallet) error {
-	if err := r.resources.DB.Delete(wallet).Error; err != nil {
+	if err := r.resources.DB.WithContext(ctx).Delete(wallet).Error; err != nil {
 		api.GetLogger(ctx).Error("Failed to delete wallet", logger.Field("error", err), logger.Field("wallet", wallet))
 		return err
 	}
 	return nil
 }
+
+// CreateBalanceSnapshot stores the liability of a wallet at a point in time, keeping the existing snapshot if there is one
+func (r *walletRepo) CreateBalanceSnapshot(ctx context.Context, snapshot *model.WalletBalanceSnapshot) error {
+	if err := r.resources.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(snapshot).Error; err != nil {
+		api.GetLogger(ctx).Error("Failed to create wallet balance snapshot", logger.Field("error", err), logger.Field("walletId", snapshot.WalletID), logger.Field("balanceAt", snapshot.BalanceAt))
+		return err
+	}
+	return nil
+}
+
+// FetchLatestBalanceSnapshot retrieves the latest snapshot of a wallet taken at or before a given time, or nil if there is none
+func (r *walletRepo) FetchLatestBalanceSnapshot(ctx context.Context, walletId string, at time.Time) (*model.WalletBalanceSnapshot, error) {
+	var snapshots []model.WalletBalanceSnapshot
+	err := r.resources.DB.WithContext(ctx).Where("wallet_id = ? AND balance_at <= ?", walletId, at).Order("balance_at desc").Limit(1).Find(&snapshots).Error
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to fetch wallet balance snapshot", logger.Field("error", err), logger.Field("walletId", walletId), logger.Field("at", at))
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
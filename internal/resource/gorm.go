@@ -17,6 +17,9 @@ func InitDB() *gorm.DB {
 	if err != nil {
 		logger.GetLogger().Panic("failed to connect to database", logger.Field("error", err))
 	}
+	if err := RegisterTenantScope(gormDB); err != nil {
+		logger.GetLogger().Panic("failed to register tenant scope", logger.Field("error", err))
+	}
 	db, err := gormDB.DB()
 	if err != nil {
 		logger.GetLogger().Panic("failed to get database connection", logger.Field("error", err))
@@ -0,0 +1,88 @@
+package resource
+
+import (
+	"errors"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+// tenantColumn is the column holding the tenant of the models owned by a tenant
+const tenantColumn = "tenant_id"
+
+// ErrTenantMissing is returned when a model owned by a tenant is used without a tenant in the context
+var ErrTenantMissing = errors.New("tenant missing from context")
+
+// RegisterTenantScope confines every query, update and delete on a model with a tenant column to the tenant of the context,
+// and stamps created models with it, so no query can read or move value across tenants.
+// Queries made without a tenant fail, unless the context is explicitly allowed to read across tenants.
+func RegisterTenantScope(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:stamp", stampTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", scopeTenantUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenant:scope", scopeTenant)
+}
+
+func scopeTenant(db *gorm.DB) {
+	if db.Statement.Schema == nil || db.Statement.Schema.LookUpField(tenantColumn) == nil {
+		return
+	}
+	tenantId, ok := api.GetTenantID(db.Statement.Context)
+	if !ok {
+		if !api.IsAllTenants(db.Statement.Context) {
+			_ = db.AddError(ErrTenantMissing)
+		}
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: tenantColumn}, Value: tenantId},
+	}})
+}
+
+// scopeTenantUpdate scopes an update to the tenant and keeps the tenant on saved models,
+// so a model built without its tenant is never moved out of it
+func scopeTenantUpdate(db *gorm.DB) {
+	scopeTenant(db)
+	if _, ok := api.GetTenantID(db.Statement.Context); ok && db.Error == nil {
+		stampTenant(db)
+	}
+}
+
+func stampTenant(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantColumn)
+	if field == nil {
+		return
+	}
+	tenantId, ok := api.GetTenantID(db.Statement.Context)
+	if !ok {
+		_ = db.AddError(ErrTenantMissing)
+		return
+	}
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := field.Set(db.Statement.Context, reflect.Indirect(value.Index(i)), tenantId); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(db.Statement.Context, value, tenantId); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
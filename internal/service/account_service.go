@@ -8,6 +8,9 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"io"
+	"time"
 )
 
 type AccountService interface {
@@ -21,14 +24,28 @@ type AccountService interface {
 	GetWalletAccountsSum(ctx context.Context, walletId string) (uint64, error)
 	// DeleteAccount deletes an account by ID
 	DeleteAccount(ctx context.Context, accountId string) error
+	// FreezeAccount deactivates an account so it can no longer be debited
+	FreezeAccount(ctx context.Context, accountId string, req *AccountStatusRequest) (*model.Account, error)
+	// UnfreezeAccount reactivates a frozen account
+	UnfreezeAccount(ctx context.Context, accountId string, req *AccountStatusRequest) (*model.Account, error)
+	// GetAccountStatement summarizes the transactions of an account over a period, its transactions are then
+	// streamed with WriteAccountStatement
+	GetAccountStatement(ctx context.Context, walletId, accountId string, req *StatementRequest) (*AccountStatement, error)
+	// WriteAccountStatement writes a statement in its json, csv or pdf format, streaming its transactions.
+	// When reading them fails part way, the statement is ended with an error marker instead of its closing balance
+	WriteAccountStatement(ctx context.Context, statement *AccountStatement, w io.Writer) error
+	// GetBalanceAt fetches the balance an account had at a point in time, including the transactions made at that time
+	GetBalanceAt(ctx context.Context, walletId, accountId string, at time.Time) (*AccountBalance, error)
 }
 
 type accountService struct {
 	repos *repository.Repos
+	// pdfDocumentSize is the number of transactions of a pdf statement document, longer statements are split
+	pdfDocumentSize int
 }
 
 func NewAccountService(repos *repository.Repos) AccountService {
-	return &accountService{repos: repos}
+	return &accountService{repos: repos, pdfDocumentSize: statementPDFDocumentSize}
 }
 
 func (s *accountService) CreateAccount(ctx context.Context, walletId, userId string) (*model.Account, error) {
@@ -36,8 +53,7 @@ func (s *accountService) CreateAccount(ctx context.Context, walletId, userId str
 	if user == nil {
 		return nil, errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
 	}
-	if err := api.IsAuthorizedUser(ctx, user.ID); err != nil {
-		api.GetLogger(ctx).Error("User not authorized", logger.Field("userId", user.ID))
+	if err := authorizeOwner(ctx, s.repos, user.ID, api.PermissionAccountWrite); err != nil {
 		return nil, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
@@ -61,16 +77,14 @@ func (s *accountService) GetAccount(ctx context.Context, accountId string) (*mod
 	if account == nil {
 		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
 	}
-	if err := api.IsAuthorizedUser(ctx, account.UserID); err != nil {
-		api.GetLogger(ctx).Error("User not authorized", logger.Field("userId", account.UserID))
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionAccountRead); err != nil {
 		return nil, err
 	}
 	return account, nil
 }
 
 func (s *accountService) GetWalletAccountsSum(ctx context.Context, walletId string) (uint64, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionAccountRead); err != nil {
 		return 0, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
@@ -85,8 +99,7 @@ func (s *accountService) GetWalletAccountsSum(ctx context.Context, walletId stri
 }
 
 func (s *accountService) GetWalletAccounts(ctx context.Context, walletId string, page int, limit int) (*api.List[model.Account], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionAccountRead); err != nil {
 		return nil, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
@@ -105,8 +118,7 @@ func (s *accountService) GetWalletAccounts(ctx context.Context, walletId string,
 }
 
 func (s *accountService) DeleteAccount(ctx context.Context, accountId string) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionAccountWrite); err != nil {
 		return err
 	}
 	account, err := s.repos.Account.FetchAccountByID(ctx, accountId)
@@ -118,3 +130,182 @@ func (s *accountService) DeleteAccount(ctx context.Context, accountId string) er
 	account.SetOldRecord(account)
 	return s.repos.Account.DeleteAccount(ctx, account)
 }
+
+func (s *accountService) FreezeAccount(ctx context.Context, accountId string, req *AccountStatusRequest) (*model.Account, error) {
+	return s.setAccountStatus(ctx, accountId, false, req)
+}
+
+func (s *accountService) UnfreezeAccount(ctx context.Context, accountId string, req *AccountStatusRequest) (*model.Account, error) {
+	return s.setAccountStatus(ctx, accountId, true, req)
+}
+
+// setAccountStatus activates or deactivates an account, recording the reason in the audit remarks
+func (s *accountService) setAccountStatus(ctx context.Context, accountId string, isActive bool, req *AccountStatusRequest) (*model.Account, error) {
+	if err := authorize(ctx, s.repos, api.PermissionAccountFreeze); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid account status request", logger.Field("fields", fields))
+		return nil, errs.NewValidationError("Invalid account status request", "", fields)
+	}
+	account, err := s.repos.Account.FetchAccountByID(ctx, accountId)
+	if account == nil {
+		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
+	}
+	if account.IsActive == isActive {
+		if isActive {
+			return nil, errs.NewConflictError("Account is not frozen", "ACCOUNT_NOT_FROZEN", nil)
+		}
+		return nil, errs.NewConflictError("Account is already frozen", "ACCOUNT_ALREADY_FROZEN", nil)
+	}
+	account.SetOldRecord(*account)
+	account.IsActive = isActive
+	account.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	if isActive {
+		account.SetRemarks(fmt.Sprintf("Account unfrozen: %s", req.Reason))
+	} else {
+		account.SetRemarks(fmt.Sprintf("Account frozen: %s", req.Reason))
+	}
+	if err := s.repos.Account.UpdateAccountStatus(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *accountService) GetAccountStatement(ctx context.Context, walletId, accountId string, req *StatementRequest) (*AccountStatement, error) {
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid statement request", logger.Field("fields", fields))
+		return nil, errs.NewValidationError("Invalid statement request", "", fields)
+	}
+	from, _ := time.Parse(time.RFC3339, req.From)
+	to, _ := time.Parse(time.RFC3339, req.To)
+	if !to.After(from) {
+		api.GetLogger(ctx).Error("Invalid statement period", logger.Field("from", from), logger.Field("to", to))
+		return nil, errs.NewValidationError("Invalid statement request", "", map[string]string{"to": "must be after from"})
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
+	if wallet == nil {
+		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId))
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	account, err := s.repos.Account.FetchAccountByID(ctx, accountId)
+	if account == nil || account.WalletID != walletId {
+		api.GetLogger(ctx).Error("Account not found", logger.Field("walletId", walletId), logger.Field("accountId", accountId))
+		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
+	}
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionTransactionRead); err != nil {
+		return nil, err
+	}
+	statement := &AccountStatement{
+		AccountID: account.ID,
+		WalletID:  wallet.ID,
+		UserID:    account.UserID,
+		Currency:  wallet.Currency,
+		From:      from,
+		To:        to,
+		Format:    req.Format,
+	}
+	if statement.Format == "" {
+		statement.Format = statementFormatJSON
+	}
+	if statement.OpeningBalance, err = s.balanceBefore(ctx, accountId, from, 0); err != nil {
+		return nil, err
+	}
+	if statement.ClosingBalance, err = s.balanceBefore(ctx, accountId, to, statement.OpeningBalance); err != nil {
+		return nil, err
+	}
+	if statement.Totals, err = s.repos.Transaction.SumAccountTransactionsByReason(ctx, accountId, from, to); err != nil {
+		return nil, err
+	}
+	for _, total := range statement.Totals {
+		switch {
+		case total.Type == model.TransactionTypeCredit:
+			statement.TotalCredited += total.Amount
+		case total.Reason == model.TransactionReasonExpired:
+			statement.Expired += total.Amount
+			statement.TotalDebited += total.Amount
+		default:
+			statement.TotalDebited += total.Amount
+		}
+	}
+	if statement.Format == statementFormatPDF {
+		var count int64
+		for _, total := range statement.Totals {
+			count += total.Count
+		}
+		statement.Archived = count > int64(s.pdfDocumentSize)
+	}
+	return statement, nil
+}
+
+func (s *accountService) WriteAccountStatement(ctx context.Context, statement *AccountStatement, w io.Writer) error {
+	var writer statementWriter
+	switch statement.Format {
+	case statementFormatJSON:
+		writer = newJSONStatementWriter(w)
+	case statementFormatCSV:
+		writer = newCSVStatementWriter(w)
+	case statementFormatPDF:
+		if statement.Archived {
+			writer = newPDFArchiveStatementWriter(w, s.pdfDocumentSize)
+		} else {
+			writer = newPDFStatementWriter(w)
+		}
+	default:
+		return errs.NewBadRequestError("Unsupported statement format", "UNSUPPORTED_STATEMENT_FORMAT", nil)
+	}
+	if err := writer.WriteHeader(statement); err != nil {
+		return err
+	}
+	err := s.repos.Transaction.FetchAccountTransactionsInBatches(ctx, statement.AccountID, statement.From, statement.To, statementBatchSize, func(transactions []model.Transaction) error {
+		lines := make([]StatementLine, len(transactions))
+		for i, transaction := range transactions {
+			lines[i] = newStatementLine(transaction)
+		}
+		return writer.WriteLines(lines)
+	})
+	if err != nil {
+		api.GetLogger(ctx).Error("Failed to write statement", logger.Field("error", err), logger.Field("accountId", statement.AccountID))
+		if markErr := writer.WriteError(err); markErr != nil {
+			api.GetLogger(ctx).Error("Failed to mark statement incomplete", logger.Field("error", markErr), logger.Field("accountId", statement.AccountID))
+		}
+		return err
+	}
+	return writer.WriteFooter(statement)
+}
+
+func (s *accountService) GetBalanceAt(ctx context.Context, walletId, accountId string, at time.Time) (*AccountBalance, error) {
+	account, err := s.repos.Account.FetchAccountByID(ctx, accountId)
+	if account == nil || account.WalletID != walletId {
+		api.GetLogger(ctx).Error("Account not found", logger.Field("walletId", walletId), logger.Field("accountId", accountId))
+		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
+	}
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionAccountRead); err != nil {
+		return nil, err
+	}
+	// timestamps are stored to the microsecond, so this includes the transactions made at that exact time
+	transaction, err := s.repos.Transaction.FetchLastAccountTransaction(ctx, accountId, at.Add(time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+	balance := &AccountBalance{AccountID: account.ID, WalletID: account.WalletID, At: at}
+	if transaction != nil {
+		balance.Balance = transaction.NewBalance
+		balance.TransactionID = &transaction.ID
+	}
+	return balance, nil
+}
+
+// balanceBefore returns the balance of an account right before a given time, from the last transaction made before it
+func (s *accountService) balanceBefore(ctx context.Context, accountId string, before time.Time, fallback uint64) (uint64, error) {
+	transaction, err := s.repos.Transaction.FetchLastAccountTransaction(ctx, accountId, before)
+	if err != nil {
+		return 0, err
+	}
+	if transaction == nil {
+		return fallback, nil
+	}
+	return transaction.NewBalance, nil
+}
@@ -1,12 +1,20 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"go.uber.org/mock/gomock"
+	"io"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAccountService_CreateAccount(t *testing.T) {
@@ -39,8 +47,9 @@ func TestAccountService_CreateAccount(t *testing.T) {
 			ctx:  api.CreateAppContext(context.Background(), api.AppActorUser, "unauthorized-user", test_requestId),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId}, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
 			},
-			expectedError: "UNAUTHORIZED",
+			expectedError: "PERMISSION_DENIED",
 			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
 				return service.CreateAccount(ctx, test_walletId, test_userId)
 			},
@@ -106,8 +115,9 @@ func TestAccountService_GetAccount(t *testing.T) {
 			ctx:  api.CreateAppContext(context.Background(), api.AppActorUser, "unauthorized-user", test_requestId),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId}, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
 			},
-			expectedError: "UNAUTHORIZED",
+			expectedError: "PERMISSION_DENIED",
 			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
 				return service.GetAccount(ctx, test_accountId)
 			},
@@ -124,7 +134,7 @@ func TestAccountService_DeleteAccount(t *testing.T) {
 	testcases := []TestCase[AccountService]{
 		{
 			name: "Success case",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAccountWrite),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId}, nil)
 				mocks.accountRepo.EXPECT().DeleteAccount(ctx, gomock.Any()).Return(nil)
@@ -136,7 +146,7 @@ func TestAccountService_DeleteAccount(t *testing.T) {
 		},
 		{
 			name: "Account not found",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAccountWrite),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(nil, nil)
 			},
@@ -147,9 +157,11 @@ func TestAccountService_DeleteAccount(t *testing.T) {
 			expectResult: false,
 		},
 		{
-			name:          "User must be admin",
-			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
-			expectedError: "UNAUTHORIZED",
+			name: "Permission denied",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
 			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
 				return nil, service.DeleteAccount(ctx, test_accountId)
 			},
@@ -157,7 +169,7 @@ func TestAccountService_DeleteAccount(t *testing.T) {
 		},
 		{
 			name: "Repo Error Deleting Account",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAccountWrite),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId}, nil)
 				mocks.accountRepo.EXPECT().DeleteAccount(ctx, gomock.Any()).Return(errs.NewInternalError("DB error", "", nil))
@@ -179,7 +191,7 @@ func TestAccountService_GetWalletAccountsSum(t *testing.T) {
 	testcases := []TestCase[AccountService]{
 		{
 			name: "Success case",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAccountRead),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
 				mocks.accountRepo.EXPECT().SumWalletAccounts(ctx, test_walletId).Return(uint64(100), nil)
@@ -191,7 +203,7 @@ func TestAccountService_GetWalletAccountsSum(t *testing.T) {
 		},
 		{
 			name: "Wallet not found",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAccountRead),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(nil, nil)
 			},
@@ -202,9 +214,11 @@ func TestAccountService_GetWalletAccountsSum(t *testing.T) {
 			expectResult: false,
 		},
 		{
-			name:          "User must be admin",
-			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
-			expectedError: "UNAUTHORIZED",
+			name: "Permission denied",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
 			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
 				return service.GetWalletAccountsSum(ctx, test_walletId)
 			},
@@ -212,7 +226,7 @@ func TestAccountService_GetWalletAccountsSum(t *testing.T) {
 		},
 		{
 			name: "Repo Error Summing Accounts",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAccountRead),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
 				mocks.accountRepo.EXPECT().SumWalletAccounts(ctx, test_walletId).Return(uint64(0), errs.NewInternalError("DB error", "", nil))
@@ -229,3 +243,403 @@ func TestAccountService_GetWalletAccountsSum(t *testing.T) {
 	}
 	RunTestCases[AccountService](t, serviceFactory, testcases)
 }
+
+func TestAccountService_FreezeAccount(t *testing.T) {
+	reason := &AccountStatusRequest{Reason: "Suspected fraud"}
+	testcases := []TestCase[AccountService]{
+		{
+			name: "Success case",
+			ctx:  createBackofficeContext(api.PermissionAccountFreeze),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId, IsActive: true}, nil)
+				mocks.accountRepo.EXPECT().UpdateAccountStatus(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, account *model.Account) error {
+					if account.IsActive {
+						t.Errorf("expected account to be frozen")
+					}
+					if remarks := account.GetRemarks(); remarks == nil || *remarks != "Account frozen: Suspected fraud" {
+						t.Errorf("expected the reason in the audit remarks, got %v", remarks)
+					}
+					return nil
+				})
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.FreezeAccount(ctx, test_accountId, reason)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Account already frozen",
+			ctx:  createBackofficeContext(api.PermissionAccountFreeze),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId}, nil)
+			},
+			expectedError: "ACCOUNT_ALREADY_FROZEN",
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.FreezeAccount(ctx, test_accountId, reason)
+			},
+			expectResult: false,
+		},
+		{
+			name:          "Reason is required",
+			ctx:           createBackofficeContext(api.PermissionAccountFreeze),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.FreezeAccount(ctx, test_accountId, &AccountStatusRequest{})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Permission denied",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.FreezeAccount(ctx, test_accountId, reason)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) AccountService {
+		return NewAccountService(mocks.repos)
+	}
+	RunTestCases[AccountService](t, serviceFactory, testcases)
+}
+
+func TestAccountService_UnfreezeAccount(t *testing.T) {
+	reason := &AccountStatusRequest{Reason: "Investigation closed"}
+	testcases := []TestCase[AccountService]{
+		{
+			name: "Success case",
+			ctx:  createBackofficeContext(api.PermissionAccountFreeze),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId}, nil)
+				mocks.accountRepo.EXPECT().UpdateAccountStatus(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.UnfreezeAccount(ctx, test_accountId, reason)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Account not frozen",
+			ctx:  createBackofficeContext(api.PermissionAccountFreeze),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, UserID: test_userId, IsActive: true}, nil)
+			},
+			expectedError: "ACCOUNT_NOT_FROZEN",
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.UnfreezeAccount(ctx, test_accountId, reason)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) AccountService {
+		return NewAccountService(mocks.repos)
+	}
+	RunTestCases[AccountService](t, serviceFactory, testcases)
+}
+
+func TestAccountService_GetAccountStatement(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	period := &StatementRequest{From: "2026-01-01T00:00:00Z", To: "2026-02-01T00:00:00Z"}
+	wallet := &model.Wallet{ID: test_walletId, Currency: "PTS"}
+	account := &model.Account{ID: test_accountId, WalletID: test_walletId, UserID: test_userId}
+	transactions := []model.Transaction{
+		{ID: "TX-1", Type: model.TransactionTypeCredit, Reason: model.TransactionReasonReward, Amount: 50, PreviousBalance: 100, NewBalance: 150, CreatedAt: from.Add(time.Hour)},
+		{ID: "TX-2", Type: model.TransactionTypeDebit, Reason: model.TransactionReasonExpired, Amount: 30, PreviousBalance: 150, NewBalance: 120, CreatedAt: from.Add(2 * time.Hour)},
+	}
+	setupStatement := func(mocks *Mocks, ctx context.Context) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+		mocks.transactionRepo.EXPECT().FetchLastAccountTransaction(ctx, test_accountId, from).Return(&model.Transaction{NewBalance: 100}, nil)
+		mocks.transactionRepo.EXPECT().FetchLastAccountTransaction(ctx, test_accountId, to).Return(&transactions[1], nil)
+		mocks.transactionRepo.EXPECT().SumAccountTransactionsByReason(ctx, test_accountId, from, to).Return([]repository.ReasonTotal{
+			{Type: model.TransactionTypeCredit, Reason: model.TransactionReasonReward, Amount: 50, Count: 1},
+			{Type: model.TransactionTypeDebit, Reason: model.TransactionReasonExpired, Amount: 30, Count: 1},
+		}, nil)
+	}
+	streamTransactions := func(mocks *Mocks, ctx context.Context) {
+		mocks.transactionRepo.EXPECT().FetchAccountTransactionsInBatches(ctx, test_accountId, from, to, statementBatchSize, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ time.Time, _ time.Time, _ int, process func([]model.Transaction) error) error {
+				return process(transactions)
+			})
+	}
+	failStream := func(mocks *Mocks, ctx context.Context) {
+		mocks.transactionRepo.EXPECT().FetchAccountTransactionsInBatches(ctx, test_accountId, from, to, statementBatchSize, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ time.Time, _ time.Time, _ int, process func([]model.Transaction) error) error {
+				if err := process(transactions[:1]); err != nil {
+					return err
+				}
+				return errors.New("connection reset")
+			})
+	}
+	testcases := []TestCase[AccountService]{
+		{
+			name: "Summarizes the period with running balances",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupStatement(mocks, ctx)
+				streamTransactions(mocks, ctx)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				statement, err := service.GetAccountStatement(ctx, test_walletId, test_accountId, period)
+				if err != nil {
+					return nil, err
+				}
+				if statement.OpeningBalance != 100 || statement.ClosingBalance != 120 || statement.TotalCredited != 50 || statement.TotalDebited != 30 || statement.Expired != 30 {
+					t.Errorf("unexpected statement summary %+v", statement)
+				}
+				var out bytes.Buffer
+				if err := service.WriteAccountStatement(ctx, statement, &out); err != nil {
+					return nil, err
+				}
+				var response struct {
+					Success bool             `json:"success"`
+					Result  AccountStatement `json:"result"`
+				}
+				if err := json.Unmarshal(out.Bytes(), &response); err != nil {
+					t.Fatalf("expected a json statement, got %s: %v", out.String(), err)
+				}
+				if !response.Success || response.Result.ClosingBalance != 120 || len(response.Result.Transactions) != 2 || response.Result.Transactions[1].RunningBalance != 120 {
+					t.Errorf("unexpected json statement %s", out.String())
+				}
+				return statement, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Streams the transactions of a csv statement",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupStatement(mocks, ctx)
+				streamTransactions(mocks, ctx)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				statement, err := service.GetAccountStatement(ctx, test_walletId, test_accountId, &StatementRequest{From: period.From, To: period.To, Format: "csv"})
+				if err != nil {
+					return nil, err
+				}
+				var out bytes.Buffer
+				if err := service.WriteAccountStatement(ctx, statement, &out); err != nil {
+					return nil, err
+				}
+				expected := "date,transaction_id,type,reason,program_id,amount,balance\n" +
+					"2026-01-01T00:00:00Z,,OPENING_BALANCE,,,,100\n" +
+					"2026-01-01T01:00:00Z,TX-1,CREDIT,REWARD,,50,150\n" +
+					"2026-01-01T02:00:00Z,TX-2,DEBIT,EXPIRED,,30,120\n" +
+					"2026-02-01T00:00:00Z,,CLOSING_BALANCE,,,,120\n"
+				if out.String() != expected {
+					t.Errorf("unexpected csv statement:\n%s", out.String())
+				}
+				return statement, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Renders a pdf statement",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupStatement(mocks, ctx)
+				streamTransactions(mocks, ctx)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				statement, err := service.GetAccountStatement(ctx, test_walletId, test_accountId, &StatementRequest{From: period.From, To: period.To, Format: "pdf"})
+				if err != nil {
+					return nil, err
+				}
+				var out bytes.Buffer
+				if err := service.WriteAccountStatement(ctx, statement, &out); err != nil {
+					return nil, err
+				}
+				if !bytes.HasPrefix(out.Bytes(), []byte("%PDF-")) {
+					t.Errorf("expected a pdf document")
+				}
+				return statement, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Marks a streamed statement that fails part way",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupStatement(mocks, ctx)
+				failStream(mocks, ctx)
+				setupStatement(mocks, ctx)
+				failStream(mocks, ctx)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				for _, format := range []string{"json", "csv"} {
+					statement, err := service.GetAccountStatement(ctx, test_walletId, test_accountId, &StatementRequest{From: period.From, To: period.To, Format: format})
+					if err != nil {
+						return nil, err
+					}
+					var out bytes.Buffer
+					if err := service.WriteAccountStatement(ctx, statement, &out); err == nil {
+						t.Errorf("expected the %s statement to fail", format)
+					}
+					if !strings.Contains(out.String(), statementIncompleteCode) || strings.Contains(out.String(), "CLOSING_BALANCE") {
+						t.Errorf("expected the %s statement to end with an error marker:\n%s", format, out.String())
+					}
+					if format == "json" && !json.Valid(out.Bytes()) {
+						t.Errorf("expected the failed json statement to stay valid json:\n%s", out.String())
+					}
+				}
+				return true, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Splits a long pdf statement into several documents",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupStatement(mocks, ctx)
+				streamTransactions(mocks, ctx)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				service.(*accountService).pdfDocumentSize = 1
+				statement, err := service.GetAccountStatement(ctx, test_walletId, test_accountId, &StatementRequest{From: period.From, To: period.To, Format: "pdf"})
+				if err != nil {
+					return nil, err
+				}
+				if !statement.Archived {
+					t.Errorf("expected the statement to be split")
+				}
+				var out bytes.Buffer
+				if err := service.WriteAccountStatement(ctx, statement, &out); err != nil {
+					return nil, err
+				}
+				archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+				if err != nil {
+					t.Fatalf("expected a zip archive: %v", err)
+				}
+				if len(archive.File) != 2 || archive.File[1].Name != "statement-"+test_accountId+"-part-2.pdf" {
+					t.Fatalf("expected 2 documents, got %v", archive.File)
+				}
+				for _, file := range archive.File {
+					document, err := file.Open()
+					if err != nil {
+						return nil, err
+					}
+					body, err := io.ReadAll(document)
+					if err != nil {
+						return nil, err
+					}
+					if !bytes.HasPrefix(body, []byte("%PDF-")) {
+						t.Errorf("expected %s to be a pdf document", file.Name)
+					}
+				}
+				return statement, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Marks a split pdf statement that fails part way",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupStatement(mocks, ctx)
+				failStream(mocks, ctx)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				service.(*accountService).pdfDocumentSize = 1
+				statement, err := service.GetAccountStatement(ctx, test_walletId, test_accountId, &StatementRequest{From: period.From, To: period.To, Format: "pdf"})
+				if err != nil {
+					return nil, err
+				}
+				var out bytes.Buffer
+				if err := service.WriteAccountStatement(ctx, statement, &out); err == nil {
+					t.Errorf("expected the pdf statement to fail")
+				}
+				archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+				if err != nil {
+					t.Fatalf("expected the failed archive to stay readable: %v", err)
+				}
+				if len(archive.File) != 1 || archive.File[0].Name != "error.json" {
+					t.Errorf("expected only the error marker, got %v", archive.File)
+				}
+				return true, nil
+			},
+			expectResult: true,
+		},
+		{
+			name:       "Rejects a period ending before it starts",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.GetAccountStatement(ctx, test_walletId, test_accountId, &StatementRequest{From: period.To, To: period.From})
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name: "Rejects an account of another wallet",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, WalletID: "other", UserID: test_userId}, nil)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.GetAccountStatement(ctx, test_walletId, test_accountId, period)
+			},
+			expectedError: "ACCOUNT_NOT_FOUND",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) AccountService {
+		return NewAccountService(mocks.repos)
+	}
+	RunTestCases[AccountService](t, serviceFactory, testcases)
+}
+
+func TestAccountService_GetBalanceAt(t *testing.T) {
+	at := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	account := &model.Account{ID: test_accountId, WalletID: test_walletId, UserID: test_userId}
+	testcases := []TestCase[AccountService]{
+		{
+			name: "Includes the transactions made at that time",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+				mocks.transactionRepo.EXPECT().FetchLastAccountTransaction(ctx, test_accountId, at.Add(time.Microsecond)).
+					Return(&model.Transaction{ID: "TX-1", NewBalance: 120, CreatedAt: at}, nil)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				balance, err := service.GetBalanceAt(ctx, test_walletId, test_accountId, at)
+				if err != nil {
+					return nil, err
+				}
+				if balance.Balance != 120 || balance.TransactionID == nil || *balance.TransactionID != "TX-1" {
+					t.Errorf("unexpected balance %+v", balance)
+				}
+				return balance, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Is empty before the first transaction",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+				mocks.transactionRepo.EXPECT().FetchLastAccountTransaction(ctx, test_accountId, at.Add(time.Microsecond)).Return(nil, nil)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				balance, err := service.GetBalanceAt(ctx, test_walletId, test_accountId, at)
+				if err != nil {
+					return nil, err
+				}
+				if balance.Balance != 0 || balance.TransactionID != nil {
+					t.Errorf("unexpected balance %+v", balance)
+				}
+				return balance, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Rejects an account of another wallet",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, WalletID: "other", UserID: test_userId}, nil)
+			},
+			testFunc: func(service AccountService, ctx context.Context) (interface{}, error) {
+				return service.GetBalanceAt(ctx, test_walletId, test_accountId, at)
+			},
+			expectedError: "ACCOUNT_NOT_FOUND",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) AccountService {
+		return NewAccountService(mocks.repos)
+	}
+	RunTestCases[AccountService](t, serviceFactory, testcases)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"time"
+)
+
+const (
+	// apiKeyPrefix marks the keys issued by the wallet, so leaked keys are easy to spot
+	apiKeyPrefix = "dwk_"
+	// apiKeyDisplayLength is how much of a key is stored in clear to recognize it
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	// apiKeyTouchInterval is how often the last use of a key is recorded
+	apiKeyTouchInterval = time.Minute
+)
+
+type ApiKeyService interface {
+	// CreateApiKey issues a new API key scoped to wallets and permissions, returning the key once
+	CreateApiKey(ctx context.Context, req *CreateApiKeyRequest) (*ApiKeyResponse, error)
+	// RotateApiKey replaces the key of an API key, keeping its scope, and returns the new key once
+	RotateApiKey(ctx context.Context, apiKeyId string) (*ApiKeyResponse, error)
+	// UpdateApiKey updates the name, scope, rate limit or status of an API key
+	UpdateApiKey(ctx context.Context, apiKeyId string, req *UpdateApiKeyRequest) (*model.ApiKey, error)
+	// GetApiKey returns an API key without the key itself
+	GetApiKey(ctx context.Context, apiKeyId string) (*model.ApiKey, error)
+	// GetApiKeys returns a paginated list of API keys without the keys themselves
+	GetApiKeys(ctx context.Context, page int, limit int) (*api.List[model.ApiKey], error)
+	// DeleteApiKey deletes an API key, revoking it immediately
+	DeleteApiKey(ctx context.Context, apiKeyId string) error
+	// Authenticate resolves the scope of an active API key, recording its use
+	Authenticate(ctx context.Context, key string) (*api.ApiKeyScope, error)
+}
+
+type apiKeyService struct {
+	repos *repository.Repos
+}
+
+func NewApiKeyService(repos *repository.Repos) ApiKeyService {
+	return &apiKeyService{repos: repos}
+}
+
+func (s *apiKeyService) CreateApiKey(ctx context.Context, req *CreateApiKeyRequest) (*ApiKeyResponse, error) {
+	if err := authorize(ctx, s.repos, api.PermissionApiKeyWrite); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid api key request", logger.Field("fields", fields), logger.Field("request", req))
+		return nil, errs.NewValidationError("Invalid api key request", "", fields)
+	}
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+	if err := authorizeGrant(ctx, s.repos, req.Permissions); err != nil {
+		return nil, err
+	}
+	key, err := generateApiKey()
+	if err != nil {
+		api.GetLogger(ctx).Error("Unable to generate api key", logger.Field("error", err))
+		return nil, errs.NewInternalError("Unable to generate api key", "", err)
+	}
+	apiKey := &model.ApiKey{
+		Name:        req.Name,
+		Prefix:      key[:apiKeyDisplayLength],
+		KeyHash:     hashApiKey(key),
+		WalletIDs:   req.WalletIDs,
+		Permissions: req.Permissions,
+		RateLimit:   req.RateLimit,
+		IsActive:    true,
+	}
+	apiKey.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	apiKey.SetRemarks("API key created")
+	if err := s.repos.ApiKey.CreateApiKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	return &ApiKeyResponse{ApiKey: apiKey, Key: key}, nil
+}
+
+func (s *apiKeyService) RotateApiKey(ctx context.Context, apiKeyId string) (*ApiKeyResponse, error) {
+	if err := authorize(ctx, s.repos, api.PermissionApiKeyWrite); err != nil {
+		return nil, err
+	}
+	apiKey, err := s.repos.ApiKey.FetchApiKeyByID(ctx, apiKeyId)
+	if apiKey == nil {
+		return nil, errs.NewNotFoundError("API key not found", "API_KEY_NOT_FOUND", err)
+	}
+	key, err := generateApiKey()
+	if err != nil {
+		api.GetLogger(ctx).Error("Unable to generate api key", logger.Field("error", err))
+		return nil, errs.NewInternalError("Unable to generate api key", "", err)
+	}
+	apiKey.SetOldRecord(*apiKey)
+	now := time.Now()
+	apiKey.Prefix = key[:apiKeyDisplayLength]
+	apiKey.KeyHash = hashApiKey(key)
+	apiKey.RotatedAt = &now
+	apiKey.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	apiKey.SetRemarks("API key rotated")
+	if err := s.repos.ApiKey.UpdateApiKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	return &ApiKeyResponse{ApiKey: apiKey, Key: key}, nil
+}
+
+func (s *apiKeyService) UpdateApiKey(ctx context.Context, apiKeyId string, req *UpdateApiKeyRequest) (*model.ApiKey, error) {
+	if err := authorize(ctx, s.repos, api.PermissionApiKeyWrite); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid api key request", logger.Field("fields", fields), logger.Field("request", req))
+		return nil, errs.NewValidationError("Invalid api key request", "", fields)
+	}
+	apiKey, err := s.repos.ApiKey.FetchApiKeyByID(ctx, apiKeyId)
+	if apiKey == nil {
+		return nil, errs.NewNotFoundError("API key not found", "API_KEY_NOT_FOUND", err)
+	}
+	apiKey.SetOldRecord(*apiKey)
+	if req.Name != nil {
+		apiKey.Name = *req.Name
+	}
+	if req.WalletIDs != nil {
+		apiKey.WalletIDs = *req.WalletIDs
+	}
+	if req.Permissions != nil {
+		if err := validatePermissions(*req.Permissions); err != nil {
+			return nil, err
+		}
+		if err := authorizeGrant(ctx, s.repos, *req.Permissions); err != nil {
+			return nil, err
+		}
+		apiKey.Permissions = *req.Permissions
+	}
+	if req.RateLimit != nil {
+		apiKey.RateLimit = *req.RateLimit
+	}
+	if req.IsActive != nil {
+		apiKey.IsActive = *req.IsActive
+	}
+	apiKey.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	apiKey.SetRemarks("API key updated")
+	if err := s.repos.ApiKey.UpdateApiKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (s *apiKeyService) GetApiKey(ctx context.Context, apiKeyId string) (*model.ApiKey, error) {
+	if err := authorize(ctx, s.repos, api.PermissionApiKeyRead); err != nil {
+		return nil, err
+	}
+	apiKey, err := s.repos.ApiKey.FetchApiKeyByID(ctx, apiKeyId)
+	if apiKey == nil {
+		return nil, errs.NewNotFoundError("API key not found", "API_KEY_NOT_FOUND", err)
+	}
+	return apiKey, nil
+}
+
+func (s *apiKeyService) GetApiKeys(ctx context.Context, page int, limit int) (*api.List[model.ApiKey], error) {
+	if err := authorize(ctx, s.repos, api.PermissionApiKeyRead); err != nil {
+		return nil, err
+	}
+	apiKeys, err := s.repos.ApiKey.FetchApiKeys(ctx, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repos.ApiKey.CountApiKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &api.List[model.ApiKey]{Items: apiKeys, Page: page, Limit: limit, Total: total}, nil
+}
+
+func (s *apiKeyService) DeleteApiKey(ctx context.Context, apiKeyId string) error {
+	if err := authorize(ctx, s.repos, api.PermissionApiKeyWrite); err != nil {
+		return err
+	}
+	apiKey, err := s.repos.ApiKey.FetchApiKeyByID(ctx, apiKeyId)
+	if apiKey == nil {
+		return errs.NewNotFoundError("API key not found", "API_KEY_NOT_FOUND", err)
+	}
+	apiKey.SetOldRecord(*apiKey)
+	apiKey.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	apiKey.SetRemarks("API key deleted")
+	return s.repos.ApiKey.DeleteApiKey(ctx, apiKey)
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*api.ApiKeyScope, error) {
+	apiKey, err := s.repos.ApiKey.FetchApiKeyByHash(ctx, hashApiKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || !apiKey.IsActive {
+		api.GetLogger(ctx).Error("Invalid api key")
+		return nil, errs.NewUnauthorizedError("Invalid API key", "INVALID_API_KEY", nil)
+	}
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repos.ApiKey.TouchApiKey(ctx, apiKey.ID, now); err != nil {
+			api.GetLogger(ctx).Error("Unable to record api key use", logger.Field("apiKeyId", apiKey.ID), logger.Field("error", err))
+		}
+	}
+	return &api.ApiKeyScope{
+		KeyID:       apiKey.ID,
+		TenantID:    apiKey.TenantID,
+		WalletIDs:   apiKey.WalletIDs,
+		Permissions: apiKey.Permissions,
+		RateLimit:   apiKey.RateLimit,
+	}, nil
+}
+
+// generateApiKey generates a random key, of which only the hash is stored
+func generateApiKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(secret), nil
+}
+
+// hashApiKey hashes a key for storage. Keys are random and long enough for a fast unsalted hash to be safe.
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
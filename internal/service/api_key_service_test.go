@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createApiKeyContext creates the context of the SYSTEM actor authenticated with an API key scoped to wallets and permissions
+func createApiKeyContext(walletIds []string, permissions ...string) context.Context {
+	ctx := api.CreateAppContext(context.Background(), api.AppActorSystem, "key-123", test_requestId)
+	return api.WithWalletScope(api.WithPermissions(ctx, permissions...), walletIds...)
+}
+
+func TestApiKeyService_CreateApiKey(t *testing.T) {
+	testcases := []TestCase[ApiKeyService]{
+		{
+			name: "Stores only the hash of the key it returns",
+			ctx:  createBackofficeContext(api.PermissionApiKeyWrite, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().CreateApiKey(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				res, err := service.CreateApiKey(ctx, &CreateApiKeyRequest{Name: "checkout", WalletIDs: []string{test_walletId}, Permissions: []string{api.PermissionTransactionCredit}, RateLimit: 60})
+				if err != nil {
+					return nil, err
+				}
+				if !strings.HasPrefix(res.Key, res.Prefix) || res.KeyHash != hashApiKey(res.Key) || strings.Contains(res.KeyHash, res.Key) {
+					t.Errorf("expected the key to be stored hashed, got %+v", res.ApiKey)
+				}
+				return res, nil
+			},
+			expectResult: true,
+		},
+		{
+			name:          "Rejects unknown permissions",
+			ctx:           createBackofficeContext(api.PermissionApiKeyWrite),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.CreateApiKey(ctx, &CreateApiKeyRequest{Name: "checkout", WalletIDs: []string{test_walletId}, Permissions: []string{"wallet:destroy"}, RateLimit: 60})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Rejects permissions the actor doesn't hold",
+			ctx:  createBackofficeContext(api.PermissionApiKeyWrite, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.CreateApiKey(ctx, &CreateApiKeyRequest{Name: "checkout", WalletIDs: []string{test_walletId}, Permissions: []string{api.PermissionTransactionCredit, api.PermissionTransactionDebit}, RateLimit: 60})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Permission denied",
+			ctx:  createBackofficeContext(api.PermissionApiKeyRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.CreateApiKey(ctx, &CreateApiKeyRequest{Name: "checkout", WalletIDs: []string{test_walletId}, Permissions: []string{api.PermissionTransactionCredit}, RateLimit: 60})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ApiKeyService {
+		return NewApiKeyService(mocks.repos)
+	}
+	RunTestCases[ApiKeyService](t, serviceFactory, testcases)
+}
+
+func TestApiKeyService_UpdateApiKey(t *testing.T) {
+	testcases := []TestCase[ApiKeyService]{
+		{
+			name: "Rejects widening a key beyond the permissions of the actor",
+			ctx:  createBackofficeContext(api.PermissionApiKeyWrite, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().FetchApiKeyByID(ctx, "key-123").Return(&model.ApiKey{ID: "key-123", Permissions: []string{api.PermissionTransactionCredit}}, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				permissions := []string{api.PermissionTransactionCredit, api.PermissionProgramInvoke}
+				return service.UpdateApiKey(ctx, "key-123", &UpdateApiKeyRequest{Permissions: &permissions})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ApiKeyService {
+		return NewApiKeyService(mocks.repos)
+	}
+	RunTestCases[ApiKeyService](t, serviceFactory, testcases)
+}
+
+func TestApiKeyService_RotateApiKey(t *testing.T) {
+	testcases := []TestCase[ApiKeyService]{
+		{
+			name: "Replaces the key and keeps the scope",
+			ctx:  createBackofficeContext(api.PermissionApiKeyWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().FetchApiKeyByID(ctx, "key-123").Return(&model.ApiKey{ID: "key-123", KeyHash: "old-hash", WalletIDs: []string{test_walletId}}, nil)
+				mocks.apiKeyRepo.EXPECT().UpdateApiKey(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, apiKey *model.ApiKey) error {
+					if apiKey.KeyHash == "old-hash" || apiKey.RotatedAt == nil || len(apiKey.WalletIDs) != 1 {
+						t.Errorf("expected a new key with the same scope, got %+v", apiKey)
+					}
+					return nil
+				})
+			},
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.RotateApiKey(ctx, "key-123")
+			},
+			expectResult: true,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ApiKeyService {
+		return NewApiKeyService(mocks.repos)
+	}
+	RunTestCases[ApiKeyService](t, serviceFactory, testcases)
+}
+
+func TestApiKeyService_Authenticate(t *testing.T) {
+	key := apiKeyPrefix + "secret"
+	recently := time.Now().Add(-time.Second)
+	testcases := []TestCase[ApiKeyService]{
+		{
+			name: "Resolves the scope of an active key and records its use",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().FetchApiKeyByHash(ctx, hashApiKey(key)).Return(&model.ApiKey{Tenanted: model.Tenanted{TenantID: "tenant-a"}, ID: "key-123", IsActive: true, WalletIDs: []string{test_walletId}, Permissions: []string{api.PermissionProgramInvoke}, RateLimit: 60}, nil)
+				mocks.apiKeyRepo.EXPECT().TouchApiKey(ctx, "key-123", gomock.Any()).Return(nil)
+			},
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				scope, err := service.Authenticate(ctx, key)
+				if err == nil && (scope.KeyID != "key-123" || scope.TenantID != "tenant-a" || scope.RateLimit != 60 || len(scope.WalletIDs) != 1) {
+					t.Errorf("expected the scope of key-123, got %+v", scope)
+				}
+				return scope, err
+			},
+			expectResult: true,
+		},
+		{
+			name: "Records the use of a key at most once a minute",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().FetchApiKeyByHash(ctx, hashApiKey(key)).Return(&model.ApiKey{ID: "key-123", IsActive: true, LastUsedAt: &recently}, nil)
+			},
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.Authenticate(ctx, key)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Rejects inactive keys",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().FetchApiKeyByHash(ctx, hashApiKey(key)).Return(&model.ApiKey{ID: "key-123", IsActive: false}, nil)
+			},
+			expectedError: "INVALID_API_KEY",
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.Authenticate(ctx, key)
+			},
+			expectResult: false,
+		},
+		{
+			name: "Rejects unknown keys",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.apiKeyRepo.EXPECT().FetchApiKeyByHash(ctx, hashApiKey(key)).Return(nil, nil)
+			},
+			expectedError: "INVALID_API_KEY",
+			testFunc: func(service ApiKeyService, ctx context.Context) (interface{}, error) {
+				return service.Authenticate(ctx, key)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ApiKeyService {
+		return NewApiKeyService(mocks.repos)
+	}
+	RunTestCases[ApiKeyService](t, serviceFactory, testcases)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+)
+
+type approvalContextKey struct{}
+
+// withApproval marks a context as carrying out an approved operation, so the operation isn't held for approval again
+func withApproval(ctx context.Context, approval *model.Approval) context.Context {
+	return context.WithValue(ctx, approvalContextKey{}, approval)
+}
+
+// approvalOf returns the approval a context carries out, when it carries out the given operation
+func approvalOf(ctx context.Context, operation string) (*model.Approval, bool) {
+	approval, ok := ctx.Value(approvalContextKey{}).(*model.Approval)
+	return approval, ok && approval.Operation == operation
+}
+
+// requireApproval holds a sensitive operation requested by a backoffice actor until a second one approves it.
+// It stores a snapshot of the payload and returns an APPROVAL_REQUIRED error carrying the approval ID.
+// Operations requested by other actors, or replayed from an approval, go through.
+func requireApproval(ctx context.Context, repos *repository.Repos, operation, permission string, payload interface{}) error {
+	if api.GetActor(ctx) != api.AppActorAdmin {
+		return nil
+	}
+	if _, ok := approvalOf(ctx, operation); ok {
+		return nil
+	}
+	approval := &model.Approval{
+		Operation:  operation,
+		Permission: permission,
+		Status:     model.ApprovalStatusPending,
+		MakerActor: api.GetActor(ctx),
+		MakerID:    api.GetActorID(ctx),
+	}
+	if err := types.StructToJSONB(payload, &approval.Payload); err != nil {
+		api.GetLogger(ctx).Error("Unable to snapshot approval payload", logger.Field("error", err), logger.Field("operation", operation))
+		return errs.NewInternalError("Unable to request approval", "", err)
+	}
+	approval.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	approval.SetRemarks("Approval requested for " + operation)
+	if err := repos.Approval.CreateApproval(ctx, approval); err != nil {
+		return err
+	}
+	return errs.NewAcceptedError("Operation is pending approval", "APPROVAL_REQUIRED", map[string]string{"approvalId": approval.ID})
+}
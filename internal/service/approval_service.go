@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"time"
+)
+
+type ApprovalService interface {
+	// GetApprovals returns a paginated list of approvals, optionally filtered by status
+	GetApprovals(ctx context.Context, status string, page int, limit int) (*api.List[model.Approval], error)
+	// GetApproval returns an approval and the payload it holds
+	GetApproval(ctx context.Context, approvalId string) (*model.Approval, error)
+	// Approve carries out the operation held by a pending approval on behalf of a second backoffice actor
+	Approve(ctx context.Context, approvalId string, req *ApprovalDecisionRequest) (*model.Approval, error)
+	// Reject discards the operation held by a pending approval
+	Reject(ctx context.Context, approvalId string, req *ApprovalDecisionRequest) (*model.Approval, error)
+}
+
+type transactionApprovalPayload struct {
+	WalletID  string             `json:"walletId"`
+	AccountID string             `json:"accountId"`
+	Request   TransactionRequest `json:"request"`
+}
+
+type exchangeRateApprovalPayload struct {
+	ExchangeRateID string                     `json:"exchangeRateId"`
+	Request        *UpdateExchangeRateRequest `json:"request,omitempty"`
+}
+
+type walletApprovalPayload struct {
+	WalletID string               `json:"walletId"`
+	Request  *UpdateWalletRequest `json:"request,omitempty"`
+}
+
+type programApprovalPayload struct {
+	ProgramID uint64               `json:"programId"`
+	Request   UpdateProgramRequest `json:"request"`
+	// Version is the version the program ran when its activation was requested, the one the checker approves
+	Version uint64 `json:"version,omitempty"`
+}
+
+// programPublishApprovalPayload holds the draft the maker asked to publish, so a draft edited since isn't published
+type programPublishApprovalPayload struct {
+	ProgramID uint64                   `json:"programId"`
+	Draft     *model.ProgramDefinition `json:"draft"`
+}
+
+type programRollbackApprovalPayload struct {
+	ProgramID uint64                 `json:"programId"`
+	Request   RollbackProgramRequest `json:"request"`
+}
+
+// approvalExecutors replay each operation from the payload snapshot taken when it was requested
+var approvalExecutors = map[string]func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error){
+	model.ApprovalOperationCreateTransaction: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p transactionApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		return services.Transaction.CreateTransaction(ctx, p.WalletID, p.AccountID, &p.Request)
+	},
+	model.ApprovalOperationCreateExchangeRate: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var req CreateExchangeRateRequest
+		if err := decodeApprovalPayload(payload, &req); err != nil {
+			return nil, err
+		}
+		return services.ExchangeRate.CreateExchangeRate(ctx, &req)
+	},
+	model.ApprovalOperationUpdateExchangeRate: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p exchangeRateApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		if p.Request == nil {
+			return nil, errs.NewUnprocessableEntityError("Approval payload is invalid", "APPROVAL_INVALID_PAYLOAD", nil)
+		}
+		return services.ExchangeRate.UpdateExchangeRate(ctx, p.ExchangeRateID, p.Request)
+	},
+	model.ApprovalOperationDeleteExchangeRate: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p exchangeRateApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		return nil, services.ExchangeRate.DeleteExchangeRate(ctx, p.ExchangeRateID)
+	},
+	model.ApprovalOperationDeleteWallet: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p walletApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		return nil, services.Wallet.DeleteWallet(ctx, p.WalletID)
+	},
+	model.ApprovalOperationUpdateWallet: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p walletApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		if p.Request == nil {
+			return nil, errs.NewUnprocessableEntityError("Approval payload is invalid", "APPROVAL_INVALID_PAYLOAD", nil)
+		}
+		return services.Wallet.UpdateWallet(ctx, p.WalletID, p.Request)
+	},
+	model.ApprovalOperationCreateProgram: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var req CreateProgramRequest
+		if err := decodeApprovalPayload(payload, &req); err != nil {
+			return nil, err
+		}
+		return services.Program.CreateProgram(ctx, req)
+	},
+	model.ApprovalOperationUpdateProgram: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p programApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		return services.Program.UpdateProgram(ctx, p.ProgramID, p.Request)
+	},
+	model.ApprovalOperationPublishProgram: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p programPublishApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		return services.Program.PublishProgram(ctx, p.ProgramID)
+	},
+	model.ApprovalOperationRollbackProgram: func(ctx context.Context, services *Services, payload types.JSONB) (interface{}, error) {
+		var p programRollbackApprovalPayload
+		if err := decodeApprovalPayload(payload, &p); err != nil {
+			return nil, err
+		}
+		return services.Program.RollbackProgram(ctx, p.ProgramID, p.Request)
+	},
+}
+
+type approvalService struct {
+	repos    *repository.Repos
+	services *Services
+}
+
+// NewApprovalService creates the approval service, which carries out approved operations through the other services
+func NewApprovalService(repos *repository.Repos, services *Services) ApprovalService {
+	return &approvalService{repos: repos, services: services}
+}
+
+func (s *approvalService) GetApprovals(ctx context.Context, status string, page int, limit int) (*api.List[model.Approval], error) {
+	if err := authorize(ctx, s.repos, api.PermissionApprovalRead); err != nil {
+		return nil, err
+	}
+	approvals, err := s.repos.Approval.FetchApprovals(ctx, status, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repos.Approval.CountApprovals(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	return &api.List[model.Approval]{Items: approvals, Page: page, Limit: limit, Total: total}, nil
+}
+
+func (s *approvalService) GetApproval(ctx context.Context, approvalId string) (*model.Approval, error) {
+	if err := authorize(ctx, s.repos, api.PermissionApprovalRead); err != nil {
+		return nil, err
+	}
+	approval, err := s.repos.Approval.FetchApprovalByID(ctx, approvalId)
+	if approval == nil {
+		return nil, errs.NewNotFoundError("Approval not found", "APPROVAL_NOT_FOUND", err)
+	}
+	return approval, nil
+}
+
+func (s *approvalService) Approve(ctx context.Context, approvalId string, req *ApprovalDecisionRequest) (*model.Approval, error) {
+	approval, err := s.fetchDecidableApproval(ctx, approvalId, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, s.repos, approval.Permission); err != nil {
+		return nil, err
+	}
+	execute, ok := approvalExecutors[approval.Operation]
+	if !ok {
+		api.GetLogger(ctx).Error("Unknown approval operation", logger.Field("approvalId", approvalId), logger.Field("operation", approval.Operation))
+		return nil, errs.NewUnprocessableEntityError("Approval operation is not supported", "APPROVAL_INVALID_OPERATION", nil)
+	}
+	// The approval is claimed before its operation runs, so a concurrent approval can't carry it out a second time
+	if err := s.repos.Approval.ClaimApproval(ctx, approvalId); err != nil {
+		return nil, err
+	}
+	// A failed operation leaves the approval pending, so it can be approved again once the failure is resolved
+	result, err := execute(withApproval(ctx, approval), s.services, approval.Payload)
+	if err != nil {
+		api.GetLogger(ctx).Error("Unable to carry out approved operation", logger.Field("approvalId", approvalId), logger.Field("error", err))
+		if releaseErr := s.repos.Approval.ReleaseApproval(ctx, approvalId); releaseErr != nil {
+			api.GetLogger(ctx).Error("Unable to release approval", logger.Field("approvalId", approvalId), logger.Field("error", releaseErr))
+		}
+		return nil, err
+	}
+	if result != nil {
+		var snapshot types.JSONB
+		if err := types.StructToJSONB(result, &snapshot); err == nil {
+			approval.Result = &snapshot
+		}
+	}
+	s.decide(ctx, approval, model.ApprovalStatusApproved, req)
+	if err := s.repos.Approval.DecideApproval(ctx, approval, model.ApprovalStatusApproving); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+func (s *approvalService) Reject(ctx context.Context, approvalId string, req *ApprovalDecisionRequest) (*model.Approval, error) {
+	approval, err := s.fetchDecidableApproval(ctx, approvalId, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Reason == "" {
+		return nil, errs.NewValidationError("Invalid approval decision request", "", map[string]string{"reason": "required"})
+	}
+	s.decide(ctx, approval, model.ApprovalStatusRejected, req)
+	if err := s.repos.Approval.DecideApproval(ctx, approval, model.ApprovalStatusPending); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// fetchDecidableApproval fetches a pending approval the actor may decide on, which excludes approvals they requested themselves
+func (s *approvalService) fetchDecidableApproval(ctx context.Context, approvalId string, req *ApprovalDecisionRequest) (*model.Approval, error) {
+	if err := authorize(ctx, s.repos, api.PermissionApprovalDecide); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid approval decision request", logger.Field("fields", fields), logger.Field("request", req))
+		return nil, errs.NewValidationError("Invalid approval decision request", "", fields)
+	}
+	approval, err := s.repos.Approval.FetchApprovalByID(ctx, approvalId)
+	if approval == nil {
+		return nil, errs.NewNotFoundError("Approval not found", "APPROVAL_NOT_FOUND", err)
+	}
+	if approval.Status != model.ApprovalStatusPending {
+		api.GetLogger(ctx).Error("Approval already decided", logger.Field("approvalId", approvalId), logger.Field("status", approval.Status))
+		return nil, errs.NewConflictError("Approval already decided", "APPROVAL_ALREADY_DECIDED", nil)
+	}
+	if approval.MakerActor == api.GetActor(ctx) && approval.MakerID == api.GetActorID(ctx) {
+		api.GetLogger(ctx).Error("Self approval not allowed", logger.Field("approvalId", approvalId), logger.Field("actorId", approval.MakerID))
+		return nil, errs.NewForbiddenError("An approval can't be decided by the actor who requested it", "SELF_APPROVAL_NOT_ALLOWED", nil)
+	}
+	return approval, nil
+}
+
+// decide records the checker and the decision on an approval, naming both maker and checker in the audit remarks
+func (s *approvalService) decide(ctx context.Context, approval *model.Approval, status string, req *ApprovalDecisionRequest) {
+	approval.SetOldRecord(*approval)
+	now := time.Now()
+	checkerActor, checkerId := api.GetActor(ctx), api.GetActorID(ctx)
+	approval.Status = status
+	approval.CheckerActor = &checkerActor
+	approval.CheckerID = &checkerId
+	approval.DecidedAt = &now
+	if req.Reason != "" {
+		approval.Reason = &req.Reason
+	}
+	approval.SetActor(checkerActor, checkerId)
+	approval.SetRemarks(fmt.Sprintf("%s %s requested by %s %s, %s by %s %s", approval.Operation, approval.ID, approval.MakerActor, approval.MakerID, status, checkerActor, checkerId))
+}
+
+// decodeApprovalPayload decodes a payload snapshot back into the request it was taken from
+func decodeApprovalPayload(payload types.JSONB, v interface{}) error {
+	bytes, err := json.Marshal(payload)
+	if err == nil {
+		err = json.Unmarshal(bytes, v)
+	}
+	if err != nil {
+		return errs.NewUnprocessableEntityError("Approval payload is invalid", "APPROVAL_INVALID_PAYLOAD", err)
+	}
+	return nil
+}
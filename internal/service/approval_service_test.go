@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const test_checkerId = "checker-123"
+
+func createCheckerContext(permissions ...string) context.Context {
+	return api.WithPermissions(api.CreateAppContext(context.Background(), api.AppActorAdmin, test_checkerId, test_requestId), permissions...)
+}
+
+func newWalletDeletionApproval(status string) *model.Approval {
+	return &model.Approval{
+		ID:         "approval-123",
+		Operation:  model.ApprovalOperationDeleteWallet,
+		Permission: api.PermissionWalletWrite,
+		Status:     status,
+		Payload:    types.JSONB{"walletId": test_walletId},
+		MakerActor: api.AppActorAdmin,
+		MakerID:    test_adminId,
+	}
+}
+
+func TestApprovalService_Approve(t *testing.T) {
+	testcases := []TestCase[ApprovalService]{
+		{
+			name: "Approving carries out the operation and records the checker",
+			ctx:  createCheckerContext(api.PermissionApprovalDecide, api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusPending), nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(gomock.Any(), test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
+				mocks.walletRepo.EXPECT().DeleteWallet(gomock.Any(), gomock.Any()).Return(nil)
+				mocks.approvalRepo.EXPECT().ClaimApproval(ctx, "approval-123").Return(nil)
+				mocks.approvalRepo.EXPECT().DecideApproval(ctx, gomock.Any(), model.ApprovalStatusApproving).DoAndReturn(func(ctx context.Context, approval *model.Approval, from string) error {
+					if approval.Status != model.ApprovalStatusApproved || approval.CheckerID == nil || *approval.CheckerID != test_checkerId || approval.DecidedAt == nil {
+						t.Errorf("expected an approval decided by %s, got %+v", test_checkerId, approval)
+					}
+					if remarks := approval.GetRemarks(); remarks == nil || !strings.Contains(*remarks, test_adminId) || !strings.Contains(*remarks, test_checkerId) {
+						t.Errorf("expected the audit remarks to name both maker and checker, got %v", remarks)
+					}
+					return nil
+				})
+			},
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Approve(ctx, "approval-123", &ApprovalDecisionRequest{})
+			},
+			expectResult: true,
+		},
+		{
+			name: "A failed operation leaves the approval pending",
+			ctx:  createCheckerContext(api.PermissionApprovalDecide, api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusPending), nil)
+				mocks.approvalRepo.EXPECT().ClaimApproval(ctx, "approval-123").Return(nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(gomock.Any(), test_walletId).Return(nil, nil)
+				mocks.approvalRepo.EXPECT().ReleaseApproval(ctx, "approval-123").Return(nil)
+			},
+			expectedError: "WALLET_NOT_FOUND",
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Approve(ctx, "approval-123", &ApprovalDecisionRequest{})
+			},
+			expectResult: false,
+		},
+		{
+			name: "The maker can't approve their own request",
+			ctx:  createBackofficeContext(api.PermissionApprovalDecide, api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusPending), nil)
+			},
+			expectedError: "SELF_APPROVAL_NOT_ALLOWED",
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Approve(ctx, "approval-123", &ApprovalDecisionRequest{})
+			},
+			expectResult: false,
+		},
+		{
+			name: "An approval can only be decided once",
+			ctx:  createCheckerContext(api.PermissionApprovalDecide, api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusRejected), nil)
+			},
+			expectedError: "APPROVAL_ALREADY_DECIDED",
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Approve(ctx, "approval-123", &ApprovalDecisionRequest{})
+			},
+			expectResult: false,
+		},
+		{
+			name: "The checker needs the permission of the operation",
+			ctx:  createCheckerContext(api.PermissionApprovalDecide),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusPending), nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Approve(ctx, "approval-123", &ApprovalDecisionRequest{})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ApprovalService {
+		services := &Services{Wallet: NewWalletService(mocks.repos)}
+		return NewApprovalService(mocks.repos, services)
+	}
+	RunTestCases[ApprovalService](t, serviceFactory, testcases)
+}
+
+func TestApprovalService_ApproveConcurrently(t *testing.T) {
+	ctrl, mocks, service := SetupTest(t, func(mocks *Mocks) ApprovalService {
+		return NewApprovalService(mocks.repos, &Services{Wallet: NewWalletService(mocks.repos)})
+	})
+	defer ctrl.Finish()
+	ctx := createCheckerContext(api.PermissionApprovalDecide, api.PermissionWalletWrite)
+	// Both checkers read the approval as pending, while the database lets a single one of them claim it
+	var claimed atomic.Bool
+	mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").DoAndReturn(func(ctx context.Context, approvalId string) (*model.Approval, error) {
+		return newWalletDeletionApproval(model.ApprovalStatusPending), nil
+	}).Times(2)
+	mocks.approvalRepo.EXPECT().ClaimApproval(ctx, "approval-123").DoAndReturn(func(ctx context.Context, approvalId string) error {
+		if !claimed.CompareAndSwap(false, true) {
+			return errs.NewConflictError("Approval already decided", "APPROVAL_ALREADY_DECIDED", nil)
+		}
+		return nil
+	}).Times(2)
+	mocks.walletRepo.EXPECT().FetchWalletByID(gomock.Any(), test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
+	mocks.walletRepo.EXPECT().DeleteWallet(gomock.Any(), gomock.Any()).Return(nil)
+	mocks.approvalRepo.EXPECT().DecideApproval(ctx, gomock.Any(), model.ApprovalStatusApproving).Return(nil)
+
+	var wg sync.WaitGroup
+	results := make([]error, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, results[i] = service.Approve(ctx, "approval-123", &ApprovalDecisionRequest{})
+		}(i)
+	}
+	wg.Wait()
+	approved, conflicts := 0, 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			approved++
+		case errs.HandleError(err).Code == "APPROVAL_ALREADY_DECIDED":
+			conflicts++
+		default:
+			t.Errorf("expected the approval to be carried out or already decided, got %v", err)
+		}
+	}
+	if approved != 1 || conflicts != 1 {
+		t.Errorf("expected the operation to be carried out once, got %d approvals and %d conflicts", approved, conflicts)
+	}
+}
+
+func TestApprovalService_Reject(t *testing.T) {
+	testcases := []TestCase[ApprovalService]{
+		{
+			name: "Rejecting records the checker and the reason",
+			ctx:  createCheckerContext(api.PermissionApprovalDecide),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusPending), nil)
+				mocks.approvalRepo.EXPECT().DecideApproval(ctx, gomock.Any(), model.ApprovalStatusPending).DoAndReturn(func(ctx context.Context, approval *model.Approval, from string) error {
+					if approval.Status != model.ApprovalStatusRejected || approval.CheckerID == nil || *approval.CheckerID != test_checkerId || approval.Reason == nil {
+						t.Errorf("expected an approval rejected by %s with a reason, got %+v", test_checkerId, approval)
+					}
+					return nil
+				})
+			},
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Reject(ctx, "approval-123", &ApprovalDecisionRequest{Reason: "Wallet still in use"})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Rejecting requires a reason",
+			ctx:  createCheckerContext(api.PermissionApprovalDecide),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.approvalRepo.EXPECT().FetchApprovalByID(ctx, "approval-123").Return(newWalletDeletionApproval(model.ApprovalStatusPending), nil)
+			},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ApprovalService, ctx context.Context) (interface{}, error) {
+				return service.Reject(ctx, "approval-123", &ApprovalDecisionRequest{})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ApprovalService {
+		return NewApprovalService(mocks.repos, &Services{})
+	}
+	RunTestCases[ApprovalService](t, serviceFactory, testcases)
+}
@@ -25,8 +25,7 @@ func NewAuditService(repos *repository.Repos) AuditService {
 }
 
 func (s *auditService) GetTableAuditLogs(ctx context.Context, tableName string, page int, limit int) (*api.List[model.Audit], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionAuditRead); err != nil {
 		return nil, err
 	}
 	audits, err := s.repos.Audit.FetchTableAuditLogs(ctx, tableName, page, limit)
@@ -41,8 +40,7 @@ func (s *auditService) GetTableAuditLogs(ctx context.Context, tableName string,
 }
 
 func (s *auditService) GetRecordAuditLogs(ctx context.Context, tableName, recordId string, page int, limit int) (*api.List[model.Audit], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionAuditRead); err != nil {
 		return nil, err
 	}
 	audits, err := s.repos.Audit.FetchRecordAuditLogs(ctx, tableName, recordId, page, limit)
@@ -57,8 +55,7 @@ func (s *auditService) GetRecordAuditLogs(ctx context.Context, tableName, record
 }
 
 func (s *auditService) GetActorAuditLogs(ctx context.Context, actor, actorId string, page int, limit int) (*api.List[model.Audit], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionAuditRead); err != nil {
 		return nil, err
 	}
 	audits, err := s.repos.Audit.FetchActorAuditLogs(ctx, actor, actorId, page, limit)
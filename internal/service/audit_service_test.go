@@ -11,7 +11,7 @@ func TestAuditService_GetActorAuditLogs(t *testing.T) {
 	testcases := []TestCase[AuditService]{
 		{
 			name: "success case",
-			ctx:  api.CreateAppContext(context.Background(), api.AppActorAdmin, test_userId, test_requestId),
+			ctx:  createBackofficeContext(api.PermissionAuditRead),
 			setupMocks: func(mocks *Mocks, ctx context.Context) {
 				mocks.auditRepo.EXPECT().FetchActorAuditLogs(ctx, api.AppActorAdmin, test_userId, 1, 10).Return([]model.Audit{
 					{
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+)
+
+// authorize ensures the actor holds a permission, auditing the attempt when it doesn't
+func authorize(ctx context.Context, repos *repository.Repos, permission string) error {
+	return auditDenied(ctx, repos, permission, api.HasPermission(ctx, permission))
+}
+
+// authorizeGrant ensures an actor only grants the permissions it holds itself, auditing the first one it doesn't hold
+func authorizeGrant(ctx context.Context, repos *repository.Repos, permissions []string) error {
+	for _, permission := range permissions {
+		if err := authorize(ctx, repos, permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizeOwner ensures the actor either owns the record or holds a permission, auditing the attempt when neither holds
+func authorizeOwner(ctx context.Context, repos *repository.Repos, recordOwner, permission string) error {
+	return auditDenied(ctx, repos, permission, api.IsAuthorizedUser(ctx, recordOwner, permission))
+}
+
+// authorizeWallet ensures an actor restricted to a set of wallets can use the wallet, auditing the attempt when it can't
+func authorizeWallet(ctx context.Context, repos *repository.Repos, walletId string) error {
+	return auditDenied(ctx, repos, "wallet:"+walletId, api.HasWalletAccess(ctx, walletId))
+}
+
+// auditDenied records a denied attempt in the audit log and returns the denial
+func auditDenied(ctx context.Context, repos *repository.Repos, permission string, err error) error {
+	if err == nil {
+		return nil
+	}
+	api.GetLogger(ctx).Error("Permission denied", logger.Field("permission", permission))
+	remarks := fmt.Sprintf("Permission %s denied", permission)
+	audit := &model.Audit{
+		Operation: model.AuditOperationAccessDenied,
+		Table:     "permissions",
+		RecordID:  permission,
+		Actor:     api.GetActor(ctx),
+		ActorID:   api.GetActorID(ctx),
+		Remarks:   &remarks,
+	}
+	if auditErr := repos.Audit.CreateAudit(ctx, audit); auditErr != nil {
+		api.GetLogger(ctx).Error("Unable to audit denied attempt", logger.Field("error", auditErr))
+	}
+	return err
+}
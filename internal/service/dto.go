@@ -2,6 +2,7 @@ package service
 
 import (
 	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	rule_engine "github.com/abdelrahman146/digital-wallet/pkg/rules_engine"
 	"github.com/abdelrahman146/digital-wallet/pkg/types"
 	"github.com/shopspring/decimal"
@@ -9,27 +10,54 @@ import (
 )
 
 type CreateProgramRequest struct {
-	Name         string           `json:"name,omitempty" validate:"required,min=1,max=100"`
-	WalletID     string           `json:"walletId,omitempty" validate:"required"`
-	TriggerSlug  string           `json:"triggerSlug,omitempty" validate:"required"`
-	Condition    rule_engine.Rule `json:"condition,omitempty" validate:"required,json"`
-	Effect       types.JSONB      `json:"effect,omitempty" validate:"required,json"`
-	ValidFrom    time.Time        `json:"validFrom,omitempty" validate:"required"`
-	ValidUntil   *time.Time       `json:"validUntil,omitempty"`
-	IsActive     bool             `json:"isActive,omitempty"`
-	LimitPerUser *uint64          `json:"limitPerUser,omitempty"`
+	Name                string                 `json:"name,omitempty" validate:"required,min=1,max=100"`
+	WalletID            string                 `json:"walletId,omitempty" validate:"required"`
+	TriggerSlug         string                 `json:"triggerSlug,omitempty" validate:"required"`
+	Condition           rule_engine.Rule       `json:"condition,omitempty" validate:"required_without=ConditionExpression"`
+	ConditionExpression *string                `json:"conditionExpression,omitempty" validate:"omitempty,min=1"`
+	Effect              types.JSONB            `json:"effect,omitempty" validate:"required,json"`
+	ValidFrom           time.Time              `json:"validFrom,omitempty" validate:"required"`
+	ValidUntil          *time.Time             `json:"validUntil,omitempty"`
+	IsActive            bool                   `json:"isActive,omitempty"`
+	LimitPerUser        *uint64                `json:"limitPerUser,omitempty"`
+	Priority            int                    `json:"priority,omitempty"`
+	ExclusiveGroup      *string                `json:"exclusiveGroup,omitempty" validate:"omitempty,slug"`
+	StopProcessing      bool                   `json:"stopProcessing,omitempty"`
+	MaxRewardPerEvent   *uint64                `json:"maxRewardPerEvent,omitempty" validate:"omitempty,gt=0"`
+	Schedule            *model.ProgramSchedule `json:"schedule,omitempty"`
+	Budget              *model.ProgramBudget   `json:"budget,omitempty"`
 }
 
 type UpdateProgramRequest struct {
-	Name         *string           `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
-	WalletID     *string           `json:"walletId,omitempty" validate:"omitempty"`
-	TriggerSlug  *string           `json:"triggerSlug,omitempty" validate:"omitempty"`
-	Condition    *rule_engine.Rule `json:"condition,omitempty" validate:"omitempty,json"`
-	Effect       *types.JSONB      `json:"effect,omitempty" validate:"omitempty,json"`
-	ValidFrom    *time.Time        `json:"validFrom,omitempty" validate:"omitempty"`
-	ValidUntil   *time.Time        `json:"validUntil,omitempty" validate:"omitempty"`
-	IsActive     *bool             `json:"isActive,omitempty"`
-	LimitPerUser *uint64           `json:"limitPerUser,omitempty"`
+	Name                *string                `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	WalletID            *string                `json:"walletId,omitempty" validate:"omitempty"`
+	TriggerSlug         *string                `json:"triggerSlug,omitempty" validate:"omitempty"`
+	Condition           *rule_engine.Rule      `json:"condition,omitempty" validate:"omitempty,json"`
+	ConditionExpression *string                `json:"conditionExpression,omitempty" validate:"omitempty,min=1"`
+	Effect              *types.JSONB           `json:"effect,omitempty" validate:"omitempty,json"`
+	ValidFrom           *time.Time             `json:"validFrom,omitempty" validate:"omitempty"`
+	ValidUntil          *time.Time             `json:"validUntil,omitempty" validate:"omitempty"`
+	IsActive            *bool                  `json:"isActive,omitempty"`
+	LimitPerUser        *uint64                `json:"limitPerUser,omitempty"`
+	Priority            *int                   `json:"priority,omitempty"`
+	ExclusiveGroup      *string                `json:"exclusiveGroup,omitempty" validate:"omitempty"`
+	StopProcessing      *bool                  `json:"stopProcessing,omitempty"`
+	MaxRewardPerEvent   *uint64                `json:"maxRewardPerEvent,omitempty"`
+	Schedule            *model.ProgramSchedule `json:"schedule,omitempty"`
+	// Budget replaces the budget of the program at once, as budgets are not versioned. A budget of 0 points removes it
+	Budget *model.ProgramBudget `json:"budget,omitempty"`
+}
+
+type RollbackProgramRequest struct {
+	Version uint64 `json:"version,omitempty" validate:"required,gt=0"`
+}
+
+// SimulateProgramRequest is a trigger fired for a user to simulate a program with. Version selects a published version
+// of the program, which defaults to the draft of the program or its current version when it has no draft
+type SimulateProgramRequest struct {
+	UserID  string                 `json:"userId,omitempty" validate:"required"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+	Version *uint64                `json:"version,omitempty" validate:"omitempty,gt=0"`
 }
 
 type CreateTriggerRequest struct {
@@ -47,13 +75,25 @@ type UpdateTriggerRequest struct {
 type ExchangeResponse struct {
 	FromTransaction model.Transaction `json:"fromTransaction"`
 	ToTransaction   model.Transaction `json:"toTransaction"`
+	// Route lists the wallets an exchange was routed through, when it needed more than one leg
+	Route []string `json:"route,omitempty"`
+	// Legs holds the transactions of every leg, when the exchange needed more than one leg
+	Legs []ExchangeResponse `json:"legs,omitempty"`
 }
 
 type CreateExchangeRateRequest struct {
-	FromWalletID string          `json:"fromWalletId,omitempty" validate:"required"`
-	ToWalletID   string          `json:"toWalletId,omitempty" validate:"required"`
-	TierID       *string         `json:"tierId,omitempty"`
-	ExchangeRate decimal.Decimal `json:"exchangeRate,omitempty" validate:"required"`
+	FromWalletID  string          `json:"fromWalletId,omitempty" validate:"required"`
+	ToWalletID    string          `json:"toWalletId,omitempty" validate:"required"`
+	TierID        *string         `json:"tierId,omitempty"`
+	ExchangeRate  decimal.Decimal `json:"exchangeRate,omitempty" validate:"required"`
+	MinimumAmount *uint64         `json:"minimumAmount,omitempty"`
+	ValidFrom     *time.Time      `json:"validFrom,omitempty"`
+}
+
+type UpdateExchangeRateRequest struct {
+	ExchangeRate  decimal.Decimal `json:"exchangeRate,omitempty" validate:"required"`
+	MinimumAmount *uint64         `json:"minimumAmount,omitempty"`
+	ValidFrom     *time.Time      `json:"validFrom,omitempty"`
 }
 
 type CreateUserRequest struct {
@@ -66,25 +106,51 @@ type CreateTierRequest struct {
 	Name string `json:"name,omitempty" validate:"required,min=1,max=100"`
 }
 
+type SetTierPolicyRequest struct {
+	MaxBalance *uint64 `json:"maxBalance,omitempty"`
+	// @swaggertype number
+	EarnMultiplier       *decimal.Decimal `json:"earnMultiplier,omitempty"`
+	MaxTransactionAmount *uint64          `json:"maxTransactionAmount,omitempty"`
+	DailyDebitLimit      *uint64          `json:"dailyDebitLimit,omitempty"`
+}
+
+type SetTierRuleRequest struct {
+	Rank      int    `json:"rank" validate:"gte=0"`
+	WalletID  string `json:"walletId,omitempty" validate:"required"`
+	Metric    string `json:"metric,omitempty" validate:"required,oneof=EARNED SPENT"`
+	Threshold uint64 `json:"threshold,omitempty" validate:"required"`
+	// Window, RetentionPeriod and GracePeriod are in milliseconds
+	Window          int64  `json:"window,omitempty" validate:"required,gt=0"`
+	RetentionPeriod *int64 `json:"retentionPeriod,omitempty" validate:"omitempty,gt=0"`
+	GracePeriod     *int64 `json:"gracePeriod,omitempty" validate:"omitempty,gt=0"`
+	IsActive        *bool  `json:"isActive,omitempty"`
+}
+
 type CreateWalletRequest struct {
-	ID                string  `json:"id,omitempty" validate:"required"`
-	Name              string  `json:"name,omitempty" validate:"required,min=1,max=100"`
-	Description       *string `json:"description,omitempty" validate:"max=255"`
-	Currency          string  `json:"currency,omitempty" validate:"required,min=1,max=4"`
-	IsMonetary        bool    `json:"isMonetary,omitempty"`
-	PointsExpireAfter *int    `json:"pointsExpireAfter,omitempty"`
-	LimitPerUser      *uint64 `json:"limitPerUser,omitempty"`
-	LimitGlobal       *uint64 `json:"limitGlobal,omitempty"`
+	ID                  string   `json:"id,omitempty" validate:"required"`
+	Name                string   `json:"name,omitempty" validate:"required,min=1,max=100"`
+	Description         *string  `json:"description,omitempty" validate:"max=255"`
+	Currency            string   `json:"currency,omitempty" validate:"required,min=1,max=4"`
+	IsMonetary          bool     `json:"isMonetary,omitempty"`
+	PointsExpireAfter   *int     `json:"pointsExpireAfter,omitempty"`
+	LimitPerUser        *uint64  `json:"limitPerUser,omitempty"`
+	LimitGlobal         *uint64  `json:"limitGlobal,omitempty"`
+	ApprovalThreshold   *uint64  `json:"approvalThreshold,omitempty"`
+	ConsumptionStrategy string   `json:"consumptionStrategy,omitempty" validate:"omitempty,oneof=FIFO EXPIRING_FIRST LIFO PRIORITY"`
+	ConsumptionPriority []string `json:"consumptionPriority,omitempty" validate:"required_if=ConsumptionStrategy PRIORITY,omitempty,dive,oneof=REWARD DEPOSIT EXCHANGE|startswith=program:"`
 }
 
 type UpdateWalletRequest struct {
-	Name              string  `json:"name,omitempty" validate:"required,min=1,max=100"`
-	Description       *string `json:"description,omitempty" validate:"max=255"`
-	Currency          string  `json:"currency,omitempty" validate:"required"`
-	IsMonetary        *bool   `json:"isMonetary,omitempty"`
-	PointsExpireAfter *int64  `json:"pointsExpireAfter,omitempty"`
-	LimitPerUser      *uint64 `json:"limitPerUser,omitempty"`
-	LimitGlobal       *uint64 `json:"limitGlobal,omitempty"`
+	Name                string   `json:"name,omitempty" validate:"required,min=1,max=100"`
+	Description         *string  `json:"description,omitempty" validate:"max=255"`
+	Currency            string   `json:"currency,omitempty" validate:"required"`
+	IsMonetary          *bool    `json:"isMonetary,omitempty"`
+	PointsExpireAfter   *int64   `json:"pointsExpireAfter,omitempty"`
+	LimitPerUser        *uint64  `json:"limitPerUser,omitempty"`
+	LimitGlobal         *uint64  `json:"limitGlobal,omitempty"`
+	ApprovalThreshold   *uint64  `json:"approvalThreshold,omitempty"`
+	ConsumptionStrategy string   `json:"consumptionStrategy,omitempty" validate:"omitempty,oneof=FIFO EXPIRING_FIRST LIFO PRIORITY"`
+	ConsumptionPriority []string `json:"consumptionPriority,omitempty" validate:"required_if=ConsumptionStrategy PRIORITY,omitempty,dive,oneof=REWARD DEPOSIT EXCHANGE|startswith=program:"`
 }
 
 type TransactionRequest struct {
@@ -94,3 +160,225 @@ type TransactionRequest struct {
 	Metadata  types.JSONB `json:"metadata,omitempty"`
 	ProgramID *string     `json:"programId,omitempty" validate:"omitempty"`
 }
+
+// TransactionFilterRequest filters a transaction listing from the query string. Dates are RFC 3339 and Cursor is the
+// nextCursor of the previous page
+type TransactionFilterRequest struct {
+	Type          string  `query:"type" validate:"omitempty,oneof=CREDIT DEBIT"`
+	Reason        string  `query:"reason" validate:"omitempty,oneof=REWARD PURCHASE REDEEM PENALTY EXPIRED EXCHANGE WITHDRAWAL DEPOSIT"`
+	ProgramID     *string `query:"programId" validate:"omitempty,numeric"`
+	MinAmount     *uint64 `query:"minAmount"`
+	MaxAmount     *uint64 `query:"maxAmount"`
+	From          string  `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To            string  `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	MetadataKey   string  `query:"metadataKey" validate:"required_with=MetadataValue,max=100"`
+	MetadataValue string  `query:"metadataValue" validate:"max=255"`
+	Cursor        string  `query:"cursor"`
+}
+
+// StatementRequest selects the period of an account statement, in RFC 3339, and the format it is exported in
+type StatementRequest struct {
+	From   string `query:"from" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	To     string `query:"to" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	Format string `query:"format" validate:"omitempty,oneof=json csv pdf"`
+}
+
+// AccountStatement summarizes the transactions of an account over a period, from its opening to its closing balance
+type AccountStatement struct {
+	AccountID      string                   `json:"accountId"`
+	WalletID       string                   `json:"walletId"`
+	UserID         string                   `json:"userId"`
+	Currency       string                   `json:"currency"`
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	OpeningBalance uint64                   `json:"openingBalance"`
+	ClosingBalance uint64                   `json:"closingBalance"`
+	TotalCredited  uint64                   `json:"totalCredited"`
+	TotalDebited   uint64                   `json:"totalDebited"`
+	Expired        uint64                   `json:"expired"`
+	Totals         []repository.ReasonTotal `json:"totals"`
+	Transactions   []StatementLine          `json:"transactions,omitempty"` // streamed by the json statement writer
+	Format         string                   `json:"-"`
+	// Archived is set on pdf statements too long for one document, which are split into several pdf documents in a zip archive
+	Archived bool `json:"-"`
+}
+
+// StatementLine is a transaction of an account statement along with the balance of the account after it
+type StatementLine struct {
+	ID             string    `json:"id"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	ProgramID      *string   `json:"programId,omitempty"`
+	Amount         uint64    `json:"amount"`
+	RunningBalance uint64    `json:"runningBalance"`
+}
+
+// AccountBalance is the balance of an account at a point in time, as left by the last transaction made up to it
+type AccountBalance struct {
+	AccountID     string    `json:"accountId"`
+	WalletID      string    `json:"walletId"`
+	At            time.Time `json:"at"`
+	Balance       uint64    `json:"balance"`
+	TransactionID *string   `json:"transactionId,omitempty"`
+}
+
+// CreditLot is a credit of an account that has not been fully consumed yet, along with what is left of it
+type CreditLot struct {
+	TransactionID   string     `json:"transactionId"`
+	Reason          string     `json:"reason"`
+	ProgramID       *string    `json:"programId,omitempty"`
+	Amount          uint64     `json:"amount"`
+	ConsumedAmount  uint64     `json:"consumedAmount"`
+	AvailableAmount uint64     `json:"availableAmount"`
+	ExpireAt        *time.Time `json:"expireAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// LiabilityReportRequest selects the months of a liability report, from and to being inclusive months as in 2026-01
+type LiabilityReportRequest struct {
+	From             string `query:"from" validate:"required,datetime=2006-01"`
+	To               string `query:"to" validate:"required,datetime=2006-01"`
+	MonetaryWalletID string `query:"monetaryWalletId"`
+	Format           string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// LiabilityReport is the monthly movement of the outstanding points of a wallet, valued in a monetary wallet when one is given
+type LiabilityReport struct {
+	WalletID         string                 `json:"walletId"`
+	Currency         string                 `json:"currency"`
+	MonetaryWalletID *string                `json:"monetaryWalletId,omitempty"`
+	MonetaryCurrency *string                `json:"monetaryCurrency,omitempty"`
+	From             string                 `json:"from"`
+	To               string                 `json:"to"`
+	OpeningLiability uint64                 `json:"openingLiability"`
+	ClosingLiability uint64                 `json:"closingLiability"`
+	TotalIssued      uint64                 `json:"totalIssued"`
+	TotalRedeemed    uint64                 `json:"totalRedeemed"`
+	TotalExpired     uint64                 `json:"totalExpired"`
+	TotalOtherDebits uint64                 `json:"totalOtherDebits"`
+	Months           []LiabilityReportMonth `json:"months"`
+	Format           string                 `json:"-"`
+}
+
+// LiabilityReportMonth is the movement of the outstanding points of a wallet over a month. Expired points are the breakage,
+// and the other debits the points withdrawn, exchanged to other wallets or taken as penalties
+type LiabilityReportMonth struct {
+	Month            string `json:"month"`
+	OpeningLiability uint64 `json:"openingLiability"`
+	Issued           uint64 `json:"issued"`
+	Redeemed         uint64 `json:"redeemed"`
+	Expired          uint64 `json:"expired"`
+	OtherDebits      uint64 `json:"otherDebits"`
+	ClosingLiability uint64 `json:"closingLiability"`
+	// @swaggertype number
+	ExchangeRate *decimal.Decimal `json:"exchangeRate,omitempty"`
+	// @swaggertype number
+	MonetaryValue *decimal.Decimal `json:"monetaryValue,omitempty"`
+}
+
+// WalletLiability is the sum of the balances of the accounts of a wallet at a point in time
+type WalletLiability struct {
+	WalletID   string     `json:"walletId"`
+	At         time.Time  `json:"at"`
+	Liability  uint64     `json:"liability"`
+	SnapshotAt *time.Time `json:"snapshotAt,omitempty"`
+}
+
+type AccountStatusRequest struct {
+	Reason string `json:"reason,omitempty" validate:"required,min=1,max=255"`
+}
+
+type CreateRoleRequest struct {
+	ID          string   `json:"id,omitempty" validate:"required,slug,max=50"`
+	Name        string   `json:"name,omitempty" validate:"required,min=1,max=100"`
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions,omitempty" validate:"required,min=1"`
+}
+
+type UpdateRoleRequest struct {
+	Name        *string   `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=255"`
+	Permissions *[]string `json:"permissions,omitempty" validate:"omitempty,min=1"`
+}
+
+type ApprovalDecisionRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=255"`
+}
+
+type CreateApiKeyRequest struct {
+	Name        string   `json:"name,omitempty" validate:"required,min=1,max=100"`
+	WalletIDs   []string `json:"walletIds,omitempty" validate:"required,min=1"`
+	Permissions []string `json:"permissions,omitempty" validate:"required,min=1"`
+	RateLimit   int      `json:"rateLimit,omitempty" validate:"required,gt=0"`
+}
+
+type UpdateApiKeyRequest struct {
+	Name        *string   `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	WalletIDs   *[]string `json:"walletIds,omitempty" validate:"omitempty,min=1"`
+	Permissions *[]string `json:"permissions,omitempty" validate:"omitempty,min=1"`
+	RateLimit   *int      `json:"rateLimit,omitempty" validate:"omitempty,gt=0"`
+	IsActive    *bool     `json:"isActive,omitempty"`
+}
+
+// ApiKeyResponse holds an API key along with the key itself, which is only returned when it is created or rotated
+type ApiKeyResponse struct {
+	*model.ApiKey
+	Key string `json:"key"`
+}
+
+// InvocationResult lists the programs a fired trigger ran, in priority order, with the ones that fired and the ones
+// that were suppressed along with the reason
+type InvocationResult struct {
+	Fired      []ProgramOutcome `json:"fired"`
+	Suppressed []ProgramOutcome `json:"suppressed"`
+}
+
+// ProgramOutcome is what a program did for a fired trigger
+type ProgramOutcome struct {
+	ProgramID uint64 `json:"programId"`
+	Name      string `json:"name"`
+	Version   uint64 `json:"version"`
+	// Reward is the amount credited by a program that fired, after the tier multiplier and its cap per event
+	Reward uint64 `json:"reward,omitempty"`
+	Capped bool   `json:"capped,omitempty"`
+	// Partial tells the reward was reduced to what remained of the program budget
+	Partial       bool   `json:"partial,omitempty"`
+	TransactionID string `json:"transactionId,omitempty"`
+	// Reason tells why a program was suppressed, with the details in Message
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	SuppressionReasonOutsideSchedule    = "OUTSIDE_SCHEDULE"
+	SuppressionReasonConditionNotMet    = "CONDITION_NOT_MET"
+	SuppressionReasonInvalidRule        = "INVALID_CONDITION"
+	SuppressionReasonExclusiveGroup     = "EXCLUSIVE_GROUP"
+	SuppressionReasonStopped            = "STOPPED"
+	SuppressionReasonEffectFailed       = "EFFECT_FAILED"
+	SuppressionReasonRewardFailed       = "REWARD_FAILED"
+	SuppressionReasonBudgetExhausted    = "BUDGET_EXHAUSTED"
+	SuppressionReasonBudgetInsufficient = "BUDGET_INSUFFICIENT"
+	SuppressionReasonWalletNotInScope   = "WALLET_NOT_IN_SCOPE"
+)
+
+// SimulationResult is what a version or the draft of a program would do for a trigger, with the reward it would
+// credit or the reason it would not fire
+type SimulationResult struct {
+	ProgramID uint64 `json:"programId"`
+	// Version is the simulated version, which is 0 when the draft is simulated
+	Version uint64 `json:"version,omitempty"`
+	Draft   bool   `json:"draft"`
+	Fired   bool   `json:"fired"`
+	Reward  uint64 `json:"reward,omitempty"`
+	Capped  bool   `json:"capped,omitempty"`
+	Partial bool   `json:"partial,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type FireTriggerRequest struct {
+	UserID string                 `json:"userId,omitempty" validate:"required"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
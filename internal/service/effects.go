@@ -9,51 +9,59 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/utils"
 )
 
-func ApplyEffect(ctx context.Context, program model.Program, data map[string]interface{}) error {
+// ApplyEffect evaluates the effect of a program and returns the amount to reward, which is zero for effects that don't reward points
+func ApplyEffect(ctx context.Context, program model.Program, data map[string]interface{}) (uint64, error) {
 	switch program.Effect["type"] {
 	case "FIXED":
 		return EvaluateFixedEffect(ctx, program, data)
 	case "FORMULA":
 		return EvaluateFormulaEffect(ctx, program, data)
 	case "PROMOTE":
-		return EvaluateTierEffect(ctx, program, data)
+		return 0, EvaluateTierEffect(ctx, program, data)
 	case "CALL":
-		return EvaluateCallEffect(ctx, program, data)
+		return 0, EvaluateCallEffect(ctx, program, data)
 	default:
-		return errs.NewUnprocessableEntityError("Program has Invalid Effect Type", "PROGRAM_INVALID_EFFECT_TYPE", nil)
+		return 0, errs.NewUnprocessableEntityError("Program has Invalid Effect Type", "PROGRAM_INVALID_EFFECT_TYPE", nil)
 	}
 }
 
-func EvaluateFixedEffect(ctx context.Context, program model.Program, data map[string]interface{}) error {
-	return nil
+func EvaluateFixedEffect(ctx context.Context, program model.Program, data map[string]interface{}) (uint64, error) {
+	amount, ok := toRewardAmount(program.Effect["amount"])
+	if !ok {
+		return 0, errs.NewUnprocessableEntityError("Program type is 'FIXED' but doesn't have a valid amount", "PROGRAM_INVALID_EFFECT_AMOUNT", nil)
+	}
+	return amount, nil
 }
 
-func EvaluateFormulaEffect(ctx context.Context, program model.Program, data map[string]interface{}) error {
+func EvaluateFormulaEffect(ctx context.Context, program model.Program, data map[string]interface{}) (uint64, error) {
 	formula, ok := program.Effect["formula"].(string)
 	if !ok || formula == "" {
-		return errs.NewUnprocessableEntityError("Program type is 'FORMULA' but doesn't have a formula", "PROGRAM_INVALID_EFFECT_FORMULA", nil)
+		return 0, errs.NewUnprocessableEntityError("Program type is 'FORMULA' but doesn't have a formula", "PROGRAM_INVALID_EFFECT_FORMULA", nil)
 	}
-	params, ok := program.Effect["parameters"].([]string)
+	params, ok := toStringSlice(program.Effect["parameters"])
 	if !ok || params == nil {
-		return errs.NewUnprocessableEntityError("Program type is 'FORMULA' but doesn't have parameters", "PROGRAM_INVALID_EFFECT_PARAMETERS", nil)
+		return 0, errs.NewUnprocessableEntityError("Program type is 'FORMULA' but doesn't have parameters", "PROGRAM_INVALID_EFFECT_PARAMETERS", nil)
 	}
 	paramValues := make(map[string]interface{}, len(params))
 	for _, param := range params {
 		paramValues[param], ok = utils.GetField(data, param)
 		if !ok {
-			return errs.NewUnprocessableEntityError(fmt.Sprintf("Invalid Pramater: %s", param), "PROGRAM_INVALID_EFFECT_PRAMATER_VALUE", nil)
+			return 0, errs.NewUnprocessableEntityError(fmt.Sprintf("Invalid Pramater: %s", param), "PROGRAM_INVALID_EFFECT_PRAMATER_VALUE", nil)
 		}
 	}
 	exp, err := govaluate.NewEvaluableExpression(formula)
 	if err != nil {
-		return errs.NewUnprocessableEntityError("Invalid Formula: "+formula, "PROGRAM_INVALID_EFFECT_FORMULA", err)
+		return 0, errs.NewUnprocessableEntityError("Invalid Formula: "+formula, "PROGRAM_INVALID_EFFECT_FORMULA", err)
 	}
-	_, err = exp.Evaluate(paramValues)
+	result, err := exp.Evaluate(paramValues)
 	if err != nil {
-		return errs.NewUnprocessableEntityError(fmt.Sprintf("Unable to evaluate formula: %s and params %v", formula, params), "PROGRAM_INVALID_EFFECT_FORMULA", err)
+		return 0, errs.NewUnprocessableEntityError(fmt.Sprintf("Unable to evaluate formula: %s and params %v", formula, params), "PROGRAM_INVALID_EFFECT_FORMULA", err)
 	}
-	// TODO: continue
-	return nil
+	amount, ok := toRewardAmount(result)
+	if !ok {
+		return 0, errs.NewUnprocessableEntityError(fmt.Sprintf("Formula %s did not evaluate to a valid amount: %v", formula, result), "PROGRAM_INVALID_EFFECT_FORMULA", nil)
+	}
+	return amount, nil
 }
 
 func EvaluateCallEffect(ctx context.Context, program model.Program, data map[string]interface{}) error {
@@ -63,3 +71,43 @@ func EvaluateCallEffect(ctx context.Context, program model.Program, data map[str
 func EvaluateTierEffect(ctx context.Context, program model.Program, data map[string]interface{}) error {
 	return nil
 }
+
+// toRewardAmount converts a numeric effect value to a whole amount of points, truncating any fraction
+func toRewardAmount(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v < 0 {
+			return 0, false
+		}
+		return uint64(v), true
+	case int:
+		if v < 0 {
+			return 0, false
+		}
+		return uint64(v), true
+	case uint64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// toStringSlice accepts both string slices and the generic slices produced by decoding JSON
+func toStringSlice(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items[i] = str
+		}
+		return items, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// exchangeRoute is an ordered list of exchange rates leading from a source wallet to a destination wallet
+type exchangeRoute []model.ExchangeRate
+
+// Wallets returns the IDs of the wallets the route passes through, including the source and destination
+func (r exchangeRoute) Wallets() []string {
+	if len(r) == 0 {
+		return nil
+	}
+	wallets := []string{r[0].FromWalletID}
+	for _, rate := range r {
+		wallets = append(wallets, rate.ToWalletID)
+	}
+	return wallets
+}
+
+// applyExchangeRate converts an amount using an exchange rate, truncating any fraction
+func applyExchangeRate(amount uint64, rate decimal.Decimal) uint64 {
+	return decimal.NewFromUint64(amount).Mul(rate).BigInt().Uint64()
+}
+
+// acceptsAmount reports whether an amount reaches the minimum amount of an exchange rate
+func acceptsAmount(rate model.ExchangeRate, amount uint64) bool {
+	return rate.MinimumAmount == nil || amount >= *rate.MinimumAmount
+}
+
+// findExchangeRoute finds the route through the exchange rate graph that credits the most to the destination wallet
+// for the given amount, using at most maxHops legs and visiting each wallet once. Routes only pass through the
+// intermediate wallets given, those the user holds an account in. Legs below their minimum amount are skipped, and ties
+// are broken in favour of the shorter route. It returns a nil route when the destination is unreachable.
+func findExchangeRoute(rates []model.ExchangeRate, fromWalletId, toWalletId string, amount uint64, maxHops int, intermediates map[string]bool) (exchangeRoute, uint64) {
+	edges := make(map[string][]model.ExchangeRate)
+	for _, rate := range rates {
+		edges[rate.FromWalletID] = append(edges[rate.FromWalletID], rate)
+	}
+
+	var best exchangeRoute
+	var bestAmount uint64
+	var path exchangeRoute
+	visited := map[string]bool{fromWalletId: true}
+
+	var walk func(walletId string, amount uint64)
+	walk = func(walletId string, amount uint64) {
+		if walletId == toWalletId {
+			if best == nil || amount > bestAmount || (amount == bestAmount && len(path) < len(best)) {
+				best = append(exchangeRoute{}, path...)
+				bestAmount = amount
+			}
+			return
+		}
+		if len(path) >= maxHops {
+			return
+		}
+		for _, rate := range edges[walletId] {
+			if visited[rate.ToWalletID] || (rate.ToWalletID != toWalletId && !intermediates[rate.ToWalletID]) {
+				continue
+			}
+			if !acceptsAmount(rate, amount) {
+				continue
+			}
+			converted := applyExchangeRate(amount, rate.ExchangeRate)
+			if converted == 0 {
+				continue
+			}
+			visited[rate.ToWalletID] = true
+			path = append(path, rate)
+			walk(rate.ToWalletID, converted)
+			path = path[:len(path)-1]
+			visited[rate.ToWalletID] = false
+		}
+	}
+	walk(fromWalletId, amount)
+	return best, bestAmount
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/shopspring/decimal"
+	"reflect"
+	"testing"
+)
+
+func TestFindExchangeRoute(t *testing.T) {
+	minimum := uint64(500)
+	rate := func(id uint64, from, to string, value string) model.ExchangeRate {
+		return model.ExchangeRate{ID: id, FromWalletID: from, ToWalletID: to, ExchangeRate: decimal.RequireFromString(value)}
+	}
+	rates := []model.ExchangeRate{
+		rate(1, "loyalty", "store-credit", "0.5"),
+		rate(2, "store-credit", "aed", "0.2"),
+		rate(3, "loyalty", "miles", "2"),
+		rate(4, "miles", "partner", "1"),
+		rate(5, "partner", "aed", "0.1"),
+		rate(6, "aed", "loyalty", "10"),
+	}
+	held := map[string]bool{"loyalty": true, "store-credit": true, "miles": true, "partner": true, "aed": true}
+	testcases := []struct {
+		name           string
+		rates          []model.ExchangeRate
+		from, to       string
+		amount         uint64
+		maxHops        int
+		intermediates  map[string]bool
+		expectedRoute  []string
+		expectedAmount uint64
+	}{
+		{
+			name:           "Picks the route crediting the most",
+			rates:          rates,
+			from:           "loyalty",
+			to:             "aed",
+			amount:         1000,
+			maxHops:        3,
+			expectedRoute:  []string{"loyalty", "miles", "partner", "aed"},
+			expectedAmount: 200,
+		},
+		{
+			name:           "Routes only through wallets the user holds an account in",
+			rates:          rates,
+			from:           "loyalty",
+			to:             "aed",
+			amount:         1000,
+			maxHops:        3,
+			intermediates:  map[string]bool{"store-credit": true, "partner": true},
+			expectedRoute:  []string{"loyalty", "store-credit", "aed"},
+			expectedAmount: 100,
+		},
+		{
+			name:           "Respects the maximum hop count",
+			rates:          rates,
+			from:           "loyalty",
+			to:             "aed",
+			amount:         1000,
+			maxHops:        2,
+			expectedRoute:  []string{"loyalty", "store-credit", "aed"},
+			expectedAmount: 100,
+		},
+		{
+			name: "Prefers the shorter route on ties",
+			rates: []model.ExchangeRate{
+				rate(1, "loyalty", "store-credit", "1"),
+				rate(2, "store-credit", "aed", "1"),
+				rate(3, "loyalty", "aed", "1"),
+			},
+			from:           "loyalty",
+			to:             "aed",
+			amount:         100,
+			maxHops:        3,
+			expectedRoute:  []string{"loyalty", "aed"},
+			expectedAmount: 100,
+		},
+		{
+			name: "Skips legs below their minimum amount",
+			rates: []model.ExchangeRate{
+				rate(1, "loyalty", "store-credit", "0.5"),
+				{ID: 2, FromWalletID: "store-credit", ToWalletID: "aed", ExchangeRate: decimal.NewFromInt(1), MinimumAmount: &minimum},
+			},
+			from:    "loyalty",
+			to:      "aed",
+			amount:  800,
+			maxHops: 3,
+		},
+		{
+			name:    "Unreachable destination",
+			rates:   rates,
+			from:    "aed",
+			to:      "partner",
+			amount:  1000,
+			maxHops: 1,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			intermediates := tc.intermediates
+			if intermediates == nil {
+				intermediates = held
+			}
+			route, amount := findExchangeRoute(tc.rates, tc.from, tc.to, tc.amount, tc.maxHops, intermediates)
+			if !reflect.DeepEqual(route.Wallets(), tc.expectedRoute) {
+				t.Errorf("expected route %v, got %v", tc.expectedRoute, route.Wallets())
+			}
+			if amount != tc.expectedAmount {
+				t.Errorf("expected amount %d, got %d", tc.expectedAmount, amount)
+			}
+		})
+	}
+}
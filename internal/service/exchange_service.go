@@ -2,12 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
 	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
-	"github.com/shopspring/decimal"
+	"time"
 )
 
 type ExchangeRateService interface {
@@ -17,30 +18,42 @@ type ExchangeRateService interface {
 	GetExchangeRates(ctx context.Context, page int, limit int) (*api.List[model.ExchangeRate], error)
 	// GetExchangeRatesByWalletID fetches all exchange rates for a wallet
 	GetExchangeRatesByWalletID(ctx context.Context, walletId string, page int, limit int) (*api.List[model.ExchangeRate], error)
-	// UpdateExchangeRate updates an exchange rate
-	UpdateExchangeRate(ctx context.Context, exchangeRateId string, exchangeRate decimal.Decimal) (*model.ExchangeRate, error)
-	// DeleteExchangeRate deletes an exchange rate
+	// GetExchangeRateVersions fetches the version history of an exchange rate pair
+	GetExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string, page int, limit int) (*api.List[model.ExchangeRate], error)
+	// GetEffectiveExchangeRate fetches the exchange rate version that applied at a given time
+	GetEffectiveExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string, at time.Time) (*model.ExchangeRate, error)
+	// UpdateExchangeRate schedules a new version of an exchange rate
+	UpdateExchangeRate(ctx context.Context, exchangeRateId string, req *UpdateExchangeRateRequest) (*model.ExchangeRate, error)
+	// DeleteExchangeRate closes the active version of an exchange rate, or deletes a version that has not started yet
 	DeleteExchangeRate(ctx context.Context, exchangeRateId string) error
 }
 
 type exchangeRateService struct {
 	repos *repository.Repos
+	// now is the clock versions are closed at and compared with
+	now func() time.Time
 }
 
 func NewExchangeRateService(repos *repository.Repos) ExchangeRateService {
-	return &exchangeRateService{repos: repos}
+	return &exchangeRateService{repos: repos, now: time.Now}
 }
 
 func (s *exchangeRateService) CreateExchangeRate(ctx context.Context, req *CreateExchangeRateRequest) (*model.ExchangeRate, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateWrite); err != nil {
+		return nil, err
+	}
+	if err := requireApproval(ctx, s.repos, model.ApprovalOperationCreateExchangeRate, api.PermissionExchangeRateWrite, req); err != nil {
 		return nil, err
 	}
 	exchangeRate := &model.ExchangeRate{
-		FromWalletID: req.FromWalletID,
-		ToWalletID:   req.ToWalletID,
-		TierID:       req.TierID,
-		ExchangeRate: req.ExchangeRate,
+		FromWalletID:  req.FromWalletID,
+		ToWalletID:    req.ToWalletID,
+		TierID:        req.TierID,
+		ExchangeRate:  req.ExchangeRate,
+		MinimumAmount: req.MinimumAmount,
+	}
+	if req.ValidFrom != nil {
+		exchangeRate.ValidFrom = *req.ValidFrom
 	}
 	exchangeRate.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
 	exchangeRate.SetRemarks("Exchange rate created")
@@ -51,8 +64,7 @@ func (s *exchangeRateService) CreateExchangeRate(ctx context.Context, req *Creat
 }
 
 func (s *exchangeRateService) GetExchangeRates(ctx context.Context, page int, limit int) (*api.List[model.ExchangeRate], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateRead); err != nil {
 		return nil, err
 	}
 	exchangeRates, err := s.repos.ExchangeRate.FetchExchangeRates(ctx, page, limit)
@@ -64,8 +76,7 @@ func (s *exchangeRateService) GetExchangeRates(ctx context.Context, page int, li
 }
 
 func (s *exchangeRateService) GetExchangeRatesByWalletID(ctx context.Context, walletId string, page int, limit int) (*api.List[model.ExchangeRate], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateRead); err != nil {
 		return nil, err
 	}
 	exchangeRates, err := s.repos.ExchangeRate.FetchWalletExchangeRates(ctx, walletId, page, limit)
@@ -76,27 +87,73 @@ func (s *exchangeRateService) GetExchangeRatesByWalletID(ctx context.Context, wa
 	return &api.List[model.ExchangeRate]{Items: exchangeRates, Total: total, Page: page, Limit: limit}, nil
 }
 
-func (s *exchangeRateService) UpdateExchangeRate(ctx context.Context, exchangeRateId string, newRate decimal.Decimal) (*model.ExchangeRate, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+func (s *exchangeRateService) GetExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string, page int, limit int) (*api.List[model.ExchangeRate], error) {
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateRead); err != nil {
 		return nil, err
 	}
-	exchangeRate, err := s.repos.ExchangeRate.FetchExchangeRateByID(ctx, exchangeRateId)
+	exchangeRates, err := s.repos.ExchangeRate.FetchExchangeRateVersions(ctx, fromWalletId, toWalletId, tierId, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repos.ExchangeRate.CountExchangeRateVersions(ctx, fromWalletId, toWalletId, tierId)
 	if err != nil {
 		return nil, err
 	}
+	return &api.List[model.ExchangeRate]{Items: exchangeRates, Total: total, Page: page, Limit: limit}, nil
+}
+
+func (s *exchangeRateService) GetEffectiveExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string, at time.Time) (*model.ExchangeRate, error) {
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateRead); err != nil {
+		return nil, err
+	}
+	exchangeRate, err := s.repos.ExchangeRate.FetchExchangeRate(ctx, fromWalletId, toWalletId, tierId, at)
+	if err != nil {
+		return nil, err
+	}
+	if exchangeRate == nil {
+		api.GetLogger(ctx).Error("Exchange Rate not found", logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", tierId), logger.Field("at", at))
+		return nil, errs.NewNotFoundError("Exchange Rate not found", "EXCHANGE_RATE_NOT_FOUND", err)
+	}
+	return exchangeRate, nil
+}
+
+func (s *exchangeRateService) UpdateExchangeRate(ctx context.Context, exchangeRateId string, req *UpdateExchangeRateRequest) (*model.ExchangeRate, error) {
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateWrite); err != nil {
+		return nil, err
+	}
+	current, err := s.repos.ExchangeRate.FetchExchangeRateByID(ctx, exchangeRateId)
+	if current == nil {
+		api.GetLogger(ctx).Error("Exchange Rate not found", logger.Field("exchangeRateId", exchangeRateId))
+		return nil, errs.NewNotFoundError("Exchange Rate not found", "EXCHANGE_RATE_NOT_FOUND", err)
+	}
+	payload := exchangeRateApprovalPayload{ExchangeRateID: exchangeRateId, Request: req}
+	if err := requireApproval(ctx, s.repos, model.ApprovalOperationUpdateExchangeRate, api.PermissionExchangeRateWrite, payload); err != nil {
+		return nil, err
+	}
+	// Rates are never overwritten, a new version is scheduled for the same pair instead
+	exchangeRate := &model.ExchangeRate{
+		FromWalletID:  current.FromWalletID,
+		ToWalletID:    current.ToWalletID,
+		TierID:        current.TierID,
+		ExchangeRate:  req.ExchangeRate,
+		MinimumAmount: current.MinimumAmount,
+	}
+	if req.MinimumAmount != nil {
+		exchangeRate.MinimumAmount = req.MinimumAmount
+	}
+	if req.ValidFrom != nil {
+		exchangeRate.ValidFrom = *req.ValidFrom
+	}
 	exchangeRate.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
-	exchangeRate.SetRemarks("Exchange rate updated")
-	exchangeRate.SetOldRecord(*exchangeRate)
-	exchangeRate.ExchangeRate = newRate
-	if err := s.repos.ExchangeRate.UpdateExchangeRate(ctx, exchangeRate); err != nil {
+	exchangeRate.SetRemarks(fmt.Sprintf("Exchange rate updated, supersedes version %d", current.ID))
+	if err := s.repos.ExchangeRate.CreateExchangeRate(ctx, exchangeRate); err != nil {
 		return nil, err
 	}
 	return exchangeRate, nil
 }
 
 func (s *exchangeRateService) DeleteExchangeRate(ctx context.Context, exchangeRateId string) error {
-	if err := api.IsAdmin(ctx); err != nil {
+	if err := authorize(ctx, s.repos, api.PermissionExchangeRateWrite); err != nil {
 		return err
 	}
 	exchangeRate, err := s.repos.ExchangeRate.FetchExchangeRateByID(ctx, exchangeRateId)
@@ -104,8 +161,21 @@ func (s *exchangeRateService) DeleteExchangeRate(ctx context.Context, exchangeRa
 		api.GetLogger(ctx).Error("Exchange Rate not found", logger.Field("exchangeRateId", exchangeRateId))
 		return errs.NewNotFoundError("Exchange Rate not found", "EXCHANGE_RATE_NOT_FOUND", err)
 	}
+	payload := exchangeRateApprovalPayload{ExchangeRateID: exchangeRateId}
+	if err := requireApproval(ctx, s.repos, model.ApprovalOperationDeleteExchangeRate, api.PermissionExchangeRateWrite, payload); err != nil {
+		return err
+	}
+	// Versions that applied to transactions are kept as history, only versions that have not started can be removed
+	now := s.now()
+	if !exchangeRate.IsActiveAt(now) && !exchangeRate.ValidFrom.After(now) {
+		return errs.NewConflictError("The exchange rate version is already closed", "EXCHANGE_RATE_VERSION_CLOSED", nil)
+	}
 	exchangeRate.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
-	exchangeRate.SetRemarks("Exchange rate deleted")
-	exchangeRate.SetOldRecord(exchangeRate)
-	return s.repos.ExchangeRate.DeleteExchangeRate(ctx, exchangeRate)
+	exchangeRate.SetOldRecord(*exchangeRate)
+	if exchangeRate.ValidFrom.After(now) {
+		exchangeRate.SetRemarks("Scheduled exchange rate version deleted")
+		return s.repos.ExchangeRate.DeleteExchangeRate(ctx, exchangeRate)
+	}
+	exchangeRate.SetRemarks("Exchange rate version closed")
+	return s.repos.ExchangeRate.CloseExchangeRate(ctx, exchangeRate, now)
 }
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestExchangeRateService_UpdateExchangeRate(t *testing.T) {
+	tierId := "gold"
+	validFrom := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := &model.ExchangeRate{ID: 7, FromWalletID: test_walletId, ToWalletID: "aed", TierID: &tierId, ExchangeRate: decimal.NewFromInt(2)}
+	testcases := []TestCase[ExchangeRateService]{
+		{
+			name: "Success case creates a new version of the same pair",
+			ctx:  withApproval(createBackofficeContext(api.PermissionExchangeRateWrite), &model.Approval{Operation: model.ApprovalOperationUpdateExchangeRate}),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRateByID(ctx, "7").Return(current, nil)
+				mocks.exchangeRateRepo.EXPECT().CreateExchangeRate(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, exchangeRate *model.ExchangeRate) error {
+					if exchangeRate.ID != 0 || exchangeRate.FromWalletID != current.FromWalletID || exchangeRate.ToWalletID != current.ToWalletID || exchangeRate.TierID != current.TierID {
+						t.Errorf("expected a new version of the pair, got %+v", exchangeRate)
+					}
+					if !exchangeRate.ValidFrom.Equal(validFrom) || !exchangeRate.ExchangeRate.Equal(decimal.NewFromInt(3)) {
+						t.Errorf("expected rate 3 valid from %v, got %+v", validFrom, exchangeRate)
+					}
+					return nil
+				})
+			},
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return service.UpdateExchangeRate(ctx, "7", &UpdateExchangeRateRequest{ExchangeRate: decimal.NewFromInt(3), ValidFrom: &validFrom})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Held for approval when changed from the backoffice",
+			ctx:  createBackofficeContext(api.PermissionExchangeRateWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRateByID(ctx, "7").Return(current, nil)
+				mocks.approvalRepo.EXPECT().CreateApproval(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, approval *model.Approval) error {
+					if approval.Operation != model.ApprovalOperationUpdateExchangeRate || approval.Status != model.ApprovalStatusPending || approval.MakerID != test_adminId {
+						t.Errorf("expected a pending exchange rate update requested by %s, got %+v", test_adminId, approval)
+					}
+					if approval.Payload["exchangeRateId"] != "7" {
+						t.Errorf("expected the payload to hold the exchange rate, got %v", approval.Payload)
+					}
+					approval.ID = "approval-123"
+					return nil
+				})
+			},
+			expectedError: "APPROVAL_REQUIRED",
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return service.UpdateExchangeRate(ctx, "7", &UpdateExchangeRateRequest{ExchangeRate: decimal.NewFromInt(3)})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Exchange rate not found",
+			ctx:  createBackofficeContext(api.PermissionExchangeRateWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRateByID(ctx, "7").Return(nil, nil)
+			},
+			expectedError: "EXCHANGE_RATE_NOT_FOUND",
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return service.UpdateExchangeRate(ctx, "7", &UpdateExchangeRateRequest{ExchangeRate: decimal.NewFromInt(3)})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Permission denied",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return service.UpdateExchangeRate(ctx, "7", &UpdateExchangeRateRequest{ExchangeRate: decimal.NewFromInt(3)})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ExchangeRateService {
+		return NewExchangeRateService(mocks.repos)
+	}
+	RunTestCases[ExchangeRateService](t, serviceFactory, testcases)
+}
+
+func TestExchangeRateService_GetEffectiveExchangeRate(t *testing.T) {
+	at := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	testcases := []TestCase[ExchangeRateService]{
+		{
+			name: "Success case",
+			ctx:  createBackofficeContext(api.PermissionExchangeRateRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, test_walletId, "aed", nil, at).Return(&model.ExchangeRate{ID: 7}, nil)
+			},
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return service.GetEffectiveExchangeRate(ctx, test_walletId, "aed", nil, at)
+			},
+			expectResult: true,
+		},
+		{
+			name: "No version active at the given time",
+			ctx:  createBackofficeContext(api.PermissionExchangeRateRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, test_walletId, "aed", nil, at).Return(nil, nil)
+			},
+			expectedError: "EXCHANGE_RATE_NOT_FOUND",
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return service.GetEffectiveExchangeRate(ctx, test_walletId, "aed", nil, at)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ExchangeRateService {
+		return NewExchangeRateService(mocks.repos)
+	}
+	RunTestCases[ExchangeRateService](t, serviceFactory, testcases)
+}
+
+func TestExchangeRateService_DeleteExchangeRate(t *testing.T) {
+	now := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	ctx := withApproval(createBackofficeContext(api.PermissionExchangeRateWrite), &model.Approval{Operation: model.ApprovalOperationDeleteExchangeRate})
+	closedAt := now.Add(-time.Hour)
+	testcases := []TestCase[ExchangeRateService]{
+		{
+			name: "Closes the active version instead of deleting it",
+			ctx:  ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				active := &model.ExchangeRate{ID: 7, FromWalletID: test_walletId, ToWalletID: "aed", ValidFrom: now.AddDate(0, -1, 0)}
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRateByID(ctx, "7").Return(active, nil)
+				mocks.exchangeRateRepo.EXPECT().CloseExchangeRate(ctx, active, now).Return(nil)
+			},
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return nil, service.DeleteExchangeRate(ctx, "7")
+			},
+			expectResult: false,
+		},
+		{
+			name: "Deletes a version that has not started yet",
+			ctx:  ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				scheduled := &model.ExchangeRate{ID: 8, FromWalletID: test_walletId, ToWalletID: "aed", ValidFrom: now.AddDate(0, 0, 1)}
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRateByID(ctx, "8").Return(scheduled, nil)
+				mocks.exchangeRateRepo.EXPECT().DeleteExchangeRate(ctx, scheduled).Return(nil)
+			},
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return nil, service.DeleteExchangeRate(ctx, "8")
+			},
+			expectResult: false,
+		},
+		{
+			name: "Rejects a version that is already closed",
+			ctx:  ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				closed := &model.ExchangeRate{ID: 6, FromWalletID: test_walletId, ToWalletID: "aed", ValidFrom: now.AddDate(0, -2, 0), ValidUntil: &closedAt}
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRateByID(ctx, "6").Return(closed, nil)
+			},
+			expectedError: "EXCHANGE_RATE_VERSION_CLOSED",
+			testFunc: func(service ExchangeRateService, ctx context.Context) (interface{}, error) {
+				return nil, service.DeleteExchangeRate(ctx, "6")
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ExchangeRateService {
+		service := NewExchangeRateService(mocks.repos).(*exchangeRateService)
+		service.now = func() time.Time { return now }
+		return service
+	}
+	RunTestCases[ExchangeRateService](t, serviceFactory, testcases)
+}
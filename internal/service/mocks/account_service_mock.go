@@ -11,9 +11,12 @@ package service_mock
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	service "github.com/abdelrahman146/digital-wallet/internal/service"
 	api "github.com/abdelrahman146/digital-wallet/pkg/api"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -70,6 +73,21 @@ func (mr *MockAccountServiceMockRecorder) DeleteAccount(ctx, accountId any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccountService)(nil).DeleteAccount), ctx, accountId)
 }
 
+// FreezeAccount mocks base method.
+func (m *MockAccountService) FreezeAccount(ctx context.Context, accountId string, req *service.AccountStatusRequest) (*model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeAccount", ctx, accountId, req)
+	ret0, _ := ret[0].(*model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeAccount indicates an expected call of FreezeAccount.
+func (mr *MockAccountServiceMockRecorder) FreezeAccount(ctx, accountId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeAccount", reflect.TypeOf((*MockAccountService)(nil).FreezeAccount), ctx, accountId, req)
+}
+
 // GetAccount mocks base method.
 func (m *MockAccountService) GetAccount(ctx context.Context, accountId string) (*model.Account, error) {
 	m.ctrl.T.Helper()
@@ -85,6 +103,36 @@ func (mr *MockAccountServiceMockRecorder) GetAccount(ctx, accountId any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockAccountService)(nil).GetAccount), ctx, accountId)
 }
 
+// GetAccountStatement mocks base method.
+func (m *MockAccountService) GetAccountStatement(ctx context.Context, walletId, accountId string, req *service.StatementRequest) (*service.AccountStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountStatement", ctx, walletId, accountId, req)
+	ret0, _ := ret[0].(*service.AccountStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountStatement indicates an expected call of GetAccountStatement.
+func (mr *MockAccountServiceMockRecorder) GetAccountStatement(ctx, walletId, accountId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountStatement", reflect.TypeOf((*MockAccountService)(nil).GetAccountStatement), ctx, walletId, accountId, req)
+}
+
+// GetBalanceAt mocks base method.
+func (m *MockAccountService) GetBalanceAt(ctx context.Context, walletId, accountId string, at time.Time) (*service.AccountBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAt", ctx, walletId, accountId, at)
+	ret0, _ := ret[0].(*service.AccountBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAt indicates an expected call of GetBalanceAt.
+func (mr *MockAccountServiceMockRecorder) GetBalanceAt(ctx, walletId, accountId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAt", reflect.TypeOf((*MockAccountService)(nil).GetBalanceAt), ctx, walletId, accountId, at)
+}
+
 // GetWalletAccounts mocks base method.
 func (m *MockAccountService) GetWalletAccounts(ctx context.Context, walletId string, page, limit int) (*api.List[model.Account], error) {
 	m.ctrl.T.Helper()
@@ -114,3 +162,32 @@ func (mr *MockAccountServiceMockRecorder) GetWalletAccountsSum(ctx, walletId any
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWalletAccountsSum", reflect.TypeOf((*MockAccountService)(nil).GetWalletAccountsSum), ctx, walletId)
 }
+
+// UnfreezeAccount mocks base method.
+func (m *MockAccountService) UnfreezeAccount(ctx context.Context, accountId string, req *service.AccountStatusRequest) (*model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfreezeAccount", ctx, accountId, req)
+	ret0, _ := ret[0].(*model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfreezeAccount indicates an expected call of UnfreezeAccount.
+func (mr *MockAccountServiceMockRecorder) UnfreezeAccount(ctx, accountId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeAccount", reflect.TypeOf((*MockAccountService)(nil).UnfreezeAccount), ctx, accountId, req)
+}
+
+// WriteAccountStatement mocks base method.
+func (m *MockAccountService) WriteAccountStatement(ctx context.Context, statement *service.AccountStatement, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteAccountStatement", ctx, statement, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteAccountStatement indicates an expected call of WriteAccountStatement.
+func (mr *MockAccountServiceMockRecorder) WriteAccountStatement(ctx, statement, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAccountStatement", reflect.TypeOf((*MockAccountService)(nil).WriteAccountStatement), ctx, statement, w)
+}
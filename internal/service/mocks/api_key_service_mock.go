@@ -0,0 +1,147 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/api_key_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/api_key_service.go -destination=internal/service/mocks/api_key_service_mock.go -package=service_mock
+//
+
+// Package service_mock is a generated GoMock package.
+package service_mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	service "github.com/abdelrahman146/digital-wallet/internal/service"
+	api "github.com/abdelrahman146/digital-wallet/pkg/api"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApiKeyService is a mock of ApiKeyService interface.
+type MockApiKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyServiceMockRecorder
+}
+
+// MockApiKeyServiceMockRecorder is the mock recorder for MockApiKeyService.
+type MockApiKeyServiceMockRecorder struct {
+	mock *MockApiKeyService
+}
+
+// NewMockApiKeyService creates a new mock instance.
+func NewMockApiKeyService(ctrl *gomock.Controller) *MockApiKeyService {
+	mock := &MockApiKeyService{ctrl: ctrl}
+	mock.recorder = &MockApiKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyService) EXPECT() *MockApiKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockApiKeyService) Authenticate(ctx context.Context, key string) (*api.ApiKeyScope, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, key)
+	ret0, _ := ret[0].(*api.ApiKeyScope)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockApiKeyServiceMockRecorder) Authenticate(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockApiKeyService)(nil).Authenticate), ctx, key)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKeyService) CreateApiKey(ctx context.Context, req *service.CreateApiKeyRequest) (*service.ApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", ctx, req)
+	ret0, _ := ret[0].(*service.ApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeyServiceMockRecorder) CreateApiKey(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKeyService)(nil).CreateApiKey), ctx, req)
+}
+
+// DeleteApiKey mocks base method.
+func (m *MockApiKeyService) DeleteApiKey(ctx context.Context, apiKeyId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApiKey", ctx, apiKeyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApiKey indicates an expected call of DeleteApiKey.
+func (mr *MockApiKeyServiceMockRecorder) DeleteApiKey(ctx, apiKeyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApiKey", reflect.TypeOf((*MockApiKeyService)(nil).DeleteApiKey), ctx, apiKeyId)
+}
+
+// GetApiKey mocks base method.
+func (m *MockApiKeyService) GetApiKey(ctx context.Context, apiKeyId string) (*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKey", ctx, apiKeyId)
+	ret0, _ := ret[0].(*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKey indicates an expected call of GetApiKey.
+func (mr *MockApiKeyServiceMockRecorder) GetApiKey(ctx, apiKeyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKey", reflect.TypeOf((*MockApiKeyService)(nil).GetApiKey), ctx, apiKeyId)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKeyService) GetApiKeys(ctx context.Context, page, limit int) (*api.List[model.ApiKey], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", ctx, page, limit)
+	ret0, _ := ret[0].(*api.List[model.ApiKey])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeyServiceMockRecorder) GetApiKeys(ctx, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKeyService)(nil).GetApiKeys), ctx, page, limit)
+}
+
+// RotateApiKey mocks base method.
+func (m *MockApiKeyService) RotateApiKey(ctx context.Context, apiKeyId string) (*service.ApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateApiKey", ctx, apiKeyId)
+	ret0, _ := ret[0].(*service.ApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateApiKey indicates an expected call of RotateApiKey.
+func (mr *MockApiKeyServiceMockRecorder) RotateApiKey(ctx, apiKeyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateApiKey", reflect.TypeOf((*MockApiKeyService)(nil).RotateApiKey), ctx, apiKeyId)
+}
+
+// UpdateApiKey mocks base method.
+func (m *MockApiKeyService) UpdateApiKey(ctx context.Context, apiKeyId string, req *service.UpdateApiKeyRequest) (*model.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApiKey", ctx, apiKeyId, req)
+	ret0, _ := ret[0].(*model.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateApiKey indicates an expected call of UpdateApiKey.
+func (mr *MockApiKeyServiceMockRecorder) UpdateApiKey(ctx, apiKeyId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApiKey", reflect.TypeOf((*MockApiKeyService)(nil).UpdateApiKey), ctx, apiKeyId, req)
+}
@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/approval_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/approval_service.go -destination=internal/service/mocks/approval_service_mock.go -package=service_mock
+//
+
+// Package service_mock is a generated GoMock package.
+package service_mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	service "github.com/abdelrahman146/digital-wallet/internal/service"
+	api "github.com/abdelrahman146/digital-wallet/pkg/api"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApprovalService is a mock of ApprovalService interface.
+type MockApprovalService struct {
+	ctrl     *gomock.Controller
+	recorder *MockApprovalServiceMockRecorder
+}
+
+// MockApprovalServiceMockRecorder is the mock recorder for MockApprovalService.
+type MockApprovalServiceMockRecorder struct {
+	mock *MockApprovalService
+}
+
+// NewMockApprovalService creates a new mock instance.
+func NewMockApprovalService(ctrl *gomock.Controller) *MockApprovalService {
+	mock := &MockApprovalService{ctrl: ctrl}
+	mock.recorder = &MockApprovalServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApprovalService) EXPECT() *MockApprovalServiceMockRecorder {
+	return m.recorder
+}
+
+// Approve mocks base method.
+func (m *MockApprovalService) Approve(ctx context.Context, approvalId string, req *service.ApprovalDecisionRequest) (*model.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Approve", ctx, approvalId, req)
+	ret0, _ := ret[0].(*model.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Approve indicates an expected call of Approve.
+func (mr *MockApprovalServiceMockRecorder) Approve(ctx, approvalId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Approve", reflect.TypeOf((*MockApprovalService)(nil).Approve), ctx, approvalId, req)
+}
+
+// GetApproval mocks base method.
+func (m *MockApprovalService) GetApproval(ctx context.Context, approvalId string) (*model.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApproval", ctx, approvalId)
+	ret0, _ := ret[0].(*model.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApproval indicates an expected call of GetApproval.
+func (mr *MockApprovalServiceMockRecorder) GetApproval(ctx, approvalId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApproval", reflect.TypeOf((*MockApprovalService)(nil).GetApproval), ctx, approvalId)
+}
+
+// GetApprovals mocks base method.
+func (m *MockApprovalService) GetApprovals(ctx context.Context, status string, page, limit int) (*api.List[model.Approval], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApprovals", ctx, status, page, limit)
+	ret0, _ := ret[0].(*api.List[model.Approval])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApprovals indicates an expected call of GetApprovals.
+func (mr *MockApprovalServiceMockRecorder) GetApprovals(ctx, status, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApprovals", reflect.TypeOf((*MockApprovalService)(nil).GetApprovals), ctx, status, page, limit)
+}
+
+// Reject mocks base method.
+func (m *MockApprovalService) Reject(ctx context.Context, approvalId string, req *service.ApprovalDecisionRequest) (*model.Approval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reject", ctx, approvalId, req)
+	ret0, _ := ret[0].(*model.Approval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reject indicates an expected call of Reject.
+func (mr *MockApprovalServiceMockRecorder) Reject(ctx, approvalId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reject", reflect.TypeOf((*MockApprovalService)(nil).Reject), ctx, approvalId, req)
+}
@@ -12,11 +12,11 @@ package service_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	service "github.com/abdelrahman146/digital-wallet/internal/service"
 	api "github.com/abdelrahman146/digital-wallet/pkg/api"
-	decimal "github.com/shopspring/decimal"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -72,6 +72,36 @@ func (mr *MockExchangeRateServiceMockRecorder) DeleteExchangeRate(ctx, exchangeR
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExchangeRate", reflect.TypeOf((*MockExchangeRateService)(nil).DeleteExchangeRate), ctx, exchangeRateId)
 }
 
+// GetEffectiveExchangeRate mocks base method.
+func (m *MockExchangeRateService) GetEffectiveExchangeRate(ctx context.Context, fromWalletId, toWalletId string, tierId *string, at time.Time) (*model.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveExchangeRate", ctx, fromWalletId, toWalletId, tierId, at)
+	ret0, _ := ret[0].(*model.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectiveExchangeRate indicates an expected call of GetEffectiveExchangeRate.
+func (mr *MockExchangeRateServiceMockRecorder) GetEffectiveExchangeRate(ctx, fromWalletId, toWalletId, tierId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveExchangeRate", reflect.TypeOf((*MockExchangeRateService)(nil).GetEffectiveExchangeRate), ctx, fromWalletId, toWalletId, tierId, at)
+}
+
+// GetExchangeRateVersions mocks base method.
+func (m *MockExchangeRateService) GetExchangeRateVersions(ctx context.Context, fromWalletId, toWalletId string, tierId *string, page, limit int) (*api.List[model.ExchangeRate], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRateVersions", ctx, fromWalletId, toWalletId, tierId, page, limit)
+	ret0, _ := ret[0].(*api.List[model.ExchangeRate])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRateVersions indicates an expected call of GetExchangeRateVersions.
+func (mr *MockExchangeRateServiceMockRecorder) GetExchangeRateVersions(ctx, fromWalletId, toWalletId, tierId, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRateVersions", reflect.TypeOf((*MockExchangeRateService)(nil).GetExchangeRateVersions), ctx, fromWalletId, toWalletId, tierId, page, limit)
+}
+
 // GetExchangeRates mocks base method.
 func (m *MockExchangeRateService) GetExchangeRates(ctx context.Context, page, limit int) (*api.List[model.ExchangeRate], error) {
 	m.ctrl.T.Helper()
@@ -103,16 +133,16 @@ func (mr *MockExchangeRateServiceMockRecorder) GetExchangeRatesByWalletID(ctx, w
 }
 
 // UpdateExchangeRate mocks base method.
-func (m *MockExchangeRateService) UpdateExchangeRate(ctx context.Context, exchangeRateId string, exchangeRate decimal.Decimal) (*model.ExchangeRate, error) {
+func (m *MockExchangeRateService) UpdateExchangeRate(ctx context.Context, exchangeRateId string, req *service.UpdateExchangeRateRequest) (*model.ExchangeRate, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateExchangeRate", ctx, exchangeRateId, exchangeRate)
+	ret := m.ctrl.Call(m, "UpdateExchangeRate", ctx, exchangeRateId, req)
 	ret0, _ := ret[0].(*model.ExchangeRate)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateExchangeRate indicates an expected call of UpdateExchangeRate.
-func (mr *MockExchangeRateServiceMockRecorder) UpdateExchangeRate(ctx, exchangeRateId, exchangeRate any) *gomock.Call {
+func (mr *MockExchangeRateServiceMockRecorder) UpdateExchangeRate(ctx, exchangeRateId, req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExchangeRate", reflect.TypeOf((*MockExchangeRateService)(nil).UpdateExchangeRate), ctx, exchangeRateId, exchangeRate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExchangeRate", reflect.TypeOf((*MockExchangeRateService)(nil).UpdateExchangeRate), ctx, exchangeRateId, req)
 }
@@ -71,6 +71,21 @@ func (mr *MockProgramServiceMockRecorder) DeleteProgram(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProgram", reflect.TypeOf((*MockProgramService)(nil).DeleteProgram), ctx, id)
 }
 
+// DiscardProgramDraft mocks base method.
+func (m *MockProgramService) DiscardProgramDraft(ctx context.Context, id uint64) (*model.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardProgramDraft", ctx, id)
+	ret0, _ := ret[0].(*model.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscardProgramDraft indicates an expected call of DiscardProgramDraft.
+func (mr *MockProgramServiceMockRecorder) DiscardProgramDraft(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardProgramDraft", reflect.TypeOf((*MockProgramService)(nil).DiscardProgramDraft), ctx, id)
+}
+
 // GetProgram mocks base method.
 func (m *MockProgramService) GetProgram(ctx context.Context, id uint64) (*model.Program, error) {
 	m.ctrl.T.Helper()
@@ -86,12 +101,28 @@ func (mr *MockProgramServiceMockRecorder) GetProgram(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProgram", reflect.TypeOf((*MockProgramService)(nil).GetProgram), ctx, id)
 }
 
+// GetProgramVersion mocks base method.
+func (m *MockProgramService) GetProgramVersion(ctx context.Context, id, version uint64) (*model.ProgramVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProgramVersion", ctx, id, version)
+	ret0, _ := ret[0].(*model.ProgramVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProgramVersion indicates an expected call of GetProgramVersion.
+func (mr *MockProgramServiceMockRecorder) GetProgramVersion(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProgramVersion", reflect.TypeOf((*MockProgramService)(nil).GetProgramVersion), ctx, id, version)
+}
+
 // InvokePrograms mocks base method.
-func (m *MockProgramService) InvokePrograms(ctx context.Context, triggerSlug, userId string, triggerData map[string]any) error {
+func (m *MockProgramService) InvokePrograms(ctx context.Context, triggerSlug, userId string, triggerData map[string]any) (*service.InvocationResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "InvokePrograms", ctx, triggerSlug, userId, triggerData)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*service.InvocationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // InvokePrograms indicates an expected call of InvokePrograms.
@@ -100,6 +131,21 @@ func (mr *MockProgramServiceMockRecorder) InvokePrograms(ctx, triggerSlug, userI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvokePrograms", reflect.TypeOf((*MockProgramService)(nil).InvokePrograms), ctx, triggerSlug, userId, triggerData)
 }
 
+// ListProgramVersions mocks base method.
+func (m *MockProgramService) ListProgramVersions(ctx context.Context, id uint64, page, limit int) (*api.List[model.ProgramVersion], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProgramVersions", ctx, id, page, limit)
+	ret0, _ := ret[0].(*api.List[model.ProgramVersion])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProgramVersions indicates an expected call of ListProgramVersions.
+func (mr *MockProgramServiceMockRecorder) ListProgramVersions(ctx, id, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProgramVersions", reflect.TypeOf((*MockProgramService)(nil).ListProgramVersions), ctx, id, page, limit)
+}
+
 // ListPrograms mocks base method.
 func (m *MockProgramService) ListPrograms(ctx context.Context, page, limit int) (*api.List[model.Program], error) {
 	m.ctrl.T.Helper()
@@ -115,6 +161,51 @@ func (mr *MockProgramServiceMockRecorder) ListPrograms(ctx, page, limit any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPrograms", reflect.TypeOf((*MockProgramService)(nil).ListPrograms), ctx, page, limit)
 }
 
+// PublishProgram mocks base method.
+func (m *MockProgramService) PublishProgram(ctx context.Context, id uint64) (*model.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishProgram", ctx, id)
+	ret0, _ := ret[0].(*model.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishProgram indicates an expected call of PublishProgram.
+func (mr *MockProgramServiceMockRecorder) PublishProgram(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishProgram", reflect.TypeOf((*MockProgramService)(nil).PublishProgram), ctx, id)
+}
+
+// RollbackProgram mocks base method.
+func (m *MockProgramService) RollbackProgram(ctx context.Context, id uint64, req service.RollbackProgramRequest) (*model.Program, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackProgram", ctx, id, req)
+	ret0, _ := ret[0].(*model.Program)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackProgram indicates an expected call of RollbackProgram.
+func (mr *MockProgramServiceMockRecorder) RollbackProgram(ctx, id, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackProgram", reflect.TypeOf((*MockProgramService)(nil).RollbackProgram), ctx, id, req)
+}
+
+// SimulateProgram mocks base method.
+func (m *MockProgramService) SimulateProgram(ctx context.Context, id uint64, req service.SimulateProgramRequest) (*service.SimulationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SimulateProgram", ctx, id, req)
+	ret0, _ := ret[0].(*service.SimulationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimulateProgram indicates an expected call of SimulateProgram.
+func (mr *MockProgramServiceMockRecorder) SimulateProgram(ctx, id, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimulateProgram", reflect.TypeOf((*MockProgramService)(nil).SimulateProgram), ctx, id, req)
+}
+
 // UpdateProgram mocks base method.
 func (m *MockProgramService) UpdateProgram(ctx context.Context, id uint64, req service.UpdateProgramRequest) (*model.Program, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/report_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/report_service.go -destination=internal/service/mocks/report_service_mock.go -package=service_mock
+//
+
+// Package service_mock is a generated GoMock package.
+package service_mock
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	service "github.com/abdelrahman146/digital-wallet/internal/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReportService is a mock of ReportService interface.
+type MockReportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportServiceMockRecorder
+}
+
+// MockReportServiceMockRecorder is the mock recorder for MockReportService.
+type MockReportServiceMockRecorder struct {
+	mock *MockReportService
+}
+
+// NewMockReportService creates a new mock instance.
+func NewMockReportService(ctrl *gomock.Controller) *MockReportService {
+	mock := &MockReportService{ctrl: ctrl}
+	mock.recorder = &MockReportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportService) EXPECT() *MockReportServiceMockRecorder {
+	return m.recorder
+}
+
+// GetLiabilityReport mocks base method.
+func (m *MockReportService) GetLiabilityReport(ctx context.Context, walletId string, req *service.LiabilityReportRequest) (*service.LiabilityReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLiabilityReport", ctx, walletId, req)
+	ret0, _ := ret[0].(*service.LiabilityReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLiabilityReport indicates an expected call of GetLiabilityReport.
+func (mr *MockReportServiceMockRecorder) GetLiabilityReport(ctx, walletId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLiabilityReport", reflect.TypeOf((*MockReportService)(nil).GetLiabilityReport), ctx, walletId, req)
+}
+
+// RefreshLiabilitySummaries mocks base method.
+func (m *MockReportService) RefreshLiabilitySummaries(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshLiabilitySummaries", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshLiabilitySummaries indicates an expected call of RefreshLiabilitySummaries.
+func (mr *MockReportServiceMockRecorder) RefreshLiabilitySummaries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshLiabilitySummaries", reflect.TypeOf((*MockReportService)(nil).RefreshLiabilitySummaries), ctx)
+}
+
+// WriteLiabilityReport mocks base method.
+func (m *MockReportService) WriteLiabilityReport(ctx context.Context, report *service.LiabilityReport, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteLiabilityReport", ctx, report, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteLiabilityReport indicates an expected call of WriteLiabilityReport.
+func (mr *MockReportServiceMockRecorder) WriteLiabilityReport(ctx, report, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLiabilityReport", reflect.TypeOf((*MockReportService)(nil).WriteLiabilityReport), ctx, report, w)
+}
@@ -0,0 +1,191 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/role_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/service/role_service.go -destination=internal/service/mocks/role_service_mock.go -package=service_mock
+//
+
+// Package service_mock is a generated GoMock package.
+package service_mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/abdelrahman146/digital-wallet/internal/model"
+	service "github.com/abdelrahman146/digital-wallet/internal/service"
+	api "github.com/abdelrahman146/digital-wallet/pkg/api"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoleService is a mock of RoleService interface.
+type MockRoleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleServiceMockRecorder
+}
+
+// MockRoleServiceMockRecorder is the mock recorder for MockRoleService.
+type MockRoleServiceMockRecorder struct {
+	mock *MockRoleService
+}
+
+// NewMockRoleService creates a new mock instance.
+func NewMockRoleService(ctrl *gomock.Controller) *MockRoleService {
+	mock := &MockRoleService{ctrl: ctrl}
+	mock.recorder = &MockRoleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleService) EXPECT() *MockRoleServiceMockRecorder {
+	return m.recorder
+}
+
+// AssignRole mocks base method.
+func (m *MockRoleService) AssignRole(ctx context.Context, roleId, actorId string) (*model.ActorRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", ctx, roleId, actorId)
+	ret0, _ := ret[0].(*model.ActorRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockRoleServiceMockRecorder) AssignRole(ctx, roleId, actorId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockRoleService)(nil).AssignRole), ctx, roleId, actorId)
+}
+
+// CreateRole mocks base method.
+func (m *MockRoleService) CreateRole(ctx context.Context, req *service.CreateRoleRequest) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, req)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockRoleServiceMockRecorder) CreateRole(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockRoleService)(nil).CreateRole), ctx, req)
+}
+
+// DeleteRole mocks base method.
+func (m *MockRoleService) DeleteRole(ctx context.Context, roleId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, roleId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockRoleServiceMockRecorder) DeleteRole(ctx, roleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockRoleService)(nil).DeleteRole), ctx, roleId)
+}
+
+// GetActorPermissions mocks base method.
+func (m *MockRoleService) GetActorPermissions(ctx context.Context, actorId string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActorPermissions", ctx, actorId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActorPermissions indicates an expected call of GetActorPermissions.
+func (mr *MockRoleServiceMockRecorder) GetActorPermissions(ctx, actorId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActorPermissions", reflect.TypeOf((*MockRoleService)(nil).GetActorPermissions), ctx, actorId)
+}
+
+// GetActorRoles mocks base method.
+func (m *MockRoleService) GetActorRoles(ctx context.Context, actorId string) ([]model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActorRoles", ctx, actorId)
+	ret0, _ := ret[0].([]model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActorRoles indicates an expected call of GetActorRoles.
+func (mr *MockRoleServiceMockRecorder) GetActorRoles(ctx, actorId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActorRoles", reflect.TypeOf((*MockRoleService)(nil).GetActorRoles), ctx, actorId)
+}
+
+// GetPermissions mocks base method.
+func (m *MockRoleService) GetPermissions(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPermissions", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPermissions indicates an expected call of GetPermissions.
+func (mr *MockRoleServiceMockRecorder) GetPermissions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermissions", reflect.TypeOf((*MockRoleService)(nil).GetPermissions), ctx)
+}
+
+// GetRole mocks base method.
+func (m *MockRoleService) GetRole(ctx context.Context, roleId string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", ctx, roleId)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockRoleServiceMockRecorder) GetRole(ctx, roleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRoleService)(nil).GetRole), ctx, roleId)
+}
+
+// GetRoles mocks base method.
+func (m *MockRoleService) GetRoles(ctx context.Context, page, limit int) (*api.List[model.Role], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx, page, limit)
+	ret0, _ := ret[0].(*api.List[model.Role])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockRoleServiceMockRecorder) GetRoles(ctx, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockRoleService)(nil).GetRoles), ctx, page, limit)
+}
+
+// RevokeRole mocks base method.
+func (m *MockRoleService) RevokeRole(ctx context.Context, roleId, actorId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, roleId, actorId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockRoleServiceMockRecorder) RevokeRole(ctx, roleId, actorId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockRoleService)(nil).RevokeRole), ctx, roleId, actorId)
+}
+
+// UpdateRole mocks base method.
+func (m *MockRoleService) UpdateRole(ctx context.Context, roleId string, req *service.UpdateRoleRequest) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", ctx, roleId, req)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockRoleServiceMockRecorder) UpdateRole(ctx, roleId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockRoleService)(nil).UpdateRole), ctx, roleId, req)
+}
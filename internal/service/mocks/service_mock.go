@@ -18,6 +18,9 @@ func NewServiceMock(t *testing.T) (*service.Services, *gomock.Controller) {
 		ExchangeRate: NewMockExchangeRateService(ctrl),
 		Trigger:      NewMockTriggerService(ctrl),
 		Program:      NewMockProgramService(ctrl),
+		Role:         NewMockRoleService(ctrl),
+		Approval:     NewMockApprovalService(ctrl),
+		ApiKey:       NewMockApiKeyService(ctrl),
 	}, ctrl
 }
 
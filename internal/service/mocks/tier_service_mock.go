@@ -71,6 +71,63 @@ func (mr *MockTierServiceMockRecorder) DeleteTier(ctx, tierId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTier", reflect.TypeOf((*MockTierService)(nil).DeleteTier), ctx, tierId)
 }
 
+// DeleteTierPolicy mocks base method.
+func (m *MockTierService) DeleteTierPolicy(ctx context.Context, tierId, walletId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTierPolicy", ctx, tierId, walletId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTierPolicy indicates an expected call of DeleteTierPolicy.
+func (mr *MockTierServiceMockRecorder) DeleteTierPolicy(ctx, tierId, walletId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTierPolicy", reflect.TypeOf((*MockTierService)(nil).DeleteTierPolicy), ctx, tierId, walletId)
+}
+
+// DeleteTierRule mocks base method.
+func (m *MockTierService) DeleteTierRule(ctx context.Context, tierId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTierRule", ctx, tierId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTierRule indicates an expected call of DeleteTierRule.
+func (mr *MockTierServiceMockRecorder) DeleteTierRule(ctx, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTierRule", reflect.TypeOf((*MockTierService)(nil).DeleteTierRule), ctx, tierId)
+}
+
+// EvaluateTiers mocks base method.
+func (m *MockTierService) EvaluateTiers(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateTiers", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EvaluateTiers indicates an expected call of EvaluateTiers.
+func (mr *MockTierServiceMockRecorder) EvaluateTiers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateTiers", reflect.TypeOf((*MockTierService)(nil).EvaluateTiers), ctx)
+}
+
+// EvaluateUserTier mocks base method.
+func (m *MockTierService) EvaluateUserTier(ctx context.Context, userId string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateUserTier", ctx, userId)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateUserTier indicates an expected call of EvaluateUserTier.
+func (mr *MockTierServiceMockRecorder) EvaluateUserTier(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateUserTier", reflect.TypeOf((*MockTierService)(nil).EvaluateUserTier), ctx, userId)
+}
+
 // GetTierByID mocks base method.
 func (m *MockTierService) GetTierByID(ctx context.Context, tierId string) (*model.Tier, error) {
 	m.ctrl.T.Helper()
@@ -86,6 +143,36 @@ func (mr *MockTierServiceMockRecorder) GetTierByID(ctx, tierId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTierByID", reflect.TypeOf((*MockTierService)(nil).GetTierByID), ctx, tierId)
 }
 
+// GetTierPolicies mocks base method.
+func (m *MockTierService) GetTierPolicies(ctx context.Context, tierId string) ([]model.TierPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTierPolicies", ctx, tierId)
+	ret0, _ := ret[0].([]model.TierPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTierPolicies indicates an expected call of GetTierPolicies.
+func (mr *MockTierServiceMockRecorder) GetTierPolicies(ctx, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTierPolicies", reflect.TypeOf((*MockTierService)(nil).GetTierPolicies), ctx, tierId)
+}
+
+// GetTierRule mocks base method.
+func (m *MockTierService) GetTierRule(ctx context.Context, tierId string) (*model.TierRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTierRule", ctx, tierId)
+	ret0, _ := ret[0].(*model.TierRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTierRule indicates an expected call of GetTierRule.
+func (mr *MockTierServiceMockRecorder) GetTierRule(ctx, tierId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTierRule", reflect.TypeOf((*MockTierService)(nil).GetTierRule), ctx, tierId)
+}
+
 // GetTiers mocks base method.
 func (m *MockTierService) GetTiers(ctx context.Context, page, limit int) (*api.List[model.Tier], error) {
 	m.ctrl.T.Helper()
@@ -100,3 +187,33 @@ func (mr *MockTierServiceMockRecorder) GetTiers(ctx, page, limit any) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTiers", reflect.TypeOf((*MockTierService)(nil).GetTiers), ctx, page, limit)
 }
+
+// SetTierPolicy mocks base method.
+func (m *MockTierService) SetTierPolicy(ctx context.Context, tierId, walletId string, req *service.SetTierPolicyRequest) (*model.TierPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTierPolicy", ctx, tierId, walletId, req)
+	ret0, _ := ret[0].(*model.TierPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetTierPolicy indicates an expected call of SetTierPolicy.
+func (mr *MockTierServiceMockRecorder) SetTierPolicy(ctx, tierId, walletId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTierPolicy", reflect.TypeOf((*MockTierService)(nil).SetTierPolicy), ctx, tierId, walletId, req)
+}
+
+// SetTierRule mocks base method.
+func (m *MockTierService) SetTierRule(ctx context.Context, tierId string, req *service.SetTierRuleRequest) (*model.TierRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTierRule", ctx, tierId, req)
+	ret0, _ := ret[0].(*model.TierRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetTierRule indicates an expected call of SetTierRule.
+func (mr *MockTierServiceMockRecorder) SetTierRule(ctx, tierId, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTierRule", reflect.TypeOf((*MockTierService)(nil).SetTierRule), ctx, tierId, req)
+}
@@ -87,6 +87,21 @@ func (mr *MockTransactionServiceMockRecorder) GetAccountExpiringTransactionsSum(
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountExpiringTransactionsSum", reflect.TypeOf((*MockTransactionService)(nil).GetAccountExpiringTransactionsSum), ctx, accountId)
 }
 
+// GetAccountLots mocks base method.
+func (m *MockTransactionService) GetAccountLots(ctx context.Context, walletId, accountId string) ([]service.CreditLot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountLots", ctx, walletId, accountId)
+	ret0, _ := ret[0].([]service.CreditLot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountLots indicates an expected call of GetAccountLots.
+func (mr *MockTransactionServiceMockRecorder) GetAccountLots(ctx, walletId, accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountLots", reflect.TypeOf((*MockTransactionService)(nil).GetAccountLots), ctx, walletId, accountId)
+}
+
 // GetAccountTransactionSum mocks base method.
 func (m *MockTransactionService) GetAccountTransactionSum(ctx context.Context, walletId, accountId string) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -103,18 +118,18 @@ func (mr *MockTransactionServiceMockRecorder) GetAccountTransactionSum(ctx, wall
 }
 
 // GetAccountTransactions mocks base method.
-func (m *MockTransactionService) GetAccountTransactions(ctx context.Context, walletId, accountId string, page, limit int) (*api.List[model.Transaction], error) {
+func (m *MockTransactionService) GetAccountTransactions(ctx context.Context, walletId, accountId string, req *service.TransactionFilterRequest, page, limit int) (*api.List[model.Transaction], error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountTransactions", ctx, walletId, accountId, page, limit)
+	ret := m.ctrl.Call(m, "GetAccountTransactions", ctx, walletId, accountId, req, page, limit)
 	ret0, _ := ret[0].(*api.List[model.Transaction])
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAccountTransactions indicates an expected call of GetAccountTransactions.
-func (mr *MockTransactionServiceMockRecorder) GetAccountTransactions(ctx, walletId, accountId, page, limit any) *gomock.Call {
+func (mr *MockTransactionServiceMockRecorder) GetAccountTransactions(ctx, walletId, accountId, req, page, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountTransactions", reflect.TypeOf((*MockTransactionService)(nil).GetAccountTransactions), ctx, walletId, accountId, page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountTransactions", reflect.TypeOf((*MockTransactionService)(nil).GetAccountTransactions), ctx, walletId, accountId, req, page, limit)
 }
 
 // GetExpiredWalletTransactions mocks base method.
@@ -132,6 +147,21 @@ func (mr *MockTransactionServiceMockRecorder) GetExpiredWalletTransactions(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredWalletTransactions", reflect.TypeOf((*MockTransactionService)(nil).GetExpiredWalletTransactions), ctx, walletId)
 }
 
+// GetTransactionConsumptions mocks base method.
+func (m *MockTransactionService) GetTransactionConsumptions(ctx context.Context, walletId, accountId, transactionId string) ([]model.TransactionConsumption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionConsumptions", ctx, walletId, accountId, transactionId)
+	ret0, _ := ret[0].([]model.TransactionConsumption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionConsumptions indicates an expected call of GetTransactionConsumptions.
+func (mr *MockTransactionServiceMockRecorder) GetTransactionConsumptions(ctx, walletId, accountId, transactionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionConsumptions", reflect.TypeOf((*MockTransactionService)(nil).GetTransactionConsumptions), ctx, walletId, accountId, transactionId)
+}
+
 // GetWalletTransactionSum mocks base method.
 func (m *MockTransactionService) GetWalletTransactionSum(ctx context.Context, walletId string) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -148,16 +178,16 @@ func (mr *MockTransactionServiceMockRecorder) GetWalletTransactionSum(ctx, walle
 }
 
 // GetWalletTransactions mocks base method.
-func (m *MockTransactionService) GetWalletTransactions(ctx context.Context, walletId string, page, limit int) (*api.List[model.Transaction], error) {
+func (m *MockTransactionService) GetWalletTransactions(ctx context.Context, walletId string, req *service.TransactionFilterRequest, page, limit int) (*api.List[model.Transaction], error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWalletTransactions", ctx, walletId, page, limit)
+	ret := m.ctrl.Call(m, "GetWalletTransactions", ctx, walletId, req, page, limit)
 	ret0, _ := ret[0].(*api.List[model.Transaction])
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetWalletTransactions indicates an expected call of GetWalletTransactions.
-func (mr *MockTransactionServiceMockRecorder) GetWalletTransactions(ctx, walletId, page, limit any) *gomock.Call {
+func (mr *MockTransactionServiceMockRecorder) GetWalletTransactions(ctx, walletId, req, page, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWalletTransactions", reflect.TypeOf((*MockTransactionService)(nil).GetWalletTransactions), ctx, walletId, page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWalletTransactions", reflect.TypeOf((*MockTransactionService)(nil).GetWalletTransactions), ctx, walletId, req, page, limit)
 }
@@ -86,6 +86,21 @@ func (mr *MockUserServiceMockRecorder) GetUserByID(ctx, userId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserService)(nil).GetUserByID), ctx, userId)
 }
 
+// GetUserTierTimeline mocks base method.
+func (m *MockUserService) GetUserTierTimeline(ctx context.Context, userId string, page, limit int) (*api.List[model.UserTierHistory], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTierTimeline", ctx, userId, page, limit)
+	ret0, _ := ret[0].(*api.List[model.UserTierHistory])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTierTimeline indicates an expected call of GetUserTierTimeline.
+func (mr *MockUserServiceMockRecorder) GetUserTierTimeline(ctx, userId, page, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTierTimeline", reflect.TypeOf((*MockUserService)(nil).GetUserTierTimeline), ctx, userId, page, limit)
+}
+
 // GetUsers mocks base method.
 func (m *MockUserService) GetUsers(ctx context.Context, page, limit int) (*api.List[model.User], error) {
 	m.ctrl.T.Helper()
@@ -12,6 +12,7 @@ package service_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/abdelrahman146/digital-wallet/internal/model"
 	service "github.com/abdelrahman146/digital-wallet/internal/service"
@@ -86,6 +87,21 @@ func (mr *MockWalletServiceMockRecorder) GetAccountsSum(ctx, walletId any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsSum", reflect.TypeOf((*MockWalletService)(nil).GetAccountsSum), ctx, walletId)
 }
 
+// GetLiabilityAt mocks base method.
+func (m *MockWalletService) GetLiabilityAt(ctx context.Context, walletId string, at time.Time) (*service.WalletLiability, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLiabilityAt", ctx, walletId, at)
+	ret0, _ := ret[0].(*service.WalletLiability)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLiabilityAt indicates an expected call of GetLiabilityAt.
+func (mr *MockWalletServiceMockRecorder) GetLiabilityAt(ctx, walletId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLiabilityAt", reflect.TypeOf((*MockWalletService)(nil).GetLiabilityAt), ctx, walletId, at)
+}
+
 // GetTransactionsSum mocks base method.
 func (m *MockWalletService) GetTransactionsSum(ctx context.Context, walletId string) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -131,6 +147,20 @@ func (mr *MockWalletServiceMockRecorder) GetWallets(ctx, page, limit any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWallets", reflect.TypeOf((*MockWalletService)(nil).GetWallets), ctx, page, limit)
 }
 
+// SnapshotBalances mocks base method.
+func (m *MockWalletService) SnapshotBalances(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotBalances", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SnapshotBalances indicates an expected call of SnapshotBalances.
+func (mr *MockWalletServiceMockRecorder) SnapshotBalances(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotBalances", reflect.TypeOf((*MockWalletService)(nil).SnapshotBalances), ctx)
+}
+
 // UpdateWallet mocks base method.
 func (m *MockWalletService) UpdateWallet(ctx context.Context, walletId string, req *service.UpdateWalletRequest) (*model.Wallet, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/config"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+)
+
+// checkUserActive ensures an inactive user can't post any transaction
+func checkUserActive(ctx context.Context, user *model.User) error {
+	if !user.IsActive {
+		api.GetLogger(ctx).Error("User is inactive", logger.Field("userId", user.ID))
+		return errs.NewForbiddenError("User is inactive", "USER_INACTIVE", nil)
+	}
+	return nil
+}
+
+// checkPosting ensures a wallet and an account are in a state that allows posting a transaction of the given type.
+// Archived and inactive wallets reject all postings. Frozen (inactive) accounts reject debits,
+// and accept credits only when FrozenAccountsAcceptCredits is enabled.
+func checkPosting(ctx context.Context, wallet *model.Wallet, account *model.Account, transactionType string) error {
+	if wallet.IsArchived {
+		api.GetLogger(ctx).Error("Wallet is archived", logger.Field("walletId", wallet.ID))
+		return errs.NewForbiddenError("Wallet is archived", "WALLET_ARCHIVED", nil)
+	}
+	if !wallet.IsActive {
+		api.GetLogger(ctx).Error("Wallet is inactive", logger.Field("walletId", wallet.ID))
+		return errs.NewForbiddenError("Wallet is inactive", "WALLET_INACTIVE", nil)
+	}
+	if account.IsActive {
+		return nil
+	}
+	if transactionType == model.TransactionTypeCredit && config.GetConfig().FrozenAccountsAcceptCredits {
+		return nil
+	}
+	api.GetLogger(ctx).Error("Account is frozen", logger.Field("accountId", account.ID), logger.Field("type", transactionType))
+	return errs.NewForbiddenError("Account is frozen", "ACCOUNT_FROZEN", nil)
+}
@@ -1,13 +1,23 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
 	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/config"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	rule_engine "github.com/abdelrahman146/digital-wallet/pkg/rules_engine"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ProgramService interface {
@@ -16,32 +26,89 @@ type ProgramService interface {
 	DeleteProgram(ctx context.Context, id uint64) error
 	GetProgram(ctx context.Context, id uint64) (*model.Program, error)
 	ListPrograms(ctx context.Context, page, limit int) (*api.List[model.Program], error)
-	InvokePrograms(ctx context.Context, triggerSlug string, userId string, triggerData map[string]interface{}) error
+	InvokePrograms(ctx context.Context, triggerSlug string, userId string, triggerData map[string]interface{}) (*InvocationResult, error)
+	PublishProgram(ctx context.Context, id uint64) (*model.Program, error)
+	RollbackProgram(ctx context.Context, id uint64, req RollbackProgramRequest) (*model.Program, error)
+	DiscardProgramDraft(ctx context.Context, id uint64) (*model.Program, error)
+	ListProgramVersions(ctx context.Context, id uint64, page, limit int) (*api.List[model.ProgramVersion], error)
+	GetProgramVersion(ctx context.Context, id uint64, version uint64) (*model.ProgramVersion, error)
+	SimulateProgram(ctx context.Context, id uint64, req SimulateProgramRequest) (*SimulationResult, error)
 }
 
 type programService struct {
 	repos *repository.Repos
+	// conditions caches the compiled program conditions by program and update time
+	conditions *rule_engine.Cache
+	// location is the timezone of conditions and schedules that don't set their own
+	location *time.Location
+	// now is the clock programs are invoked at, which conditions and schedules are evaluated with
+	now func() time.Time
+	// budgetAlertThresholds are the percentages of a program budget consumed at which an alert is published, for the
+	// budgets that don't set their own
+	budgetAlertThresholds []int
 }
 
 func NewProgramService(repos *repository.Repos) ProgramService {
-	return &programService{repos: repos}
+	location, err := time.LoadLocation(config.GetConfig().RulesTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+	s := &programService{repos: repos, location: location, now: time.Now, budgetAlertThresholds: config.GetConfig().ProgramBudgetAlertThresholds}
+	s.conditions = rule_engine.NewCache(rule_engine.WithLocation(location), rule_engine.WithClock(func() time.Time { return s.now() }))
+	return s
 }
 
 func (s *programService) CreateProgram(ctx context.Context, req CreateProgramRequest) (*model.Program, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionProgramWrite); err != nil {
 		return nil, err
 	}
+	if req.ConditionExpression != nil {
+		if !reflect.DeepEqual(req.Condition, rule_engine.Rule{}) {
+			return nil, errs.NewValidationError("Invalid program condition", "", map[string]string{"conditionExpression": "cannot be used together with condition"})
+		}
+		condition, err := parseConditionExpression(*req.ConditionExpression)
+		if err != nil {
+			return nil, err
+		}
+		req.Condition, req.ConditionExpression = condition, nil
+	} else if err := validateCondition(req.Condition); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(req.Schedule); err != nil {
+		return nil, err
+	}
+	if err := validateBudget(req.Budget); err != nil {
+		return nil, err
+	}
+	if req.IsActive {
+		if err := authorize(ctx, s.repos, api.PermissionProgramPublish); err != nil {
+			return nil, err
+		}
+		if err := requireApproval(ctx, s.repos, model.ApprovalOperationCreateProgram, api.PermissionProgramPublish, req); err != nil {
+			return nil, err
+		}
+	}
 	program := &model.Program{
-		Name:         req.Name,
-		WalletID:     req.WalletID,
-		TriggerSlug:  req.TriggerSlug,
-		Condition:    req.Condition,
-		Effect:       req.Effect,
-		ValidFrom:    req.ValidFrom,
-		ValidUntil:   req.ValidUntil,
-		IsActive:     req.IsActive,
-		LimitPerUser: req.LimitPerUser,
+		Name:                req.Name,
+		WalletID:            req.WalletID,
+		TriggerSlug:         req.TriggerSlug,
+		Condition:           req.Condition,
+		ConditionExpression: rule_engine.Format(req.Condition),
+		Effect:              req.Effect,
+		ValidFrom:           req.ValidFrom,
+		ValidUntil:          req.ValidUntil,
+		IsActive:            req.IsActive,
+		LimitPerUser:        req.LimitPerUser,
+		Priority:            req.Priority,
+		ExclusiveGroup:      req.ExclusiveGroup,
+		StopProcessing:      req.StopProcessing,
+		MaxRewardPerEvent:   req.MaxRewardPerEvent,
+		Schedule:            normalizeSchedule(req.Schedule),
+		Budget:              req.Budget,
+		Version:             1,
+	}
+	if program.ExclusiveGroup != nil && *program.ExclusiveGroup == "" {
+		program.ExclusiveGroup = nil
 	}
 	program.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
 	program.SetRemarks("Program created")
@@ -52,44 +119,138 @@ func (s *programService) CreateProgram(ctx context.Context, req CreateProgramReq
 }
 
 func (s *programService) UpdateProgram(ctx context.Context, id uint64, req UpdateProgramRequest) (*model.Program, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionProgramWrite); err != nil {
 		return nil, err
 	}
 	program, err := s.repos.Program.FetchProgramByID(ctx, id)
 	if program == nil {
 		return nil, errs.NewNotFoundError("Program not found", "PROGRAM_NOT_FOUND", err)
 	}
+	if req.ConditionExpression != nil {
+		if req.Condition != nil {
+			return nil, errs.NewValidationError("Invalid program condition", "", map[string]string{"conditionExpression": "cannot be used together with condition"})
+		}
+		condition, err := parseConditionExpression(*req.ConditionExpression)
+		if err != nil {
+			return nil, err
+		}
+		req.Condition, req.ConditionExpression = &condition, nil
+	} else if req.Condition != nil {
+		if err := validateCondition(*req.Condition); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateSchedule(req.Schedule); err != nil {
+		return nil, err
+	}
+	// A budget of 0 points removes the budget
+	if req.Budget != nil && req.Budget.Points > 0 {
+		if err := validateBudget(req.Budget); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsActive != nil && *req.IsActive && !program.IsActive {
+		if err := authorize(ctx, s.repos, api.PermissionProgramPublish); err != nil {
+			return nil, err
+		}
+		// A program that ran out of its budget is only switched on again along with a larger budget
+		budget := program.Budget
+		if req.Budget != nil {
+			budget = req.Budget
+		}
+		if budget != nil && budget.Points > 0 && budget.Remaining(program.BudgetConsumed) == 0 {
+			return nil, errs.NewValidationError("Invalid program budget", "", map[string]string{"budget.points": "the budget is exhausted, raise it to switch the program on"})
+		}
+		payload := programApprovalPayload{ProgramID: id, Request: req, Version: program.Version}
+		if err := requireApproval(ctx, s.repos, model.ApprovalOperationUpdateProgram, api.PermissionProgramPublish, payload); err != nil {
+			return nil, err
+		}
+		// A version published while the activation waited for approval was not seen by the checker
+		if approval, ok := approvalOf(ctx, model.ApprovalOperationUpdateProgram); ok {
+			var payload programApprovalPayload
+			if err := decodeApprovalPayload(approval.Payload, &payload); err != nil {
+				return nil, err
+			}
+			if payload.Version != 0 && payload.Version != program.Version {
+				api.GetLogger(ctx).Error("Program version changed since its activation was requested", logger.Field("programId", id), logger.Field("approvalId", approval.ID))
+				return nil, errs.NewConflictError("Program version changed since its activation was requested", "PROGRAM_VERSION_CHANGED", nil)
+			}
+		}
+	}
 	program.SetOldRecord(*program)
+	// Edits go to the draft, which the program only runs once it is published
+	draft := program.Definition()
+	if program.Draft != nil {
+		draft = *program.Draft
+	}
+	edited := draft
 	if req.Name != nil {
-		program.Name = *req.Name
+		edited.Name = *req.Name
 	}
 	if req.WalletID != nil {
-		program.WalletID = *req.WalletID
+		edited.WalletID = *req.WalletID
 	}
 	if req.TriggerSlug != nil {
-		program.TriggerSlug = *req.TriggerSlug
+		edited.TriggerSlug = *req.TriggerSlug
 	}
 	if req.Condition != nil {
-		program.Condition = *req.Condition
+		edited.Condition = *req.Condition
+		edited.ConditionExpression = rule_engine.Format(edited.Condition)
 	}
 	if req.Effect != nil {
-		program.Effect = *req.Effect
+		edited.Effect = *req.Effect
 	}
 	if req.ValidFrom != nil {
-		program.ValidFrom = *req.ValidFrom
+		edited.ValidFrom = *req.ValidFrom
 	}
 	if req.ValidUntil != nil {
-		program.ValidUntil = req.ValidUntil
-	}
-	if req.IsActive != nil {
-		program.IsActive = *req.IsActive
+		edited.ValidUntil = req.ValidUntil
 	}
 	if req.LimitPerUser != nil {
-		program.LimitPerUser = req.LimitPerUser
+		edited.LimitPerUser = req.LimitPerUser
+	}
+	if req.Priority != nil {
+		edited.Priority = *req.Priority
+	}
+	// An empty group takes the program out of its exclusive group, and a cap of 0 removes the cap
+	if req.ExclusiveGroup != nil {
+		edited.ExclusiveGroup = req.ExclusiveGroup
+		if *req.ExclusiveGroup == "" {
+			edited.ExclusiveGroup = nil
+		}
+	}
+	if req.StopProcessing != nil {
+		edited.StopProcessing = *req.StopProcessing
+	}
+	if req.Schedule != nil {
+		edited.Schedule = normalizeSchedule(req.Schedule)
+	}
+	if req.MaxRewardPerEvent != nil {
+		edited.MaxRewardPerEvent = req.MaxRewardPerEvent
+		if *req.MaxRewardPerEvent == 0 {
+			edited.MaxRewardPerEvent = nil
+		}
+	}
+	remarks := "Program updated"
+	if !reflect.DeepEqual(edited, draft) {
+		program.Draft = &edited
+		remarks = "Program draft updated"
+	}
+	// Budgets and switching a program on or off are not versioned and apply at once
+	if req.Budget != nil {
+		program.Budget = req.Budget
+		if req.Budget.Points == 0 {
+			program.Budget = nil
+		}
+	}
+	if req.IsActive != nil && *req.IsActive != program.IsActive {
+		if err := authorize(ctx, s.repos, api.PermissionProgramPublish); err != nil {
+			return nil, err
+		}
+		program.IsActive = *req.IsActive
 	}
 	program.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
-	program.SetRemarks("Program updated")
+	program.SetRemarks(remarks)
 	if err := s.repos.Program.UpdateProgram(ctx, program); err != nil {
 		return nil, err
 	}
@@ -97,8 +258,7 @@ func (s *programService) UpdateProgram(ctx context.Context, id uint64, req Updat
 }
 
 func (s *programService) DeleteProgram(ctx context.Context, id uint64) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionProgramWrite); err != nil {
 		return err
 	}
 	program, err := s.repos.Program.FetchProgramByID(ctx, id)
@@ -112,8 +272,7 @@ func (s *programService) DeleteProgram(ctx context.Context, id uint64) error {
 }
 
 func (s *programService) GetProgram(ctx context.Context, id uint64) (*model.Program, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionProgramRead); err != nil {
 		return nil, err
 	}
 	program, err := s.repos.Program.FetchProgramByID(ctx, id)
@@ -124,8 +283,7 @@ func (s *programService) GetProgram(ctx context.Context, id uint64) (*model.Prog
 }
 
 func (s *programService) ListPrograms(ctx context.Context, page, limit int) (*api.List[model.Program], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionProgramRead); err != nil {
 		return nil, err
 	}
 	programs, err := s.repos.Program.FetchPrograms(ctx, page, limit)
@@ -139,33 +297,607 @@ func (s *programService) ListPrograms(ctx context.Context, page, limit int) (*ap
 	return &api.List[model.Program]{Items: programs, Limit: limit, Page: page, Total: count}, nil
 }
 
-func (s *programService) InvokePrograms(ctx context.Context, triggerSlug string, userId string, triggerData map[string]interface{}) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
-		return err
+// PublishProgram publishes the draft of a program as its next version, which the program runs from then on
+func (s *programService) PublishProgram(ctx context.Context, id uint64) (*model.Program, error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramPublish); err != nil {
+		return nil, err
+	}
+	program, err := s.repos.Program.FetchProgramByID(ctx, id)
+	if program == nil {
+		return nil, errs.NewNotFoundError("Program not found", "PROGRAM_NOT_FOUND", err)
+	}
+	if program.Draft == nil {
+		return nil, errs.NewConflictError("Program has no draft to publish", "PROGRAM_DRAFT_NOT_FOUND", nil)
+	}
+	draft := *program.Draft
+	// Publishing changes what an active program rewards, so it needs a second actor, who publishes the draft they saw
+	if program.IsActive {
+		payload := programPublishApprovalPayload{ProgramID: id, Draft: &draft}
+		if err := requireApproval(ctx, s.repos, model.ApprovalOperationPublishProgram, api.PermissionProgramPublish, payload); err != nil {
+			return nil, err
+		}
+	}
+	if approval, ok := approvalOf(ctx, model.ApprovalOperationPublishProgram); ok {
+		var payload programPublishApprovalPayload
+		if err := decodeApprovalPayload(approval.Payload, &payload); err != nil {
+			return nil, err
+		}
+		if payload.Draft == nil || !sameDefinition(*payload.Draft, draft) {
+			api.GetLogger(ctx).Error("Program draft changed since its publication was requested", logger.Field("programId", id), logger.Field("approvalId", approval.ID))
+			return nil, errs.NewConflictError("Program draft changed since its publication was requested", "PROGRAM_DRAFT_CHANGED", nil)
+		}
+	}
+	program.SetOldRecord(*program)
+	program.Draft = nil
+	return s.publish(ctx, program, draft, nil, "Program draft published")
+}
+
+// RollbackProgram publishes an earlier version of a program again as its next version. The draft is left as it is
+func (s *programService) RollbackProgram(ctx context.Context, id uint64, req RollbackProgramRequest) (*model.Program, error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramPublish); err != nil {
+		return nil, err
 	}
-	programs, err := s.repos.Program.FetchTriggerPrograms(ctx, triggerSlug)
+	if req.Version == 0 {
+		return nil, errs.NewValidationError("Invalid rollback request", "", map[string]string{"version": "required"})
+	}
+	program, err := s.repos.Program.FetchProgramByID(ctx, id)
+	if program == nil {
+		return nil, errs.NewNotFoundError("Program not found", "PROGRAM_NOT_FOUND", err)
+	}
+	if req.Version == program.Version {
+		return nil, errs.NewConflictError("Program already runs this version", "PROGRAM_VERSION_PUBLISHED", nil)
+	}
+	version, err := s.repos.Program.FetchProgramVersion(ctx, id, req.Version)
+	if version == nil {
+		return nil, errs.NewNotFoundError("Program version not found", "PROGRAM_VERSION_NOT_FOUND", err)
+	}
+	if program.IsActive {
+		payload := programRollbackApprovalPayload{ProgramID: id, Request: req}
+		if err := requireApproval(ctx, s.repos, model.ApprovalOperationRollbackProgram, api.PermissionProgramPublish, payload); err != nil {
+			return nil, err
+		}
+	}
+	program.SetOldRecord(*program)
+	return s.publish(ctx, program, version.Definition, &version.Version, fmt.Sprintf("Program rolled back to version %d", version.Version))
+}
+
+// sameDefinition reports whether two definitions are stored the same, whatever types their values were decoded into
+func sameDefinition(a, b model.ProgramDefinition) bool {
+	aValue, aErr := a.Value()
+	bValue, bErr := b.Value()
+	return aErr == nil && bErr == nil && bytes.Equal(aValue.([]byte), bValue.([]byte))
+}
+
+// publish makes the program run the definition as its next version
+func (s *programService) publish(ctx context.Context, program *model.Program, definition model.ProgramDefinition, rolledBackFrom *uint64, remarks string) (*model.Program, error) {
+	program.ApplyDefinition(definition)
+	program.Version++
+	version := &model.ProgramVersion{
+		ProgramID:      program.ID,
+		Version:        program.Version,
+		Definition:     program.Definition(),
+		RolledBackFrom: rolledBackFrom,
+	}
+	version.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	version.SetRemarks(remarks)
+	program.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	program.SetRemarks(remarks)
+	if err := s.repos.Program.PublishProgram(ctx, program, version); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+func (s *programService) DiscardProgramDraft(ctx context.Context, id uint64) (*model.Program, error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramWrite); err != nil {
+		return nil, err
+	}
+	program, err := s.repos.Program.FetchProgramByID(ctx, id)
+	if program == nil {
+		return nil, errs.NewNotFoundError("Program not found", "PROGRAM_NOT_FOUND", err)
+	}
+	if program.Draft == nil {
+		return nil, errs.NewConflictError("Program has no draft to discard", "PROGRAM_DRAFT_NOT_FOUND", nil)
+	}
+	program.SetOldRecord(*program)
+	program.Draft = nil
+	program.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	program.SetRemarks("Program draft discarded")
+	if err := s.repos.Program.UpdateProgram(ctx, program); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+func (s *programService) ListProgramVersions(ctx context.Context, id uint64, page, limit int) (*api.List[model.ProgramVersion], error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramRead); err != nil {
+		return nil, err
+	}
+	versions, err := s.repos.Program.FetchProgramVersions(ctx, id, page, limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if len(programs) == 0 {
-		return nil
+	count, err := s.repos.Program.CountProgramVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &api.List[model.ProgramVersion]{Items: versions, Limit: limit, Page: page, Total: count}, nil
+}
+
+func (s *programService) GetProgramVersion(ctx context.Context, id uint64, version uint64) (*model.ProgramVersion, error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramRead); err != nil {
+		return nil, err
+	}
+	programVersion, err := s.repos.Program.FetchProgramVersion(ctx, id, version)
+	if programVersion == nil {
+		return nil, errs.NewNotFoundError("Program version not found", "PROGRAM_VERSION_NOT_FOUND", err)
+	}
+	return programVersion, nil
+}
+
+// SimulateProgram runs a version of a program, or its draft when it has one and no version is asked for, against a
+// trigger fired for a user now. Nothing is recorded or rewarded, so aggregates only cover the past events of the user,
+// and the other programs of the trigger are left out
+func (s *programService) SimulateProgram(ctx context.Context, id uint64, req SimulateProgramRequest) (*SimulationResult, error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramWrite); err != nil {
+		return nil, err
+	}
+	program, err := s.repos.Program.FetchProgramByID(ctx, id)
+	if program == nil {
+		return nil, errs.NewNotFoundError("Program not found", "PROGRAM_NOT_FOUND", err)
+	}
+	user, err := s.repos.User.FetchUserByID(ctx, req.UserID)
+	if user == nil {
+		return nil, errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
+	}
+	result := &SimulationResult{ProgramID: program.ID}
+	simulated := *program
+	switch {
+	case req.Version != nil && *req.Version != program.Version:
+		version, err := s.repos.Program.FetchProgramVersion(ctx, id, *req.Version)
+		if version == nil {
+			return nil, errs.NewNotFoundError("Program version not found", "PROGRAM_VERSION_NOT_FOUND", err)
+		}
+		simulated.ApplyDefinition(version.Definition)
+		result.Version = version.Version
+	case req.Version == nil && program.Draft != nil:
+		simulated.ApplyDefinition(*program.Draft)
+		result.Draft = true
+	default:
+		result.Version = program.Version
+	}
+
+	now := s.now()
+	if simulated.Schedule != nil && !simulated.Schedule.IsOpenAt(now, s.location) {
+		result.Reason, result.Message = SuppressionReasonOutsideSchedule, "The program is outside of its schedule"
+		return result, nil
+	}
+	// Simulated conditions are cached apart from the ones programs run with
+	key := program.TenantID + "/" + strconv.FormatUint(program.ID, 10) + "/simulation"
+	revision := strconv.FormatUint(result.Version, 10)
+	if result.Draft {
+		revision = "draft@" + strconv.FormatInt(program.UpdatedAt.UnixNano(), 10)
+	}
+	condition, err := s.conditions.Compile(key, revision, simulated.Condition)
+	if err != nil {
+		result.Reason, result.Message = SuppressionReasonInvalidRule, err.Error()
+		return result, nil
+	}
+	data := map[string]interface{}{"userData": newUserData(user), "triggerData": req.Data}
+	conditionMet, err := condition.EvaluateWith(data, newEventHistory(ctx, s.repos, user.ID))
+	if err != nil || !conditionMet {
+		result.Reason, result.Message = SuppressionReasonConditionNotMet, "The condition is not met"
+		if err != nil {
+			result.Message = err.Error()
+		}
+		return result, nil
+	}
+	amount, err := ApplyEffect(ctx, simulated, data)
+	if err != nil {
+		result.Reason, result.Message = SuppressionReasonEffectFailed, errs.HandleError(err).Message
+		return result, nil
+	}
+	if amount == 0 {
+		result.Fired = true
+		return result, nil
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, simulated.WalletID)
+	if wallet == nil {
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	policy, err := resolveAccountPolicy(ctx, s.repos, wallet, user.TierID)
+	if err != nil {
+		return nil, err
+	}
+	reward, cappedFrom := scaleReward(policy, &simulated, amount)
+	result.Reward, result.Capped = reward, cappedFrom > 0
+	if simulated.Budget != nil {
+		granted := simulated.Budget.Grant(simulated.BudgetConsumed, reward)
+		switch {
+		case granted == 0 && simulated.Budget.Remaining(simulated.BudgetConsumed) == 0:
+			result.Reward, result.Reason, result.Message = 0, SuppressionReasonBudgetExhausted, "The budget of the program is exhausted"
+			return result, nil
+		case granted == 0:
+			result.Reward, result.Reason, result.Message = 0, SuppressionReasonBudgetInsufficient, "The reward exceeds the remaining budget of the program"
+			return result, nil
+		}
+		result.Reward, result.Partial = granted, granted < reward
+	}
+	result.Fired = true
+	return result, nil
+}
+
+// InvokePrograms runs the active programs of a fired trigger for the user by descending priority. A program is
+// suppressed outside of its schedule, once a program of its exclusive group fired or a program that stops processing
+// fired, and the result lists the programs that fired and the ones that were suppressed, with the reason.
+func (s *programService) InvokePrograms(ctx context.Context, triggerSlug string, userId string, triggerData map[string]interface{}) (*InvocationResult, error) {
+	if err := authorize(ctx, s.repos, api.PermissionProgramInvoke); err != nil {
+		return nil, err
 	}
-	data := make(map[string]interface{})
-	userData := make(map[string]interface{})
 	user, err := s.repos.User.FetchUserByID(ctx, userId)
 	if user == nil {
-		return errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
+		return nil, errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
+	}
+	if err := checkUserActive(ctx, user); err != nil {
+		return nil, err
 	}
-	data["userData"] = userData
+	trigger, err := s.repos.Trigger.FetchTriggerBySlug(ctx, triggerSlug)
+	if trigger == nil {
+		api.GetLogger(ctx).Error("Trigger not found", logger.Field("triggerSlug", triggerSlug))
+		return nil, errs.NewNotFoundError("Trigger not found", "TRIGGER_NOT_FOUND", err)
+	}
+	// The event is recorded before the programs run, so aggregates such as count(purchase, month) include it
+	event := &model.TriggerEvent{TriggerSlug: trigger.Slug, UserID: userId, Data: triggerData}
+	if err := s.repos.Trigger.CreateTriggerEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	programs, err := s.repos.Program.FetchTriggerPrograms(ctx, triggerSlug, now)
+	if err != nil {
+		return nil, err
+	}
+	result := &InvocationResult{Fired: []ProgramOutcome{}, Suppressed: []ProgramOutcome{}}
+	if len(programs) == 0 {
+		return result, nil
+	}
+	data := make(map[string]interface{})
+	history := newEventHistory(ctx, s.repos, userId)
+	data["userData"] = newUserData(user)
 	data["triggerData"] = triggerData
 
+	// groups keeps the program that fired for each exclusive group, and stoppedBy the program that stopped processing
+	groups := make(map[string]*model.Program)
+	var stoppedBy *model.Program
 	for _, program := range programs {
-		conditionMet, err := rule_engine.EvaluateRule(program.Condition, data)
-		if err != nil || !conditionMet {
+		outcome := ProgramOutcome{ProgramID: program.ID, Name: program.Name, Version: program.Version}
+		suppress := func(reason, message string) {
+			outcome.Reason, outcome.Message = reason, message
+			result.Suppressed = append(result.Suppressed, outcome)
+		}
+		// Programs rewarding in wallets the actor is restricted from are left for other callers
+		if api.HasWalletAccess(ctx, program.WalletID) != nil {
+			suppress(SuppressionReasonWalletNotInScope, fmt.Sprintf("The program rewards in wallet %s, outside of the wallets of the caller", program.WalletID))
+			continue
+		}
+		if stoppedBy != nil {
+			suppress(SuppressionReasonStopped, fmt.Sprintf("Program %d fired and stops the programs after it", stoppedBy.ID))
+			continue
+		}
+		if program.Schedule != nil && !program.Schedule.IsOpenAt(now, s.location) {
+			suppress(SuppressionReasonOutsideSchedule, "The program is outside of its schedule")
+			continue
+		}
+		if program.Budget != nil && program.Budget.Remaining(program.BudgetConsumed) == 0 {
+			suppress(SuppressionReasonBudgetExhausted, "The budget of the program is exhausted")
 			continue
 		}
-		// TODO: apply effect
+		if program.ExclusiveGroup != nil {
+			if winner, ok := groups[*program.ExclusiveGroup]; ok {
+				suppress(SuppressionReasonExclusiveGroup, fmt.Sprintf("Program %d of group %s fired first", winner.ID, *program.ExclusiveGroup))
+				continue
+			}
+		}
+		condition, err := s.compileCondition(program)
+		if err != nil {
+			api.GetLogger(ctx).Error("Invalid program condition", logger.Field("programId", program.ID), logger.Field("error", err))
+			suppress(SuppressionReasonInvalidRule, err.Error())
+			continue
+		}
+		conditionMet, err := condition.EvaluateWith(data, history)
+		if err != nil {
+			suppress(SuppressionReasonConditionNotMet, err.Error())
+			continue
+		}
+		if !conditionMet {
+			suppress(SuppressionReasonConditionNotMet, "The condition is not met")
+			continue
+		}
+		amount, err := ApplyEffect(ctx, *program, data)
+		if err != nil {
+			api.GetLogger(ctx).Error("Unable to apply program effect", logger.Field("programId", program.ID), logger.Field("error", err))
+			suppress(SuppressionReasonEffectFailed, errs.HandleError(err).Message)
+			continue
+		}
+		if amount > 0 {
+			transaction, err := s.rewardUser(ctx, program, user, amount)
+			if err != nil {
+				api.GetLogger(ctx).Error("Unable to reward user", logger.Field("programId", program.ID), logger.Field("userId", userId), logger.Field("error", err))
+				customErr := errs.HandleError(err)
+				switch customErr.Code {
+				case "PROGRAM_BUDGET_EXHAUSTED":
+					suppress(SuppressionReasonBudgetExhausted, customErr.Message)
+				case "PROGRAM_BUDGET_INSUFFICIENT":
+					suppress(SuppressionReasonBudgetInsufficient, customErr.Message)
+				default:
+					suppress(SuppressionReasonRewardFailed, customErr.Message)
+				}
+				continue
+			}
+			if transaction != nil {
+				outcome.Reward, outcome.TransactionID = transaction.Amount, transaction.ID
+				outcome.Capped = transaction.Metadata["cappedFrom"] != nil
+				outcome.Partial = transaction.Metadata["partialFrom"] != nil
+			}
+		}
+		result.Fired = append(result.Fired, outcome)
+		if program.ExclusiveGroup != nil {
+			groups[*program.ExclusiveGroup] = program
+		}
+		if program.StopProcessing {
+			stoppedBy = program
+		}
 	}
-	return nil
+	return result, nil
+}
+
+// eventHistory resolves the aggregate fields of program conditions over the past events of a user.
+// Aggregates are cached, so programs sharing one only query it once per invocation
+type eventHistory struct {
+	ctx      context.Context
+	repos    *repository.Repos
+	userId   string
+	resolved map[string]float64
+}
+
+func newEventHistory(ctx context.Context, repos *repository.Repos, userId string) *eventHistory {
+	return &eventHistory{ctx: ctx, repos: repos, userId: userId, resolved: make(map[string]float64)}
+}
+
+func (h *eventHistory) Count(trigger string, since time.Time) (float64, error) {
+	key := fmt.Sprintf("count(%s)@%d", trigger, since.UnixNano())
+	if total, ok := h.resolved[key]; ok {
+		return total, nil
+	}
+	total, err := h.repos.Trigger.CountTriggerEvents(h.ctx, h.userId, trigger, since)
+	if err != nil {
+		return 0, err
+	}
+	h.resolved[key] = float64(total)
+	return float64(total), nil
+}
+
+func (h *eventHistory) Sum(field, trigger string, since time.Time) (float64, error) {
+	key := fmt.Sprintf("sum(%s,%s)@%d", field, trigger, since.UnixNano())
+	if total, ok := h.resolved[key]; ok {
+		return total, nil
+	}
+	total, err := h.repos.Trigger.SumTriggerEvents(h.ctx, h.userId, trigger, field, since)
+	if err != nil {
+		return 0, err
+	}
+	h.resolved[key] = total
+	return total, nil
+}
+
+// compileCondition returns the compiled condition of a program, compiling it again only once a new version is published
+func (s *programService) compileCondition(program *model.Program) (rule_engine.CompiledRule, error) {
+	key := program.TenantID + "/" + strconv.FormatUint(program.ID, 10)
+	version := strconv.FormatUint(program.Version, 10)
+	return s.conditions.Compile(key, version, program.Condition)
+}
+
+// validateCondition compiles a program condition, reporting each invalid part of it as a field error
+func validateCondition(condition rule_engine.Rule) error {
+	_, err := rule_engine.Compile(condition)
+	var compileErr *rule_engine.CompileError
+	if errors.As(err, &compileErr) {
+		fields := make(map[string]string, len(compileErr.Fields))
+		for path, problem := range compileErr.Fields {
+			fields["condition."+path] = problem
+		}
+		return errs.NewValidationError("Invalid program condition", "", fields)
+	}
+	return err
+}
+
+// validateSchedule reports each invalid part of a program schedule as a field error
+func validateSchedule(schedule *model.ProgramSchedule) error {
+	if schedule == nil {
+		return nil
+	}
+	problems := schedule.Validate()
+	if len(problems) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(problems))
+	for path, problem := range problems {
+		fields["schedule."+path] = problem
+	}
+	return errs.NewValidationError("Invalid program schedule", "", fields)
+}
+
+// validateBudget reports each invalid part of a program budget as a field error
+func validateBudget(budget *model.ProgramBudget) error {
+	if budget == nil {
+		return nil
+	}
+	problems := budget.Validate()
+	if len(problems) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(problems))
+	for path, problem := range problems {
+		fields["budget."+path] = problem
+	}
+	return errs.NewValidationError("Invalid program budget", "", fields)
+}
+
+// normalizeSchedule drops schedules without windows or blackout dates, so an empty schedule runs the program any time
+func normalizeSchedule(schedule *model.ProgramSchedule) *model.ProgramSchedule {
+	if schedule == nil || (len(schedule.Windows) == 0 && len(schedule.BlackoutDates) == 0) {
+		return nil
+	}
+	return schedule
+}
+
+// parseConditionExpression parses a program condition written in the rule language, reporting syntax errors and
+// invalid parts of the rule at their line and column in the expression
+func parseConditionExpression(expression string) (rule_engine.Rule, error) {
+	parsed, err := rule_engine.ParseExpression(expression)
+	var syntaxErr *rule_engine.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return rule_engine.Rule{}, errs.NewValidationError("Invalid program condition", "", map[string]string{"conditionExpression": syntaxErr.Error()})
+	}
+	if err != nil {
+		return rule_engine.Rule{}, err
+	}
+	_, err = rule_engine.Compile(parsed.Rule)
+	var compileErr *rule_engine.CompileError
+	if errors.As(err, &compileErr) {
+		problems := make([]*rule_engine.SyntaxError, 0, len(compileErr.Fields))
+		for path, problem := range compileErr.Fields {
+			part := path[strings.LastIndex(path, ".")+1:]
+			problems = append(problems, &rule_engine.SyntaxError{Position: parsed.Position(path), Message: part + " " + problem})
+		}
+		sort.Slice(problems, func(i, j int) bool { return problems[i].Offset < problems[j].Offset })
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.Error()
+		}
+		return rule_engine.Rule{}, errs.NewValidationError("Invalid program condition", "", map[string]string{"conditionExpression": strings.Join(messages, "; ")})
+	}
+	if err != nil {
+		return rule_engine.Rule{}, err
+	}
+	return parsed.Rule, nil
+}
+
+// scaleReward scales the amount granted by the effect of a program by the earn multiplier of the tier policy and caps it
+// by the maximum reward of the program per event, returning the reward it was capped from when it was
+func scaleReward(policy *accountPolicy, program *model.Program, amount uint64) (reward uint64, cappedFrom uint64) {
+	reward = policy.applyEarnMultiplier(amount)
+	if program.MaxRewardPerEvent != nil && reward > *program.MaxRewardPerEvent {
+		return *program.MaxRewardPerEvent, reward
+	}
+	return reward, 0
+}
+
+// rewardUser credits the reward of a program to the user's account in the program wallet, scaled and limited by the user's tier policy
+// and capped by the maximum reward of the program per event
+func (s *programService) rewardUser(ctx context.Context, program *model.Program, user *model.User, amount uint64) (*model.Transaction, error) {
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, program.WalletID)
+	if wallet == nil {
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	account, err := s.repos.Account.FetchAccountByUserID(ctx, program.WalletID, user.ID)
+	if account == nil {
+		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
+	}
+	if err := checkPosting(ctx, wallet, account, model.TransactionTypeCredit); err != nil {
+		return nil, err
+	}
+	policy, err := resolveAccountPolicy(ctx, s.repos, wallet, user.TierID)
+	if err != nil {
+		return nil, err
+	}
+	reward, cappedFrom := scaleReward(policy, program, amount)
+	if reward == 0 {
+		return nil, nil
+	}
+	metadata := types.JSONB{
+		"triggerSlug":    program.TriggerSlug,
+		"baseAmount":     amount,
+		"earnMultiplier": policy.EarnMultiplier.String(),
+	}
+	if cappedFrom > 0 {
+		metadata["cappedFrom"] = cappedFrom
+	}
+	if err := policy.checkCredit(ctx, account, reward); err != nil {
+		return nil, err
+	}
+	programId := strconv.FormatUint(program.ID, 10)
+	programVersion := program.Version
+	transaction := &model.Transaction{
+		AccountID:      account.ID,
+		WalletID:       wallet.ID,
+		Amount:         reward,
+		Reason:         model.TransactionReasonReward,
+		Type:           model.TransactionTypeCredit,
+		ProgramID:      &programId,
+		ProgramVersion: &programVersion,
+		Metadata:       metadata,
+	}
+	transaction.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	transaction.SetRemarks("Reward granted by program " + programId)
+	if wallet.PointsExpireAfter != nil {
+		expireAt := time.Now().Add(wallet.PointsExpireAfter.Duration())
+		transaction.ExpireAt = &expireAt
+	}
+	if program.Budget == nil {
+		if err := s.repos.Transaction.CreateTransaction(ctx, transaction, account.Version); err != nil {
+			return nil, err
+		}
+		return transaction, nil
+	}
+	consumption, err := s.repos.Transaction.CreateRewardTransaction(ctx, transaction, account.Version, program.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.alertBudget(ctx, consumption)
+	return transaction, nil
+}
+
+// alertBudget publishes an alert for each threshold of a program budget that a reward crossed. The reward is already
+// posted, so an alert that can't be published is only logged
+func (s *programService) alertBudget(ctx context.Context, consumption *repository.BudgetConsumption) {
+	program := consumption.Program
+	if program.Budget == nil {
+		return
+	}
+	for _, threshold := range program.Budget.CrossedThresholds(consumption.Before, consumption.After, s.budgetAlertThresholds) {
+		alert := &model.ProgramBudgetAlert{
+			TenantID:      program.TenantID,
+			ProgramID:     program.ID,
+			Name:          program.Name,
+			Threshold:     threshold,
+			Points:        program.Budget.Points,
+			Consumed:      consumption.After,
+			ConsumedValue: program.Budget.MonetaryValueOf(consumption.After),
+			Currency:      program.Budget.Currency,
+			Exhausted:     program.Budget.Remaining(consumption.After) == 0,
+			CreatedAt:     s.now(),
+		}
+		if err := s.repos.Program.PublishBudgetAlert(ctx, alert); err != nil {
+			api.GetLogger(ctx).Error("Unable to publish program budget alert", logger.Field("programId", program.ID), logger.Field("threshold", threshold), logger.Field("error", err))
+		}
+	}
+}
+
+// newUserData exposes the attributes of a user to program conditions as userData, e.g. userData.tier or
+// userData.createdAt. Attributes that are not set, like the tier of a user without one, are null
+func newUserData(user *model.User) map[string]interface{} {
+	userData := map[string]interface{}{
+		"id":             user.ID,
+		"tier":           nil,
+		"tierAchievedAt": nil,
+		"createdAt":      user.CreatedAt,
+		"isActive":       user.IsActive,
+	}
+	if user.TierID != nil {
+		userData["tier"] = *user.TierID
+	}
+	if user.TierAchievedAt != nil {
+		userData["tierAchievedAt"] = *user.TierAchievedAt
+	}
+	return userData
 }
@@ -0,0 +1,958 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	rule_engine "github.com/abdelrahman146/digital-wallet/pkg/rules_engine"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgramService_InvokePrograms(t *testing.T) {
+	tierId := "gold"
+	program := &model.Program{
+		ID:          5,
+		WalletID:    test_walletId,
+		TriggerSlug: "purchase",
+		Condition:   rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10},
+		Effect:      types.JSONB{"type": "FIXED", "amount": float64(100)},
+		Version:     3,
+	}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId, Balance: 50}
+	setupReward := func(mocks *Mocks, ctx context.Context, user *model.User) {
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(user, nil)
+		mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+		mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).Return(nil)
+		mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", gomock.Any()).Return([]*model.Program{program}, nil)
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, test_walletId, test_userId).Return(account, nil)
+	}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Reward is scaled by the tier earn multiplier",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupReward(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &tierId})
+				mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, tierId).Return(&model.TierPolicy{WalletID: test_walletId, TierID: tierId, EarnMultiplier: decimal.RequireFromString("1.5")}, nil)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).DoAndReturn(func(ctx context.Context, transaction *model.Transaction, accountVersion uint64) error {
+					if transaction.Amount != 150 || transaction.Reason != model.TransactionReasonReward || *transaction.ProgramID != "5" {
+						t.Errorf("expected a reward of 150 from program 5, got %+v", transaction)
+					}
+					if transaction.ProgramVersion == nil || *transaction.ProgramVersion != 3 {
+						t.Errorf("expected the reward to record version 3 of program 5, got %v", transaction.ProgramVersion)
+					}
+					return nil
+				})
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+				if result != nil && (len(result.Fired) != 1 || result.Fired[0].Reward != 150) {
+					t.Errorf("expected program 5 to fire with a reward of 150, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Reward beyond the tier maximum balance is not granted",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupReward(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &tierId})
+				maxBalance := uint64(100)
+				mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, tierId).Return(&model.TierPolicy{WalletID: test_walletId, TierID: tierId, EarnMultiplier: decimal.NewFromInt(1), MaxBalance: &maxBalance}, nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+				if result != nil && (len(result.Suppressed) != 1 || result.Suppressed[0].Reason != SuppressionReasonRewardFailed) {
+					t.Errorf("expected the reward of program 5 to fail, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Event is recorded and aggregates are resolved once for programs sharing them",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				thirdPurchase := rule_engine.Rule{Field: "count(purchase, month)", Operator: "==", Val: 3}
+				bigSpender := rule_engine.Rule{Logic: "AND", Rules: []rule_engine.Rule{
+					{Field: "count(purchase, month)", Operator: ">=", Val: 3},
+					{Field: "sum(amount, purchase, 30d)", Operator: ">", Val: 1000},
+				}}
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+				mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+				mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, event *model.TriggerEvent) error {
+					if event.TriggerSlug != "purchase" || event.UserID != test_userId || event.Data["amount"] != 20 {
+						t.Errorf("expected the purchase of %s to be recorded, got %+v", test_userId, event)
+					}
+					return nil
+				})
+				mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", gomock.Any()).Return([]*model.Program{
+					{ID: 6, WalletID: test_walletId, TriggerSlug: "purchase", Condition: thirdPurchase},
+					{ID: 7, WalletID: test_walletId, TriggerSlug: "purchase", Condition: bigSpender},
+				}, nil)
+				mocks.triggerRepo.EXPECT().CountTriggerEvents(ctx, test_userId, "purchase", gomock.Any()).Return(int64(2), nil).Times(1)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+			},
+		},
+		{
+			name: "Unknown trigger is not recorded",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+				mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchse").Return(nil, nil)
+			},
+			expectedError: "TRIGGER_NOT_FOUND",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.InvokePrograms(ctx, "purchse", test_userId, map[string]interface{}{"amount": 20})
+			},
+		},
+		{
+			name: "Permission denied",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		return NewProgramService(mocks.repos)
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_InvokeProgramsByPriority(t *testing.T) {
+	group := "welcome"
+	maxReward := uint64(60)
+	purchase := rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10}
+	fixed := func(amount float64) types.JSONB { return types.JSONB{"type": "FIXED", "amount": amount} }
+	programs := []*model.Program{
+		{ID: 10, Name: "Welcome bonus", WalletID: test_walletId, Priority: 10, ExclusiveGroup: &group, Condition: purchase, Effect: fixed(100), MaxRewardPerEvent: &maxReward},
+		{ID: 11, Name: "Welcome gift", WalletID: test_walletId, Priority: 5, ExclusiveGroup: &group, Condition: purchase, Effect: fixed(50)},
+		{ID: 12, Name: "Big purchase", WalletID: test_walletId, Priority: 3, Condition: rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 100}, Effect: fixed(30)},
+		{ID: 13, Name: "Final offer", WalletID: test_walletId, Priority: 2, StopProcessing: true, Condition: purchase, Effect: fixed(20)},
+		{ID: 14, Name: "Everyday points", WalletID: test_walletId, Priority: 1, Condition: purchase, Effect: fixed(10)},
+	}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Exclusive groups, stop processing and reward caps suppress or limit lower priority programs",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+				mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+				mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).Return(nil)
+				mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", gomock.Any()).Return(programs, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil).Times(2)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, test_walletId, test_userId).Return(account, nil).Times(2)
+				var rewards []uint64
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).DoAndReturn(func(ctx context.Context, transaction *model.Transaction, accountVersion uint64) error {
+					rewards = append(rewards, transaction.Amount)
+					if len(rewards) == 2 && (rewards[0] != 60 || rewards[1] != 20) {
+						t.Errorf("expected rewards of 60 and 20, got %v", rewards)
+					}
+					return nil
+				}).Times(2)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+				if err != nil {
+					return nil, err
+				}
+				if len(result.Fired) != 2 || result.Fired[0].ProgramID != 10 || !result.Fired[0].Capped || result.Fired[1].ProgramID != 13 {
+					t.Errorf("expected programs 10 (capped) and 13 to fire, got %+v", result.Fired)
+				}
+				reasons := map[uint64]string{}
+				for _, outcome := range result.Suppressed {
+					reasons[outcome.ProgramID] = outcome.Reason
+				}
+				expected := map[uint64]string{11: SuppressionReasonExclusiveGroup, 12: SuppressionReasonConditionNotMet, 14: SuppressionReasonStopped}
+				if !reflect.DeepEqual(reasons, expected) {
+					t.Errorf("expected suppressed programs %v, got %v", expected, reasons)
+				}
+				return result, nil
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		return NewProgramService(mocks.repos)
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_InvokeProgramsOnSchedule(t *testing.T) {
+	// Friday 2024-03-15 15:00 UTC, 19:00 in Dubai
+	now := time.Date(2024, time.March, 15, 15, 0, 0, 0, time.UTC)
+	purchase := rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10}
+	programs := []*model.Program{
+		{ID: 20, Name: "Happy hour", WalletID: test_walletId, Condition: purchase, Effect: types.JSONB{"type": "FIXED", "amount": float64(100)},
+			Schedule: &model.ProgramSchedule{Timezone: "Asia/Dubai", Windows: []model.ScheduleWindow{{Weekdays: []string{"FRI"}, From: "18:00", Until: "22:00"}}}},
+		{ID: 21, Name: "Weekend", WalletID: test_walletId, Condition: purchase, Effect: types.JSONB{"type": "FIXED", "amount": float64(50)},
+			Schedule: &model.ProgramSchedule{Windows: []model.ScheduleWindow{{Weekdays: []string{"SAT", "SUN"}}}}},
+		{ID: 22, Name: "Late night", WalletID: test_walletId, Condition: purchase, Effect: types.JSONB{"type": "FIXED", "amount": float64(50)},
+			Schedule: &model.ProgramSchedule{Windows: []model.ScheduleWindow{{Weekdays: []string{"THU"}, From: "22:00", Until: "02:00"}}}},
+		{ID: 23, Name: "Everyday", WalletID: test_walletId, Condition: purchase, Effect: types.JSONB{"type": "FIXED", "amount": float64(10)},
+			Schedule: &model.ProgramSchedule{BlackoutDates: []string{"2024-03-15"}}},
+	}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Programs outside of their schedule are suppressed",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+				mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+				mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).Return(nil)
+				mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", now).Return(programs, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, test_walletId, test_userId).Return(account, nil)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+				if err != nil {
+					return nil, err
+				}
+				if len(result.Fired) != 1 || result.Fired[0].ProgramID != 20 {
+					t.Errorf("expected only the happy hour to fire, got %+v", result.Fired)
+				}
+				for _, outcome := range result.Suppressed {
+					if outcome.Reason != SuppressionReasonOutsideSchedule {
+						t.Errorf("expected program %d to be outside of its schedule, got %s", outcome.ProgramID, outcome.Reason)
+					}
+				}
+				if len(result.Suppressed) != 3 {
+					t.Errorf("expected 3 suppressed programs, got %+v", result.Suppressed)
+				}
+				return result, nil
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		service := NewProgramService(mocks.repos).(*programService)
+		service.now = func() time.Time { return now }
+		return service
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_InvokeProgramsOnUserData(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 15, 0, 0, 0, time.UTC)
+	gold, silver := "gold", "silver"
+	premium := &model.Program{ID: 30, Name: "Premium tiers", WalletID: test_walletId, TriggerSlug: "purchase",
+		Condition: rule_engine.Rule{Field: "userData.tier", Operator: "in", Val: []interface{}{"gold", "platinum"}},
+		Effect:    types.JSONB{"type": "FIXED", "amount": float64(100)}}
+	loyal := &model.Program{ID: 31, Name: "Loyal members", WalletID: test_walletId, TriggerSlug: "purchase",
+		Condition: rule_engine.Rule{Field: "userData.createdAt", Operator: "olderthan", Val: "30d"},
+		Effect:    types.JSONB{"type": "FIXED", "amount": float64(50)}}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId}
+	setupInvoke := func(mocks *Mocks, ctx context.Context, user *model.User, program *model.Program) {
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(user, nil)
+		mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+		mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).Return(nil)
+		mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", now).Return([]*model.Program{program}, nil)
+	}
+	setupReward := func(mocks *Mocks, ctx context.Context) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, test_walletId, test_userId).Return(account, nil)
+		mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, gomock.Any()).Return(nil, nil).AnyTimes()
+		mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+	}
+	expectOutcome := func(fired bool) func(service ProgramService, ctx context.Context) (interface{}, error) {
+		return func(service ProgramService, ctx context.Context) (interface{}, error) {
+			result, err := service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+			if err != nil {
+				return nil, err
+			}
+			if fired && len(result.Fired) != 1 {
+				t.Errorf("expected the program to fire, got %+v", result)
+			}
+			if !fired && (len(result.Suppressed) != 1 || result.Suppressed[0].Reason != SuppressionReasonConditionNotMet) {
+				t.Errorf("expected the condition of the program not to be met, got %+v", result)
+			}
+			return result, nil
+		}
+	}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "A tier condition fires for a gold user",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &gold}, premium)
+				setupReward(mocks, ctx)
+			},
+			testFunc:     expectOutcome(true),
+			expectResult: true,
+		},
+		{
+			name: "A tier condition is not met by a silver user",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &silver}, premium)
+			},
+			testFunc:     expectOutcome(false),
+			expectResult: true,
+		},
+		{
+			name: "A tier condition is not met by a user without a tier",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, &model.User{ID: test_userId, IsActive: true}, premium)
+			},
+			testFunc:     expectOutcome(false),
+			expectResult: true,
+		},
+		{
+			name: "Users created more than 30 days ago are rewarded",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, &model.User{ID: test_userId, IsActive: true, CreatedAt: now.AddDate(0, 0, -31)}, loyal)
+				setupReward(mocks, ctx)
+			},
+			testFunc:     expectOutcome(true),
+			expectResult: true,
+		},
+		{
+			name: "Users created within the last 30 days are not rewarded",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, &model.User{ID: test_userId, IsActive: true, CreatedAt: now.AddDate(0, 0, -29)}, loyal)
+			},
+			testFunc:     expectOutcome(false),
+			expectResult: true,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		service := NewProgramService(mocks.repos).(*programService)
+		service.now = func() time.Time { return now }
+		return service
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_InvokeProgramsOutsideWalletScope(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 15, 0, 0, 0, time.UTC)
+	inScope := &model.Program{ID: 40, Name: "In scope", WalletID: test_walletId, TriggerSlug: "purchase",
+		Condition: rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10},
+		Effect:    types.JSONB{"type": "FIXED", "amount": float64(100)}}
+	outOfScope := &model.Program{ID: 41, Name: "Out of scope", WalletID: "other-wallet", TriggerSlug: "purchase",
+		Condition: rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10},
+		Effect:    types.JSONB{"type": "FIXED", "amount": float64(100)}}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Programs of wallets outside the scope of the API key are suppressed",
+			ctx:  createApiKeyContext([]string{test_walletId}, api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+				mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+				mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).Return(nil)
+				mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", now).Return([]*model.Program{outOfScope, inScope}, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, test_walletId, test_userId).Return(account, nil)
+				mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, gomock.Any()).Return(nil, nil).AnyTimes()
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+			},
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+				if err != nil {
+					return nil, err
+				}
+				if len(result.Fired) != 1 || result.Fired[0].ProgramID != inScope.ID {
+					t.Errorf("expected the program in scope to fire, got %+v", result.Fired)
+				}
+				if len(result.Suppressed) != 1 || result.Suppressed[0].ProgramID != outOfScope.ID || result.Suppressed[0].Reason != SuppressionReasonWalletNotInScope {
+					t.Errorf("expected the program out of scope to be suppressed, got %+v", result.Suppressed)
+				}
+				return result, nil
+			},
+			expectResult: true,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		service := NewProgramService(mocks.repos).(*programService)
+		service.now = func() time.Time { return now }
+		return service
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_InvokeProgramsWithBudget(t *testing.T) {
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId}
+	newProgram := func(consumed uint64, allowPartial bool) *model.Program {
+		value := decimal.NewFromInt(500)
+		return &model.Program{ID: 30, Name: "Launch", WalletID: test_walletId, IsActive: true,
+			Condition:      rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10},
+			Effect:         types.JSONB{"type": "FIXED", "amount": float64(100)},
+			Budget:         &model.ProgramBudget{Points: 1000, MonetaryValue: &value, Currency: "USD", AllowPartial: allowPartial},
+			BudgetConsumed: consumed,
+		}
+	}
+	setupInvoke := func(mocks *Mocks, ctx context.Context, program *model.Program) {
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+		mocks.triggerRepo.EXPECT().FetchTriggerBySlug(ctx, "purchase").Return(&model.Trigger{Slug: "purchase"}, nil)
+		mocks.triggerRepo.EXPECT().CreateTriggerEvent(ctx, gomock.Any()).Return(nil)
+		mocks.programRepo.EXPECT().FetchTriggerPrograms(ctx, "purchase", gomock.Any()).Return([]*model.Program{program}, nil)
+	}
+	setupReward := func(mocks *Mocks, ctx context.Context) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, test_walletId, test_userId).Return(account, nil)
+	}
+	invoke := func(service ProgramService, ctx context.Context) (*InvocationResult, error) {
+		return service.InvokePrograms(ctx, "purchase", test_userId, map[string]interface{}{"amount": 20})
+	}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Reward is consumed from the budget and crossed thresholds are alerted",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram(750, false)
+				setupInvoke(mocks, ctx, program)
+				setupReward(mocks, ctx)
+				mocks.transactionRepo.EXPECT().CreateRewardTransaction(ctx, gomock.Any(), account.Version, uint64(30)).DoAndReturn(func(ctx context.Context, transaction *model.Transaction, accountVersion uint64, programId uint64) (*repository.BudgetConsumption, error) {
+					consumed := *program
+					consumed.BudgetConsumed += transaction.Amount
+					return &repository.BudgetConsumption{Program: &consumed, Before: 750, After: consumed.BudgetConsumed}, nil
+				})
+				mocks.programRepo.EXPECT().PublishBudgetAlert(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, alert *model.ProgramBudgetAlert) error {
+					if alert.ProgramID != 30 || alert.Threshold != 80 || alert.Consumed != 850 || alert.ConsumedValue.String() != "425" || alert.Exhausted {
+						t.Errorf("expected an alert for 80%% of the budget consumed, got %+v", alert)
+					}
+					return nil
+				})
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := invoke(service, ctx)
+				if result != nil && (len(result.Fired) != 1 || result.Fired[0].Reward != 100 || result.Fired[0].Partial) {
+					t.Errorf("expected the full reward of 100, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Partial reward exhausts the budget and is alerted",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram(950, true)
+				setupInvoke(mocks, ctx, program)
+				setupReward(mocks, ctx)
+				mocks.transactionRepo.EXPECT().CreateRewardTransaction(ctx, gomock.Any(), account.Version, uint64(30)).DoAndReturn(func(ctx context.Context, transaction *model.Transaction, accountVersion uint64, programId uint64) (*repository.BudgetConsumption, error) {
+					transaction.Metadata["partialFrom"] = transaction.Amount
+					transaction.Amount = 50
+					paused := *program
+					paused.BudgetConsumed, paused.IsActive = 1000, false
+					return &repository.BudgetConsumption{Program: &paused, Before: 950, After: 1000}, nil
+				})
+				mocks.programRepo.EXPECT().PublishBudgetAlert(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, alert *model.ProgramBudgetAlert) error {
+					if alert.Threshold != 100 || !alert.Exhausted {
+						t.Errorf("expected an alert for the exhausted budget, got %+v", alert)
+					}
+					return nil
+				})
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := invoke(service, ctx)
+				if result != nil && (len(result.Fired) != 1 || result.Fired[0].Reward != 50 || !result.Fired[0].Partial) {
+					t.Errorf("expected a partial reward of 50, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Reward exceeding the remaining budget is not granted without partial rewards",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, newProgram(950, false))
+				setupReward(mocks, ctx)
+				mocks.transactionRepo.EXPECT().CreateRewardTransaction(ctx, gomock.Any(), account.Version, uint64(30)).
+					Return(nil, errs.NewPaymentRequiredError("Reward exceeds the remaining program budget", "PROGRAM_BUDGET_INSUFFICIENT", nil))
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := invoke(service, ctx)
+				if result != nil && (len(result.Fired) != 0 || len(result.Suppressed) != 1 || result.Suppressed[0].Reason != SuppressionReasonBudgetInsufficient) {
+					t.Errorf("expected the program to be suppressed for its budget, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Program with an exhausted budget is suppressed",
+			ctx:  createBackofficeContext(api.PermissionProgramInvoke),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupInvoke(mocks, ctx, newProgram(1000, true))
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := invoke(service, ctx)
+				if result != nil && (len(result.Suppressed) != 1 || result.Suppressed[0].Reason != SuppressionReasonBudgetExhausted) {
+					t.Errorf("expected the program to be suppressed for its exhausted budget, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Program with an exhausted budget is only switched on with a larger budget",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite, api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram(1000, false)
+				program.IsActive = false
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(30)).Return(program, nil)
+			},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				active := true
+				return service.UpdateProgram(ctx, 30, UpdateProgramRequest{IsActive: &active})
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		service := NewProgramService(mocks.repos).(*programService)
+		service.budgetAlertThresholds = []int{50, 80}
+		return service
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_CreateProgram(t *testing.T) {
+	validRequest := func(condition rule_engine.Rule) CreateProgramRequest {
+		return CreateProgramRequest{
+			Name:        "Purchase reward",
+			WalletID:    test_walletId,
+			TriggerSlug: "purchase",
+			Condition:   condition,
+			Effect:      types.JSONB{"type": "FIXED", "amount": float64(100)},
+		}
+	}
+	testcases := []TestCase[ProgramService]{
+		{
+			name:          "Program with an invalid schedule is rejected with field errors",
+			ctx:           createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				req := validRequest(rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10})
+				req.Schedule = &model.ProgramSchedule{
+					Timezone:      "Mars/Olympus",
+					Windows:       []model.ScheduleWindow{{Weekdays: []string{"FUNDAY"}, From: "6pm", Until: "22:00"}},
+					BlackoutDates: []string{"25/12/2024"},
+				}
+				program, err := service.CreateProgram(ctx, req)
+				fields := errs.HandleError(err).Fields
+				for _, field := range []string{"schedule.timezone", "schedule.windows[0].weekdays", "schedule.windows[0].from", "schedule.blackoutDates[0]"} {
+					if fields[field] == "" {
+						t.Errorf("expected a field error for %s, got %v", field, fields)
+					}
+				}
+				return program, err
+			},
+		},
+		{
+			name:          "Program with an invalid budget is rejected with field errors",
+			ctx:           createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				req := validRequest(rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10})
+				value := decimal.NewFromInt(-5)
+				req.Budget = &model.ProgramBudget{MonetaryValue: &value, AlertThresholds: []int{50, 120}}
+				program, err := service.CreateProgram(ctx, req)
+				fields := errs.HandleError(err).Fields
+				for _, field := range []string{"budget.points", "budget.monetaryValue", "budget.currency", "budget.alertThresholds[1]"} {
+					if fields[field] == "" {
+						t.Errorf("expected a field error for %s, got %v", field, fields)
+					}
+				}
+				return program, err
+			},
+		},
+		{
+			name: "Program with a valid condition is created",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().CreateProgram(ctx, gomock.Any()).Return(nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.CreateProgram(ctx, validRequest(rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10}))
+			},
+		},
+		{
+			name:          "Program with an invalid condition is rejected with field errors",
+			ctx:           createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				program, err := service.CreateProgram(ctx, validRequest(rule_engine.Rule{
+					Logic: "AND",
+					Rules: []rule_engine.Rule{
+						{Field: "triggerData.amount", Operator: "greater", Val: 10},
+						{Field: "triggerData.sku", Operator: "matches", Val: "("},
+					},
+				}))
+				fields := errs.HandleError(err).Fields
+				if fields["condition.rules[0].operator"] == "" || fields["condition.rules[1].value"] == "" {
+					t.Errorf("expected field errors for the operator and the regex, got %v", fields)
+				}
+				return program, err
+			},
+		},
+		{
+			name: "Program with a condition expression is created with the parsed condition",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().CreateProgram(ctx, gomock.Any()).Return(nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				req := validRequest(rule_engine.Rule{})
+				expression := `triggerData.amount >= 100 and userData.tier in ["gold", "platinum"]`
+				req.ConditionExpression = &expression
+				program, err := service.CreateProgram(ctx, req)
+				if program != nil && (program.Condition.Logic != "AND" || len(program.Condition.Rules) != 2 || program.ConditionExpression != expression) {
+					t.Errorf("expected the expression to be parsed, got %+v", program.Condition)
+				}
+				return program, err
+			},
+		},
+		{
+			name:          "Program with an invalid condition expression is rejected with its position",
+			ctx:           createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				req := validRequest(rule_engine.Rule{})
+				expression := "triggerData.amount >= 100 and\ntriggerData.sku matches \"(\""
+				req.ConditionExpression = &expression
+				program, err := service.CreateProgram(ctx, req)
+				if problem := errs.HandleError(err).Fields["conditionExpression"]; !strings.HasPrefix(problem, "line 2, column 25: value") {
+					t.Errorf("expected the invalid regex to be reported at line 2, column 25, got %q", problem)
+				}
+				return program, err
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		return NewProgramService(mocks.repos)
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_UpdateProgram(t *testing.T) {
+	program := &model.Program{ID: 5, WalletID: test_walletId, TriggerSlug: "purchase", Condition: rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10}}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Condition with a NOT holding two rules is rejected",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+			},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				condition := rule_engine.Rule{Logic: "NOT", Rules: []rule_engine.Rule{
+					{Field: "triggerData.amount", Operator: ">", Val: 10},
+					{Field: "triggerData.amount", Operator: "<", Val: 100},
+				}}
+				return service.UpdateProgram(ctx, 5, UpdateProgramRequest{Condition: &condition})
+			},
+		},
+		{
+			name: "Condition expression with a syntax error is rejected",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+			},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				expression := "triggerData.amount >"
+				return service.UpdateProgram(ctx, 5, UpdateProgramRequest{ConditionExpression: &expression})
+			},
+		},
+		{
+			name: "Condition and condition expression cannot be used together",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+			},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				expression := "triggerData.amount > 10"
+				return service.UpdateProgram(ctx, 5, UpdateProgramRequest{Condition: &program.Condition, ConditionExpression: &expression})
+			},
+		},
+		{
+			name: "Edits go to the draft and leave the published version running",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				published := *program
+				published.Version = 2
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(&published, nil)
+				mocks.programRepo.EXPECT().UpdateProgram(ctx, gomock.Any()).Return(nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				expression := "triggerData.amount > 50"
+				updated, err := service.UpdateProgram(ctx, 5, UpdateProgramRequest{ConditionExpression: &expression})
+				if err != nil {
+					return nil, err
+				}
+				if updated.Version != 2 || updated.Condition.Val != 10 {
+					t.Errorf("expected version 2 to keep running, got %+v", updated)
+				}
+				if updated.Draft == nil || updated.Draft.ConditionExpression != expression {
+					t.Errorf("expected the draft to hold the new condition, got %+v", updated.Draft)
+				}
+				return updated, nil
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		return NewProgramService(mocks.repos)
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_PublishProgram(t *testing.T) {
+	condition := rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10}
+	newProgram := func() *model.Program {
+		return &model.Program{ID: 5, Name: "Purchase", WalletID: test_walletId, TriggerSlug: "purchase", Condition: condition, Version: 3}
+	}
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Draft is published as the next version",
+			ctx:  createBackofficeContext(api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram()
+				draft := program.Definition()
+				draft.Condition = rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 50}
+				program.Draft = &draft
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+				mocks.programRepo.EXPECT().PublishProgram(ctx, program, gomock.Any()).DoAndReturn(func(ctx context.Context, program *model.Program, version *model.ProgramVersion) error {
+					if version.ProgramID != 5 || version.Version != 4 || version.RolledBackFrom != nil || !reflect.DeepEqual(version.Definition.Condition, draft.Condition) {
+						t.Errorf("expected version 4 to hold the draft, got %+v", version)
+					}
+					return nil
+				})
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				program, err := service.PublishProgram(ctx, 5)
+				if program != nil && (program.Version != 4 || program.Draft != nil || program.ConditionExpression != "triggerData.amount > 50") {
+					t.Errorf("expected the program to run version 4 without a draft, got %+v", program)
+				}
+				return program, err
+			},
+		},
+		{
+			name: "Program without a draft has nothing to publish",
+			ctx:  createBackofficeContext(api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(newProgram(), nil)
+			},
+			expectedError: "PROGRAM_DRAFT_NOT_FOUND",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.PublishProgram(ctx, 5)
+			},
+		},
+		{
+			name: "Earlier version is published again on rollback",
+			ctx:  createBackofficeContext(api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				earlier := newProgram().Definition()
+				earlier.Condition = rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 5}
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(newProgram(), nil)
+				mocks.programRepo.EXPECT().FetchProgramVersion(ctx, uint64(5), uint64(1)).Return(&model.ProgramVersion{ProgramID: 5, Version: 1, Definition: earlier}, nil)
+				mocks.programRepo.EXPECT().PublishProgram(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, program *model.Program, version *model.ProgramVersion) error {
+					if version.Version != 4 || version.RolledBackFrom == nil || *version.RolledBackFrom != 1 {
+						t.Errorf("expected version 4 to roll back to version 1, got %+v", version)
+					}
+					return nil
+				})
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				program, err := service.RollbackProgram(ctx, 5, RollbackProgramRequest{Version: 1})
+				if program != nil && (program.Version != 4 || program.Condition.Val != 5) {
+					t.Errorf("expected the program to run the condition of version 1 as version 4, got %+v", program)
+				}
+				return program, err
+			},
+		},
+		{
+			name: "Rollback to the running version is rejected",
+			ctx:  createBackofficeContext(api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(newProgram(), nil)
+			},
+			expectedError: "PROGRAM_VERSION_PUBLISHED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.RollbackProgram(ctx, 5, RollbackProgramRequest{Version: 3})
+			},
+		},
+		{
+			name: "Publishing the draft of an active program is held for approval",
+			ctx:  createBackofficeContext(api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram()
+				program.IsActive = true
+				draft := program.Definition()
+				draft.Effect = types.JSONB{"type": "FIXED", "amount": float64(1000)}
+				program.Draft = &draft
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+				mocks.approvalRepo.EXPECT().CreateApproval(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, approval *model.Approval) error {
+					if approval.Operation != model.ApprovalOperationPublishProgram || approval.Payload["draft"] == nil {
+						t.Errorf("expected an approval to publish the draft, got %+v", approval)
+					}
+					approval.ID = "approval-123"
+					return nil
+				})
+			},
+			expectedError: "APPROVAL_REQUIRED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.PublishProgram(ctx, 5)
+			},
+		},
+		{
+			name: "Rolling back an active program is held for approval",
+			ctx:  createBackofficeContext(api.PermissionProgramPublish),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram()
+				program.IsActive = true
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+				mocks.programRepo.EXPECT().FetchProgramVersion(ctx, uint64(5), uint64(1)).Return(&model.ProgramVersion{ProgramID: 5, Version: 1, Definition: newProgram().Definition()}, nil)
+				mocks.approvalRepo.EXPECT().CreateApproval(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, approval *model.Approval) error {
+					if approval.Operation != model.ApprovalOperationRollbackProgram {
+						t.Errorf("expected an approval to roll the program back, got %+v", approval)
+					}
+					approval.ID = "approval-123"
+					return nil
+				})
+			},
+			expectedError: "APPROVAL_REQUIRED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.RollbackProgram(ctx, 5, RollbackProgramRequest{Version: 1})
+			},
+		},
+		{
+			name: "Approved publication is refused once the draft changed",
+			ctx: withApproval(createBackofficeContext(api.PermissionProgramPublish), &model.Approval{
+				ID:        "approval-123",
+				Operation: model.ApprovalOperationPublishProgram,
+				Payload:   types.JSONB{"programId": float64(5), "draft": map[string]interface{}{"name": "Purchase", "effect": map[string]interface{}{"type": "FIXED", "amount": float64(100)}}},
+			}),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				program := newProgram()
+				program.IsActive = true
+				draft := program.Definition()
+				draft.Effect = types.JSONB{"type": "FIXED", "amount": float64(1000)}
+				program.Draft = &draft
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+			},
+			expectedError: "PROGRAM_DRAFT_CHANGED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.PublishProgram(ctx, 5)
+			},
+		},
+		{
+			name: "Approved activation is refused once another version was published",
+			ctx: withApproval(createBackofficeContext(api.PermissionProgramWrite, api.PermissionProgramPublish), &model.Approval{
+				ID:        "approval-123",
+				Operation: model.ApprovalOperationUpdateProgram,
+				Payload:   types.JSONB{"programId": float64(5), "request": map[string]interface{}{"isActive": true}, "version": float64(2)},
+			}),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(newProgram(), nil)
+			},
+			expectedError: "PROGRAM_VERSION_CHANGED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				active := true
+				return service.UpdateProgram(ctx, 5, UpdateProgramRequest{IsActive: &active})
+			},
+		},
+		{
+			name: "Publishing needs the publish permission",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				return service.PublishProgram(ctx, 5)
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		return NewProgramService(mocks.repos)
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
+
+func TestProgramService_SimulateProgram(t *testing.T) {
+	maxReward := uint64(80)
+	program := &model.Program{ID: 5, WalletID: test_walletId, TriggerSlug: "purchase", Version: 2,
+		Condition: rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 10},
+		Effect:    types.JSONB{"type": "FIXED", "amount": float64(100)},
+	}
+	draft := program.Definition()
+	draft.Condition = rule_engine.Rule{Field: "triggerData.amount", Operator: ">", Val: 50}
+	draft.MaxRewardPerEvent = &maxReward
+	program.Draft = &draft
+	testcases := []TestCase[ProgramService]{
+		{
+			name: "Draft is simulated without recording or rewarding anything",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId, IsActive: true}, nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				result, err := service.SimulateProgram(ctx, 5, SimulateProgramRequest{UserID: test_userId, Data: map[string]interface{}{"amount": 60}})
+				if result != nil && (!result.Draft || !result.Fired || result.Reward != 80 || !result.Capped) {
+					t.Errorf("expected the draft to fire with a reward capped at 80, got %+v", result)
+				}
+				return result, err
+			},
+		},
+		{
+			name: "Published version is simulated when it is asked for",
+			ctx:  createBackofficeContext(api.PermissionProgramWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.programRepo.EXPECT().FetchProgramByID(ctx, uint64(5)).Return(program, nil)
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+			},
+			expectResult: true,
+			testFunc: func(service ProgramService, ctx context.Context) (interface{}, error) {
+				version := uint64(2)
+				result, err := service.SimulateProgram(ctx, 5, SimulateProgramRequest{UserID: test_userId, Data: map[string]interface{}{"amount": 5}, Version: &version})
+				if result != nil && (result.Draft || result.Version != 2 || result.Fired || result.Reason != SuppressionReasonConditionNotMet) {
+					t.Errorf("expected version 2 not to fire, got %+v", result)
+				}
+				return result, err
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ProgramService {
+		return NewProgramService(mocks.repos)
+	}
+	RunTestCases[ProgramService](t, serviceFactory, testcases)
+}
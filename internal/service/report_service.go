@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"github.com/shopspring/decimal"
+	"io"
+	"time"
+)
+
+const (
+	// reportMonthLayout is the layout of the months of a report
+	reportMonthLayout = "2006-01"
+	// reportMaxMonths is the longest period a report can cover
+	reportMaxMonths = 120
+	// liabilityRefreshBatchSize is the number of wallets loaded at a time while refreshing the liability summaries
+	liabilityRefreshBatchSize = 100
+)
+
+type ReportService interface {
+	// GetLiabilityReport reports the points a wallet issued, had redeemed and had expire every month of a period,
+	// along with its outstanding liability, valued in a monetary wallet when one is given
+	GetLiabilityReport(ctx context.Context, walletId string, req *LiabilityReportRequest) (*LiabilityReport, error)
+	// WriteLiabilityReport writes a liability report as csv
+	WriteLiabilityReport(ctx context.Context, report *LiabilityReport, w io.Writer) error
+	// RefreshLiabilitySummaries refreshes the monthly liability summaries of every wallet, from their latest month on
+	RefreshLiabilitySummaries(ctx context.Context) error
+}
+
+type reportService struct {
+	repos *repository.Repos
+}
+
+func NewReportService(repos *repository.Repos) ReportService {
+	return &reportService{repos: repos}
+}
+
+func (s *reportService) GetLiabilityReport(ctx context.Context, walletId string, req *LiabilityReportRequest) (*LiabilityReport, error) {
+	if err := authorize(ctx, s.repos, api.PermissionReportRead); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid report request", logger.Field("fields", fields))
+		return nil, errs.NewValidationError("Invalid report request", "", fields)
+	}
+	from, _ := time.Parse(reportMonthLayout, req.From)
+	to, _ := time.Parse(reportMonthLayout, req.To)
+	if to.Before(from) || to.After(from.AddDate(0, reportMaxMonths-1, 0)) {
+		api.GetLogger(ctx).Error("Invalid report period", logger.Field("from", from), logger.Field("to", to))
+		return nil, errs.NewValidationError("Invalid report request", "", map[string]string{"to": "must be after from and within 120 months of it"})
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
+	if wallet == nil {
+		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId))
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	report := &LiabilityReport{WalletID: wallet.ID, Currency: wallet.Currency, From: req.From, To: req.To, Format: req.Format}
+	if report.Format == "" {
+		report.Format = statementFormatJSON
+	}
+	if req.MonetaryWalletID != "" {
+		monetaryWallet, err := s.repos.Wallet.FetchWalletByID(ctx, req.MonetaryWalletID)
+		if monetaryWallet == nil {
+			api.GetLogger(ctx).Error("Monetary wallet not found", logger.Field("walletId", req.MonetaryWalletID))
+			return nil, errs.NewNotFoundError("Monetary wallet not found", "WALLET_NOT_FOUND", err)
+		}
+		if !monetaryWallet.IsMonetary {
+			return nil, errs.NewValidationError("Invalid report request", "", map[string]string{"monetaryWalletId": "must be a monetary wallet"})
+		}
+		report.MonetaryWalletID = &monetaryWallet.ID
+		report.MonetaryCurrency = &monetaryWallet.Currency
+	}
+	end := to.AddDate(0, 1, 0)
+	opening, err := s.repos.Report.SumLiabilityBefore(ctx, walletId, from)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := s.repos.Report.FetchLiabilitySummaries(ctx, walletId, from, end)
+	if err != nil {
+		return nil, err
+	}
+	byMonth := make(map[string]model.WalletLiabilitySummary, len(summaries))
+	for _, summary := range summaries {
+		byMonth[summary.Month.UTC().Format(reportMonthLayout)] = summary
+	}
+	liability := opening
+	report.OpeningLiability = uint64(opening)
+	report.Months = []LiabilityReportMonth{}
+	for month := from; month.Before(end); month = month.AddDate(0, 1, 0) {
+		summary := byMonth[month.Format(reportMonthLayout)]
+		line := LiabilityReportMonth{
+			Month:            month.Format(reportMonthLayout),
+			OpeningLiability: uint64(liability),
+			Issued:           summary.Issued,
+			Redeemed:         summary.Redeemed,
+			Expired:          summary.Expired,
+			OtherDebits:      summary.OtherDebits,
+		}
+		liability += int64(summary.Issued) - int64(summary.Redeemed) - int64(summary.Expired) - int64(summary.OtherDebits)
+		line.ClosingLiability = uint64(liability)
+		if report.MonetaryWalletID != nil {
+			if err := s.valueMonth(ctx, walletId, *report.MonetaryWalletID, month.AddDate(0, 1, 0), &line); err != nil {
+				return nil, err
+			}
+		}
+		report.TotalIssued += line.Issued
+		report.TotalRedeemed += line.Redeemed
+		report.TotalExpired += line.Expired
+		report.TotalOtherDebits += line.OtherDebits
+		report.Months = append(report.Months, line)
+	}
+	report.ClosingLiability = uint64(liability)
+	return report, nil
+}
+
+// valueMonth values the closing liability of a month at the default exchange rate to the monetary wallet that applied
+// at its end, or now for the current month. Months without an exchange rate are left without a value
+func (s *reportService) valueMonth(ctx context.Context, walletId, monetaryWalletId string, end time.Time, line *LiabilityReportMonth) error {
+	at := end.Add(-time.Microsecond)
+	if now := time.Now(); at.After(now) {
+		at = now
+	}
+	exchangeRate, err := s.repos.ExchangeRate.FetchExchangeRate(ctx, walletId, monetaryWalletId, nil, at)
+	if err != nil {
+		return err
+	}
+	if exchangeRate == nil {
+		return nil
+	}
+	value := decimal.NewFromUint64(line.ClosingLiability).Mul(exchangeRate.ExchangeRate)
+	line.ExchangeRate = &exchangeRate.ExchangeRate
+	line.MonetaryValue = &value
+	return nil
+}
+
+func (s *reportService) WriteLiabilityReport(ctx context.Context, report *LiabilityReport, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"month", "opening_liability", "issued", "redeemed", "expired", "other_debits", "closing_liability", "exchange_rate", "monetary_value"}); err != nil {
+		return err
+	}
+	for _, line := range report.Months {
+		record := []string{line.Month, formatUint(line.OpeningLiability), formatUint(line.Issued), formatUint(line.Redeemed), formatUint(line.Expired), formatUint(line.OtherDebits), formatUint(line.ClosingLiability), "", ""}
+		if line.ExchangeRate != nil {
+			record[7] = line.ExchangeRate.String()
+			record[8] = line.MonetaryValue.String()
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		api.GetLogger(ctx).Error("Failed to write liability report", logger.Field("error", err), logger.Field("walletId", report.WalletID))
+		return err
+	}
+	return nil
+}
+
+func (s *reportService) RefreshLiabilitySummaries(ctx context.Context) error {
+	if err := authorize(ctx, s.repos, api.PermissionWalletWrite); err != nil {
+		return err
+	}
+	for page := 1; ; page++ {
+		wallets, err := s.repos.Wallet.FetchWallets(ctx, page, liabilityRefreshBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range wallets {
+			if err := s.refreshWalletSummaries(ctx, wallets[i].ID); err != nil {
+				api.GetLogger(ctx).Error("Unable to refresh liability summaries", logger.Field("walletId", wallets[i].ID), logger.Field("error", err))
+			}
+		}
+		if len(wallets) < liabilityRefreshBatchSize {
+			return nil
+		}
+	}
+}
+
+// refreshWalletSummaries summarizes the transactions of a wallet again from its latest summarized month, which may
+// have been summarized before it ended, or from its first transaction
+func (s *reportService) refreshWalletSummaries(ctx context.Context, walletId string) error {
+	latest, err := s.repos.Report.FetchLatestLiabilitySummary(ctx, walletId)
+	if err != nil {
+		return err
+	}
+	var from time.Time
+	if latest != nil {
+		from = latest.Month
+	}
+	summaries, err := s.repos.Report.AggregateLiabilitySummaries(ctx, walletId, from)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range summaries {
+		summaries[i].RefreshedAt = now
+	}
+	return s.repos.Report.SaveLiabilitySummaries(ctx, summaries)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestReportService_GetLiabilityReport(t *testing.T) {
+	january := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	wallet := &model.Wallet{ID: test_walletId, Currency: "PTS"}
+	summaries := []model.WalletLiabilitySummary{
+		{WalletID: test_walletId, Month: january, Issued: 1000, Redeemed: 300, Expired: 0, OtherDebits: 50},
+		{WalletID: test_walletId, Month: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Issued: 200, Redeemed: 100, Expired: 150},
+	}
+	setupReport := func(mocks *Mocks, ctx context.Context) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+		mocks.reportRepo.EXPECT().SumLiabilityBefore(ctx, test_walletId, january).Return(int64(500), nil)
+		mocks.reportRepo.EXPECT().FetchLiabilitySummaries(ctx, test_walletId, january, april).Return(summaries, nil)
+	}
+	request := &LiabilityReportRequest{From: "2026-01", To: "2026-03"}
+	testcases := []TestCase[ReportService]{
+		{
+			name: "Rolls the liability forward month by month",
+			ctx:  createBackofficeContext(api.PermissionReportRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupReport(mocks, ctx)
+			},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				report, err := service.GetLiabilityReport(ctx, test_walletId, request)
+				if err != nil {
+					return nil, err
+				}
+				if len(report.Months) != 3 {
+					t.Fatalf("expected 3 months, got %+v", report.Months)
+				}
+				if report.Months[0].OpeningLiability != 500 || report.Months[0].OtherDebits != 50 || report.Months[0].ClosingLiability != 1150 {
+					t.Errorf("unexpected january %+v", report.Months[0])
+				}
+				if report.Months[1].Issued != 0 || report.Months[1].ClosingLiability != 1150 {
+					t.Errorf("expected february without transactions to carry the liability, got %+v", report.Months[1])
+				}
+				if report.Months[2].Expired != 150 || report.Months[2].ClosingLiability != 1100 {
+					t.Errorf("unexpected march %+v", report.Months[2])
+				}
+				if report.OpeningLiability != 500 || report.ClosingLiability != 1100 || report.TotalIssued != 1200 || report.TotalRedeemed != 400 || report.TotalExpired != 150 || report.TotalOtherDebits != 50 {
+					t.Errorf("unexpected report totals %+v", report)
+				}
+				return report, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Values the closing liability in a monetary wallet",
+			ctx:  createBackofficeContext(api.PermissionReportRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupReport(mocks, ctx)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "usd").Return(&model.Wallet{ID: "usd", Currency: "USD", IsMonetary: true}, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, test_walletId, "usd", nil, february.Add(-time.Microsecond)).Return(&model.ExchangeRate{ExchangeRate: decimal.RequireFromString("0.01")}, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, test_walletId, "usd", nil, gomock.Any()).Return(nil, nil).Times(2)
+			},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				report, err := service.GetLiabilityReport(ctx, test_walletId, &LiabilityReportRequest{From: request.From, To: request.To, MonetaryWalletID: "usd", Format: "csv"})
+				if err != nil {
+					return nil, err
+				}
+				if report.Months[0].MonetaryValue == nil || !report.Months[0].MonetaryValue.Equal(decimal.RequireFromString("11.5")) {
+					t.Errorf("unexpected january value %+v", report.Months[0])
+				}
+				if report.Months[1].MonetaryValue != nil {
+					t.Errorf("expected a month without an exchange rate to have no value, got %+v", report.Months[1])
+				}
+				var out bytes.Buffer
+				if err := service.WriteLiabilityReport(ctx, report, &out); err != nil {
+					return nil, err
+				}
+				expected := "month,opening_liability,issued,redeemed,expired,other_debits,closing_liability,exchange_rate,monetary_value\n" +
+					"2026-01,500,1000,300,0,50,1150,0.01,11.5\n" +
+					"2026-02,1150,0,0,0,0,1150,,\n" +
+					"2026-03,1150,200,100,150,0,1100,,\n"
+				if out.String() != expected {
+					t.Errorf("unexpected csv report:\n%s", out.String())
+				}
+				return report, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Fails when the exchange rate cannot be looked up",
+			ctx:  createBackofficeContext(api.PermissionReportRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupReport(mocks, ctx)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "usd").Return(&model.Wallet{ID: "usd", Currency: "USD", IsMonetary: true}, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, test_walletId, "usd", nil, gomock.Any()).Return(nil, errs.NewInternalError("Database unavailable", "", nil))
+			},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				return service.GetLiabilityReport(ctx, test_walletId, &LiabilityReportRequest{From: request.From, To: request.To, MonetaryWalletID: "usd"})
+			},
+			expectedError: "INTERNAL_ERROR",
+			expectResult:  false,
+		},
+		{
+			name: "Rejects a wallet that is not monetary to value the liability in",
+			ctx:  createBackofficeContext(api.PermissionReportRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "miles").Return(&model.Wallet{ID: "miles"}, nil)
+			},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				return service.GetLiabilityReport(ctx, test_walletId, &LiabilityReportRequest{From: request.From, To: request.To, MonetaryWalletID: "miles"})
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name:       "Rejects a period ending before it starts",
+			ctx:        createBackofficeContext(api.PermissionReportRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				return service.GetLiabilityReport(ctx, test_walletId, &LiabilityReportRequest{From: "2026-03", To: "2026-01"})
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name: "Requires the report permission",
+			ctx:  createBackofficeContext(api.PermissionWalletRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				return service.GetLiabilityReport(ctx, test_walletId, request)
+			},
+			expectedError: "PERMISSION_DENIED",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ReportService {
+		return NewReportService(mocks.repos)
+	}
+	RunTestCases[ReportService](t, serviceFactory, testcases)
+}
+
+func TestReportService_RefreshLiabilitySummaries(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	testcases := []TestCase[ReportService]{
+		{
+			name: "Summarizes again from the latest month, and every month of a new wallet",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWallets(ctx, 1, liabilityRefreshBatchSize).Return([]model.Wallet{{ID: test_walletId}, {ID: "new"}}, nil)
+				mocks.reportRepo.EXPECT().FetchLatestLiabilitySummary(ctx, test_walletId).Return(&model.WalletLiabilitySummary{WalletID: test_walletId, Month: march}, nil)
+				mocks.reportRepo.EXPECT().AggregateLiabilitySummaries(ctx, test_walletId, march).Return([]model.WalletLiabilitySummary{{WalletID: test_walletId, Month: march, Issued: 10}}, nil)
+				mocks.reportRepo.EXPECT().SaveLiabilitySummaries(ctx, gomock.Len(1)).Return(nil)
+				mocks.reportRepo.EXPECT().FetchLatestLiabilitySummary(ctx, "new").Return(nil, nil)
+				mocks.reportRepo.EXPECT().AggregateLiabilitySummaries(ctx, "new", time.Time{}).Return(nil, nil)
+				mocks.reportRepo.EXPECT().SaveLiabilitySummaries(ctx, gomock.Len(0)).Return(nil)
+			},
+			testFunc: func(service ReportService, ctx context.Context) (interface{}, error) {
+				return nil, service.RefreshLiabilitySummaries(ctx)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) ReportService {
+		return NewReportService(mocks.repos)
+	}
+	RunTestCases[ReportService](t, serviceFactory, testcases)
+}
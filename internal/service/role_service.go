@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"slices"
+)
+
+type RoleService interface {
+	// GetPermissions returns every permission a role can be granted
+	GetPermissions(ctx context.Context) ([]string, error)
+	// CreateRole creates a new role granting a set of permissions
+	CreateRole(ctx context.Context, req *CreateRoleRequest) (*model.Role, error)
+	// UpdateRole updates a role, replacing its permissions when provided
+	UpdateRole(ctx context.Context, roleId string, req *UpdateRoleRequest) (*model.Role, error)
+	// GetRole returns a role and its permissions
+	GetRole(ctx context.Context, roleId string) (*model.Role, error)
+	// GetRoles returns a paginated list of roles and their permissions
+	GetRoles(ctx context.Context, page int, limit int) (*api.List[model.Role], error)
+	// DeleteRole deletes a role, revoking it from every actor it is assigned to
+	DeleteRole(ctx context.Context, roleId string) error
+	// AssignRole assigns a role to a backoffice actor
+	AssignRole(ctx context.Context, roleId, actorId string) (*model.ActorRole, error)
+	// RevokeRole revokes a role from a backoffice actor
+	RevokeRole(ctx context.Context, roleId, actorId string) error
+	// GetActorRoles returns the roles assigned to a backoffice actor
+	GetActorRoles(ctx context.Context, actorId string) ([]model.Role, error)
+	// GetActorPermissions returns the permissions granted to an authenticated actor, which the actor needs before any permission can be checked
+	GetActorPermissions(ctx context.Context, actorId string) ([]string, error)
+}
+
+type roleService struct {
+	repos *repository.Repos
+}
+
+func NewRoleService(repos *repository.Repos) RoleService {
+	return &roleService{repos: repos}
+}
+
+func (s *roleService) GetPermissions(ctx context.Context) ([]string, error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleRead); err != nil {
+		return nil, err
+	}
+	return api.Permissions, nil
+}
+
+func (s *roleService) CreateRole(ctx context.Context, req *CreateRoleRequest) (*model.Role, error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleWrite); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid role request", logger.Field("fields", fields), logger.Field("request", req))
+		return nil, errs.NewValidationError("Invalid role request", "", fields)
+	}
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+	role := &model.Role{
+		ID:          req.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	role.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	role.SetRemarks("Role created")
+	if err := s.repos.Role.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(ctx context.Context, roleId string, req *UpdateRoleRequest) (*model.Role, error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleWrite); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid role request", logger.Field("fields", fields), logger.Field("request", req))
+		return nil, errs.NewValidationError("Invalid role request", "", fields)
+	}
+	role, err := s.repos.Role.FetchRoleByID(ctx, roleId)
+	if role == nil {
+		return nil, errs.NewNotFoundError("Role not found", "ROLE_NOT_FOUND", err)
+	}
+	role.SetOldRecord(*role)
+	if req.Name != nil {
+		role.Name = *req.Name
+	}
+	if req.Description != nil {
+		role.Description = req.Description
+	}
+	if req.Permissions != nil {
+		if err := validatePermissions(*req.Permissions); err != nil {
+			return nil, err
+		}
+		role.Permissions = *req.Permissions
+	}
+	role.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	role.SetRemarks("Role updated")
+	if err := s.repos.Role.UpdateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *roleService) GetRole(ctx context.Context, roleId string) (*model.Role, error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleRead); err != nil {
+		return nil, err
+	}
+	role, err := s.repos.Role.FetchRoleByID(ctx, roleId)
+	if role == nil {
+		return nil, errs.NewNotFoundError("Role not found", "ROLE_NOT_FOUND", err)
+	}
+	return role, nil
+}
+
+func (s *roleService) GetRoles(ctx context.Context, page int, limit int) (*api.List[model.Role], error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleRead); err != nil {
+		return nil, err
+	}
+	roles, err := s.repos.Role.FetchRoles(ctx, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repos.Role.CountRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &api.List[model.Role]{Items: roles, Page: page, Limit: limit, Total: total}, nil
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, roleId string) error {
+	if err := authorize(ctx, s.repos, api.PermissionRoleWrite); err != nil {
+		return err
+	}
+	role, err := s.repos.Role.FetchRoleByID(ctx, roleId)
+	if role == nil {
+		return errs.NewNotFoundError("Role not found", "ROLE_NOT_FOUND", err)
+	}
+	role.SetOldRecord(*role)
+	role.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	role.SetRemarks("Role deleted")
+	return s.repos.Role.DeleteRole(ctx, role)
+}
+
+func (s *roleService) AssignRole(ctx context.Context, roleId, actorId string) (*model.ActorRole, error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleWrite); err != nil {
+		return nil, err
+	}
+	role, err := s.repos.Role.FetchRoleByID(ctx, roleId)
+	if role == nil {
+		return nil, errs.NewNotFoundError("Role not found", "ROLE_NOT_FOUND", err)
+	}
+	actorRole, err := s.repos.Role.FetchActorRole(ctx, actorId, roleId)
+	if err != nil {
+		return nil, err
+	}
+	if actorRole != nil {
+		return nil, errs.NewConflictError("Role already assigned to the actor", "ROLE_ALREADY_ASSIGNED", nil)
+	}
+	actorRole = &model.ActorRole{ActorID: actorId, RoleID: roleId}
+	actorRole.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	actorRole.SetRemarks(fmt.Sprintf("Role %s assigned to %s", roleId, actorId))
+	if err := s.repos.Role.AssignRole(ctx, actorRole); err != nil {
+		return nil, err
+	}
+	return actorRole, nil
+}
+
+func (s *roleService) RevokeRole(ctx context.Context, roleId, actorId string) error {
+	if err := authorize(ctx, s.repos, api.PermissionRoleWrite); err != nil {
+		return err
+	}
+	actorRole, err := s.repos.Role.FetchActorRole(ctx, actorId, roleId)
+	if err != nil {
+		return err
+	}
+	if actorRole == nil {
+		return errs.NewNotFoundError("Role not assigned to the actor", "ROLE_NOT_ASSIGNED", nil)
+	}
+	actorRole.SetOldRecord(*actorRole)
+	actorRole.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	actorRole.SetRemarks(fmt.Sprintf("Role %s revoked from %s", roleId, actorId))
+	return s.repos.Role.RevokeRole(ctx, actorRole)
+}
+
+func (s *roleService) GetActorRoles(ctx context.Context, actorId string) ([]model.Role, error) {
+	if err := authorize(ctx, s.repos, api.PermissionRoleRead); err != nil {
+		return nil, err
+	}
+	return s.repos.Role.FetchActorRoles(ctx, actorId)
+}
+
+func (s *roleService) GetActorPermissions(ctx context.Context, actorId string) ([]string, error) {
+	return s.repos.Role.FetchActorPermissions(ctx, actorId)
+}
+
+// validatePermissions ensures every permission granted to a role or an API key is a known permission
+func validatePermissions(permissions []string) error {
+	fields := map[string]string{}
+	for i, permission := range permissions {
+		if !slices.Contains(api.Permissions, permission) {
+			fields[fmt.Sprintf("permissions[%d]", i)] = fmt.Sprintf("unknown permission %s", permission)
+		}
+	}
+	if len(fields) > 0 {
+		return errs.NewValidationError("Invalid permissions", "", fields)
+	}
+	return nil
+}
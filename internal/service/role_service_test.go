@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestRoleService_CreateRole(t *testing.T) {
+	testcases := []TestCase[RoleService]{
+		{
+			name: "Success case",
+			ctx:  createBackofficeContext(api.PermissionRoleWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.roleRepo.EXPECT().CreateRole(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service RoleService, ctx context.Context) (interface{}, error) {
+				return service.CreateRole(ctx, &CreateRoleRequest{ID: "support", Name: "Support", Permissions: []string{api.PermissionUserRead, api.PermissionAccountFreeze}})
+			},
+			expectResult: true,
+		},
+		{
+			name:          "Unknown permission",
+			ctx:           createBackofficeContext(api.PermissionRoleWrite),
+			setupMocks:    func(mocks *Mocks, ctx context.Context) {},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service RoleService, ctx context.Context) (interface{}, error) {
+				return service.CreateRole(ctx, &CreateRoleRequest{ID: "support", Name: "Support", Permissions: []string{"wallet:everything"}})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Permission denied is audited",
+			ctx:  createBackofficeContext(api.PermissionRoleRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, audit *model.Audit) error {
+					if audit.Operation != model.AuditOperationAccessDenied || audit.RecordID != api.PermissionRoleWrite {
+						t.Errorf("expected a denied %s audit, got %s %s", api.PermissionRoleWrite, audit.Operation, audit.RecordID)
+					}
+					if audit.Actor != api.AppActorAdmin || audit.ActorID != test_adminId {
+						t.Errorf("expected the audit to record the actor, got %s %s", audit.Actor, audit.ActorID)
+					}
+					return nil
+				})
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service RoleService, ctx context.Context) (interface{}, error) {
+				return service.CreateRole(ctx, &CreateRoleRequest{ID: "support", Name: "Support", Permissions: []string{api.PermissionUserRead}})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) RoleService {
+		return NewRoleService(mocks.repos)
+	}
+	RunTestCases[RoleService](t, serviceFactory, testcases)
+}
+
+func TestRoleService_AssignRole(t *testing.T) {
+	role := &model.Role{ID: "support", Name: "Support", Permissions: []string{api.PermissionUserRead}}
+	testcases := []TestCase[RoleService]{
+		{
+			name: "Success case",
+			ctx:  createBackofficeContext(api.PermissionRoleWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.roleRepo.EXPECT().FetchRoleByID(ctx, role.ID).Return(role, nil)
+				mocks.roleRepo.EXPECT().FetchActorRole(ctx, test_adminId, role.ID).Return(nil, nil)
+				mocks.roleRepo.EXPECT().AssignRole(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service RoleService, ctx context.Context) (interface{}, error) {
+				return service.AssignRole(ctx, role.ID, test_adminId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Role already assigned",
+			ctx:  createBackofficeContext(api.PermissionRoleWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.roleRepo.EXPECT().FetchRoleByID(ctx, role.ID).Return(role, nil)
+				mocks.roleRepo.EXPECT().FetchActorRole(ctx, test_adminId, role.ID).Return(&model.ActorRole{ActorID: test_adminId, RoleID: role.ID}, nil)
+			},
+			expectedError: "ROLE_ALREADY_ASSIGNED",
+			testFunc: func(service RoleService, ctx context.Context) (interface{}, error) {
+				return service.AssignRole(ctx, role.ID, test_adminId)
+			},
+			expectResult: false,
+		},
+		{
+			name: "Role not found",
+			ctx:  createBackofficeContext(api.PermissionRoleWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.roleRepo.EXPECT().FetchRoleByID(ctx, role.ID).Return(nil, nil)
+			},
+			expectedError: "ROLE_NOT_FOUND",
+			testFunc: func(service RoleService, ctx context.Context) (interface{}, error) {
+				return service.AssignRole(ctx, role.ID, test_adminId)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) RoleService {
+		return NewRoleService(mocks.repos)
+	}
+	RunTestCases[RoleService](t, serviceFactory, testcases)
+}
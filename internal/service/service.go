@@ -10,4 +10,8 @@ type Services struct {
 	ExchangeRate ExchangeRateService
 	Trigger      TriggerService
 	Program      ProgramService
+	Role         RoleService
+	Approval     ApprovalService
+	ApiKey       ApiKeyService
+	Report       ReportService
 }
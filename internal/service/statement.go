@@ -0,0 +1,404 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/go-pdf/fpdf"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	statementFormatJSON = "json"
+	statementFormatCSV  = "csv"
+	statementFormatPDF  = "pdf"
+	// statementBatchSize is the number of transactions loaded at a time while writing a statement
+	statementBatchSize = 500
+	// statementPDFDocumentSize is the number of transactions a pdf document holds. A pdf document is rendered in memory
+	// before it is sent, so longer statements are split into several documents streamed in a zip archive
+	statementPDFDocumentSize = 2000
+	// statementIncompleteCode marks a streamed statement that failed after its response had started
+	statementIncompleteCode = "STATEMENT_INCOMPLETE"
+)
+
+// statementWriter writes a statement in an export format, its transactions arriving a batch at a time.
+// A statement that fails once written is ended with WriteError instead of WriteFooter
+type statementWriter interface {
+	WriteHeader(statement *AccountStatement) error
+	WriteLines(lines []StatementLine) error
+	WriteFooter(statement *AccountStatement) error
+	WriteError(err error) error
+}
+
+// flusher is implemented by the buffered writers a statement can be streamed to
+type flusher interface {
+	Flush() error
+}
+
+func newStatementLine(transaction model.Transaction) StatementLine {
+	return StatementLine{
+		ID:             transaction.ID,
+		CreatedAt:      transaction.CreatedAt,
+		Type:           transaction.Type,
+		Reason:         transaction.Reason,
+		ProgramID:      transaction.ProgramID,
+		Amount:         transaction.Amount,
+		RunningBalance: transaction.NewBalance,
+	}
+}
+
+// jsonStatementWriter writes a statement in the success response of the api, streaming its transactions
+// into the transactions array as they are read
+type jsonStatementWriter struct {
+	w     io.Writer
+	lines int
+}
+
+func newJSONStatementWriter(w io.Writer) *jsonStatementWriter {
+	return &jsonStatementWriter{w: w}
+}
+
+func (j *jsonStatementWriter) WriteHeader(statement *AccountStatement) error {
+	summary := *statement
+	summary.Transactions = nil
+	body, err := json.Marshal(api.NewSuccessResponse(&summary))
+	if err != nil {
+		return err
+	}
+	// leave the result open so the transactions can be appended to it
+	body = bytes.TrimSuffix(body, []byte("}}"))
+	return j.write(append(body, `,"transactions":[`...))
+}
+
+func (j *jsonStatementWriter) WriteLines(lines []StatementLine) error {
+	var body []byte
+	for _, line := range lines {
+		if j.lines > 0 {
+			body = append(body, ',')
+		}
+		encoded, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		body = append(body, encoded...)
+		j.lines++
+	}
+	return j.write(body)
+}
+
+func (j *jsonStatementWriter) WriteFooter(_ *AccountStatement) error {
+	return j.write([]byte("]}}"))
+}
+
+func (j *jsonStatementWriter) WriteError(err error) error {
+	marker, _ := json.Marshal(api.ErrorResponseBody{Message: "Statement incomplete: " + err.Error(), HttpCode: 500, Code: statementIncompleteCode})
+	body := append([]byte(`]},"error":`), marker...)
+	return j.write(append(body, '}'))
+}
+
+func (j *jsonStatementWriter) write(body []byte) error {
+	if _, err := j.w.Write(body); err != nil {
+		return err
+	}
+	if f, ok := j.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// csvStatementWriter writes a statement as a csv table, opened and closed by the balance rows.
+// Every batch is flushed so long statements are streamed as they are read
+type csvStatementWriter struct {
+	w   io.Writer
+	csv *csv.Writer
+}
+
+func newCSVStatementWriter(w io.Writer) *csvStatementWriter {
+	return &csvStatementWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+func (c *csvStatementWriter) WriteHeader(statement *AccountStatement) error {
+	if err := c.csv.Write([]string{"date", "transaction_id", "type", "reason", "program_id", "amount", "balance"}); err != nil {
+		return err
+	}
+	if err := c.csv.Write([]string{statement.From.Format(time.RFC3339), "", "OPENING_BALANCE", "", "", "", formatUint(statement.OpeningBalance)}); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+func (c *csvStatementWriter) WriteLines(lines []StatementLine) error {
+	for _, line := range lines {
+		programId := ""
+		if line.ProgramID != nil {
+			programId = *line.ProgramID
+		}
+		record := []string{line.CreatedAt.Format(time.RFC3339), line.ID, line.Type, line.Reason, programId, formatUint(line.Amount), formatUint(line.RunningBalance)}
+		if err := c.csv.Write(record); err != nil {
+			return err
+		}
+	}
+	return c.flush()
+}
+
+func (c *csvStatementWriter) WriteFooter(statement *AccountStatement) error {
+	if err := c.csv.Write([]string{statement.To.Format(time.RFC3339), "", "CLOSING_BALANCE", "", "", "", formatUint(statement.ClosingBalance)}); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// WriteError ends the table with an error row in place of the closing balance
+func (c *csvStatementWriter) WriteError(err error) error {
+	if err := c.csv.Write([]string{"", "", "ERROR", statementIncompleteCode, "", "", err.Error()}); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+func (c *csvStatementWriter) flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	if f, ok := c.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// pdfStatementWriter writes a statement as a pdf document, repeating the table header on every page.
+// The document is held in memory and only written out by WriteFooter, statements too long to be held in memory
+// are written by the pdfArchiveStatementWriter instead
+type pdfStatementWriter struct {
+	w   io.Writer
+	pdf *fpdf.Fpdf
+	// part is the part of a split statement the document holds, 0 when the statement is not split
+	part int
+}
+
+var pdfStatementColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Date", 38, "L"},
+	{"Transaction", 42, "L"},
+	{"Type", 18, "L"},
+	{"Reason", 24, "L"},
+	{"Amount", 34, "R"},
+	{"Balance", 34, "R"},
+}
+
+func newPDFStatementWriter(w io.Writer) *pdfStatementWriter {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	return &pdfStatementWriter{w: w, pdf: pdf}
+}
+
+func (p *pdfStatementWriter) WriteHeader(statement *AccountStatement) error {
+	p.pdf.SetHeaderFunc(func() {
+		if p.pdf.PageNo() > 1 {
+			p.tableHeader()
+		}
+	})
+	p.pdf.AddPage()
+	p.pdf.SetFont("Helvetica", "B", 14)
+	p.pdf.CellFormat(0, 8, "Account Statement", "", 1, "L", false, 0, "")
+	p.pdf.SetFont("Helvetica", "", 10)
+	for _, row := range [][2]string{
+		{"Account", statement.AccountID},
+		{"Wallet", fmt.Sprintf("%s (%s)", statement.WalletID, statement.Currency)},
+		{"User", statement.UserID},
+		{"Period", fmt.Sprintf("%s to %s", statement.From.Format(time.RFC3339), statement.To.Format(time.RFC3339))},
+		{"Opening balance", formatUint(statement.OpeningBalance)},
+	} {
+		p.pdf.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+		p.pdf.CellFormat(0, 6, row[1], "", 1, "L", false, 0, "")
+	}
+	if p.part > 0 {
+		p.pdf.CellFormat(40, 6, "Part", "", 0, "L", false, 0, "")
+		p.pdf.CellFormat(0, 6, strconv.Itoa(p.part), "", 1, "L", false, 0, "")
+	}
+	p.pdf.Ln(4)
+	p.tableHeader()
+	return p.pdf.Error()
+}
+
+func (p *pdfStatementWriter) WriteLines(lines []StatementLine) error {
+	p.pdf.SetFont("Helvetica", "", 8)
+	for _, line := range lines {
+		values := []string{line.CreatedAt.Format("2006-01-02 15:04:05"), line.ID, line.Type, line.Reason, formatUint(line.Amount), formatUint(line.RunningBalance)}
+		for i, column := range pdfStatementColumns {
+			p.pdf.CellFormat(column.width, 5, values[i], "B", 0, column.align, false, 0, "")
+		}
+		p.pdf.Ln(-1)
+	}
+	return p.pdf.Error()
+}
+
+func (p *pdfStatementWriter) WriteFooter(statement *AccountStatement) error {
+	p.writeTotals(statement)
+	return p.pdf.Output(p.w)
+}
+
+// WriteError drops the document, nothing of it has been written yet
+func (p *pdfStatementWriter) WriteError(_ error) error {
+	return nil
+}
+
+// writeContinued ends a document that does not hold the last transactions of a split statement
+func (p *pdfStatementWriter) writeContinued() {
+	p.pdf.SetHeaderFunc(nil)
+	p.pdf.Ln(6)
+	p.pdf.SetFont("Helvetica", "I", 9)
+	p.pdf.CellFormat(0, 6, fmt.Sprintf("Continued in part %d", p.part+1), "", 1, "L", false, 0, "")
+}
+
+func (p *pdfStatementWriter) writeTotals(statement *AccountStatement) {
+	p.pdf.SetHeaderFunc(nil)
+	p.pdf.Ln(6)
+	p.pdf.SetFont("Helvetica", "B", 10)
+	p.pdf.CellFormat(0, 6, "Totals by reason", "", 1, "L", false, 0, "")
+	p.pdf.SetFont("Helvetica", "", 9)
+	for _, total := range statement.Totals {
+		p.pdf.CellFormat(60, 5, fmt.Sprintf("%s %s (%d)", total.Type, total.Reason, total.Count), "", 0, "L", false, 0, "")
+		p.pdf.CellFormat(40, 5, formatUint(total.Amount), "", 1, "R", false, 0, "")
+	}
+	p.pdf.Ln(2)
+	for _, row := range [][2]string{
+		{"Total credited", formatUint(statement.TotalCredited)},
+		{"Total debited", formatUint(statement.TotalDebited)},
+		{"Expired", formatUint(statement.Expired)},
+		{"Closing balance", formatUint(statement.ClosingBalance)},
+	} {
+		p.pdf.CellFormat(60, 5, row[0], "", 0, "L", false, 0, "")
+		p.pdf.CellFormat(40, 5, row[1], "", 1, "R", false, 0, "")
+	}
+}
+
+func (p *pdfStatementWriter) tableHeader() {
+	p.pdf.SetFont("Helvetica", "B", 9)
+	for _, column := range pdfStatementColumns {
+		p.pdf.CellFormat(column.width, 6, column.title, "B", 0, column.align, false, 0, "")
+	}
+	p.pdf.Ln(-1)
+	p.pdf.SetFont("Helvetica", "", 8)
+}
+
+// pdfArchiveStatementWriter writes a long statement as several pdf documents of up to size transactions each, in a
+// zip archive. Every document is written to the archive and flushed once it is complete, so only the document being
+// rendered is held in memory. A statement that fails part way keeps its completed documents and ends with an error.json
+// marker in place of the rest
+type pdfArchiveStatementWriter struct {
+	w         io.Writer
+	archive   *zip.Writer
+	size      int
+	statement *AccountStatement
+	document  *pdfStatementWriter
+	lines     int
+}
+
+func newPDFArchiveStatementWriter(w io.Writer, size int) *pdfArchiveStatementWriter {
+	return &pdfArchiveStatementWriter{w: w, archive: zip.NewWriter(w), size: size}
+}
+
+func (a *pdfArchiveStatementWriter) WriteHeader(statement *AccountStatement) error {
+	a.statement = statement
+	return a.startDocument()
+}
+
+func (a *pdfArchiveStatementWriter) WriteLines(lines []StatementLine) error {
+	for len(lines) > 0 {
+		if a.lines == a.size {
+			a.document.writeContinued()
+			if err := a.completeDocument(); err != nil {
+				return err
+			}
+			if err := a.startDocument(); err != nil {
+				return err
+			}
+		}
+		n := min(a.size-a.lines, len(lines))
+		if err := a.document.WriteLines(lines[:n]); err != nil {
+			return err
+		}
+		a.lines += n
+		lines = lines[n:]
+	}
+	return nil
+}
+
+func (a *pdfArchiveStatementWriter) WriteFooter(statement *AccountStatement) error {
+	a.document.writeTotals(statement)
+	if err := a.completeDocument(); err != nil {
+		return err
+	}
+	return a.close()
+}
+
+// WriteError drops the document being rendered and ends the archive with an error marker
+func (a *pdfArchiveStatementWriter) WriteError(err error) error {
+	marker, _ := json.Marshal(api.ErrorResponseBody{Message: "Statement incomplete: " + err.Error(), HttpCode: 500, Code: statementIncompleteCode})
+	entry, createErr := a.archive.Create("error.json")
+	if createErr != nil {
+		return createErr
+	}
+	if _, err := entry.Write(marker); err != nil {
+		return err
+	}
+	return a.close()
+}
+
+func (a *pdfArchiveStatementWriter) startDocument() error {
+	part := 1
+	if a.document != nil {
+		part = a.document.part + 1
+	}
+	a.document = newPDFStatementWriter(nil)
+	a.document.part = part
+	a.lines = 0
+	return a.document.WriteHeader(a.statement)
+}
+
+func (a *pdfArchiveStatementWriter) completeDocument() error {
+	entry, err := a.archive.Create(fmt.Sprintf("statement-%s-part-%d.pdf", a.statement.AccountID, a.document.part))
+	if err != nil {
+		return err
+	}
+	if err := a.document.pdf.Output(entry); err != nil {
+		return err
+	}
+	if err := a.archive.Flush(); err != nil {
+		return err
+	}
+	if f, ok := a.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (a *pdfArchiveStatementWriter) close() error {
+	if err := a.archive.Close(); err != nil {
+		return err
+	}
+	if f, ok := a.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func formatUint(value uint64) string {
+	return strconv.FormatUint(value, 10)
+}
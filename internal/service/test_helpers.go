@@ -20,6 +20,11 @@ const (
 	test_requestId = "mock-123"
 )
 
+// createBackofficeContext creates the context of a backoffice actor holding the given permissions
+func createBackofficeContext(permissions ...string) context.Context {
+	return api.WithPermissions(api.CreateAppContext(context.Background(), api.AppActorAdmin, test_adminId, test_requestId), permissions...)
+}
+
 type TestCase[Service any] struct {
 	name          string
 	ctx           context.Context
@@ -39,6 +44,11 @@ type Mocks struct {
 	exchangeRateRepo *repository_mock.MockExchangeRateRepo
 	programRepo      *repository_mock.MockProgramRepo
 	triggerRepo      *repository_mock.MockTriggerRepo
+	roleRepo         *repository_mock.MockRoleRepo
+	approvalRepo     *repository_mock.MockApprovalRepo
+	apiKeyRepo       *repository_mock.MockApiKeyRepo
+	tenantRepo       *repository_mock.MockTenantRepo
+	reportRepo       *repository_mock.MockReportRepo
 	repos            *repository.Repos
 }
 
@@ -52,6 +62,11 @@ func NewServiceMocks(ctrl *gomock.Controller) *Mocks {
 	exchangeRateRepo := repository_mock.NewMockExchangeRateRepo(ctrl)
 	programRepo := repository_mock.NewMockProgramRepo(ctrl)
 	triggerRepo := repository_mock.NewMockTriggerRepo(ctrl)
+	roleRepo := repository_mock.NewMockRoleRepo(ctrl)
+	approvalRepo := repository_mock.NewMockApprovalRepo(ctrl)
+	apiKeyRepo := repository_mock.NewMockApiKeyRepo(ctrl)
+	tenantRepo := repository_mock.NewMockTenantRepo(ctrl)
+	reportRepo := repository_mock.NewMockReportRepo(ctrl)
 	return &Mocks{
 		auditRepo:        auditRepo,
 		accountRepo:      accountRepo,
@@ -62,6 +77,11 @@ func NewServiceMocks(ctrl *gomock.Controller) *Mocks {
 		exchangeRateRepo: exchangeRateRepo,
 		programRepo:      programRepo,
 		triggerRepo:      triggerRepo,
+		roleRepo:         roleRepo,
+		approvalRepo:     approvalRepo,
+		apiKeyRepo:       apiKeyRepo,
+		tenantRepo:       tenantRepo,
+		reportRepo:       reportRepo,
 		repos: &repository.Repos{
 			Audit:        auditRepo,
 			Account:      accountRepo,
@@ -72,6 +92,11 @@ func NewServiceMocks(ctrl *gomock.Controller) *Mocks {
 			ExchangeRate: exchangeRateRepo,
 			Program:      programRepo,
 			Trigger:      triggerRepo,
+			Role:         roleRepo,
+			Approval:     approvalRepo,
+			ApiKey:       apiKeyRepo,
+			Tenant:       tenantRepo,
+			Report:       reportRepo,
 		},
 	}
 }
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// dailyDebitReasons are the debits counting towards the daily debit limit, which leaves out expired points
+var dailyDebitReasons = []string{
+	model.TransactionReasonPurchase,
+	model.TransactionReasonRedeem,
+	model.TransactionReasonPenalty,
+	model.TransactionReasonWithdrawal,
+	model.TransactionReasonExchange,
+}
+
+// accountPolicy holds the limits applying to an account, taken from the tier policy of its user
+// and falling back to the wallet defaults for anything the tier policy leaves unset
+type accountPolicy struct {
+	MaxBalance           *uint64
+	EarnMultiplier       decimal.Decimal
+	MaxTransactionAmount *uint64
+	DailyDebitLimit      *uint64
+}
+
+// resolveAccountPolicy resolves the limits of a wallet for a user tier
+func resolveAccountPolicy(ctx context.Context, repos *repository.Repos, wallet *model.Wallet, tierId *string) (*accountPolicy, error) {
+	policy := &accountPolicy{
+		MaxBalance:     wallet.LimitPerUser,
+		EarnMultiplier: decimal.NewFromInt(1),
+	}
+	if tierId == nil {
+		return policy, nil
+	}
+	tierPolicy, err := repos.Tier.FetchTierPolicy(ctx, wallet.ID, *tierId)
+	if err != nil {
+		return nil, err
+	}
+	if tierPolicy == nil {
+		return policy, nil
+	}
+	if tierPolicy.MaxBalance != nil {
+		policy.MaxBalance = tierPolicy.MaxBalance
+	}
+	policy.EarnMultiplier = tierPolicy.EarnMultiplier
+	policy.MaxTransactionAmount = tierPolicy.MaxTransactionAmount
+	policy.DailyDebitLimit = tierPolicy.DailyDebitLimit
+	return policy, nil
+}
+
+// checkCredit ensures crediting an amount to an account stays within the policy
+func (p *accountPolicy) checkCredit(ctx context.Context, account *model.Account, amount uint64) error {
+	if err := p.checkTransactionAmount(ctx, amount); err != nil {
+		return err
+	}
+	if p.MaxBalance != nil && account.Balance+amount > *p.MaxBalance {
+		api.GetLogger(ctx).Error("Limit per user exceeded", logger.Field("limit", *p.MaxBalance), logger.Field("balance", account.Balance), logger.Field("amount", amount))
+		return errs.NewForbiddenError("Limit per user exceeded", "LIMIT_PER_USER_EXCEEDED", nil)
+	}
+	return nil
+}
+
+// checkDebit ensures debiting an amount from an account stays within the policy, including what it already debited today
+func (p *accountPolicy) checkDebit(ctx context.Context, repos *repository.Repos, account *model.Account, amount uint64) error {
+	if err := p.checkTransactionAmount(ctx, amount); err != nil {
+		return err
+	}
+	if p.DailyDebitLimit == nil {
+		return nil
+	}
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	debited, err := repos.Transaction.SumAccountTransactionsSince(ctx, account.ID, model.TransactionTypeDebit, dailyDebitReasons, startOfDay)
+	if err != nil {
+		return err
+	}
+	if debited+amount > *p.DailyDebitLimit {
+		api.GetLogger(ctx).Error("Daily debit limit exceeded", logger.Field("limit", *p.DailyDebitLimit), logger.Field("debited", debited), logger.Field("amount", amount))
+		return errs.NewForbiddenError("Daily debit limit exceeded", "DAILY_DEBIT_LIMIT_EXCEEDED", nil)
+	}
+	return nil
+}
+
+func (p *accountPolicy) checkTransactionAmount(ctx context.Context, amount uint64) error {
+	if p.MaxTransactionAmount != nil && amount > *p.MaxTransactionAmount {
+		api.GetLogger(ctx).Error("Transaction limit exceeded", logger.Field("limit", *p.MaxTransactionAmount), logger.Field("amount", amount))
+		return errs.NewForbiddenError("Transaction limit exceeded", "TRANSACTION_LIMIT_EXCEEDED", nil)
+	}
+	return nil
+}
+
+// applyEarnMultiplier scales an earned amount by the policy multiplier, truncating any fraction
+func (p *accountPolicy) applyEarnMultiplier(amount uint64) uint64 {
+	return decimal.NewFromUint64(amount).Mul(p.EarnMultiplier).BigInt().Uint64()
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"time"
+)
+
+// tierEvaluationBatchSize is the number of users loaded at a time while evaluating all users
+const tierEvaluationBatchSize = 100
+
+// tierRuleMetrics maps each tier rule metric to the transactions it sums up
+var tierRuleMetrics = map[string]struct {
+	transactionType string
+	reasons         []string
+}{
+	model.TierRuleMetricEarned: {model.TransactionTypeCredit, []string{model.TransactionReasonReward, model.TransactionReasonDeposit}},
+	model.TierRuleMetricSpent:  {model.TransactionTypeDebit, []string{model.TransactionReasonPurchase, model.TransactionReasonRedeem}},
+}
+
+func (s *tierService) EvaluateTiers(ctx context.Context) error {
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
+		return err
+	}
+	rules, err := s.repos.Tier.FetchTierRules(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for afterId := ""; ; {
+		users, err := s.repos.User.FetchUsersAfter(ctx, afterId, tierEvaluationBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			if err := s.evaluateUserTier(ctx, &users[i], rules, now); err != nil {
+				api.GetLogger(ctx).Error("Unable to evaluate user tier", logger.Field("userId", users[i].ID), logger.Field("error", err))
+			}
+		}
+		if len(users) < tierEvaluationBatchSize {
+			return nil
+		}
+		afterId = users[len(users)-1].ID
+	}
+}
+
+func (s *tierService) EvaluateUserTier(ctx context.Context, userId string) (*model.User, error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
+		return nil, err
+	}
+	user, err := s.repos.User.FetchUserByID(ctx, userId)
+	if user == nil {
+		return nil, errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
+	}
+	rules, err := s.repos.Tier.FetchTierRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.evaluateUserTier(ctx, user, rules, time.Now()); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// evaluateUserTier moves a user to the highest ranked tier they qualify for, given rules sorted by rank, highest first.
+// Upgrades apply immediately. Downgrades wait for the retention period of the current tier to end,
+// then for its grace period, which starts once the user stops qualifying.
+// Users whose tier has no active rule were placed there manually and are left alone.
+func (s *tierService) evaluateUserTier(ctx context.Context, user *model.User, rules []model.TierRule, now time.Time) error {
+	var current, qualified *model.TierRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsActive {
+			continue
+		}
+		if user.TierID != nil && rule.TierID == *user.TierID {
+			current = rule
+		}
+		if qualified != nil {
+			continue
+		}
+		ok, err := s.qualifiesForTier(ctx, user, rule, now)
+		if err != nil {
+			return err
+		}
+		if ok {
+			qualified = rule
+		}
+	}
+	if user.TierID != nil && current == nil {
+		return nil
+	}
+
+	user.SetOldRecord(*user)
+	user.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	switch {
+	case qualified != nil && (current == nil || qualified.Rank > current.Rank):
+		user.TierID = &qualified.TierID
+		user.TierAchievedAt = &now
+		user.TierGraceUntil = nil
+		user.SetRemarks(fmt.Sprintf("Upgraded to tier %s: %s at least %d points in wallet %s within %s", qualified.TierID, qualified.Metric, qualified.Threshold, qualified.WalletID, qualified.Window.Duration()))
+	case current == nil:
+		return nil
+	case qualified != nil && qualified.Rank == current.Rank:
+		if user.TierGraceUntil == nil {
+			return nil
+		}
+		user.TierGraceUntil = nil
+		user.SetRemarks(fmt.Sprintf("Qualifies for tier %s again, grace period cancelled", current.TierID))
+	case current.RetentionPeriod != nil && user.TierAchievedAt != nil && now.Before(user.TierAchievedAt.Add(current.RetentionPeriod.Duration())):
+		return nil
+	case current.GracePeriod != nil && user.TierGraceUntil == nil:
+		graceUntil := now.Add(current.GracePeriod.Duration())
+		user.TierGraceUntil = &graceUntil
+		user.SetRemarks(fmt.Sprintf("No longer qualifies for tier %s, grace period until %s", current.TierID, graceUntil.Format(time.RFC3339)))
+	case user.TierGraceUntil != nil && now.Before(*user.TierGraceUntil):
+		return nil
+	default:
+		user.TierGraceUntil = nil
+		if qualified != nil {
+			user.TierID = &qualified.TierID
+			user.TierAchievedAt = &now
+			user.SetRemarks(fmt.Sprintf("Downgraded from tier %s to tier %s", current.TierID, qualified.TierID))
+		} else {
+			user.TierID = nil
+			user.TierAchievedAt = nil
+			user.SetRemarks(fmt.Sprintf("Downgraded from tier %s, no longer qualifies for any tier", current.TierID))
+		}
+	}
+	return s.repos.User.UpdateUserTier(ctx, user)
+}
+
+// qualifiesForTier computes the metric of a tier rule for a user over the rule window and compares it to the threshold
+func (s *tierService) qualifiesForTier(ctx context.Context, user *model.User, rule *model.TierRule, now time.Time) (bool, error) {
+	metric, ok := tierRuleMetrics[rule.Metric]
+	if !ok {
+		return false, errs.NewUnprocessableEntityError("Tier rule has an invalid metric", "TIER_RULE_INVALID_METRIC", nil)
+	}
+	for _, account := range user.Accounts {
+		if account.WalletID != rule.WalletID {
+			continue
+		}
+		sum, err := s.repos.Transaction.SumAccountTransactionsSince(ctx, account.ID, metric.transactionType, metric.reasons, now.Add(-rule.Window.Duration()))
+		if err != nil {
+			return false, err
+		}
+		return sum >= rule.Threshold, nil
+	}
+	return false, nil
+}
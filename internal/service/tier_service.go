@@ -6,7 +6,10 @@ import (
 	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
 	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"github.com/shopspring/decimal"
+	"time"
 )
 
 type TierService interface {
@@ -14,6 +17,22 @@ type TierService interface {
 	GetTierByID(ctx context.Context, tierId string) (*model.Tier, error)
 	GetTiers(ctx context.Context, page int, limit int) (*api.List[model.Tier], error)
 	DeleteTier(ctx context.Context, tierId string) error
+	// SetTierPolicy creates or replaces the policy of a tier for a wallet
+	SetTierPolicy(ctx context.Context, tierId, walletId string, req *SetTierPolicyRequest) (*model.TierPolicy, error)
+	// GetTierPolicies returns the policies of a tier across all wallets
+	GetTierPolicies(ctx context.Context, tierId string) ([]model.TierPolicy, error)
+	// DeleteTierPolicy deletes the policy of a tier for a wallet, restoring the wallet defaults
+	DeleteTierPolicy(ctx context.Context, tierId, walletId string) error
+	// SetTierRule creates or replaces the qualification rule of a tier
+	SetTierRule(ctx context.Context, tierId string, req *SetTierRuleRequest) (*model.TierRule, error)
+	// GetTierRule returns the qualification rule of a tier
+	GetTierRule(ctx context.Context, tierId string) (*model.TierRule, error)
+	// DeleteTierRule deletes the qualification rule of a tier, leaving its users in place
+	DeleteTierRule(ctx context.Context, tierId string) error
+	// EvaluateUserTier evaluates a user against the tier rules, applying any upgrade or downgrade
+	EvaluateUserTier(ctx context.Context, userId string) (*model.User, error)
+	// EvaluateTiers evaluates every user against the tier rules, applying any upgrade or downgrade
+	EvaluateTiers(ctx context.Context) error
 }
 
 type tierService struct {
@@ -25,8 +44,7 @@ func NewTierService(repos *repository.Repos) TierService {
 }
 
 func (s *tierService) CreateTier(ctx context.Context, req *CreateTierRequest) (*model.Tier, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
 		return nil, err
 	}
 	if err := validator.GetValidator().ValidateStruct(req); err != nil {
@@ -46,6 +64,9 @@ func (s *tierService) CreateTier(ctx context.Context, req *CreateTierRequest) (*
 }
 
 func (s *tierService) GetTierByID(ctx context.Context, tierId string) (*model.Tier, error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierRead); err != nil {
+		return nil, err
+	}
 	tier, err := s.repos.Tier.FetchTierByID(ctx, tierId)
 	if err != nil {
 		return nil, err
@@ -54,6 +75,9 @@ func (s *tierService) GetTierByID(ctx context.Context, tierId string) (*model.Ti
 }
 
 func (s *tierService) GetTiers(ctx context.Context, page int, limit int) (*api.List[model.Tier], error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierRead); err != nil {
+		return nil, err
+	}
 	tiers, err := s.repos.Tier.FetchTiers(ctx, page, limit)
 	if err != nil {
 		return nil, err
@@ -67,8 +91,7 @@ func (s *tierService) GetTiers(ctx context.Context, page int, limit int) (*api.L
 }
 
 func (s *tierService) DeleteTier(ctx context.Context, tierId string) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
 		return err
 	}
 	tier, err := s.repos.Tier.FetchTierByID(ctx, tierId)
@@ -80,3 +103,157 @@ func (s *tierService) DeleteTier(ctx context.Context, tierId string) error {
 	tier.SetOldRecord(tier)
 	return s.repos.Tier.DeleteTier(ctx, tier)
 }
+
+func (s *tierService) SetTierPolicy(ctx context.Context, tierId, walletId string, req *SetTierPolicyRequest) (*model.TierPolicy, error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
+		return nil, err
+	}
+	if req.EarnMultiplier != nil && req.EarnMultiplier.IsNegative() {
+		return nil, errs.NewValidationError("Invalid tier policy request", "", map[string]string{"earnMultiplier": "must not be negative"})
+	}
+	tier, err := s.repos.Tier.FetchTierByID(ctx, tierId)
+	if tier == nil {
+		return nil, errs.NewNotFoundError("Tier not found", "TIER_NOT_FOUND", err)
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
+	if wallet == nil {
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	policy, err := s.repos.Tier.FetchTierPolicy(ctx, walletId, tierId)
+	if err != nil {
+		return nil, err
+	}
+	exists := policy != nil
+	if exists {
+		policy.SetOldRecord(*policy)
+	} else {
+		policy = &model.TierPolicy{WalletID: walletId, TierID: tierId}
+	}
+	policy.MaxBalance = req.MaxBalance
+	policy.EarnMultiplier = decimal.NewFromInt(1)
+	if req.EarnMultiplier != nil {
+		policy.EarnMultiplier = *req.EarnMultiplier
+	}
+	policy.MaxTransactionAmount = req.MaxTransactionAmount
+	policy.DailyDebitLimit = req.DailyDebitLimit
+	policy.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	if exists {
+		policy.SetRemarks("Tier policy updated")
+		err = s.repos.Tier.UpdateTierPolicy(ctx, policy)
+	} else {
+		policy.SetRemarks("Tier policy created")
+		err = s.repos.Tier.CreateTierPolicy(ctx, policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *tierService) GetTierPolicies(ctx context.Context, tierId string) ([]model.TierPolicy, error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierRead); err != nil {
+		return nil, err
+	}
+	tier, err := s.repos.Tier.FetchTierByID(ctx, tierId)
+	if tier == nil {
+		return nil, errs.NewNotFoundError("Tier not found", "TIER_NOT_FOUND", err)
+	}
+	return s.repos.Tier.FetchTierPolicies(ctx, tierId)
+}
+
+func (s *tierService) DeleteTierPolicy(ctx context.Context, tierId, walletId string) error {
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
+		return err
+	}
+	policy, err := s.repos.Tier.FetchTierPolicy(ctx, walletId, tierId)
+	if policy == nil {
+		return errs.NewNotFoundError("Tier policy not found", "TIER_POLICY_NOT_FOUND", err)
+	}
+	policy.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	policy.SetRemarks("Tier policy deleted")
+	policy.SetOldRecord(*policy)
+	return s.repos.Tier.DeleteTierPolicy(ctx, policy)
+}
+
+func (s *tierService) SetTierRule(ctx context.Context, tierId string, req *SetTierRuleRequest) (*model.TierRule, error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
+		return nil, err
+	}
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		return nil, errs.NewValidationError("Invalid tier rule request", "", fields)
+	}
+	tier, err := s.repos.Tier.FetchTierByID(ctx, tierId)
+	if tier == nil {
+		return nil, errs.NewNotFoundError("Tier not found", "TIER_NOT_FOUND", err)
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, req.WalletID)
+	if wallet == nil {
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	rule, err := s.repos.Tier.FetchTierRule(ctx, tierId)
+	if err != nil {
+		return nil, err
+	}
+	exists := rule != nil
+	if exists {
+		rule.SetOldRecord(*rule)
+	} else {
+		rule = &model.TierRule{TierID: tierId, IsActive: true}
+	}
+	rule.Rank = req.Rank
+	rule.WalletID = req.WalletID
+	rule.Metric = req.Metric
+	rule.Threshold = req.Threshold
+	rule.Window = types.Interval(time.Duration(req.Window) * time.Millisecond)
+	rule.RetentionPeriod = nil
+	if req.RetentionPeriod != nil {
+		retentionPeriod := types.Interval(time.Duration(*req.RetentionPeriod) * time.Millisecond)
+		rule.RetentionPeriod = &retentionPeriod
+	}
+	rule.GracePeriod = nil
+	if req.GracePeriod != nil {
+		gracePeriod := types.Interval(time.Duration(*req.GracePeriod) * time.Millisecond)
+		rule.GracePeriod = &gracePeriod
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	rule.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	if exists {
+		rule.SetRemarks("Tier rule updated")
+		err = s.repos.Tier.UpdateTierRule(ctx, rule)
+	} else {
+		rule.SetRemarks("Tier rule created")
+		err = s.repos.Tier.CreateTierRule(ctx, rule)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *tierService) GetTierRule(ctx context.Context, tierId string) (*model.TierRule, error) {
+	if err := authorize(ctx, s.repos, api.PermissionTierRead); err != nil {
+		return nil, err
+	}
+	rule, err := s.repos.Tier.FetchTierRule(ctx, tierId)
+	if rule == nil {
+		return nil, errs.NewNotFoundError("Tier rule not found", "TIER_RULE_NOT_FOUND", err)
+	}
+	return rule, nil
+}
+
+func (s *tierService) DeleteTierRule(ctx context.Context, tierId string) error {
+	if err := authorize(ctx, s.repos, api.PermissionTierWrite); err != nil {
+		return err
+	}
+	rule, err := s.repos.Tier.FetchTierRule(ctx, tierId)
+	if rule == nil {
+		return errs.NewNotFoundError("Tier rule not found", "TIER_RULE_NOT_FOUND", err)
+	}
+	rule.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	rule.SetRemarks("Tier rule deleted")
+	rule.SetOldRecord(*rule)
+	return s.repos.Tier.DeleteTierRule(ctx, rule)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"github.com/abdelrahman146/digital-wallet/pkg/utils"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestTierService_EvaluateUserTier(t *testing.T) {
+	silver, gold, vip := "silver", "gold", "vip"
+	year := types.Interval(365 * 24 * time.Hour)
+	month := types.Interval(30 * 24 * time.Hour)
+	rules := []model.TierRule{
+		{TierID: gold, Rank: 2, WalletID: test_walletId, Metric: model.TierRuleMetricEarned, Threshold: 10000, Window: year, RetentionPeriod: &month, GracePeriod: &month, IsActive: true},
+		{TierID: silver, Rank: 1, WalletID: test_walletId, Metric: model.TierRuleMetricEarned, Threshold: 1000, Window: year, IsActive: true},
+	}
+	earnedReasons := tierRuleMetrics[model.TierRuleMetricEarned].reasons
+	longAgo := time.Now().Add(-90 * 24 * time.Hour)
+	recently := time.Now().Add(-24 * time.Hour)
+	passedGrace := time.Now().Add(-time.Hour)
+	newUser := func(tierId *string, achievedAt, graceUntil *time.Time) *model.User {
+		return &model.User{ID: test_userId, TierID: tierId, TierAchievedAt: achievedAt, TierGraceUntil: graceUntil, Accounts: []model.Account{{ID: test_accountId, WalletID: test_walletId}}}
+	}
+	setup := func(mocks *Mocks, ctx context.Context, user *model.User, earned uint64) {
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(user, nil)
+		mocks.tierRepo.EXPECT().FetchTierRules(ctx).Return(rules, nil)
+		mocks.transactionRepo.EXPECT().SumAccountTransactionsSince(ctx, test_accountId, model.TransactionTypeCredit, earnedReasons, gomock.Any()).Return(earned, nil).AnyTimes()
+	}
+	expectTier := func(mocks *Mocks, ctx context.Context, tierId *string, inGrace bool) {
+		mocks.userRepo.EXPECT().UpdateUserTier(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, user *model.User) error {
+			if !utils.EqualPtr(user.TierID, tierId) {
+				t.Errorf("expected tier %v, got %v", tierId, user.TierID)
+			}
+			if (user.TierGraceUntil != nil) != inGrace {
+				t.Errorf("expected grace period %v, got %v", inGrace, user.TierGraceUntil)
+			}
+			if user.GetRemarks() == nil {
+				t.Errorf("expected audit remarks")
+			}
+			return nil
+		})
+	}
+	testcases := []TestCase[TierService]{
+		{
+			name: "Upgrades to the highest tier the user qualifies for",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, newUser(&silver, &longAgo, nil), 12000)
+				expectTier(mocks, ctx, &gold, false)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Keeps the tier during its retention period",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, newUser(&gold, &recently, nil), 0)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Starts the grace period once the user stops qualifying",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, newUser(&gold, &longAgo, nil), 2000)
+				expectTier(mocks, ctx, &gold, true)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Downgrades once the grace period is over",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, newUser(&gold, &longAgo, &passedGrace), 2000)
+				expectTier(mocks, ctx, &silver, false)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Cancels the grace period when the user qualifies again",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				graceUntil := time.Now().Add(24 * time.Hour)
+				setup(mocks, ctx, newUser(&gold, &longAgo, &graceUntil), 10000)
+				expectTier(mocks, ctx, &gold, false)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Leaves manually assigned tiers alone",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, newUser(&vip, &longAgo, nil), 0)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Permission denied",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return service.EvaluateUserTier(ctx, test_userId)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TierService {
+		return NewTierService(mocks.repos)
+	}
+	RunTestCases[TierService](t, serviceFactory, testcases)
+}
+
+func TestTierService_EvaluateTiers(t *testing.T) {
+	rules := []model.TierRule{
+		{TierID: "silver", Rank: 1, WalletID: test_walletId, Metric: model.TierRuleMetricEarned, Threshold: 1000, Window: types.Interval(365 * 24 * time.Hour), IsActive: true},
+	}
+	batch := make([]model.User, tierEvaluationBatchSize)
+	for i := range batch {
+		batch[i] = model.User{ID: fmt.Sprintf("USER-%03d", i)}
+	}
+	testcases := []TestCase[TierService]{
+		{
+			name: "Goes through the users in batches following the last user ID",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "tier-evaluator", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.tierRepo.EXPECT().FetchTierRules(ctx).Return(rules, nil)
+				gomock.InOrder(
+					mocks.userRepo.EXPECT().FetchUsersAfter(ctx, "", tierEvaluationBatchSize).Return(batch, nil),
+					mocks.userRepo.EXPECT().FetchUsersAfter(ctx, batch[len(batch)-1].ID, tierEvaluationBatchSize).Return([]model.User{{ID: "USER-100"}}, nil),
+				)
+			},
+			testFunc: func(service TierService, ctx context.Context) (interface{}, error) {
+				return true, service.EvaluateTiers(ctx)
+			},
+			expectResult: true,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TierService {
+		return NewTierService(mocks.repos)
+	}
+	RunTestCases[TierService](t, serviceFactory, testcases)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+)
+
+func (s *transactionService) GetAccountLots(ctx context.Context, walletId, accountId string) ([]CreditLot, error) {
+	if _, err := s.fetchReadableAccount(ctx, walletId, accountId); err != nil {
+		return nil, err
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
+	if wallet == nil {
+		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId))
+		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	transactions, err := s.repos.Transaction.FetchOpenAccountLots(ctx, wallet, accountId)
+	if err != nil {
+		return nil, err
+	}
+	lots := make([]CreditLot, len(transactions))
+	for i, transaction := range transactions {
+		lots[i] = CreditLot{
+			TransactionID:   transaction.ID,
+			Reason:          transaction.Reason,
+			ProgramID:       transaction.ProgramID,
+			Amount:          transaction.Amount,
+			ConsumedAmount:  transaction.Amount - transaction.AvailableAmount,
+			AvailableAmount: transaction.AvailableAmount,
+			ExpireAt:        transaction.ExpireAt,
+			CreatedAt:       transaction.CreatedAt,
+		}
+	}
+	return lots, nil
+}
+
+func (s *transactionService) GetTransactionConsumptions(ctx context.Context, walletId, accountId, transactionId string) ([]model.TransactionConsumption, error) {
+	if _, err := s.fetchReadableAccount(ctx, walletId, accountId); err != nil {
+		return nil, err
+	}
+	return s.repos.Transaction.FetchTransactionConsumptions(ctx, accountId, transactionId)
+}
+
+// fetchReadableAccount fetches an account of a wallet, ensuring the actor can read its transactions
+func (s *transactionService) fetchReadableAccount(ctx context.Context, walletId, accountId string) (*model.Account, error) {
+	account, err := s.repos.Account.FetchAccountByID(ctx, accountId)
+	if account == nil || account.WalletID != walletId {
+		api.GetLogger(ctx).Error("Account not found", logger.Field("walletId", walletId), logger.Field("accountId", accountId))
+		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
+	}
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionTransactionRead); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
@@ -2,14 +2,15 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/abdelrahman146/digital-wallet/internal/model"
 	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/config"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/abdelrahman146/digital-wallet/pkg/types"
 	"github.com/abdelrahman146/digital-wallet/pkg/validator"
-	"github.com/shopspring/decimal"
 	"time"
 )
 
@@ -19,17 +20,21 @@ type TransactionService interface {
 	// Exchange exchanges between two accounts for the same user
 	Exchange(ctx context.Context, fromWalletId, toWalletId, userId string, amount uint64) (*ExchangeResponse, error)
 	// GetAccountTransactions returns a list of transactions for an account
-	GetAccountTransactions(ctx context.Context, walletId, accountId string, page int, limit int) (*api.List[model.Transaction], error)
+	GetAccountTransactions(ctx context.Context, walletId, accountId string, req *TransactionFilterRequest, page int, limit int) (*api.List[model.Transaction], error)
 	// GetAccountTransactionSum returns the sum of transactions for an account
 	GetAccountTransactionSum(ctx context.Context, walletId, accountId string) (uint64, error)
 	// GetWalletTransactions returns a list of transactions for a wallet
-	GetWalletTransactions(ctx context.Context, walletId string, page int, limit int) (*api.List[model.Transaction], error)
+	GetWalletTransactions(ctx context.Context, walletId string, req *TransactionFilterRequest, page int, limit int) (*api.List[model.Transaction], error)
 	// GetWalletTransactionSum returns the sum of transactions for a wallet
 	GetWalletTransactionSum(ctx context.Context, walletId string) (uint64, error)
 	// GetAccountExpiringTransactionsSum returns the sum of expiring transactions for an account
 	GetAccountExpiringTransactionsSum(ctx context.Context, accountId string) (uint64, error)
 	// GetExpiredWalletTransactions returns a list of expired transactions for a wallet
 	GetExpiredWalletTransactions(ctx context.Context, walletId string) ([]model.Transaction, error)
+	// GetAccountLots returns the credits of an account that still have an available amount, in the order its wallet consumes them
+	GetAccountLots(ctx context.Context, walletId, accountId string) ([]CreditLot, error)
+	// GetTransactionConsumptions returns the credits a debit consumed, or the debits that consumed a credit
+	GetTransactionConsumptions(ctx context.Context, walletId, accountId, transactionId string) ([]model.TransactionConsumption, error)
 }
 
 type transactionService struct {
@@ -54,10 +59,45 @@ func (s *transactionService) CreateTransaction(ctx context.Context, walletId, ac
 	if account == nil {
 		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
 	}
-	if err := api.IsAuthorizedUser(ctx, account.UserID); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("userId", account.UserID))
+	permission := api.PermissionTransactionCredit
+	if req.Type == model.TransactionTypeDebit {
+		permission = api.PermissionTransactionDebit
+	}
+	if err := authorizeOwner(ctx, s.repos, account.UserID, permission); err != nil {
+		return nil, err
+	}
+	if err := authorizeWallet(ctx, s.repos, walletId); err != nil {
 		return nil, err
 	}
+	user, err := s.repos.User.FetchUserByID(ctx, account.UserID)
+	if user == nil {
+		return nil, errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
+	}
+	if err := checkUserActive(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := checkPosting(ctx, wallet, account, req.Type); err != nil {
+		return nil, err
+	}
+	policy, err := resolveAccountPolicy(ctx, s.repos, wallet, user.TierID)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Type {
+	case model.TransactionTypeCredit:
+		err = policy.checkCredit(ctx, account, req.Amount)
+	case model.TransactionTypeDebit:
+		err = policy.checkDebit(ctx, s.repos, account, req.Amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.Reason == model.TransactionReasonPenalty || (req.Type == model.TransactionTypeCredit && wallet.ApprovalThreshold != nil && req.Amount >= *wallet.ApprovalThreshold) {
+		payload := transactionApprovalPayload{WalletID: walletId, AccountID: accountId, Request: *req}
+		if err := requireApproval(ctx, s.repos, model.ApprovalOperationCreateTransaction, permission, payload); err != nil {
+			return nil, err
+		}
+	}
 	transaction := &model.Transaction{
 		AccountID: accountId,
 		WalletID:  walletId,
@@ -88,8 +128,15 @@ func (s *transactionService) Exchange(ctx context.Context, fromWalletId, toWalle
 	}
 
 	// Check if user is authorized
-	if err := api.IsAuthorizedUser(ctx, userId); err != nil {
-		api.GetLogger(ctx).Error("User not authorized", logger.Field("userId", userId))
+	if err := authorizeOwner(ctx, s.repos, userId, api.PermissionTransactionExchange); err != nil {
+		return nil, err
+	}
+	for _, walletId := range []string{fromWalletId, toWalletId} {
+		if err := authorizeWallet(ctx, s.repos, walletId); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkUserActive(ctx, user); err != nil {
 		return nil, err
 	}
 
@@ -111,34 +158,98 @@ func (s *transactionService) Exchange(ctx context.Context, fromWalletId, toWalle
 		return nil, errs.NewNotFoundError("toWallet not found", "TO_WALLET_NOT_FOUND", nil)
 	}
 
-	// Get Exchange Rate
-	exchangeRate, err := s.repos.ExchangeRate.FetchExchangeRate(ctx, fromWalletId, toWalletId, user.TierID)
-	if exchangeRate == nil {
-		api.GetLogger(ctx).Error("Exchange Rate not found", logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", user.TierID))
-		return nil, errs.NewNotFoundError("Exchange Rate not found", "EXCHANGE_RATE_NOT_FOUND", err)
+	// Get the Exchange Rate version active now, routing through intermediate wallets when there is no direct rate
+	exchangedAt := time.Now()
+	route, err := s.resolveExchangeRoute(ctx, user, fromWalletId, toWalletId, amount, exchangedAt)
+	if err != nil {
+		return nil, err
 	}
+	walletIds := route.Wallets()
 
-	// Get Accounts
-	fromAccount, err := s.repos.Account.FetchAccountByUserID(ctx, fromWalletId, userId)
-	if fromAccount == nil {
-		api.GetLogger(ctx).Error("From Account not found", logger.Field("fromWalletId", fromWalletId), logger.Field("userId", userId))
-		return nil, errs.NewNotFoundError("From Account not found", "FROM_ACCOUNT_NOT_FOUND", err)
+	// Get Wallets along the route
+	wallets := map[string]*model.Wallet{fromWalletId: fromWallet, toWalletId: toWallet}
+	for _, walletId := range walletIds[1 : len(walletIds)-1] {
+		wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
+		if wallet == nil {
+			api.GetLogger(ctx).Error("Intermediate Wallet not found", logger.Field("walletId", walletId))
+			return nil, errs.NewNotFoundError("Intermediate Wallet not found", "INTERMEDIATE_WALLET_NOT_FOUND", err)
+		}
+		wallets[walletId] = wallet
 	}
-	toAccount, err := s.repos.Account.FetchAccountByUserID(ctx, toWalletId, userId)
-	if toAccount == nil {
-		api.GetLogger(ctx).Error("From Account not found", logger.Field("toWalletId", fromWalletId), logger.Field("userId", userId))
-		return nil, errs.NewNotFoundError("From Account not found", "TO_ACCOUNT_NOT_FOUND", err)
+
+	// Get Accounts along the route
+	accounts := make([]*model.Account, len(walletIds))
+	for i, walletId := range walletIds {
+		account, err := s.repos.Account.FetchAccountByUserID(ctx, walletId, userId)
+		if account == nil {
+			api.GetLogger(ctx).Error("Account not found", logger.Field("walletId", walletId), logger.Field("userId", userId))
+			switch i {
+			case 0:
+				return nil, errs.NewNotFoundError("From Account not found", "FROM_ACCOUNT_NOT_FOUND", err)
+			case len(walletIds) - 1:
+				return nil, errs.NewNotFoundError("To Account not found", "TO_ACCOUNT_NOT_FOUND", err)
+			default:
+				return nil, errs.NewNotFoundError("Intermediate Account not found", "INTERMEDIATE_ACCOUNT_NOT_FOUND", err)
+			}
+		}
+		accounts[i] = account
+	}
+	fromAccount, toAccount := accounts[0], accounts[len(accounts)-1]
+
+	// Check every account along the route can be posted to, intermediate accounts being both credited and debited
+	for i, account := range accounts {
+		wallet := wallets[walletIds[i]]
+		if i > 0 {
+			if err := checkPosting(ctx, wallet, account, model.TransactionTypeCredit); err != nil {
+				return nil, err
+			}
+		}
+		if i < len(accounts)-1 {
+			if err := checkPosting(ctx, wallet, account, model.TransactionTypeDebit); err != nil {
+				return nil, err
+			}
+		}
 	}
 
+	// Amounts entering each wallet along the route
+	amounts := []uint64{amount}
+	for _, exchangeRate := range route {
+		amounts = append(amounts, applyExchangeRate(amounts[len(amounts)-1], exchangeRate.ExchangeRate))
+	}
+	amountToCredit := amounts[len(amounts)-1]
+
 	// Check if balance is sufficient
 	if amount > fromAccount.Balance {
 		api.GetLogger(ctx).Error("Insufficient balance", logger.Field("amount", amount), logger.Field("balance", fromAccount.Balance))
 		return nil, errs.NewPaymentRequiredError("Insufficient balance", "INSUFFICIENT_BALANCE", nil)
 	}
-	// Check toWallet limit per user is not exceeded
-	if toWallet.LimitPerUser != nil && amount+toAccount.Balance > *toWallet.LimitPerUser {
-		api.GetLogger(ctx).Error("Limit per user exceeded", logger.Field("limit", *toWallet.LimitPerUser), logger.Field("amount", amount))
-		return nil, errs.NewForbiddenError("Limit per user exceeded", "LIMIT_PER_USER_EXCEEDED", nil)
+	// Check the tier policies of the wallets along the route, falling back to the wallet limits
+	fromPolicy, err := resolveAccountPolicy(ctx, s.repos, fromWallet, user.TierID)
+	if err != nil {
+		return nil, err
+	}
+	if err := fromPolicy.checkDebit(ctx, s.repos, fromAccount, amount); err != nil {
+		return nil, err
+	}
+	toPolicy, err := resolveAccountPolicy(ctx, s.repos, toWallet, user.TierID)
+	if err != nil {
+		return nil, err
+	}
+	if err := toPolicy.checkCredit(ctx, toAccount, amountToCredit); err != nil {
+		return nil, err
+	}
+	// Intermediate accounts are credited and then debited the amount of their leg, within the policies of their wallets
+	for i := 1; i < len(accounts)-1; i++ {
+		policy, err := resolveAccountPolicy(ctx, s.repos, wallets[walletIds[i]], user.TierID)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.checkCredit(ctx, accounts[i], amounts[i]); err != nil {
+			return nil, err
+		}
+		if err := policy.checkDebit(ctx, s.repos, accounts[i], amounts[i]); err != nil {
+			return nil, err
+		}
 	}
 
 	// Check toWallet total limit is not exceeded
@@ -147,75 +258,129 @@ func (s *transactionService) Exchange(ctx context.Context, fromWalletId, toWalle
 		api.GetLogger(ctx).Error("Error while getting wallet accounts sum", logger.Field("toWalletId", toWalletId))
 		return nil, err
 	}
-	if toWallet.LimitGlobal != nil && amount+sum > *toWallet.LimitGlobal {
-		api.GetLogger(ctx).Error("Limit global exceeded", logger.Field("limit", *toWallet.LimitGlobal), logger.Field("totalWalletBalance", sum), logger.Field("amount", amount))
+	if toWallet.LimitGlobal != nil && amountToCredit+sum > *toWallet.LimitGlobal {
+		api.GetLogger(ctx).Error("Limit global exceeded", logger.Field("limit", *toWallet.LimitGlobal), logger.Field("totalWalletBalance", sum), logger.Field("amount", amountToCredit))
 		return nil, errs.NewForbiddenError("Limit global exceeded", "LIMIT_GLOBAL_EXCEEDED", nil)
 	}
 
-	// CreateTransaction From Transaction
+	// Setup a debit and a credit transaction for every leg of the route
+	legs := make([]repository.ExchangeLeg, len(route))
+	resp := &ExchangeResponse{}
+	for i, exchangeRate := range route {
+		leg := newExchangeLeg(ctx, accounts[i], accounts[i+1], wallets[exchangeRate.ToWalletID], exchangeRate, amounts[i], amounts[i+1], exchangedAt)
+		if len(route) > 1 {
+			for _, req := range []*repository.ExchangeRequest{leg.From, leg.To} {
+				req.Transaction.Metadata["route"] = walletIds
+				req.Transaction.Metadata["routeLeg"] = i + 1
+			}
+		}
+		legs[i] = leg
+	}
+
+	if err := s.repos.Transaction.PerformExchange(ctx, legs); err != nil {
+		return nil, err
+	}
+	resp.FromTransaction = *legs[0].From.Transaction
+	resp.ToTransaction = *legs[len(legs)-1].To.Transaction
+	if len(legs) > 1 {
+		resp.Route = walletIds
+		for _, leg := range legs {
+			resp.Legs = append(resp.Legs, ExchangeResponse{FromTransaction: *leg.From.Transaction, ToTransaction: *leg.To.Transaction})
+		}
+	}
+	return resp, nil
+}
+
+// resolveExchangeRoute returns the direct exchange rate between two wallets, or the best route through the wallets the
+// user holds an active account in when there is none or the amount is below its minimum
+func (s *transactionService) resolveExchangeRoute(ctx context.Context, user *model.User, fromWalletId, toWalletId string, amount uint64, at time.Time) (exchangeRoute, error) {
+	exchangeRate, err := s.repos.ExchangeRate.FetchExchangeRate(ctx, fromWalletId, toWalletId, user.TierID, at)
+	if err != nil {
+		return nil, err
+	}
+	if exchangeRate != nil && acceptsAmount(*exchangeRate, amount) {
+		return exchangeRoute{*exchangeRate}, nil
+	}
+	exchangeRates, err := s.repos.ExchangeRate.FetchActiveExchangeRates(ctx, user.TierID, at)
+	if err != nil {
+		return nil, err
+	}
+	intermediates := make(map[string]bool)
+	for _, account := range user.Accounts {
+		intermediates[account.WalletID] = account.IsActive
+	}
+	route, _ := findExchangeRoute(exchangeRates, fromWalletId, toWalletId, amount, config.GetConfig().ExchangeMaxHops, intermediates)
+	if route == nil && exchangeRate != nil {
+		api.GetLogger(ctx).Error("Amount below the minimum of the exchange rate", logger.Field("amount", amount), logger.Field("exchangeRateId", exchangeRate.ID))
+		return nil, errs.NewValidationError("Amount is below the minimum of the exchange rate", "", map[string]string{"amount": fmt.Sprintf("must be at least %d", *exchangeRate.MinimumAmount)})
+	}
+	if route == nil {
+		api.GetLogger(ctx).Error("Exchange Rate not found", logger.Field("fromWalletId", fromWalletId), logger.Field("toWalletId", toWalletId), logger.Field("tierId", user.TierID))
+		return nil, errs.NewNotFoundError("Exchange Rate not found", "EXCHANGE_RATE_NOT_FOUND", nil)
+	}
+	return route, nil
+}
+
+// newExchangeLeg sets up the debit and credit transactions of a single exchange leg
+func newExchangeLeg(ctx context.Context, fromAccount, toAccount *model.Account, toWallet *model.Wallet, exchangeRate model.ExchangeRate, amount, amountToCredit uint64, exchangedAt time.Time) repository.ExchangeLeg {
+	// Setup From Transaction
 	fromTransaction := &model.Transaction{
-		AccountID: fromAccount.ID,
-		Amount:    amount,
-		Reason:    model.TransactionReasonExchange,
-		Type:      model.TransactionTypeDebit,
+		AccountID:      fromAccount.ID,
+		WalletID:       fromAccount.WalletID,
+		Amount:         amount,
+		Reason:         model.TransactionReasonExchange,
+		Type:           model.TransactionTypeDebit,
+		ExchangeRateID: &exchangeRate.ID,
 	}
 	fromTransaction.Metadata = make(types.JSONB)
-	fromTransaction.Metadata["toWalletId"] = toWalletId
+	fromTransaction.Metadata["toWalletId"] = toAccount.WalletID
 	fromTransaction.Metadata["toAccountId"] = toAccount.ID
+	fromTransaction.Metadata["exchangeRateId"] = exchangeRate.ID
 	fromTransaction.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
-	fromTransaction.SetRemarks("Exchange transaction created to wallet " + toWalletId)
+	fromTransaction.SetRemarks("Exchange transaction created to wallet " + toAccount.WalletID)
 
 	// Setup To Transaction
-	amountToCredit := decimal.NewFromUint64(amount)
-	if toWallet.IsMonetary {
-		amountToCredit = amountToCredit.Div(decimal.NewFromInt(100))
-	}
-	amountToCredit = amountToCredit.Mul(exchangeRate.ExchangeRate)
-	if toWallet.IsMonetary {
-		amountToCredit = amountToCredit.Mul(decimal.NewFromInt(100))
-	}
 	toTransaction := &model.Transaction{
-		AccountID: toAccount.ID,
-		Amount:    amountToCredit.BigInt().Uint64(),
-		Type:      model.TransactionTypeCredit,
-		Reason:    model.TransactionReasonExchange,
+		AccountID:      toAccount.ID,
+		WalletID:       toAccount.WalletID,
+		Amount:         amountToCredit,
+		Type:           model.TransactionTypeCredit,
+		Reason:         model.TransactionReasonExchange,
+		ExchangeRateID: &exchangeRate.ID,
 	}
 	toTransaction.Metadata = make(types.JSONB)
-	toTransaction.Metadata["fromWalletId"] = fromWalletId
+	toTransaction.Metadata["fromWalletId"] = fromAccount.WalletID
 	toTransaction.Metadata["fromAccountId"] = fromAccount.ID
 	toTransaction.Metadata["ExchangedAmount"] = amount
 	toTransaction.Metadata["exchangeRate"] = exchangeRate.ExchangeRate.String()
+	toTransaction.Metadata["exchangeRateId"] = exchangeRate.ID
 	toTransaction.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
-	toTransaction.SetRemarks("Exchange transaction created from wallet " + fromWalletId)
+	toTransaction.SetRemarks("Exchange transaction created from wallet " + fromAccount.WalletID)
 
 	if toWallet.PointsExpireAfter != nil {
-		expireAt := time.Now().Add(toWallet.PointsExpireAfter.Duration())
+		expireAt := exchangedAt.Add(toWallet.PointsExpireAfter.Duration())
 		toTransaction.ExpireAt = &expireAt
 	}
 
-	from := &repository.ExchangeRequest{
-		WalletID:       fromWalletId,
-		Transaction:    fromTransaction,
-		AccountVersion: fromAccount.Version,
-	}
-
-	to := &repository.ExchangeRequest{
-		WalletID:       toWalletId,
-		Transaction:    toTransaction,
-		AccountVersion: toAccount.Version,
+	return repository.ExchangeLeg{
+		From: &repository.ExchangeRequest{
+			WalletID:       fromAccount.WalletID,
+			Transaction:    fromTransaction,
+			AccountVersion: fromAccount.Version,
+		},
+		To: &repository.ExchangeRequest{
+			WalletID:       toAccount.WalletID,
+			Transaction:    toTransaction,
+			AccountVersion: toAccount.Version,
+		},
 	}
+}
 
-	if err := s.repos.Transaction.PerformExchange(ctx, from, to); err != nil {
+func (s *transactionService) GetAccountTransactions(ctx context.Context, walletId, accountId string, req *TransactionFilterRequest, page int, limit int) (*api.List[model.Transaction], error) {
+	filter, err := newTransactionFilter(ctx, req)
+	if err != nil {
 		return nil, err
 	}
-	resp := &ExchangeResponse{
-		FromTransaction: *fromTransaction,
-		ToTransaction:   *toTransaction,
-	}
-	return resp, nil
-}
-
-func (s *transactionService) GetAccountTransactions(ctx context.Context, walletId, accountId string, page int, limit int) (*api.List[model.Transaction], error) {
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
 	if wallet == nil {
 		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId))
@@ -226,19 +391,21 @@ func (s *transactionService) GetAccountTransactions(ctx context.Context, walletI
 		api.GetLogger(ctx).Error("Account not found", logger.Field("walletId", walletId), logger.Field("accountId", accountId))
 		return nil, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
 	}
-	if err := api.IsAuthorizedUser(ctx, account.UserID); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("userId", account.UserID))
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionTransactionRead); err != nil {
 		return nil, err
 	}
-	transactions, err := s.repos.Transaction.FetchAccountTransactions(ctx, accountId, page, limit)
+	transactions, err := s.repos.Transaction.FetchAccountTransactions(ctx, accountId, *filter, page, limit)
 	if err != nil {
 		return nil, err
 	}
-	total, err := s.repos.Transaction.CountAccountTransactions(ctx, accountId)
-	if err != nil {
-		return nil, err
+	var total int64
+	// the total is counted with the first page, the pages following a cursor skip it
+	if filter.Cursor == nil {
+		if total, err = s.repos.Transaction.CountAccountTransactions(ctx, accountId, *filter); err != nil {
+			return nil, err
+		}
 	}
-	return &api.List[model.Transaction]{Items: transactions, Page: page, Limit: limit, Total: total}, nil
+	return newTransactionList(transactions, page, limit, total), nil
 }
 
 func (s *transactionService) GetAccountTransactionSum(ctx context.Context, walletId, accountId string) (uint64, error) {
@@ -252,17 +419,19 @@ func (s *transactionService) GetAccountTransactionSum(ctx context.Context, walle
 		api.GetLogger(ctx).Error("Account not found", logger.Field("walletId", walletId), logger.Field("accountId", accountId))
 		return 0, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
 	}
-	if err := api.IsAuthorizedUser(ctx, account.UserID); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("userId", account.UserID))
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionTransactionRead); err != nil {
 		return 0, err
 	}
 	sum, err := s.repos.Transaction.SumAccountTransactions(ctx, accountId)
 	return sum, err
 }
 
-func (s *transactionService) GetWalletTransactions(ctx context.Context, walletId string, page int, limit int) (*api.List[model.Transaction], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("actor", api.GetActor(ctx)), logger.Field("userId", api.GetActorID(ctx)))
+func (s *transactionService) GetWalletTransactions(ctx context.Context, walletId string, req *TransactionFilterRequest, page int, limit int) (*api.List[model.Transaction], error) {
+	if err := authorize(ctx, s.repos, api.PermissionTransactionRead); err != nil {
+		return nil, err
+	}
+	filter, err := newTransactionFilter(ctx, req)
+	if err != nil {
 		return nil, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
@@ -270,20 +439,80 @@ func (s *transactionService) GetWalletTransactions(ctx context.Context, walletId
 		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId))
 		return nil, errs.NewNotFoundError("Wallet not found", "WALLET_NOT_FOUND", err)
 	}
-	transactions, err := s.repos.Transaction.FetchWalletTransactions(ctx, walletId, page, limit)
+	transactions, err := s.repos.Transaction.FetchWalletTransactions(ctx, walletId, *filter, page, limit)
 	if err != nil {
 		return nil, err
 	}
-	total, err := s.repos.Transaction.CountWalletTransactions(ctx, walletId)
-	if err != nil {
-		return nil, err
+	var total int64
+	// the total is counted with the first page, the pages following a cursor skip it
+	if filter.Cursor == nil {
+		if total, err = s.repos.Transaction.CountWalletTransactions(ctx, walletId, *filter); err != nil {
+			return nil, err
+		}
+	}
+	return newTransactionList(transactions, page, limit, total), nil
+}
+
+// newTransactionFilter validates a transaction filter request and converts it to a repository filter
+func newTransactionFilter(ctx context.Context, req *TransactionFilterRequest) (*repository.TransactionFilter, error) {
+	if req == nil {
+		return &repository.TransactionFilter{}, nil
 	}
-	return &api.List[model.Transaction]{Items: transactions, Page: page, Limit: limit, Total: total}, nil
+	if err := validator.GetValidator().ValidateStruct(req); err != nil {
+		fields := validator.GetValidator().GetValidationErrors(err)
+		api.GetLogger(ctx).Error("Invalid transaction filter", logger.Field("fields", fields))
+		return nil, errs.NewValidationError("Invalid transaction filter", "", fields)
+	}
+	filter := &repository.TransactionFilter{
+		Type:          req.Type,
+		Reason:        req.Reason,
+		ProgramID:     req.ProgramID,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		MetadataKey:   req.MetadataKey,
+		MetadataValue: req.MetadataValue,
+	}
+	fields := map[string]string{}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MaxAmount < *req.MinAmount {
+		fields["maxAmount"] = "must be greater than or equal to minAmount"
+	}
+	if req.From != "" {
+		from, _ := time.Parse(time.RFC3339, req.From)
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, _ := time.Parse(time.RFC3339, req.To)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		fields["to"] = "must be after from"
+	}
+	if req.Cursor != "" {
+		cursor, ok := api.ParseCursor(req.Cursor)
+		if !ok {
+			fields["cursor"] = "is invalid"
+		}
+		filter.Cursor = cursor
+	}
+	if len(fields) > 0 {
+		api.GetLogger(ctx).Error("Invalid transaction filter", logger.Field("fields", fields))
+		return nil, errs.NewValidationError("Invalid transaction filter", "", fields)
+	}
+	return filter, nil
+}
+
+// newTransactionList lists a page of transactions, with the cursor of the next page when the page is full
+func newTransactionList(transactions []model.Transaction, page int, limit int, total int64) *api.List[model.Transaction] {
+	list := &api.List[model.Transaction]{Items: transactions, Page: page, Limit: limit, Total: total}
+	if limit > 0 && len(transactions) == limit {
+		last := transactions[len(transactions)-1]
+		list.NextCursor = api.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return list
 }
 
 func (s *transactionService) GetWalletTransactionSum(ctx context.Context, walletId string) (uint64, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("actor", api.GetActor(ctx)), logger.Field("userId", api.GetActorID(ctx)))
+	if err := authorize(ctx, s.repos, api.PermissionTransactionRead); err != nil {
 		return 0, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
@@ -304,8 +533,7 @@ func (s *transactionService) GetAccountExpiringTransactionsSum(ctx context.Conte
 		api.GetLogger(ctx).Error("Account not found", logger.Field("accountId", accountId))
 		return 0, errs.NewNotFoundError("Account not found", "ACCOUNT_NOT_FOUND", err)
 	}
-	if err := api.IsAuthorizedUser(ctx, account.UserID); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("userId", account.UserID))
+	if err := authorizeOwner(ctx, s.repos, account.UserID, api.PermissionTransactionRead); err != nil {
 		return 0, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, account.WalletID)
@@ -325,8 +553,7 @@ func (s *transactionService) GetAccountExpiringTransactionsSum(ctx context.Conte
 }
 
 func (s *transactionService) GetExpiredWalletTransactions(ctx context.Context, walletId string) ([]model.Transaction, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized", logger.Field("actor", api.GetActor(ctx)), logger.Field("userId", api.GetActorID(ctx)))
+	if err := authorize(ctx, s.repos, api.PermissionTransactionRead); err != nil {
 		return nil, err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
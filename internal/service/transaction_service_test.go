@@ -0,0 +1,652 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/internal/repository"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestTransactionService_CreateTransaction(t *testing.T) {
+	tierId := "gold"
+	maxBalance := uint64(1000)
+	maxTransaction := uint64(300)
+	dailyDebitLimit := uint64(500)
+	wallet := &model.Wallet{ID: test_walletId, IsActive: true, LimitPerUser: &maxBalance}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId, Balance: 900}
+	policy := &model.TierPolicy{WalletID: test_walletId, TierID: tierId, EarnMultiplier: decimal.NewFromInt(1), MaxTransactionAmount: &maxTransaction, DailyDebitLimit: &dailyDebitLimit}
+	setupAccount := func(mocks *Mocks, ctx context.Context, user *model.User) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(user, nil)
+	}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Credit within the wallet default limit",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupAccount(mocks, ctx, &model.User{ID: test_userId, IsActive: true})
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Credit beyond the wallet default limit",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupAccount(mocks, ctx, &model.User{ID: test_userId, IsActive: true})
+			},
+			expectedError: "LIMIT_PER_USER_EXCEEDED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 200, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Tier policy raises the maximum balance",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupAccount(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &tierId})
+				raised := uint64(5000)
+				mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, tierId).Return(&model.TierPolicy{WalletID: test_walletId, TierID: tierId, MaxBalance: &raised, EarnMultiplier: decimal.NewFromInt(1)}, nil)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 200, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Tier policy per-transaction maximum",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupAccount(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &tierId})
+				mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, tierId).Return(policy, nil)
+			},
+			expectedError: "TRANSACTION_LIMIT_EXCEEDED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 400, Reason: model.TransactionReasonPurchase})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Tier policy daily debit cap",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupAccount(mocks, ctx, &model.User{ID: test_userId, IsActive: true, TierID: &tierId})
+				mocks.tierRepo.EXPECT().FetchTierPolicy(ctx, test_walletId, tierId).Return(policy, nil)
+				mocks.transactionRepo.EXPECT().SumAccountTransactionsSince(ctx, test_accountId, model.TransactionTypeDebit, dailyDebitReasons, gomock.Any()).Return(uint64(300), nil)
+			},
+			expectedError: "DAILY_DEBIT_LIMIT_EXCEEDED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 250, Reason: model.TransactionReasonPurchase})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
+
+func TestTransactionService_CreateTransaction_Status(t *testing.T) {
+	activeUser := &model.User{ID: test_userId, IsActive: true}
+	activeWallet := &model.Wallet{ID: test_walletId, IsActive: true}
+	frozenAccount := &model.Account{ID: test_accountId, WalletID: test_walletId, UserID: test_userId, Balance: 500}
+	activeAccount := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId, Balance: 500}
+	setup := func(mocks *Mocks, ctx context.Context, wallet *model.Wallet, account *model.Account, user *model.User) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(user, nil)
+	}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Backoffice actor needs the debit permission to debit",
+			ctx:  createBackofficeContext(api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(activeWallet, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(activeAccount, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 100, Reason: model.TransactionReasonPenalty})
+			},
+			expectResult: false,
+		},
+		{
+			name: "API key can only credit the wallets it is scoped to",
+			ctx:  createApiKeyContext([]string{"other-wallet"}, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(activeWallet, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(activeAccount, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "WALLET_ACCESS_DENIED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonReward})
+			},
+			expectResult: false,
+		},
+		{
+			name: "API key can only perform the operations it is scoped to",
+			ctx:  createApiKeyContext([]string{test_walletId}, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(activeWallet, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(activeAccount, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			expectedError: "PERMISSION_DENIED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 100, Reason: model.TransactionReasonPurchase})
+			},
+			expectResult: false,
+		},
+		{
+			name: "API key scoped to the wallet and operation can credit",
+			ctx:  createApiKeyContext([]string{test_walletId}, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, activeWallet, activeAccount, activeUser)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), activeAccount.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonReward})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Backoffice actor with the credit permission can credit",
+			ctx:  createBackofficeContext(api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, activeWallet, activeAccount, activeUser)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), activeAccount.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Frozen account rejects debits",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, activeWallet, frozenAccount, activeUser)
+			},
+			expectedError: "ACCOUNT_FROZEN",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 100, Reason: model.TransactionReasonPurchase})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Frozen account accepts credits",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, activeWallet, frozenAccount, activeUser)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), frozenAccount.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Archived wallet rejects credits",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, &model.Wallet{ID: test_walletId, IsActive: true, IsArchived: true}, activeAccount, activeUser)
+			},
+			expectedError: "WALLET_ARCHIVED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Inactive wallet rejects debits",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, &model.Wallet{ID: test_walletId}, activeAccount, activeUser)
+			},
+			expectedError: "WALLET_INACTIVE",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 100, Reason: model.TransactionReasonPurchase})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Newly created user can transact",
+			ctx:  createBackofficeContext(api.PermissionUserWrite, api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				var created *model.User
+				mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).DoAndReturn(func(ctx context.Context, userId string) (*model.User, error) {
+					return created, nil
+				}).Times(2)
+				mocks.userRepo.EXPECT().CreateUser(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, user *model.User) error {
+					created = user
+					return nil
+				})
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(activeWallet, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(activeAccount, nil)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), activeAccount.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				if _, err := NewUserService(service.(*transactionService).repos).CreateUser(ctx, &CreateUserRequest{ID: test_userId}); err != nil {
+					return nil, err
+				}
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Inactive user is blocked",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx, activeWallet, activeAccount, &model.User{ID: test_userId})
+			},
+			expectedError: "USER_INACTIVE",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 100, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
+
+func TestTransactionService_CreateTransaction_Approval(t *testing.T) {
+	threshold := uint64(1000)
+	wallet := &model.Wallet{ID: test_walletId, IsActive: true, ApprovalThreshold: &threshold}
+	account := &model.Account{ID: test_accountId, IsActive: true, WalletID: test_walletId, UserID: test_userId, Balance: 5000}
+	setup := func(mocks *Mocks, ctx context.Context) {
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+		mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true}, nil)
+	}
+	expectApproval := func(mocks *Mocks, ctx context.Context) {
+		mocks.approvalRepo.EXPECT().CreateApproval(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, approval *model.Approval) error {
+			if approval.Operation != model.ApprovalOperationCreateTransaction || approval.Payload["accountId"] != test_accountId {
+				t.Errorf("expected a pending transaction on %s, got %+v", test_accountId, approval)
+			}
+			return nil
+		})
+	}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Backoffice credit below the wallet threshold goes through",
+			ctx:  createBackofficeContext(api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 999, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+		{
+			name: "Backoffice credit from the wallet threshold is held for approval",
+			ctx:  createBackofficeContext(api.PermissionTransactionCredit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx)
+				expectApproval(mocks, ctx)
+			},
+			expectedError: "APPROVAL_REQUIRED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 1000, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: false,
+		},
+		{
+			name: "Backoffice penalty is held for approval",
+			ctx:  createBackofficeContext(api.PermissionTransactionDebit),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx)
+				expectApproval(mocks, ctx)
+			},
+			expectedError: "APPROVAL_REQUIRED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeDebit, Amount: 10, Reason: model.TransactionReasonPenalty})
+			},
+			expectResult: false,
+		},
+		{
+			name: "System credits don't need approval",
+			ctx:  api.CreateAppContext(context.Background(), api.AppActorSystem, "system-123", test_requestId),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setup(mocks, ctx)
+				mocks.transactionRepo.EXPECT().CreateTransaction(ctx, gomock.Any(), account.Version).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.CreateTransaction(ctx, test_walletId, test_accountId, &TransactionRequest{Type: model.TransactionTypeCredit, Amount: 2000, Reason: model.TransactionReasonDeposit})
+			},
+			expectResult: true,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
+
+func TestTransactionService_Exchange(t *testing.T) {
+	rates := []model.ExchangeRate{
+		{ID: 1, FromWalletID: "loyalty", ToWalletID: "store-credit", ExchangeRate: decimal.RequireFromString("0.5")},
+		{ID: 2, FromWalletID: "store-credit", ToWalletID: "aed", ExchangeRate: decimal.RequireFromString("0.2")},
+	}
+	userAccounts := []model.Account{
+		{ID: "acc-loyalty", IsActive: true, WalletID: "loyalty"},
+		{ID: "acc-store-credit", IsActive: true, WalletID: "store-credit"},
+		{ID: "acc-aed", IsActive: true, WalletID: "aed"},
+	}
+	setupUser := func(mocks *Mocks, ctx context.Context, accounts []model.Account) {
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true, Accounts: accounts}, nil)
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "loyalty").Return(&model.Wallet{ID: "loyalty", IsActive: true}, nil)
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "aed").Return(&model.Wallet{ID: "aed", IsActive: true}, nil)
+	}
+	setupWallets := func(mocks *Mocks, ctx context.Context) {
+		mocks.userRepo.EXPECT().FetchUserByID(ctx, test_userId).Return(&model.User{ID: test_userId, IsActive: true, Accounts: userAccounts}, nil)
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "loyalty").Return(&model.Wallet{ID: "loyalty", IsActive: true}, nil)
+		mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "aed").Return(&model.Wallet{ID: "aed", IsActive: true}, nil)
+	}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Direct exchange",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupWallets(mocks, ctx)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(&model.ExchangeRate{ID: 3, FromWalletID: "loyalty", ToWalletID: "aed", ExchangeRate: decimal.RequireFromString("0.1")}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "loyalty", test_userId).Return(&model.Account{ID: "acc-loyalty", IsActive: true, WalletID: "loyalty", Balance: 1000}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "aed", test_userId).Return(&model.Account{ID: "acc-aed", IsActive: true, WalletID: "aed"}, nil)
+				mocks.accountRepo.EXPECT().SumWalletAccounts(ctx, "aed").Return(uint64(0), nil)
+				mocks.transactionRepo.EXPECT().PerformExchange(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, legs []repository.ExchangeLeg) error {
+					if len(legs) != 1 || legs[0].To.Transaction.Amount != 100 {
+						t.Errorf("expected a single leg crediting 100, got %+v", legs)
+					}
+					if _, ok := legs[0].To.Transaction.Metadata["route"]; ok {
+						t.Errorf("expected no route for a direct exchange")
+					}
+					return nil
+				})
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Routes through an intermediate wallet when no direct rate exists",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupWallets(mocks, ctx)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(nil, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchActiveExchangeRates(ctx, nil, gomock.Any()).Return(rates, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "store-credit").Return(&model.Wallet{ID: "store-credit", IsActive: true}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "loyalty", test_userId).Return(&model.Account{ID: "acc-loyalty", IsActive: true, WalletID: "loyalty", Balance: 1000}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "store-credit", test_userId).Return(&model.Account{ID: "acc-store-credit", IsActive: true, WalletID: "store-credit"}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "aed", test_userId).Return(&model.Account{ID: "acc-aed", IsActive: true, WalletID: "aed"}, nil)
+				mocks.accountRepo.EXPECT().SumWalletAccounts(ctx, "aed").Return(uint64(0), nil)
+				mocks.transactionRepo.EXPECT().PerformExchange(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, legs []repository.ExchangeLeg) error {
+					if len(legs) != 2 {
+						t.Fatalf("expected 2 legs, got %d", len(legs))
+					}
+					if legs[0].To.Transaction.Amount != 500 || legs[1].From.Transaction.Amount != 500 || legs[1].To.Transaction.Amount != 100 {
+						t.Errorf("unexpected leg amounts %+v", legs)
+					}
+					if legs[1].From.Transaction.AccountID != "acc-store-credit" {
+						t.Errorf("expected second leg to debit the intermediate account, got %s", legs[1].From.Transaction.AccountID)
+					}
+					if route, ok := legs[1].To.Transaction.Metadata["route"].([]string); !ok || len(route) != 3 {
+						t.Errorf("expected the route in metadata, got %v", legs[1].To.Transaction.Metadata["route"])
+					}
+					return nil
+				})
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: true,
+		},
+		{
+			name: "Intermediate wallets apply their limits to the amount of their leg",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupWallets(mocks, ctx)
+				limit := uint64(400)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(nil, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchActiveExchangeRates(ctx, nil, gomock.Any()).Return(rates, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, "store-credit").Return(&model.Wallet{ID: "store-credit", IsActive: true, LimitPerUser: &limit}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "loyalty", test_userId).Return(&model.Account{ID: "acc-loyalty", IsActive: true, WalletID: "loyalty", Balance: 1000}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "store-credit", test_userId).Return(&model.Account{ID: "acc-store-credit", IsActive: true, WalletID: "store-credit"}, nil)
+				mocks.accountRepo.EXPECT().FetchAccountByUserID(ctx, "aed", test_userId).Return(&model.Account{ID: "acc-aed", IsActive: true, WalletID: "aed"}, nil)
+			},
+			expectedError: "LIMIT_PER_USER_EXCEEDED",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: false,
+		},
+		{
+			name: "Routes only through wallets the user holds an active account in",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				frozen := append([]model.Account{}, userAccounts...)
+				frozen[1].IsActive = false
+				setupUser(mocks, ctx, frozen)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(nil, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchActiveExchangeRates(ctx, nil, gomock.Any()).Return(rates, nil)
+			},
+			expectedError: "EXCHANGE_RATE_NOT_FOUND",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: false,
+		},
+		{
+			name: "Direct rate below its minimum amount is not used",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupWallets(mocks, ctx)
+				minimum := uint64(5000)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(&model.ExchangeRate{ID: 3, FromWalletID: "loyalty", ToWalletID: "aed", ExchangeRate: decimal.RequireFromString("0.1"), MinimumAmount: &minimum}, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchActiveExchangeRates(ctx, nil, gomock.Any()).Return(rates[:1], nil)
+			},
+			expectedError: "VALIDATION_ERROR",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: false,
+		},
+		{
+			name: "Exchange rate lookup failure is returned",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupWallets(mocks, ctx)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(nil, errs.NewInternalError("Database unavailable", "", nil))
+			},
+			expectedError: "INTERNAL_ERROR",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: false,
+		},
+		{
+			name: "No route within the maximum hop count",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				setupWallets(mocks, ctx)
+				mocks.exchangeRateRepo.EXPECT().FetchExchangeRate(ctx, "loyalty", "aed", nil, gomock.Any()).Return(nil, nil)
+				mocks.exchangeRateRepo.EXPECT().FetchActiveExchangeRates(ctx, nil, gomock.Any()).Return(rates[:1], nil)
+			},
+			expectedError: "EXCHANGE_RATE_NOT_FOUND",
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.Exchange(ctx, "loyalty", "aed", test_userId, 1000)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
+
+func TestTransactionService_GetWalletTransactions(t *testing.T) {
+	ctx := createBackofficeContext(api.PermissionTransactionRead)
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cursor := api.Cursor{CreatedAt: createdAt, ID: "TX-2"}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Returns the cursor of the next page when the page is full",
+			ctx:  ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
+				filter := repository.TransactionFilter{Type: model.TransactionTypeCredit}
+				mocks.transactionRepo.EXPECT().FetchWalletTransactions(ctx, test_walletId, filter, 1, 2).Return([]model.Transaction{{ID: "TX-1"}, {ID: "TX-2", CreatedAt: createdAt}}, nil)
+				mocks.transactionRepo.EXPECT().CountWalletTransactions(ctx, test_walletId, filter).Return(int64(3), nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				list, err := service.GetWalletTransactions(ctx, test_walletId, &TransactionFilterRequest{Type: model.TransactionTypeCredit}, 1, 2)
+				if err == nil && list.NextCursor != cursor.Encode() {
+					t.Errorf("expected the cursor of TX-2, got %q", list.NextCursor)
+				}
+				return list, err
+			},
+			expectResult: true,
+		},
+		{
+			name: "Lists the transactions following a cursor",
+			ctx:  ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
+				from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+				minAmount := uint64(10)
+				filter := repository.TransactionFilter{MinAmount: &minAmount, From: &from, MetadataKey: "orderId", MetadataValue: "123", Cursor: &cursor}
+				mocks.transactionRepo.EXPECT().FetchWalletTransactions(ctx, test_walletId, filter, 1, 2).Return([]model.Transaction{{ID: "TX-3"}}, nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				minAmount := uint64(10)
+				req := &TransactionFilterRequest{MinAmount: &minAmount, From: "2026-01-01T00:00:00Z", MetadataKey: "orderId", MetadataValue: "123", Cursor: cursor.Encode()}
+				list, err := service.GetWalletTransactions(ctx, test_walletId, req, 1, 2)
+				if err == nil && list.NextCursor != "" {
+					t.Errorf("expected no cursor after the last page, got %q", list.NextCursor)
+				}
+				if err == nil && list.Total != 0 {
+					t.Errorf("expected the pages following a cursor not to be counted, got %d", list.Total)
+				}
+				return list, err
+			},
+			expectResult: true,
+		},
+		{
+			name:       "Rejects an invalid cursor",
+			ctx:        ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.GetWalletTransactions(ctx, test_walletId, &TransactionFilterRequest{Cursor: "not-a-cursor"}, 1, 2)
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name:       "Rejects an inverted amount range",
+			ctx:        ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				minAmount, maxAmount := uint64(100), uint64(10)
+				return service.GetWalletTransactions(ctx, test_walletId, &TransactionFilterRequest{MinAmount: &minAmount, MaxAmount: &maxAmount}, 1, 2)
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name:       "Rejects an unknown reason",
+			ctx:        ctx,
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.GetWalletTransactions(ctx, test_walletId, &TransactionFilterRequest{Reason: "GIFT"}, 1, 2)
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
+
+func TestTransactionService_GetAccountLots(t *testing.T) {
+	expireAt := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	account := &model.Account{ID: test_accountId, WalletID: test_walletId, UserID: test_userId}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Lists what is left of every open lot",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(&model.Wallet{ID: test_walletId}, nil)
+				mocks.transactionRepo.EXPECT().FetchOpenAccountLots(ctx, &model.Wallet{ID: test_walletId}, test_accountId).Return([]model.Transaction{
+					{ID: "TX-1", Type: model.TransactionTypeCredit, Reason: model.TransactionReasonReward, Amount: 100, AvailableAmount: 40, ExpireAt: &expireAt},
+					{ID: "TX-2", Type: model.TransactionTypeCredit, Reason: model.TransactionReasonDeposit, Amount: 50, AvailableAmount: 50},
+				}, nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				lots, err := service.GetAccountLots(ctx, test_walletId, test_accountId)
+				if err != nil {
+					return nil, err
+				}
+				if len(lots) != 2 || lots[0].ConsumedAmount != 60 || lots[0].AvailableAmount != 40 || !lots[0].ExpireAt.Equal(expireAt) || lots[1].ConsumedAmount != 0 {
+					t.Errorf("unexpected lots %+v", lots)
+				}
+				return lots, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Rejects an account of another wallet",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, WalletID: "other", UserID: test_userId}, nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.GetAccountLots(ctx, test_walletId, test_accountId)
+			},
+			expectedError: "ACCOUNT_NOT_FOUND",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
+
+func TestTransactionService_GetTransactionConsumptions(t *testing.T) {
+	account := &model.Account{ID: test_accountId, WalletID: test_walletId, UserID: test_userId}
+	testcases := []TestCase[TransactionService]{
+		{
+			name: "Traces the lots a debit drew from",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(account, nil)
+				mocks.transactionRepo.EXPECT().FetchTransactionConsumptions(ctx, test_accountId, "TX-3").Return([]model.TransactionConsumption{
+					{DebitTransactionID: "TX-3", CreditTransactionID: "TX-1", Amount: 60},
+					{DebitTransactionID: "TX-3", CreditTransactionID: "TX-2", Amount: 10},
+				}, nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.GetTransactionConsumptions(ctx, test_walletId, test_accountId, "TX-3")
+			},
+			expectResult: true,
+		},
+		{
+			name: "Denies reading the trace of another user's account",
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.accountRepo.EXPECT().FetchAccountByID(ctx, test_accountId).Return(&model.Account{ID: test_accountId, WalletID: test_walletId, UserID: "other"}, nil)
+				mocks.auditRepo.EXPECT().CreateAudit(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service TransactionService, ctx context.Context) (interface{}, error) {
+				return service.GetTransactionConsumptions(ctx, test_walletId, test_accountId, "TX-3")
+			},
+			expectedError: "PERMISSION_DENIED",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) TransactionService {
+		return NewTransactionService(mocks.repos)
+	}
+	RunTestCases[TransactionService](t, serviceFactory, testcases)
+}
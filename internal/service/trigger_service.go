@@ -27,8 +27,7 @@ func NewTriggerService(repos *repository.Repos) TriggerService {
 }
 
 func (s *triggerService) CreateTrigger(ctx context.Context, req CreateTriggerRequest) (*model.Trigger, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionTriggerWrite); err != nil {
 		return nil, err
 	}
 	if err := validator.GetValidator().ValidateStruct(req); err != nil {
@@ -50,8 +49,7 @@ func (s *triggerService) CreateTrigger(ctx context.Context, req CreateTriggerReq
 }
 
 func (s *triggerService) UpdateTrigger(ctx context.Context, triggerId uint64, req UpdateTriggerRequest) (*model.Trigger, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionTriggerWrite); err != nil {
 		return nil, err
 	}
 	trigger, err := s.repos.Trigger.FetchTriggerByID(ctx, triggerId)
@@ -77,8 +75,7 @@ func (s *triggerService) UpdateTrigger(ctx context.Context, triggerId uint64, re
 }
 
 func (s *triggerService) DeleteTrigger(ctx context.Context, triggerId uint64) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionTriggerWrite); err != nil {
 		return err
 	}
 	trigger, err := s.repos.Trigger.FetchTriggerByID(ctx, triggerId)
@@ -92,8 +89,7 @@ func (s *triggerService) DeleteTrigger(ctx context.Context, triggerId uint64) er
 }
 
 func (s *triggerService) GetTrigger(ctx context.Context, triggerId uint64) (*model.Trigger, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionTriggerRead); err != nil {
 		return nil, err
 	}
 	trigger, err := s.repos.Trigger.FetchTriggerByID(ctx, triggerId)
@@ -104,8 +100,7 @@ func (s *triggerService) GetTrigger(ctx context.Context, triggerId uint64) (*mod
 }
 
 func (s *triggerService) ListTriggers(ctx context.Context, page, limit int) (*api.List[model.Trigger], error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("Unauthorized access", logger.Field("error", err))
+	if err := authorize(ctx, s.repos, api.PermissionTriggerRead); err != nil {
 		return nil, err
 	}
 	triggers, err := s.repos.Trigger.FetchTriggers(ctx, page, limit)
@@ -8,6 +8,7 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/abdelrahman146/digital-wallet/pkg/validator"
+	"time"
 )
 
 type UserService interface {
@@ -17,6 +18,8 @@ type UserService interface {
 	GetUsersByTierID(ctx context.Context, tierId string, page int, limit int) (*api.List[model.User], error)
 	GetUsers(ctx context.Context, page int, limit int) (*api.List[model.User], error)
 	DeleteUser(ctx context.Context, userId string) error
+	// GetUserTierTimeline returns the tier changes of a user, latest first
+	GetUserTierTimeline(ctx context.Context, userId string, page int, limit int) (*api.List[model.UserTierHistory], error)
 }
 
 type userService struct {
@@ -28,6 +31,9 @@ func NewUserService(repos *repository.Repos) UserService {
 }
 
 func (s *userService) CreateUser(ctx context.Context, req *CreateUserRequest) (*model.User, error) {
+	if err := authorize(ctx, s.repos, api.PermissionUserWrite); err != nil {
+		return nil, err
+	}
 	if err := validator.GetValidator().ValidateStruct(req); err != nil {
 		fields := validator.GetValidator().GetValidationErrors(err)
 		api.GetLogger(ctx).Error("Invalid user request", logger.Field("fields", fields), logger.Field("request", req))
@@ -39,8 +45,9 @@ func (s *userService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 		return nil, errs.NewConflictError("User already exists", "USER_ALREADY_EXISTS", nil)
 	}
 	user = &model.User{
-		ID:     req.ID,
-		TierID: req.TierID,
+		ID:       req.ID,
+		TierID:   req.TierID,
+		IsActive: true,
 	}
 	if err := s.repos.User.CreateUser(ctx, user); err != nil {
 		return nil, err
@@ -49,6 +56,9 @@ func (s *userService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 }
 
 func (s *userService) GetUserByID(ctx context.Context, userId string) (*model.User, error) {
+	if err := authorizeOwner(ctx, s.repos, userId, api.PermissionUserRead); err != nil {
+		return nil, err
+	}
 	user, err := s.repos.User.FetchUserByID(ctx, userId)
 	if user == nil {
 		api.GetLogger(ctx).Error("User not found", logger.Field("userId", userId), logger.Field("error", err))
@@ -58,18 +68,31 @@ func (s *userService) GetUserByID(ctx context.Context, userId string) (*model.Us
 }
 
 func (s *userService) SetUserTier(ctx context.Context, userId string, tierId string) (*model.User, error) {
+	if err := authorize(ctx, s.repos, api.PermissionUserWrite); err != nil {
+		return nil, err
+	}
 	user, err := s.repos.User.FetchUserByID(ctx, userId)
 	if user == nil {
 		api.GetLogger(ctx).Error("User not found", logger.Field("userId", userId), logger.Field("error", err))
 		return nil, errs.NewNotFoundError("User not found", "USER_NOT_FOUND", err)
 	}
-	if err := s.repos.User.UpdateUserTier(ctx, userId, tierId); err != nil {
+	now := time.Now()
+	user.SetOldRecord(*user)
+	user.TierID = &tierId
+	user.TierAchievedAt = &now
+	user.TierGraceUntil = nil
+	user.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
+	user.SetRemarks("Tier set manually to " + tierId)
+	if err := s.repos.User.UpdateUserTier(ctx, user); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
 func (s *userService) GetUsersByTierID(ctx context.Context, tierId string, page int, limit int) (*api.List[model.User], error) {
+	if err := authorize(ctx, s.repos, api.PermissionUserRead); err != nil {
+		return nil, err
+	}
 	users, err := s.repos.User.FetchUsersByTierID(ctx, tierId, page, limit)
 	if err != nil {
 		return nil, err
@@ -83,6 +106,9 @@ func (s *userService) GetUsersByTierID(ctx context.Context, tierId string, page
 }
 
 func (s *userService) GetUsers(ctx context.Context, page int, limit int) (*api.List[model.User], error) {
+	if err := authorize(ctx, s.repos, api.PermissionUserRead); err != nil {
+		return nil, err
+	}
 	users, err := s.repos.User.FetchUsers(ctx, page, limit)
 	if err != nil {
 		return nil, err
@@ -96,8 +122,7 @@ func (s *userService) GetUsers(ctx context.Context, page int, limit int) (*api.L
 }
 
 func (s *userService) DeleteUser(ctx context.Context, userId string) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionUserWrite); err != nil {
 		return err
 	}
 	user, err := s.repos.User.FetchUserByID(ctx, userId)
@@ -110,3 +135,18 @@ func (s *userService) DeleteUser(ctx context.Context, userId string) error {
 	user.SetOldRecord(*user)
 	return s.repos.User.DeleteUser(ctx, user)
 }
+
+func (s *userService) GetUserTierTimeline(ctx context.Context, userId string, page int, limit int) (*api.List[model.UserTierHistory], error) {
+	if err := authorizeOwner(ctx, s.repos, userId, api.PermissionUserRead); err != nil {
+		return nil, err
+	}
+	history, err := s.repos.User.FetchUserTierHistory(ctx, userId, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repos.User.CountUserTierHistory(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &api.List[model.UserTierHistory]{Items: history, Total: total, Page: page, Limit: limit}, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/abdelrahman146/digital-wallet/pkg/logger"
+	"time"
+)
+
+// balanceSnapshotBatchSize is the number of wallets loaded at a time while snapshotting all wallets
+const balanceSnapshotBatchSize = 100
+
+// snapshotDay is the length of the period between two balance snapshots of a wallet
+const snapshotDay = 24 * time.Hour
+
+func (s *walletService) GetLiabilityAt(ctx context.Context, walletId string, at time.Time) (*WalletLiability, error) {
+	if err := authorize(ctx, s.repos, api.PermissionWalletRead); err != nil {
+		return nil, err
+	}
+	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
+	if wallet == nil {
+		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId))
+		return nil, errs.NewNotFoundError("wallet not found", "WALLET_NOT_FOUND", err)
+	}
+	snapshot, err := s.repos.Wallet.FetchLatestBalanceSnapshot(ctx, walletId, at)
+	if err != nil {
+		return nil, err
+	}
+	liability := &WalletLiability{WalletID: wallet.ID, At: at}
+	var from time.Time
+	if snapshot != nil {
+		liability.Liability = snapshot.Liability
+		liability.SnapshotAt = &snapshot.BalanceAt
+		from = snapshot.BalanceAt
+	}
+	// timestamps are stored to the microsecond, so this includes the transactions made at that exact time
+	change, err := s.repos.Transaction.SumWalletBalanceChange(ctx, walletId, from, at.Add(time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+	liability.Liability = uint64(int64(liability.Liability) + change)
+	return liability, nil
+}
+
+func (s *walletService) SnapshotBalances(ctx context.Context) error {
+	if err := authorize(ctx, s.repos, api.PermissionWalletWrite); err != nil {
+		return err
+	}
+	today := time.Now().UTC().Truncate(snapshotDay)
+	for page := 1; ; page++ {
+		wallets, err := s.repos.Wallet.FetchWallets(ctx, page, balanceSnapshotBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range wallets {
+			if err := s.snapshotWalletBalances(ctx, &wallets[i], today); err != nil {
+				api.GetLogger(ctx).Error("Unable to snapshot wallet balances", logger.Field("walletId", wallets[i].ID), logger.Field("error", err))
+			}
+		}
+		if len(wallets) < balanceSnapshotBatchSize {
+			return nil
+		}
+	}
+}
+
+// snapshotWalletBalances takes the missing daily snapshots of a wallet up to a day, each building on the one before it.
+// A wallet without snapshots is snapshotted from the day it was created on
+func (s *walletService) snapshotWalletBalances(ctx context.Context, wallet *model.Wallet, until time.Time) error {
+	latest, err := s.repos.Wallet.FetchLatestBalanceSnapshot(ctx, wallet.ID, until)
+	if err != nil {
+		return err
+	}
+	from := wallet.CreatedAt.UTC().Truncate(snapshotDay)
+	var liability int64
+	if latest != nil {
+		from = latest.BalanceAt.UTC()
+		liability = int64(latest.Liability)
+	}
+	for day := from.Add(snapshotDay); !day.After(until); day = day.Add(snapshotDay) {
+		change, err := s.repos.Transaction.SumWalletBalanceChange(ctx, wallet.ID, from, day)
+		if err != nil {
+			return err
+		}
+		liability += change
+		snapshot := &model.WalletBalanceSnapshot{WalletID: wallet.ID, BalanceAt: day, Liability: uint64(liability), CreatedAt: time.Now()}
+		if err := s.repos.Wallet.CreateBalanceSnapshot(ctx, snapshot); err != nil {
+			return err
+		}
+		from = day
+	}
+	return nil
+}
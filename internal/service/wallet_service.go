@@ -8,6 +8,7 @@ import (
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/abdelrahman146/digital-wallet/pkg/types"
+	"github.com/abdelrahman146/digital-wallet/pkg/utils"
 	"github.com/abdelrahman146/digital-wallet/pkg/validator"
 	"time"
 )
@@ -27,6 +28,11 @@ type WalletService interface {
 	GetWallets(ctx context.Context, page int, limit int) (*api.List[model.Wallet], error)
 	// DeleteWallet deletes a wallet by ID
 	DeleteWallet(ctx context.Context, walletId string) error
+	// GetLiabilityAt fetches the sum of the balances of the accounts of a wallet at a point in time,
+	// building on the latest daily snapshot taken before it
+	GetLiabilityAt(ctx context.Context, walletId string, at time.Time) (*WalletLiability, error)
+	// SnapshotBalances takes the missing daily liability snapshots of every wallet
+	SnapshotBalances(ctx context.Context) error
 }
 
 type walletService struct {
@@ -38,8 +44,7 @@ func NewWalletService(repos *repository.Repos) WalletService {
 }
 
 func (s *walletService) CreateWallet(ctx context.Context, req *CreateWalletRequest) (*model.Wallet, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionWalletWrite); err != nil {
 		return nil, err
 	}
 	if err := validator.GetValidator().ValidateStruct(req); err != nil {
@@ -48,14 +53,20 @@ func (s *walletService) CreateWallet(ctx context.Context, req *CreateWalletReque
 		return nil, errs.NewValidationError("Invalid request", "", fields)
 	}
 	wallet := &model.Wallet{
-		ID:           req.ID,
-		Name:         req.Name,
-		Description:  req.Description,
-		Currency:     req.Currency,
-		LimitPerUser: req.LimitPerUser,
-		IsMonetary:   req.IsMonetary,
-		LimitGlobal:  req.LimitGlobal,
-	}
+		ID:                req.ID,
+		Name:              req.Name,
+		Description:       req.Description,
+		Currency:          req.Currency,
+		LimitPerUser:      req.LimitPerUser,
+		IsMonetary:        req.IsMonetary,
+		LimitGlobal:       req.LimitGlobal,
+		ApprovalThreshold: req.ApprovalThreshold,
+	}
+	wallet.ConsumptionStrategy = model.ConsumptionStrategyFIFO
+	if req.ConsumptionStrategy != "" {
+		wallet.ConsumptionStrategy = req.ConsumptionStrategy
+	}
+	wallet.ConsumptionPriority = req.ConsumptionPriority
 	wallet.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
 	wallet.SetRemarks("Wallet created")
 	if req.PointsExpireAfter != nil {
@@ -69,8 +80,7 @@ func (s *walletService) CreateWallet(ctx context.Context, req *CreateWalletReque
 }
 
 func (s *walletService) UpdateWallet(ctx context.Context, walletId string, req *UpdateWalletRequest) (*model.Wallet, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionWalletWrite); err != nil {
 		return nil, err
 	}
 	if err := validator.GetValidator().ValidateStruct(req); err != nil {
@@ -82,6 +92,13 @@ func (s *walletService) UpdateWallet(ctx context.Context, walletId string, req *
 	if err != nil {
 		return nil, err
 	}
+	// Lifting or lowering the approval threshold decides which transactions need a second actor, so it needs one too
+	if !utils.EqualPtr(wallet.ApprovalThreshold, req.ApprovalThreshold) {
+		payload := walletApprovalPayload{WalletID: walletId, Request: req}
+		if err := requireApproval(ctx, s.repos, model.ApprovalOperationUpdateWallet, api.PermissionWalletWrite, payload); err != nil {
+			return nil, err
+		}
+	}
 	wallet.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
 	wallet.SetRemarks("Wallet updated")
 	wallet.SetOldRecord(*wallet)
@@ -90,6 +107,11 @@ func (s *walletService) UpdateWallet(ctx context.Context, walletId string, req *
 	wallet.Currency = req.Currency
 	wallet.LimitPerUser = req.LimitPerUser
 	wallet.LimitGlobal = req.LimitGlobal
+	wallet.ApprovalThreshold = req.ApprovalThreshold
+	if req.ConsumptionStrategy != "" {
+		wallet.ConsumptionStrategy = req.ConsumptionStrategy
+		wallet.ConsumptionPriority = req.ConsumptionPriority
+	}
 	if req.IsMonetary != nil {
 		wallet.IsMonetary = *req.IsMonetary
 	}
@@ -105,6 +127,9 @@ func (s *walletService) UpdateWallet(ctx context.Context, walletId string, req *
 }
 
 func (s *walletService) GetWalletByID(ctx context.Context, walletId string) (*model.Wallet, error) {
+	if err := authorize(ctx, s.repos, api.PermissionWalletRead); err != nil {
+		return nil, err
+	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
 	if wallet == nil {
 		return nil, errs.NewNotFoundError("wallet not found", "WALLET_NOT_FOUND", err)
@@ -113,6 +138,9 @@ func (s *walletService) GetWalletByID(ctx context.Context, walletId string) (*mo
 }
 
 func (s *walletService) GetWallets(ctx context.Context, page int, limit int) (*api.List[model.Wallet], error) {
+	if err := authorize(ctx, s.repos, api.PermissionWalletRead); err != nil {
+		return nil, err
+	}
 	wallets, err := s.repos.Wallet.FetchWallets(ctx, page, limit)
 	if err != nil {
 		return nil, err
@@ -125,8 +153,7 @@ func (s *walletService) GetWallets(ctx context.Context, page int, limit int) (*a
 }
 
 func (s *walletService) DeleteWallet(ctx context.Context, walletId string) error {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionWalletWrite); err != nil {
 		return err
 	}
 	wallet, err := s.repos.Wallet.FetchWalletByID(ctx, walletId)
@@ -134,6 +161,10 @@ func (s *walletService) DeleteWallet(ctx context.Context, walletId string) error
 		api.GetLogger(ctx).Error("Wallet not found", logger.Field("walletId", walletId), logger.Field("error", err))
 		return errs.NewNotFoundError("wallet not found", "WALLET_NOT_FOUND", err)
 	}
+	payload := walletApprovalPayload{WalletID: walletId}
+	if err := requireApproval(ctx, s.repos, model.ApprovalOperationDeleteWallet, api.PermissionWalletWrite, payload); err != nil {
+		return err
+	}
 	wallet.SetActor(api.GetActor(ctx), api.GetActorID(ctx))
 	wallet.SetRemarks("Wallet deleted")
 	wallet.SetOldRecord(*wallet)
@@ -141,16 +172,14 @@ func (s *walletService) DeleteWallet(ctx context.Context, walletId string) error
 }
 
 func (s *walletService) GetAccountsSum(ctx context.Context, walletId string) (uint64, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionWalletRead); err != nil {
 		return 0, err
 	}
 	return s.repos.Account.SumWalletAccounts(ctx, walletId)
 }
 
 func (s *walletService) GetTransactionsSum(ctx context.Context, walletId string) (uint64, error) {
-	if err := api.IsAdmin(ctx); err != nil {
-		api.GetLogger(ctx).Error("User not authorized")
+	if err := authorize(ctx, s.repos, api.PermissionWalletRead); err != nil {
 		return 0, err
 	}
 	return s.repos.Transaction.SumWalletTransactions(ctx, walletId)
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/internal/model"
+	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestWalletService_CreateWallet_ConsumptionStrategy(t *testing.T) {
+	description := "Loyalty points"
+	testcases := []TestCase[WalletService]{
+		{
+			name: "Defaults to FIFO",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().CreateWallet(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				wallet, err := service.CreateWallet(ctx, &CreateWalletRequest{ID: test_walletId, Name: "Points", Description: &description, Currency: "PTS"})
+				if err == nil && wallet.ConsumptionStrategy != model.ConsumptionStrategyFIFO {
+					t.Errorf("expected the FIFO strategy, got %s", wallet.ConsumptionStrategy)
+				}
+				return wallet, err
+			},
+			expectResult: true,
+		},
+		{
+			name: "Ranks programs and reasons by priority",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().CreateWallet(ctx, gomock.Any()).Return(nil)
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return service.CreateWallet(ctx, &CreateWalletRequest{ID: test_walletId, Name: "Points", Description: &description, Currency: "PTS", ConsumptionStrategy: model.ConsumptionStrategyPriority, ConsumptionPriority: []string{"program:12", "REWARD"}})
+			},
+			expectResult: true,
+		},
+		{
+			name:       "Requires a priority with the PRIORITY strategy",
+			ctx:        createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return service.CreateWallet(ctx, &CreateWalletRequest{ID: test_walletId, Name: "Points", Description: &description, Currency: "PTS", ConsumptionStrategy: model.ConsumptionStrategyPriority})
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name:       "Rejects a priority that is neither a credit reason nor a program",
+			ctx:        createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return service.CreateWallet(ctx, &CreateWalletRequest{ID: test_walletId, Name: "Points", Description: &description, Currency: "PTS", ConsumptionStrategy: model.ConsumptionStrategyPriority, ConsumptionPriority: []string{"PURCHASE"}})
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+		{
+			name:       "Rejects an unknown strategy",
+			ctx:        createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return service.CreateWallet(ctx, &CreateWalletRequest{ID: test_walletId, Name: "Points", Description: &description, Currency: "PTS", ConsumptionStrategy: "RANDOM"})
+			},
+			expectedError: "VALIDATION_ERROR",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) WalletService {
+		return NewWalletService(mocks.repos)
+	}
+	RunTestCases[WalletService](t, serviceFactory, testcases)
+}
+
+func TestWalletService_UpdateWallet_ApprovalThreshold(t *testing.T) {
+	description := "Loyalty points"
+	threshold := uint64(1000)
+	wallet := func() *model.Wallet {
+		return &model.Wallet{ID: test_walletId, Name: "Points", Currency: "PTS", IsActive: true, ApprovalThreshold: &threshold}
+	}
+	testcases := []TestCase[WalletService]{
+		{
+			name: "Clearing the approval threshold is held for approval",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet(), nil)
+				mocks.approvalRepo.EXPECT().CreateApproval(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, approval *model.Approval) error {
+					if approval.Operation != model.ApprovalOperationUpdateWallet || approval.Payload["walletId"] != test_walletId {
+						t.Errorf("expected an approval to update wallet %s, got %+v", test_walletId, approval)
+					}
+					approval.ID = "approval-123"
+					return nil
+				})
+			},
+			expectedError: "APPROVAL_REQUIRED",
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return service.UpdateWallet(ctx, test_walletId, &UpdateWalletRequest{Name: "Points", Description: &description, Currency: "PTS"})
+			},
+		},
+		{
+			name: "Keeping the approval threshold updates the wallet at once",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet(), nil)
+				mocks.walletRepo.EXPECT().UpdateWallet(ctx, gomock.Any()).Return(nil)
+			},
+			expectResult: true,
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				same := threshold
+				return service.UpdateWallet(ctx, test_walletId, &UpdateWalletRequest{Name: "Loyalty points", Description: &description, Currency: "PTS", ApprovalThreshold: &same})
+			},
+		},
+	}
+	serviceFactory := func(mocks *Mocks) WalletService {
+		return NewWalletService(mocks.repos)
+	}
+	RunTestCases[WalletService](t, serviceFactory, testcases)
+}
+
+func TestWalletService_GetLiabilityAt(t *testing.T) {
+	at := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	snapshotAt := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	wallet := &model.Wallet{ID: test_walletId}
+	testcases := []TestCase[WalletService]{
+		{
+			name: "Builds on the latest snapshot",
+			ctx:  createBackofficeContext(api.PermissionWalletRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+				mocks.walletRepo.EXPECT().FetchLatestBalanceSnapshot(ctx, test_walletId, at).Return(&model.WalletBalanceSnapshot{WalletID: test_walletId, BalanceAt: snapshotAt, Liability: 1000}, nil)
+				mocks.transactionRepo.EXPECT().SumWalletBalanceChange(ctx, test_walletId, snapshotAt, at.Add(time.Microsecond)).Return(int64(-200), nil)
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				liability, err := service.GetLiabilityAt(ctx, test_walletId, at)
+				if err != nil {
+					return nil, err
+				}
+				if liability.Liability != 800 || liability.SnapshotAt == nil || !liability.SnapshotAt.Equal(snapshotAt) {
+					t.Errorf("unexpected liability %+v", liability)
+				}
+				return liability, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Sums every transaction without a snapshot",
+			ctx:  createBackofficeContext(api.PermissionWalletRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(wallet, nil)
+				mocks.walletRepo.EXPECT().FetchLatestBalanceSnapshot(ctx, test_walletId, at).Return(nil, nil)
+				mocks.transactionRepo.EXPECT().SumWalletBalanceChange(ctx, test_walletId, time.Time{}, at.Add(time.Microsecond)).Return(int64(300), nil)
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				liability, err := service.GetLiabilityAt(ctx, test_walletId, at)
+				if err != nil {
+					return nil, err
+				}
+				if liability.Liability != 300 || liability.SnapshotAt != nil {
+					t.Errorf("unexpected liability %+v", liability)
+				}
+				return liability, nil
+			},
+			expectResult: true,
+		},
+		{
+			name: "Wallet not found",
+			ctx:  createBackofficeContext(api.PermissionWalletRead),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWalletByID(ctx, test_walletId).Return(nil, nil)
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return service.GetLiabilityAt(ctx, test_walletId, at)
+			},
+			expectedError: "WALLET_NOT_FOUND",
+			expectResult:  false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) WalletService {
+		return NewWalletService(mocks.repos)
+	}
+	RunTestCases[WalletService](t, serviceFactory, testcases)
+}
+
+func TestWalletService_SnapshotBalances(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	latest := &model.WalletBalanceSnapshot{WalletID: test_walletId, BalanceAt: today.Add(-48 * time.Hour), Liability: 500}
+	testcases := []TestCase[WalletService]{
+		{
+			name: "Takes the missing daily snapshots from the latest one",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWallets(ctx, 1, balanceSnapshotBatchSize).Return([]model.Wallet{{ID: test_walletId}}, nil)
+				mocks.walletRepo.EXPECT().FetchLatestBalanceSnapshot(ctx, test_walletId, today).Return(latest, nil)
+				mocks.transactionRepo.EXPECT().SumWalletBalanceChange(ctx, test_walletId, today.Add(-48*time.Hour), today.Add(-24*time.Hour)).Return(int64(100), nil)
+				mocks.transactionRepo.EXPECT().SumWalletBalanceChange(ctx, test_walletId, today.Add(-24*time.Hour), today).Return(int64(-50), nil)
+				gomock.InOrder(
+					mocks.walletRepo.EXPECT().CreateBalanceSnapshot(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, snapshot *model.WalletBalanceSnapshot) error {
+						if !snapshot.BalanceAt.Equal(today.Add(-24*time.Hour)) || snapshot.Liability != 600 {
+							t.Errorf("unexpected snapshot %+v", snapshot)
+						}
+						return nil
+					}),
+					mocks.walletRepo.EXPECT().CreateBalanceSnapshot(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, snapshot *model.WalletBalanceSnapshot) error {
+						if !snapshot.BalanceAt.Equal(today) || snapshot.Liability != 550 {
+							t.Errorf("unexpected snapshot %+v", snapshot)
+						}
+						return nil
+					}),
+				)
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return nil, service.SnapshotBalances(ctx)
+			},
+			expectResult: false,
+		},
+		{
+			name: "Starts a wallet without snapshots from the day it was created on",
+			ctx:  createBackofficeContext(api.PermissionWalletWrite),
+			setupMocks: func(mocks *Mocks, ctx context.Context) {
+				mocks.walletRepo.EXPECT().FetchWallets(ctx, 1, balanceSnapshotBatchSize).Return([]model.Wallet{{ID: test_walletId, CreatedAt: today.Add(-12 * time.Hour)}}, nil)
+				mocks.walletRepo.EXPECT().FetchLatestBalanceSnapshot(ctx, test_walletId, today).Return(nil, nil)
+				mocks.transactionRepo.EXPECT().SumWalletBalanceChange(ctx, test_walletId, today.Add(-24*time.Hour), today).Return(int64(70), nil)
+				mocks.walletRepo.EXPECT().CreateBalanceSnapshot(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, snapshot *model.WalletBalanceSnapshot) error {
+					if !snapshot.BalanceAt.Equal(today) || snapshot.Liability != 70 {
+						t.Errorf("unexpected snapshot %+v", snapshot)
+					}
+					return nil
+				})
+			},
+			testFunc: func(service WalletService, ctx context.Context) (interface{}, error) {
+				return nil, service.SnapshotBalances(ctx)
+			},
+			expectResult: false,
+		},
+	}
+	serviceFactory := func(mocks *Mocks) WalletService {
+		return NewWalletService(mocks.repos)
+	}
+	RunTestCases[WalletService](t, serviceFactory, testcases)
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	backofficev1 "github.com/abdelrahman146/digital-wallet/api/backoffice/v1"
+	systemv1 "github.com/abdelrahman146/digital-wallet/api/system/v1"
 	_ "github.com/abdelrahman146/digital-wallet/docs"
+	"github.com/abdelrahman146/digital-wallet/internal/job"
 	"github.com/abdelrahman146/digital-wallet/internal/repository"
 	"github.com/abdelrahman146/digital-wallet/internal/resource"
 	"github.com/abdelrahman146/digital-wallet/internal/service"
 	"github.com/abdelrahman146/digital-wallet/pkg/api"
+	"github.com/abdelrahman146/digital-wallet/pkg/config"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/gofiber/fiber/v2"
@@ -82,6 +86,11 @@ func main() {
 		ExchangeRate: repository.NewExchangeRateRepo(resources),
 		Trigger:      repository.NewTriggerRepo(resources),
 		Program:      repository.NewProgramRepo(resources),
+		Role:         repository.NewRoleRepo(resources),
+		Approval:     repository.NewApprovalRepo(resources),
+		ApiKey:       repository.NewApiKeyRepo(resources),
+		Tenant:       repository.NewTenantRepo(resources),
+		Report:       repository.NewReportRepo(resources),
 	}
 
 	// Define services
@@ -95,10 +104,21 @@ func main() {
 		ExchangeRate: service.NewExchangeRateService(repos),
 		Trigger:      service.NewTriggerService(repos),
 		Program:      service.NewProgramService(repos),
+		Role:         service.NewRoleService(repos),
+		ApiKey:       service.NewApiKeyService(repos),
+		Report:       service.NewReportService(repos),
 	}
+	services.Approval = service.NewApprovalService(repos, services)
 
 	// Define routes
 	backofficev1.New(app, services)
+	systemv1.New(app, services)
+
+	// Schedule background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go job.Schedule(jobsCtx, "tier-evaluator", config.GetConfig().TierEvaluationInterval, job.ForEachTenant(repos.Tenant.FetchTenantIDs, services.Tier.EvaluateTiers))
+	go job.Schedule(jobsCtx, "balance-snapshot", config.GetConfig().BalanceSnapshotInterval, job.ForEachTenant(repos.Tenant.FetchTenantIDs, services.Wallet.SnapshotBalances))
+	go job.Schedule(jobsCtx, "liability-summaries", config.GetConfig().LiabilityRefreshInterval, job.ForEachTenant(repos.Tenant.FetchTenantIDs, services.Report.RefreshLiabilitySummaries))
 
 	// Undefined route handler
 	app.Use(func(c *fiber.Ctx) error {
@@ -120,11 +140,12 @@ func main() {
 	// Wait for interrupt signal
 	<-quit
 
-	// Gracefully shutdown the server and close the database connection
+	// Gracefully shutdown the server, stop the jobs and close the database connection
 	logger.GetLogger().Info("Shutting down server...")
 	if err := app.Shutdown(); err != nil {
 		logger.GetLogger().Error("Error shutting down server", logger.Field("error", err))
 	}
+	stopJobs()
 	resource.CloseDB(db)
 	logger.GetLogger().Info("Database connection closed")
 	broker.Close()
@@ -1,13 +1,36 @@
 package api
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"context"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// ApiKeyHeader is the header internal services send their API key in
+	ApiKeyHeader = "X-API-Key"
+	// TenantHeader is the header carrying the tenant until it is read from the jwt token
+	TenantHeader = "X-Tenant-ID"
+)
+
+// ApiKeyScope is what an API key grants to the SYSTEM actor it authenticates
+type ApiKeyScope struct {
+	KeyID       string
+	TenantID    string
+	WalletIDs   []string
+	Permissions []string
+	// RateLimit is the number of requests the key can make per minute
+	RateLimit int
+}
 
 func AdminAuthenticationMiddleware() fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		// get jwt token
 		// validate jwt token
 		// get user id from jwt token
+		// get tenant id from jwt token
 		ctx.Locals("actorId", "admin_id")
+		ctx.Locals("tenantId", ctx.Get(TenantHeader))
 		return ctx.Next()
 	}
 }
@@ -17,7 +40,36 @@ func UserAuthenticationMiddleware() fiber.Handler {
 		// get jwt token
 		// validate jwt token
 		// get user id from jwt token
+		// get tenant id from jwt token
 		ctx.Locals("actorId", "user_id")
+		ctx.Locals("tenantId", ctx.Get(TenantHeader))
+		return ctx.Next()
+	}
+}
+
+// ApiKeyAuthenticationMiddleware authenticates internal services by their API key,
+// and builds the app context of the SYSTEM actor restricted to the scope of the key
+func ApiKeyAuthenticationMiddleware(authenticate func(ctx context.Context, key string) (*ApiKeyScope, error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(ApiKeyHeader)
+		if key == "" {
+			return errs.NewUnauthorizedError("API key is missing", "API_KEY_MISSING", nil)
+		}
+		// The key is looked up across tenants as an anonymous SYSTEM actor until it is authenticated
+		if err := setAppContext(ctx, AppActorSystem, ""); err != nil {
+			return err
+		}
+		scope, err := authenticate(WithAllTenants(ctx.Context()), key)
+		if err != nil {
+			return err
+		}
+		if err := setAppContext(ctx, AppActorSystem, scope.KeyID); err != nil {
+			return err
+		}
+		ctx.Locals("tenantId", scope.TenantID)
+		ctx.Locals("permissions", append([]string{}, scope.Permissions...))
+		ctx.Locals("walletIds", append([]string{}, scope.WalletIDs...))
+		ctx.Locals("rateLimit", scope.RateLimit)
 		return ctx.Next()
 	}
 }
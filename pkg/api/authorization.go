@@ -3,23 +3,106 @@ package api
 import (
 	"context"
 	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"slices"
 )
 
-func IsAuthorizedUser(ctx context.Context, recordOwner string) error {
-	actor := GetActor(ctx)
-	actorId := GetActorID(ctx)
-	if actor != "" && actorId != "" && (actor == AppActorAdmin || actor == AppActorSystem || actorId == recordOwner) {
+// Backoffice permissions, granted to backoffice actors through their roles
+const (
+	PermissionAuditRead           = "audit:read"
+	PermissionWalletRead          = "wallet:read"
+	PermissionWalletWrite         = "wallet:write"
+	PermissionAccountRead         = "account:read"
+	PermissionAccountWrite        = "account:write"
+	PermissionAccountFreeze       = "account:freeze"
+	PermissionTransactionRead     = "transaction:read"
+	PermissionTransactionCredit   = "transaction:credit"
+	PermissionTransactionDebit    = "transaction:debit"
+	PermissionTransactionExchange = "transaction:exchange"
+	PermissionExchangeRateRead    = "exchange_rate:read"
+	PermissionExchangeRateWrite   = "exchange_rate:write"
+	PermissionTierRead            = "tier:read"
+	PermissionTierWrite           = "tier:write"
+	PermissionUserRead            = "user:read"
+	PermissionUserWrite           = "user:write"
+	PermissionTriggerRead         = "trigger:read"
+	PermissionTriggerWrite        = "trigger:write"
+	PermissionProgramRead         = "program:read"
+	PermissionProgramWrite        = "program:write"
+	PermissionProgramPublish      = "program:publish"
+	PermissionProgramInvoke       = "program:invoke"
+	PermissionRoleRead            = "role:read"
+	PermissionRoleWrite           = "role:write"
+	PermissionApprovalRead        = "approval:read"
+	PermissionApprovalDecide      = "approval:decide"
+	PermissionApiKeyRead          = "api_key:read"
+	PermissionApiKeyWrite         = "api_key:write"
+	PermissionReportRead          = "report:read"
+)
+
+// Permissions lists every permission a role can be granted
+var Permissions = []string{
+	PermissionAuditRead,
+	PermissionWalletRead,
+	PermissionWalletWrite,
+	PermissionAccountRead,
+	PermissionAccountWrite,
+	PermissionAccountFreeze,
+	PermissionTransactionRead,
+	PermissionTransactionCredit,
+	PermissionTransactionDebit,
+	PermissionTransactionExchange,
+	PermissionExchangeRateRead,
+	PermissionExchangeRateWrite,
+	PermissionTierRead,
+	PermissionTierWrite,
+	PermissionUserRead,
+	PermissionUserWrite,
+	PermissionTriggerRead,
+	PermissionTriggerWrite,
+	PermissionProgramRead,
+	PermissionProgramWrite,
+	PermissionProgramPublish,
+	PermissionProgramInvoke,
+	PermissionRoleRead,
+	PermissionRoleWrite,
+	PermissionApprovalRead,
+	PermissionApprovalDecide,
+	PermissionApiKeyRead,
+	PermissionApiKeyWrite,
+	PermissionReportRead,
+}
+
+// IsAuthorizedUser allows the owner of a record, or any actor holding the permission
+func IsAuthorizedUser(ctx context.Context, recordOwner, permission string) error {
+	if GetActor(ctx) == AppActorUser && GetActorID(ctx) != "" && GetActorID(ctx) == recordOwner {
 		return nil
 	}
-	return errs.NewUnauthorizedError("Unauthorized", "", nil)
+	return HasPermission(ctx, permission)
 }
 
-func IsAdmin(ctx context.Context) error {
-	actor := GetActor(ctx)
-	if actor != "" && (actor == AppActorAdmin || actor == AppActorSystem) {
+// HasPermission allows the system and the backoffice actors holding the permission.
+// A system actor authenticated with an API key only holds the permissions the key is scoped to.
+func HasPermission(ctx context.Context, permission string) error {
+	switch GetActor(ctx) {
+	case AppActorSystem:
+		if permissions, scoped := ctx.Value("permissions").([]string); !scoped || slices.Contains(permissions, permission) {
+			return nil
+		}
+	case AppActorAdmin:
+		if slices.Contains(GetPermissions(ctx), permission) {
+			return nil
+		}
+	}
+	return errs.NewForbiddenError("Permission denied", "PERMISSION_DENIED", nil)
+}
+
+// HasWalletAccess allows actors restricted to a set of wallets to use only those wallets
+func HasWalletAccess(ctx context.Context, walletId string) error {
+	walletIds, scoped := GetWalletScope(ctx)
+	if !scoped || slices.Contains(walletIds, walletId) {
 		return nil
 	}
-	return errs.NewUnauthorizedError("Unauthorized", "", nil)
+	return errs.NewForbiddenError("Wallet access denied", "WALLET_ACCESS_DENIED", nil)
 }
 
 func IsSystem(ctx context.Context) error {
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPermissions_MatchMigrations checks that the permissions granted to the admin role by the migrations are the
+// ones a role can be granted, so adding a permission on either side without the other fails
+func TestPermissions_MatchMigrations(t *testing.T) {
+	files, err := filepath.Glob("../../db/migrations/*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("failed to list the migrations: %v", err)
+	}
+	permission := regexp.MustCompile(`'([a-z_]+:[a-z_]+)'`)
+	var granted []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		for _, statement := range strings.Split(string(content), ";") {
+			if !strings.Contains(statement, "INSERT INTO role_permissions") {
+				continue
+			}
+			for _, match := range permission.FindAllStringSubmatch(statement, -1) {
+				granted = append(granted, match[1])
+			}
+		}
+	}
+	slices.Sort(granted)
+	granted = slices.Compact(granted)
+	expected := slices.Clone(Permissions)
+	slices.Sort(expected)
+	if !slices.Equal(granted, expected) {
+		t.Errorf("expected the migrations to grant %v, got %v", expected, granted)
+	}
+}
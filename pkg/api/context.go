@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
 	"github.com/abdelrahman146/digital-wallet/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap/zapcore"
@@ -9,18 +10,32 @@ import (
 
 func CreateAppContextMiddleware(actor string) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		requestId := ctx.Locals("requestid").(string)
-		actorId := ctx.Locals("actorId").(string)
-		l, err := logger.NewZapLogger(zapcore.DebugLevel, logger.Field("requestId", requestId), logger.Field("actor", actor), logger.Field("actorId", actorId))
-		if err != nil {
+		if tenantId, _ := ctx.Locals("tenantId").(string); tenantId == "" {
+			return errs.NewUnauthorizedError("Tenant is missing", "TENANT_MISSING", nil)
+		}
+		actorId, ok := ctx.Locals("actorId").(string)
+		if !ok || actorId == "" {
+			return errs.NewUnauthorizedError("Actor is missing", "ACTOR_MISSING", nil)
+		}
+		if err := setAppContext(ctx, actor, actorId); err != nil {
 			return err
 		}
-		ctx.Locals("logger", l)
-		ctx.Locals("actor", actor)
 		return ctx.Next()
 	}
 }
 
+func setAppContext(ctx *fiber.Ctx, actor, actorId string) error {
+	requestId, _ := ctx.Locals("requestid").(string)
+	l, err := logger.NewZapLogger(zapcore.DebugLevel, logger.Field("requestId", requestId), logger.Field("actor", actor), logger.Field("actorId", actorId))
+	if err != nil {
+		return err
+	}
+	ctx.Locals("logger", l)
+	ctx.Locals("actor", actor)
+	ctx.Locals("actorId", actorId)
+	return nil
+}
+
 func CreateAppContext(ctx context.Context, actor, actorId, requestId string) context.Context {
 	ctx = context.WithValue(ctx, "requestId", requestId)
 	ctx = context.WithValue(ctx, "actorId", actorId)
@@ -30,6 +45,43 @@ func CreateAppContext(ctx context.Context, actor, actorId, requestId string) con
 	return ctx
 }
 
+// AuthorizationMiddleware loads the permissions of the authenticated actor into the context
+func AuthorizationMiddleware(loadPermissions func(ctx context.Context, actorId string) ([]string, error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		actorId, ok := ctx.Locals("actorId").(string)
+		if !ok || actorId == "" {
+			return errs.NewUnauthorizedError("Actor is missing", "ACTOR_MISSING", nil)
+		}
+		permissions, err := loadPermissions(ctx.Context(), actorId)
+		if err != nil {
+			return err
+		}
+		ctx.Locals("permissions", permissions)
+		return ctx.Next()
+	}
+}
+
+// WithPermissions returns a copy of the context granting the given permissions to its actor
+func WithPermissions(ctx context.Context, permissions ...string) context.Context {
+	return context.WithValue(ctx, "permissions", permissions)
+}
+
+// WithTenant returns a copy of the context whose reads and writes are confined to the given tenant
+func WithTenant(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, "tenantId", tenantId)
+}
+
+// WithAllTenants returns a copy of the context allowed to read across tenants,
+// for the few lookups that find out which tenant a request belongs to
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, "allTenants", true)
+}
+
+// WithWalletScope returns a copy of the context restricting its actor to the given wallets
+func WithWalletScope(ctx context.Context, walletIds ...string) context.Context {
+	return context.WithValue(ctx, "walletIds", walletIds)
+}
+
 func GetLogger(ctx context.Context) logger.Logger {
 	return ctx.Value("logger").(logger.Logger)
 }
@@ -45,3 +97,26 @@ func GetActorID(ctx context.Context) string {
 func GetActor(ctx context.Context) string {
 	return ctx.Value("actor").(string)
 }
+
+func GetPermissions(ctx context.Context) []string {
+	permissions, _ := ctx.Value("permissions").([]string)
+	return permissions
+}
+
+// GetWalletScope returns the wallets the actor is restricted to, and whether it is restricted at all
+func GetWalletScope(ctx context.Context) ([]string, bool) {
+	walletIds, ok := ctx.Value("walletIds").([]string)
+	return walletIds, ok
+}
+
+// GetTenantID returns the tenant the context is confined to, if any
+func GetTenantID(ctx context.Context) (string, bool) {
+	tenantId, ok := ctx.Value("tenantId").(string)
+	return tenantId, ok && tenantId != ""
+}
+
+// IsAllTenants reports whether the context is allowed to read across tenants
+func IsAllTenants(ctx context.Context) bool {
+	allTenants, _ := ctx.Value("allTenants").(bool)
+	return allTenants
+}
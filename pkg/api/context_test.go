@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddlewares_RejectMissingActor(t *testing.T) {
+	middlewares := map[string]fiber.Handler{
+		"AppContext": CreateAppContextMiddleware(AppActorAdmin),
+		"Authorization": AuthorizationMiddleware(func(context.Context, string) ([]string, error) {
+			return nil, nil
+		}),
+		"RateLimit": ActorRateLimitMiddleware(time.Minute),
+	}
+	for name, middleware := range middlewares {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{
+				ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+					status, resp := NewErrorResponse(err)
+					return ctx.Status(status).JSON(resp)
+				},
+			})
+			app.Use(func(ctx *fiber.Ctx) error {
+				ctx.Locals("tenantId", "tenant-a")
+				ctx.Locals("rateLimit", 10)
+				return ctx.Next()
+			})
+			app.Use(middleware)
+			app.Get("/", func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusUnauthorized {
+				t.Errorf("expected a request without an actor to be unauthorized, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// Cursor points past the last item of a page listed newest first, by its creation time and ID
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+// ParseCursor parses a cursor returned by Encode
+func ParseCursor(value string) (*Cursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, false
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, false
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, false
+	}
+	return &Cursor{CreatedAt: parsed, ID: id}, true
+}
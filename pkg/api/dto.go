@@ -7,10 +7,13 @@ const (
 )
 
 type List[T any] struct {
-	Items []T   `json:"items"`
-	Page  int   `json:"page"`
-	Limit int   `json:"limit"`
+	Items []T `json:"items"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	// Total is left at 0 on the pages fetched by cursor, it is only counted with the first page
 	Total int64 `json:"total"`
+	// NextCursor fetches the page following this one, for the lists paged by cursor
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type ErrorResponseBody struct {
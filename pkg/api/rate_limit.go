@@ -0,0 +1,58 @@
+package api
+
+import (
+	"github.com/abdelrahman146/digital-wallet/pkg/errs"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+// ActorRateLimitMiddleware limits each authenticated actor to the number of requests per window set in the "rateLimit" local.
+// Counters are kept in memory, so the limit applies per instance. Counters of windows that are over are dropped once a
+// window, so only the actors seen in the last window are kept.
+func ActorRateLimitMiddleware(window time.Duration) fiber.Handler {
+	var mu sync.Mutex
+	windows := map[string]*rateLimitWindow{}
+	lastSweep := time.Now()
+	return func(ctx *fiber.Ctx) error {
+		limit, ok := ctx.Locals("rateLimit").(int)
+		if !ok || limit <= 0 {
+			return ctx.Next()
+		}
+		actorId, ok := ctx.Locals("actorId").(string)
+		if !ok || actorId == "" {
+			return errs.NewUnauthorizedError("Actor is missing", "ACTOR_MISSING", nil)
+		}
+		now := time.Now()
+		mu.Lock()
+		if now.Sub(lastSweep) >= window {
+			for id, expired := range windows {
+				if now.Sub(expired.start) >= window {
+					delete(windows, id)
+				}
+			}
+			lastSweep = now
+		}
+		w, exists := windows[actorId]
+		if !exists || now.Sub(w.start) >= window {
+			w = &rateLimitWindow{start: now}
+			windows[actorId] = w
+		}
+		w.count++
+		count, reset := w.count, w.start.Add(window)
+		mu.Unlock()
+		ctx.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		ctx.Set("X-RateLimit-Remaining", strconv.Itoa(max(limit-count, 0)))
+		if count > limit {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(time.Until(reset).Seconds())+1))
+			return errs.NewTooManyRequestsError("Rate limit exceeded", "RATE_LIMIT_EXCEEDED", nil)
+		}
+		return ctx.Next()
+	}
+}
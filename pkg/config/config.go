@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +17,21 @@ type Config struct {
 	DbSSLMode    string
 	DebugLevel   string
 	KafkaBrokers string
+	// ExchangeMaxHops is the maximum number of legs an exchange can be routed through
+	ExchangeMaxHops int
+	// TierEvaluationInterval is how often users are evaluated against the tier qualification rules
+	TierEvaluationInterval time.Duration
+	// BalanceSnapshotInterval is how often the missing daily liability snapshots of the wallets are taken
+	BalanceSnapshotInterval time.Duration
+	// LiabilityRefreshInterval is how often the monthly liability summaries of the wallets are refreshed
+	LiabilityRefreshInterval time.Duration
+	// RulesTimezone is the IANA timezone program conditions read dates without an offset, "today" and calendar parts in
+	RulesTimezone string
+	// FrozenAccountsAcceptCredits allows crediting frozen accounts, which can never be debited
+	FrozenAccountsAcceptCredits bool
+	// ProgramBudgetAlertThresholds are the percentages of a program budget consumed at which an alert is published,
+	// for the budgets that don't set their own
+	ProgramBudgetAlertThresholds []int
 }
 
 var config *Config
@@ -27,14 +45,21 @@ func GetConfig() *Config {
 
 func loadConfig() *Config {
 	return &Config{
-		DbHost:       GetEnv("DB_HOST", "localhost"),
-		DbPort:       GetEnv("DB_PORT", "5432"),
-		DbUser:       GetEnv("DB_USER", "postgres"),
-		DbPassword:   GetEnv("DB_PASSWORD", "password"),
-		DbName:       GetEnv("DB_NAME", "digital_wallet"),
-		DbSSLMode:    GetEnv("DB_SSLMODE", "disable"),
-		DebugLevel:   GetEnv("DEBUG_LEVEL", "info"),
-		KafkaBrokers: GetEnv("KAFKA_BROKERS", "localhost:9092"),
+		DbHost:                       GetEnv("DB_HOST", "localhost"),
+		DbPort:                       GetEnv("DB_PORT", "5432"),
+		DbUser:                       GetEnv("DB_USER", "postgres"),
+		DbPassword:                   GetEnv("DB_PASSWORD", "password"),
+		DbName:                       GetEnv("DB_NAME", "digital_wallet"),
+		DbSSLMode:                    GetEnv("DB_SSLMODE", "disable"),
+		DebugLevel:                   GetEnv("DEBUG_LEVEL", "info"),
+		KafkaBrokers:                 GetEnv("KAFKA_BROKERS", "localhost:9092"),
+		ExchangeMaxHops:              GetEnvAsInt("EXCHANGE_MAX_HOPS", 3),
+		TierEvaluationInterval:       GetEnvAsDuration("TIER_EVALUATION_INTERVAL", time.Hour),
+		BalanceSnapshotInterval:      GetEnvAsDuration("BALANCE_SNAPSHOT_INTERVAL", time.Hour),
+		LiabilityRefreshInterval:     GetEnvAsDuration("LIABILITY_REFRESH_INTERVAL", time.Hour),
+		RulesTimezone:                GetEnv("RULES_TIMEZONE", "UTC"),
+		FrozenAccountsAcceptCredits:  GetEnvAsBool("FROZEN_ACCOUNTS_ACCEPT_CREDITS", true),
+		ProgramBudgetAlertThresholds: GetEnvAsIntList("PROGRAM_BUDGET_ALERT_THRESHOLDS", []int{50, 80, 100}),
 	}
 }
 
@@ -45,6 +70,50 @@ func GetEnv(key, fallback string) string {
 	return fallback
 }
 
+func GetEnvAsInt(key string, fallback int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func GetEnvAsBool(key string, fallback bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// GetEnvAsIntList reads a comma separated list of integers, such as "50,80,100"
+func GetEnvAsIntList(key string, fallback []int) []int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		parsed, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return fallback
+		}
+		list = append(list, parsed)
+	}
+	return list
+}
+
+func GetEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
 func (c *Config) GetDbConnectionString() string {
 	return fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
 		c.DbHost, c.DbPort, c.DbUser, c.DbName, c.DbPassword, c.DbSSLMode)
@@ -11,6 +11,16 @@ func NewValidationError(text string, code string, fields map[string]string) Cust
 	}
 }
 
+// NewAcceptedError reports a request that was accepted but not carried out yet, such as one waiting for approval
+func NewAcceptedError(text string, code string, fields map[string]string) CustomError {
+	return CustomError{
+		Message:  utils.Coalesce(text, "Request accepted for processing, but not completed yet."),
+		Code:     utils.Coalesce(code, "ACCEPTED"),
+		HttpCode: 202,
+		Fields:   fields,
+	}
+}
+
 func NewNotFoundError(text string, code string, err error) CustomError {
 	return CustomError{
 		Message:  utils.Coalesce(text, "Resource could not be found"),
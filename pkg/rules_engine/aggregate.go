@@ -0,0 +1,137 @@
+package rule_engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Aggregator resolves aggregate fields over the past events of a user. It is only called when a rule reaches an
+// aggregate field, so rules that don't use them cost nothing.
+type Aggregator interface {
+	// Count returns the number of times the trigger fired since the given time
+	Count(trigger string, since time.Time) (float64, error)
+	// Sum returns the sum of a numeric field of the trigger data since the given time
+	Sum(field, trigger string, since time.Time) (float64, error)
+}
+
+// aggregateField is a field computed over past events, written as count(trigger, window) or sum(field, trigger, window).
+type aggregateField struct {
+	function string
+	field    string
+	trigger  string
+	window   window
+}
+
+// String returns the aggregate as written in rules.
+func (a aggregateField) String() string {
+	if a.function == "count" {
+		return fmt.Sprintf("count(%s, %s)", a.trigger, a.window.value)
+	}
+	return fmt.Sprintf("sum(%s, %s, %s)", a.field, a.trigger, a.window.value)
+}
+
+var (
+	aggregatePattern = regexp.MustCompile(`^(count|sum)\((.*)\)$`)
+	slugPattern      = regexp.MustCompile(`^[a-z]+(?:[-_][a-z]+)*$`)
+	pathPattern      = regexp.MustCompile(`^[A-Za-z0-9_]+(?:\.[A-Za-z0-9_]+)*$`)
+)
+
+// parseAggregateField reads an aggregate field. The boolean result is false when the field is a regular field.
+func parseAggregateField(field string) (aggregateField, bool, error) {
+	match := aggregatePattern.FindStringSubmatch(strings.TrimSpace(field))
+	if match == nil {
+		return aggregateField{}, false, nil
+	}
+	args := strings.Split(match[2], ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	aggregate := aggregateField{function: match[1]}
+	switch {
+	case aggregate.function == "count" && len(args) == 2:
+		aggregate.trigger = args[0]
+	case aggregate.function == "sum" && len(args) == 3:
+		aggregate.field, aggregate.trigger = args[0], args[1]
+		if !pathPattern.MatchString(aggregate.field) {
+			return aggregateField{}, true, fmt.Errorf("invalid field %q to sum", aggregate.field)
+		}
+	case aggregate.function == "count":
+		return aggregateField{}, true, fmt.Errorf("count takes a trigger and a window, e.g. count(purchase, 30d)")
+	default:
+		return aggregateField{}, true, fmt.Errorf("sum takes a field, a trigger and a window, e.g. sum(amount, purchase, 30d)")
+	}
+	if !slugPattern.MatchString(aggregate.trigger) {
+		return aggregateField{}, true, fmt.Errorf("invalid trigger %q", aggregate.trigger)
+	}
+	window, err := parseWindow(args[len(args)-1])
+	if err != nil {
+		return aggregateField{}, true, err
+	}
+	aggregate.window = window
+	return aggregate, true, nil
+}
+
+// window is the period an aggregate looks back over: a duration such as "30d", the current calendar "day", "week"
+// (starting on Monday), "month" or "year", or "all" for the whole history.
+type window struct {
+	value    string
+	duration time.Duration
+}
+
+func parseWindow(value string) (window, error) {
+	switch value {
+	case "day", "week", "month", "year", "all":
+		return window{value: value}, nil
+	}
+	duration, err := parseDuration(value)
+	if err != nil || duration <= 0 {
+		return window{}, fmt.Errorf("invalid window %q, use a duration such as 30d or one of day, week, month, year and all", value)
+	}
+	return window{value: value, duration: duration}, nil
+}
+
+// since returns the start of the window at the time of the clock.
+func (w window) since(env environment) time.Time {
+	now := env.now().In(env.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, env.location)
+	switch w.value {
+	case "day":
+		return today
+	case "week":
+		return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, env.location)
+	case "year":
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, env.location)
+	case "all":
+		return time.Time{}
+	default:
+		return now.Add(-w.duration)
+	}
+}
+
+// resolve computes the aggregate, reusing the result of an identical aggregate earlier in the same evaluation.
+func (a aggregateField) resolve(aggregator Aggregator, resolved map[string]float64, env environment) (float64, error) {
+	if aggregator == nil {
+		return 0, fmt.Errorf("no event history to resolve %s", a)
+	}
+	since := a.window.since(env)
+	key := a.String() + "@" + since.String()
+	if value, ok := resolved[key]; ok {
+		return value, nil
+	}
+	var value float64
+	var err error
+	if a.function == "count" {
+		value, err = aggregator.Count(a.trigger, since)
+	} else {
+		value, err = aggregator.Sum(a.field, a.trigger, since)
+	}
+	if err != nil {
+		return 0, err
+	}
+	resolved[key] = value
+	return value, nil
+}
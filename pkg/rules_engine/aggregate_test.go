@@ -0,0 +1,122 @@
+package rule_engine
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeAggregator serves fixed aggregates and records the windows it was asked for
+type fakeAggregator struct {
+	counts map[string]float64
+	sums   map[string]float64
+	calls  int
+	since  []time.Time
+}
+
+func (a *fakeAggregator) Count(trigger string, since time.Time) (float64, error) {
+	a.calls++
+	a.since = append(a.since, since)
+	return a.counts[trigger], nil
+}
+
+func (a *fakeAggregator) Sum(field, trigger string, since time.Time) (float64, error) {
+	a.calls++
+	a.since = append(a.since, since)
+	return a.sums[trigger+"."+field], nil
+}
+
+func TestEvaluateAggregates(t *testing.T) {
+	rule := Rule{
+		Logic: "AND",
+		Rules: []Rule{
+			{Field: "count(purchase, month)", Operator: "==", Val: 3},
+			{Field: "sum(amount, purchase, 30d)", Operator: ">", Val: 1000},
+		},
+	}
+	compiled, err := Compile(rule, WithClock(fixedClock))
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+
+	aggregator := &fakeAggregator{counts: map[string]float64{"purchase": 3}, sums: map[string]float64{"purchase.amount": 1200}}
+	result, err := compiled.EvaluateWith(map[string]interface{}{}, aggregator)
+	if err != nil || !result {
+		t.Fatalf("Expected the aggregates to match, got %v, %v", result, err)
+	}
+	if !aggregator.since[0].Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the month window to start on 2024-03-01, got %v", aggregator.since[0])
+	}
+	if !aggregator.since[1].Equal(fixedClock().AddDate(0, 0, -30)) {
+		t.Errorf("Expected the 30d window to start 30 days ago, got %v", aggregator.since[1])
+	}
+
+	aggregator = &fakeAggregator{counts: map[string]float64{"purchase": 2}}
+	result, err = compiled.EvaluateWith(map[string]interface{}{}, aggregator)
+	if err != nil || result {
+		t.Fatalf("Expected the aggregates not to match, got %v, %v", result, err)
+	}
+	if aggregator.calls != 1 {
+		t.Errorf("Expected the sum not to be resolved once the count failed, got %d calls", aggregator.calls)
+	}
+}
+
+func TestEvaluateAggregatesResolvedOnce(t *testing.T) {
+	rule := Rule{
+		Logic: "OR",
+		Rules: []Rule{
+			{Field: "count(purchase, week)", Operator: ">=", Val: 10},
+			{Field: "count(purchase, week)", Operator: "between", Val: []interface{}{5, 7}},
+		},
+	}
+	compiled, err := Compile(rule, WithClock(fixedClock))
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	aggregator := &fakeAggregator{counts: map[string]float64{"purchase": 6}}
+	result, err := compiled.EvaluateWith(map[string]interface{}{}, aggregator)
+	if err != nil || !result {
+		t.Fatalf("Expected the aggregates to match, got %v, %v", result, err)
+	}
+	if aggregator.calls != 1 {
+		t.Errorf("Expected the count to be resolved once, got %d calls", aggregator.calls)
+	}
+	if !aggregator.since[0].Equal(time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the week window to start on Monday 2024-03-11, got %v", aggregator.since[0])
+	}
+}
+
+func TestEvaluateRulesWithoutAggregatesSkipTheAggregator(t *testing.T) {
+	rule := Rule{Field: "amount", Operator: ">", Val: 10}
+	compiled, err := Compile(rule)
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	aggregator := &fakeAggregator{}
+	if _, err := compiled.EvaluateWith(map[string]interface{}{"amount": 20}, aggregator); err != nil {
+		t.Fatalf("Evaluation failed with error: %v", err)
+	}
+	if aggregator.calls != 0 {
+		t.Errorf("Expected no aggregate to be resolved, got %d calls", aggregator.calls)
+	}
+
+	compiled, _ = Compile(Rule{Field: "count(purchase, 7d)", Operator: ">", Val: 1})
+	if _, err := compiled.Evaluate(map[string]interface{}{}); err == nil {
+		t.Error("Expected an aggregate to fail without an aggregator")
+	}
+}
+
+func TestCompileRejectsInvalidAggregates(t *testing.T) {
+	for _, rule := range []Rule{
+		{Field: "count(purchase)", Operator: ">", Val: 1},
+		{Field: "count(purchase, fortnight)", Operator: ">", Val: 1},
+		{Field: "sum(amount, purchase)", Operator: ">", Val: 1},
+		{Field: "sum(amount;drop, purchase, 7d)", Operator: ">", Val: 1},
+		{Field: "count(Purchase!, 7d)", Operator: ">", Val: 1},
+		{Field: "count(purchase, 7d)", Operator: "contains", Val: 1},
+		{Field: "count(purchase, 7d)", Operator: ">", Val: "now-7d"},
+	} {
+		if _, err := Compile(rule); err == nil {
+			t.Errorf("Expected rule %+v to be rejected", rule)
+		}
+	}
+}
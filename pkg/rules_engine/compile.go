@@ -0,0 +1,377 @@
+package rule_engine
+
+import (
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/pkg/utils"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompiledRule is a rule whose operators, arity and values were validated ahead of evaluation.
+// Regexes are precompiled and literal values are coerced, so evaluating it only fails on the data.
+type CompiledRule struct {
+	logic    string
+	field    string
+	operator string
+	value    interface{}
+	pattern  *regexp.Regexp
+	rules    []CompiledRule
+	env      environment
+	// aggregate is set when the field is computed over past events, e.g. count(purchase, 30d)
+	aggregate *aggregateField
+}
+
+// CompileError lists every problem found in a rule, keyed by the path of the offending part (e.g. "rules[1].operator").
+type CompileError struct {
+	Fields map[string]string
+}
+
+func (e *CompileError) Error() string {
+	paths := make([]string, 0, len(e.Fields))
+	for path := range e.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	problems := make([]string, len(paths))
+	for i, path := range paths {
+		problems[i] = path + ": " + e.Fields[path]
+	}
+	return "invalid rule: " + strings.Join(problems, "; ")
+}
+
+// Compile validates a rule and prepares it for evaluation. It returns a *CompileError listing every invalid part.
+func Compile(rule Rule, options ...Option) (CompiledRule, error) {
+	problems := make(map[string]string)
+	compiled := compileRule(rule, "", newEnvironment(options), problems)
+	if len(problems) > 0 {
+		return CompiledRule{}, &CompileError{Fields: problems}
+	}
+	return compiled, nil
+}
+
+// compileRule compiles a rule and its nested rules, recording problems under the path of the rule.
+func compileRule(rule Rule, path string, env environment, problems map[string]string) CompiledRule {
+	if rule.Timezone != "" {
+		location, err := time.LoadLocation(rule.Timezone)
+		if err != nil {
+			problems[path+"timezone"] = fmt.Sprintf("unknown timezone %q", rule.Timezone)
+		} else {
+			env.location = location
+		}
+	}
+	if rule.Logic != "" {
+		return compileLogic(rule, path, env, problems)
+	}
+	compiled := CompiledRule{field: rule.Field, operator: rule.Operator, env: env}
+	// Within a quantifier, a rule without a field compares the array element itself
+	if rule.Field == "" && !env.inElement {
+		problems[path+"field"] = "is required"
+	}
+	aggregate, isAggregate, err := parseAggregateField(rule.Field)
+	if err != nil {
+		problems[path+"field"] = err.Error()
+	} else if isAggregate {
+		compiled.aggregate = &aggregate
+		if !aggregateOperators[rule.Operator] {
+			problems[path+"operator"] = fmt.Sprintf("operator %q cannot compare an aggregate", rule.Operator)
+			return compiled
+		}
+	}
+	kind, ok := operatorKinds[rule.Operator]
+	if !ok {
+		if rule.Operator == "" {
+			problems[path+"operator"] = "is required"
+		} else {
+			problems[path+"operator"] = fmt.Sprintf("unsupported operator %q", rule.Operator)
+		}
+		return compiled
+	}
+	if kind == operandRules {
+		if len(rule.Rules) == 0 {
+			problems[path+"rules"] = fmt.Sprintf("operator %s needs at least one rule", rule.Operator)
+		}
+		elementEnv := env
+		elementEnv.inElement = true
+		compiled.rules = compileRules(rule.Rules, path, elementEnv, problems)
+		if rule.Operator != "count" {
+			return compiled
+		}
+		// count compares the number of matching elements, at least the value when it is a plain number
+		kind = operandSize
+	}
+	value, err := coerceValue(kind, rule.Operator, rule.Val)
+	if err != nil {
+		problems[path+"value"] = err.Error()
+		return compiled
+	}
+	if compiled.aggregate != nil && !isNumeric(value) {
+		problems[path+"value"] = "must be a number to compare with an aggregate"
+		return compiled
+	}
+	compiled.value = value
+	if rule.Operator == "matches" {
+		pattern, err := regexp.Compile(value.(string))
+		if err != nil {
+			problems[path+"value"] = fmt.Sprintf("invalid regular expression: %v", err)
+			return compiled
+		}
+		compiled.pattern = pattern
+	}
+	return compiled
+}
+
+// compileLogic compiles an AND/OR/NOT combination and checks its arity.
+func compileLogic(rule Rule, path string, env environment, problems map[string]string) CompiledRule {
+	compiled := CompiledRule{logic: rule.Logic, env: env}
+	switch rule.Logic {
+	case "AND", "OR":
+		if len(rule.Rules) == 0 {
+			problems[path+"rules"] = fmt.Sprintf("%s logic needs at least one rule", rule.Logic)
+		}
+	case "NOT":
+		if len(rule.Rules) != 1 {
+			problems[path+"rules"] = "NOT logic must have exactly one sub-rule"
+		}
+	default:
+		problems[path+"logic"] = fmt.Sprintf("unsupported logic operator %q", rule.Logic)
+		return compiled
+	}
+	compiled.rules = compileRules(rule.Rules, path, env, problems)
+	return compiled
+}
+
+func compileRules(rules []Rule, path string, env environment, problems map[string]string) []CompiledRule {
+	compiled := make([]CompiledRule, len(rules))
+	for i, rule := range rules {
+		compiled[i] = compileRule(rule, fmt.Sprintf("%srules[%d].", path, i), env, problems)
+	}
+	return compiled
+}
+
+// operandKind describes the value an operator expects.
+type operandKind int
+
+const (
+	operandNone operandKind = iota
+	operandRules
+	operandScalar
+	operandString
+	operandOrdered
+	operandList
+	operandRange
+	operandSize
+	operandDate
+	operandDuration
+	operandCalendar
+)
+
+// operatorKinds lists every supported operator with the value it expects.
+var operatorKinds = map[string]operandKind{
+	"exists":      operandNone,
+	"notexists":   operandNone,
+	"isnull":      operandNone,
+	"any":         operandRules,
+	"all":         operandRules,
+	"none":        operandRules,
+	"count":       operandRules,
+	"==":          operandScalar,
+	"!=":          operandScalar,
+	"contains":    operandScalar,
+	">":           operandOrdered,
+	"<":           operandOrdered,
+	">=":          operandOrdered,
+	"<=":          operandOrdered,
+	"startswith":  operandString,
+	"endswith":    operandString,
+	"matches":     operandString,
+	"i==":         operandString,
+	"i!=":         operandString,
+	"icontains":   operandString,
+	"istartswith": operandString,
+	"iendswith":   operandString,
+	"in":          operandList,
+	"notin":       operandList,
+	"iin":         operandList,
+	"inotin":      operandList,
+	"containsAny": operandList,
+	"containsAll": operandList,
+	"between":     operandRange,
+	"size":        operandSize,
+	"sameday":     operandDate,
+	"anniversary": operandDate,
+	"within":      operandDuration,
+	"olderthan":   operandDuration,
+	"hour":        operandCalendar,
+	"weekday":     operandCalendar,
+	"dayofmonth":  operandCalendar,
+	"month":       operandCalendar,
+}
+
+// aggregateOperators lists the operators that can compare the number an aggregate field resolves to.
+var aggregateOperators = map[string]bool{
+	"==": true, "!=": true, ">": true, "<": true, ">=": true, "<=": true, "in": true, "notin": true, "between": true,
+}
+
+// coerceValue checks that a rule value fits what its operator expects and normalizes it: numbers become float64,
+// slices become []interface{}, comma separated strings become lists and relative dates are parsed.
+func coerceValue(kind operandKind, operator string, value interface{}) (interface{}, error) {
+	switch kind {
+	case operandNone:
+		return nil, nil
+	case operandScalar:
+		return coerceScalar(value)
+	case operandString:
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+		return value, nil
+	case operandOrdered:
+		return coerceOrdered(value)
+	case operandList:
+		list, err := ruleList(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be an array or a comma separated string")
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("must not be empty")
+		}
+		for i, item := range list {
+			if list[i], err = coerceScalar(item); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case operandRange:
+		bounds, err := ruleRange(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be an array of two values")
+		}
+		from, fromErr := coerceOrdered(bounds[0])
+		to, toErr := coerceOrdered(bounds[1])
+		if fromErr != nil || toErr != nil {
+			return nil, fmt.Errorf("must hold two numbers or two dates")
+		}
+		_, fromNumber := from.(float64)
+		_, toNumber := to.(float64)
+		if fromNumber != toNumber {
+			return nil, fmt.Errorf("must hold two numbers or two dates")
+		}
+		return []interface{}{from, to}, nil
+	case operandSize:
+		if comparison, ok := value.(map[string]interface{}); ok {
+			if len(comparison) != 1 {
+				return nil, fmt.Errorf("must hold exactly one comparison")
+			}
+			for operator, size := range comparison {
+				if operatorKinds[operator] != operandOrdered && operator != "==" && operator != "!=" {
+					return nil, fmt.Errorf("unsupported size operator %q", operator)
+				}
+				number, ok := utils.AsFloat64(size)
+				if !ok {
+					return nil, fmt.Errorf("must compare the size with a number")
+				}
+				return map[string]interface{}{operator: number}, nil
+			}
+		}
+		number, ok := utils.AsFloat64(value)
+		if !ok {
+			return nil, fmt.Errorf("must be a number or a comparison such as {\">=\": 2}")
+		}
+		return number, nil
+	case operandDate:
+		return coerceDate(value)
+	case operandDuration:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a duration such as \"7d\" or \"12h\"")
+		}
+		duration, err := parseDuration(str)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("must be a duration such as \"7d\" or \"12h\"")
+		}
+		return duration, nil
+	case operandCalendar:
+		return coerceCalendar(calendarParts[operator], value)
+	default:
+		return value, nil
+	}
+}
+
+// isNumeric reports whether a coerced value is a number or a list of numbers.
+func isNumeric(value interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if !isNumeric(item) {
+				return false
+			}
+		}
+		return true
+	}
+	_, ok := value.(float64)
+	return ok
+}
+
+// coerceScalar accepts strings, numbers, booleans and null.
+func coerceScalar(value interface{}) (interface{}, error) {
+	if number, ok := utils.AsFloat64(value); ok {
+		return number, nil
+	}
+	switch value.(type) {
+	case nil, string, bool:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("must be a string, a number, a boolean or null")
+	}
+}
+
+// coerceOrdered accepts numbers, numeric strings, dates and relative dates.
+func coerceOrdered(value interface{}) (interface{}, error) {
+	if number, ok := utils.ParseFloat64(value); ok {
+		return number, nil
+	}
+	if relative, ok := parseRelativeTime(value); ok {
+		return relative, nil
+	}
+	if utils.IsDate(value) {
+		return value, nil
+	}
+	return nil, fmt.Errorf("must be a number, a date or a relative date such as \"now-7d\"")
+}
+
+// Cache keeps compiled rules by key, so a rule is only compiled again when its version changes.
+type Cache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	options []Option
+}
+
+type cacheEntry struct {
+	version string
+	rule    CompiledRule
+}
+
+// NewCache creates a cache compiling rules with the given options.
+func NewCache(options ...Option) *Cache {
+	return &Cache{entries: make(map[string]cacheEntry), options: options}
+}
+
+// Compile returns the compiled rule cached under the key, compiling and caching the rule when the cached version differs.
+func (c *Cache) Compile(key, version string, rule Rule) (CompiledRule, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && entry.version == version {
+		return entry.rule, nil
+	}
+	compiled, err := Compile(rule, c.options...)
+	if err != nil {
+		return CompiledRule{}, err
+	}
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{version: version, rule: compiled}
+	c.mu.Unlock()
+	return compiled, nil
+}
@@ -0,0 +1,126 @@
+package rule_engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompileReportsInvalidParts(t *testing.T) {
+	rule := Rule{
+		Logic: "AND",
+		Rules: []Rule{
+			{Field: "user.name", Operator: "equals", Val: "John"},
+			{Field: "user.email", Operator: "matches", Val: "("},
+			{Logic: "NOT", Rules: []Rule{
+				{Field: "user.age", Operator: ">", Val: 18},
+				{Field: "user.age", Operator: "<", Val: 60},
+			}},
+			{Field: "user.age", Operator: "between", Val: []interface{}{18}},
+			{Operator: "exists"},
+			{Logic: "XOR", Rules: []Rule{{Field: "user.age", Operator: "exists"}}},
+			{Field: "family", Operator: "any"},
+		},
+	}
+
+	_, err := Compile(rule)
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("Expected a CompileError but got %v", err)
+	}
+	for _, path := range []string{
+		"rules[0].operator",
+		"rules[1].value",
+		"rules[2].rules",
+		"rules[3].value",
+		"rules[4].field",
+		"rules[5].logic",
+		"rules[6].rules",
+	} {
+		if _, ok := compileErr.Fields[path]; !ok {
+			t.Errorf("Expected a problem at %s, got %v", path, compileErr.Fields)
+		}
+	}
+	if len(compileErr.Fields) != 7 {
+		t.Errorf("Expected 7 problems, got %v", compileErr.Fields)
+	}
+}
+
+func TestCompileCoercesLiterals(t *testing.T) {
+	compiled, err := Compile(Rule{Field: "amount", Operator: ">=", Val: "10"})
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	if compiled.value != float64(10) {
+		t.Fatalf("Expected the value to be coerced to 10, got %#v", compiled.value)
+	}
+
+	compiled, err = Compile(Rule{Field: "tier", Operator: "in", Val: []int{1, 2}})
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	list := compiled.value.([]interface{})
+	if len(list) != 2 || list[0] != float64(1) || list[1] != float64(2) {
+		t.Fatalf("Expected the value to be coerced to [1 2], got %#v", compiled.value)
+	}
+
+	result, err := compiled.Evaluate(map[string]interface{}{"tier": 2})
+	if err != nil || !result {
+		t.Fatalf("Expected the compiled rule to match, got %v, %v", result, err)
+	}
+}
+
+func TestCompilePrecompilesRegex(t *testing.T) {
+	compiled, err := Compile(Rule{Field: "email", Operator: "matches", Val: `^\S+@\S+\.\S+$`})
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	if compiled.pattern == nil {
+		t.Fatal("Expected the regex to be precompiled")
+	}
+	result, err := compiled.Evaluate(map[string]interface{}{"email": "john@example.com"})
+	if err != nil || !result {
+		t.Fatalf("Expected the compiled rule to match, got %v, %v", result, err)
+	}
+}
+
+func TestCacheRecompilesNewVersions(t *testing.T) {
+	cache := NewCache()
+	rule := Rule{Field: "amount", Operator: ">", Val: 10}
+	if _, err := cache.Compile("1", "v1", rule); err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+
+	// The same version is served from the cache even if the rule given is different
+	compiled, err := cache.Compile("1", "v1", Rule{Field: "amount", Operator: ">", Val: 100})
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	if compiled.value != float64(10) {
+		t.Fatalf("Expected the cached rule, got %#v", compiled.value)
+	}
+
+	compiled, err = cache.Compile("1", "v2", Rule{Field: "amount", Operator: ">", Val: 100})
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	if compiled.value != float64(100) {
+		t.Fatalf("Expected the new version to be compiled, got %#v", compiled.value)
+	}
+
+	if _, err := cache.Compile("2", "v1", Rule{Field: "amount", Operator: "unknown"}); err == nil {
+		t.Fatal("Expected an invalid rule to fail")
+	}
+}
+
+func TestCompileRejectsInvalidQuantifiers(t *testing.T) {
+	for _, rule := range []Rule{
+		{Operator: "==", Val: "vip"},
+		{Field: "tags", Operator: "any"},
+		{Field: "items", Operator: "count", Val: "two", Rules: []Rule{{Field: "price", Operator: ">", Val: 50}}},
+		{Field: "items", Operator: "count", Val: map[string]interface{}{"~": 2}, Rules: []Rule{{Field: "price", Operator: ">", Val: 50}}},
+	} {
+		if _, err := Compile(rule); err == nil {
+			t.Errorf("Expected rule %+v to be rejected", rule)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package rule_engine
+
+import (
+	"fmt"
+	"github.com/abdelrahman146/digital-wallet/pkg/utils"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option configures how rules are compiled and evaluated.
+type Option func(*environment)
+
+// environment holds the clock and the timezone dates are evaluated with.
+type environment struct {
+	now      func() time.Time
+	location *time.Location
+	// inElement is set for the rules of a quantifier, which are evaluated against each element of an array
+	inElement bool
+}
+
+func newEnvironment(options []Option) environment {
+	env := environment{now: time.Now, location: time.UTC}
+	for _, option := range options {
+		option(&env)
+	}
+	return env
+}
+
+// WithClock sets the clock relative dates such as "now-7d" are resolved with. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(env *environment) {
+		env.now = now
+	}
+}
+
+// WithLocation sets the timezone of dates without an offset, of "today" and of calendar parts. Defaults to UTC.
+// A rule can override it with its own timezone.
+func WithLocation(location *time.Location) Option {
+	return func(env *environment) {
+		env.location = location
+	}
+}
+
+// relativeTime is a date relative to the evaluation time, written as "now", "today" (midnight) or either with an
+// offset such as "now-7d" or "today+1w".
+type relativeTime struct {
+	startOfDay bool
+	offset     time.Duration
+}
+
+var relativeTimePattern = regexp.MustCompile(`^(now|today)(?:\s*([+-])\s*(\w+))?$`)
+
+// parseRelativeTime reads a relative date. The boolean result is false when the value is not a relative date.
+func parseRelativeTime(value interface{}) (relativeTime, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return relativeTime{}, false
+	}
+	match := relativeTimePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(str)))
+	if match == nil {
+		return relativeTime{}, false
+	}
+	relative := relativeTime{startOfDay: match[1] == "today"}
+	if match[3] != "" {
+		offset, err := parseDuration(match[3])
+		if err != nil {
+			return relativeTime{}, false
+		}
+		if match[2] == "-" {
+			offset = -offset
+		}
+		relative.offset = offset
+	}
+	return relative, true
+}
+
+// resolve returns the date the relative date stands for at the time of the clock.
+func (r relativeTime) resolve(env environment) time.Time {
+	now := env.now().In(env.location)
+	if r.startOfDay {
+		now = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, env.location)
+	}
+	return now.Add(r.offset)
+}
+
+var durationPattern = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// parseDuration reads durations such as "7d", "2w" or any duration accepted by time.ParseDuration (e.g. "12h").
+func parseDuration(value string) (time.Duration, error) {
+	if match := durationPattern.FindStringSubmatch(value); match != nil {
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, err
+		}
+		day := 24 * time.Hour
+		if match[2] == "w" {
+			return time.Duration(count) * 7 * day, nil
+		}
+		return time.Duration(count) * day, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// calendarPart describes a part of a date that rules can compare, e.g. the hour or the weekday.
+type calendarPart struct {
+	min, max int
+	names    map[string]int
+	extract  func(time.Time) int
+}
+
+var weekdayNames = map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+// calendarParts lists the calendar operators with the part of the date they compare.
+var calendarParts = map[string]calendarPart{
+	"hour":       {min: 0, max: 23, extract: func(t time.Time) int { return t.Hour() }},
+	"weekday":    {min: 0, max: 6, names: weekdayNames, extract: func(t time.Time) int { return int(t.Weekday()) }},
+	"dayofmonth": {min: 1, max: 31, extract: func(t time.Time) int { return t.Day() }},
+	"month":      {min: 1, max: 12, names: monthNames, extract: func(t time.Time) int { return int(t.Month()) }},
+}
+
+// calendarComparison compares a calendar part with a numeric operator, e.g. {"between": [18, 22]}.
+type calendarComparison struct {
+	operator string
+	operand  interface{}
+}
+
+// coerceCalendar reads the value of a calendar operator: a single part, a list of parts or one comparison such as
+// {"between": [18, 22]}. Weekday and month names (SUN..SAT, JAN..DEC) are converted to their numbers.
+func coerceCalendar(part calendarPart, value interface{}) (calendarComparison, error) {
+	comparison := calendarComparison{operator: "==", operand: value}
+	if object, ok := value.(map[string]interface{}); ok {
+		if len(object) != 1 {
+			return calendarComparison{}, fmt.Errorf("must hold exactly one comparison")
+		}
+		for operator, operand := range object {
+			comparison = calendarComparison{operator: operator, operand: operand}
+		}
+	} else if _, ok := value.(string); !ok {
+		if _, ok := toSlice(value); ok {
+			comparison.operator = "in"
+		}
+	}
+
+	switch comparison.operator {
+	case "==", "!=", ">", "<", ">=", "<=":
+		number, err := coerceCalendarValue(part, comparison.operand)
+		if err != nil {
+			return calendarComparison{}, err
+		}
+		comparison.operand = number
+	case "in", "notin", "between":
+		values, ok := toSlice(comparison.operand)
+		if !ok || len(values) == 0 || (comparison.operator == "between" && len(values) != 2) {
+			return calendarComparison{}, fmt.Errorf("%s needs an array of values", comparison.operator)
+		}
+		numbers := make([]interface{}, len(values))
+		for i, item := range values {
+			number, err := coerceCalendarValue(part, item)
+			if err != nil {
+				return calendarComparison{}, err
+			}
+			numbers[i] = number
+		}
+		comparison.operand = numbers
+	default:
+		return calendarComparison{}, fmt.Errorf("unsupported comparison %q", comparison.operator)
+	}
+	return comparison, nil
+}
+
+func coerceCalendarValue(part calendarPart, value interface{}) (float64, error) {
+	if name, ok := value.(string); ok && part.names != nil {
+		number, ok := part.names[nameKey(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown name %q", name)
+		}
+		return float64(number), nil
+	}
+	number, ok := utils.AsFloat64(value)
+	if !ok || number != float64(int(number)) || int(number) < part.min || int(number) > part.max {
+		return 0, fmt.Errorf("must be a whole number between %d and %d", part.min, part.max)
+	}
+	return number, nil
+}
+
+// ParseWeekday reads a weekday name such as "FRI" or "Friday", from the names calendar rules accept.
+func ParseWeekday(name string) (time.Weekday, bool) {
+	day, ok := weekdayNames[nameKey(name)]
+	return time.Weekday(day), ok
+}
+
+// nameKey returns the key of a weekday or month name in the name tables: its first three letters, uppercased.
+func nameKey(name string) string {
+	key := []rune(strings.ToUpper(name))
+	return string(key[:min(3, len(key))])
+}
+
+// coerceDate reads the value of a date operator: an absolute or a relative date, defaulting to "now".
+func coerceDate(value interface{}) (interface{}, error) {
+	if value == nil {
+		return relativeTime{}, nil
+	}
+	if relative, ok := parseRelativeTime(value); ok {
+		return relative, nil
+	}
+	if utils.IsDate(value) {
+		return value, nil
+	}
+	return nil, fmt.Errorf("must be a date or a relative date such as \"now-7d\"")
+}
+
+// resolveValue replaces the relative dates of a rule value with the dates they stand for.
+func resolveValue(value interface{}, env environment) interface{} {
+	switch v := value.(type) {
+	case relativeTime:
+		return v.resolve(env)
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved[i] = resolveValue(item, env)
+		}
+		return resolved
+	default:
+		return value
+	}
+}
+
+// evaluateTimeOperator handles the operators that only apply to dates: "within", "olderthan", "sameday",
+// "anniversary" and the calendar parts. The boolean result is false when the operator is not one of them.
+func evaluateTimeOperator(operator string, fieldValue, ruleValue interface{}, env environment) (bool, bool, error) {
+	part, isCalendar := calendarParts[operator]
+	switch {
+	case isCalendar, operator == "within", operator == "olderthan", operator == "sameday", operator == "anniversary":
+	default:
+		return false, false, nil
+	}
+	fieldDate, err := utils.ParseDateIn(fieldValue, env.location)
+	if err != nil {
+		return false, true, fmt.Errorf("field value is not a valid date: %v", err)
+	}
+	fieldDate = fieldDate.In(env.location)
+
+	if isCalendar {
+		comparison := ruleValue.(calendarComparison)
+		result, err := evaluateNumeric(comparison.operator, float64(part.extract(fieldDate)), comparison.operand)
+		return result, true, err
+	}
+
+	switch operator {
+	case "within":
+		now := env.now()
+		return !fieldDate.Before(now.Add(-ruleValue.(time.Duration))) && !fieldDate.After(now), true, nil
+	case "olderthan":
+		return fieldDate.Before(env.now().Add(-ruleValue.(time.Duration))), true, nil
+	}
+
+	ruleDate, err := utils.ParseDateIn(resolveValue(ruleValue, env), env.location)
+	if err != nil {
+		return false, true, fmt.Errorf("rule value is not a valid date: %v", err)
+	}
+	ruleDate = ruleDate.In(env.location)
+	if operator == "sameday" {
+		return fieldDate.Year() == ruleDate.Year() && fieldDate.YearDay() == ruleDate.YearDay(), true, nil
+	}
+	return isAnniversary(fieldDate, ruleDate), true, nil
+}
+
+// isAnniversary reports whether the date falls on the same month and day as the original date. Anniversaries of
+// February 29 fall on February 28 in common years.
+func isAnniversary(original, date time.Time) bool {
+	month, day := original.Month(), original.Day()
+	if month == time.February && day == 29 && !isLeapYear(date.Year()) {
+		day = 28
+	}
+	return date.Month() == month && date.Day() == day
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
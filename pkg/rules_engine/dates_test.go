@@ -0,0 +1,141 @@
+package rule_engine
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fixedClock is the time the relative date rules are evaluated at: Friday 2024-03-15 16:30 UTC, 20:30 in Asia/Dubai
+func fixedClock() time.Time {
+	return time.Date(2024, time.March, 15, 16, 30, 0, 0, time.UTC)
+}
+
+// Utility function to evaluate a rule at the fixed clock
+func testEvaluateRuleAt(t *testing.T, rule Rule, dataJson string, expected bool, options ...Option) {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(dataJson), &data)
+	compiled, err := Compile(rule, append([]Option{WithClock(fixedClock)}, options...)...)
+	if err != nil {
+		t.Fatalf("Compile failed with error: %v", err)
+	}
+	result, err := compiled.Evaluate(data)
+	if err != nil {
+		t.Fatalf("Evaluation failed with error: %v", err)
+	}
+	if result != expected {
+		t.Fatalf("Expected %v but got %v for rule: %+v and data: %s", expected, result, rule, dataJson)
+	}
+}
+
+func TestEvaluateRelativeDates(t *testing.T) {
+	rule := Rule{Field: "event.date", Operator: ">=", Val: "now-7d"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-10T09:00:00Z"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-01T09:00:00Z"}}`, false)
+
+	rule = Rule{Field: "event.date", Operator: ">=", Val: "today"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15T00:30:00Z"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-14T23:30:00Z"}}`, false)
+
+	rule = Rule{Field: "event.date", Operator: "between", Val: []interface{}{"now-1w", "now"}}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-12"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-16"}}`, false)
+}
+
+func TestEvaluateWithinAndOlderThan(t *testing.T) {
+	rule := Rule{Field: "event.date", Operator: "within", Val: "7d"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-09T12:00:00Z"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-08T12:00:00Z"}}`, false)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-16T12:00:00Z"}}`, false)
+
+	rule = Rule{Field: "user.createdAt", Operator: "olderthan", Val: "30d"}
+	testEvaluateRuleAt(t, rule, `{"user": {"createdAt": "2024-01-15"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"user": {"createdAt": "2024-03-01"}}`, false)
+}
+
+func TestEvaluateCalendarParts(t *testing.T) {
+	rule := Rule{Field: "event.date", Operator: "weekday", Val: []interface{}{"SAT", "SUN"}}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-16T10:00:00Z"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15T10:00:00Z"}}`, false)
+
+	rule = Rule{Field: "event.date", Operator: "hour", Val: map[string]interface{}{"between": []interface{}{18, 22}}, Timezone: "Asia/Dubai"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15T16:30:00Z"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15T12:30:00Z"}}`, false)
+
+	rule = Rule{Field: "event.date", Operator: "month", Val: "MAR"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15"}}`, true)
+
+	rule = Rule{Field: "event.date", Operator: "dayofmonth", Val: map[string]interface{}{"<=": 7}}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15"}}`, false)
+}
+
+func TestEvaluateDatesInConfiguredTimezone(t *testing.T) {
+	dubai, err := time.LoadLocation("Asia/Dubai")
+	if err != nil {
+		t.Fatalf("Unable to load timezone: %v", err)
+	}
+
+	// 2024-03-14 21:00 UTC is already 2024-03-15 01:00 in Dubai, the same day as the clock there
+	rule := Rule{Field: "event.date", Operator: "sameday", Val: "today"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-14T21:00:00Z"}}`, true, WithLocation(dubai))
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-14T21:00:00Z"}}`, false)
+
+	// Dates without an offset are read in the configured timezone
+	rule = Rule{Field: "event.date", Operator: "<", Val: "2024-03-15T12:00:00Z"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15 15:00"}}`, true, WithLocation(dubai))
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15 15:00"}}`, false)
+
+	// Offsets are kept for non RFC3339 layouts
+	rule = Rule{Field: "event.date", Operator: "==", Val: "2024-03-15T11:00:00Z"}
+	testEvaluateRuleAt(t, rule, `{"event": {"date": "2024-03-15 15:00+04:00"}}`, true)
+}
+
+func TestEvaluateAnniversary(t *testing.T) {
+	rule := Rule{Field: "user.birthday", Operator: "anniversary"}
+	testEvaluateRuleAt(t, rule, `{"user": {"birthday": "1990-03-15"}}`, true)
+	testEvaluateRuleAt(t, rule, `{"user": {"birthday": "1990-03-16"}}`, false)
+
+	rule = Rule{Field: "user.birthday", Operator: "anniversary", Val: "2023-02-28"}
+	testEvaluateRuleAt(t, rule, `{"user": {"birthday": "2000-02-29"}}`, true)
+}
+
+func TestCompileRejectsInvalidDateRules(t *testing.T) {
+	for _, rule := range []Rule{
+		{Field: "event.date", Operator: ">=", Val: "now-7x"},
+		{Field: "event.date", Operator: "within", Val: 7},
+		{Field: "event.date", Operator: "weekday", Val: "FUNDAY"},
+		// Uppercasing shortens these names, which must not cut them past their end
+		{Field: "event.date", Operator: "weekday", Val: "ı"},
+		{Field: "event.date", Operator: "month", Val: []interface{}{"ıı"}},
+		{Field: "event.date", Operator: "hour", Val: 24},
+		{Field: "event.date", Operator: "hour", Val: 20, Timezone: "Mars/Olympus"},
+		{Field: "event.date", Operator: "sameday", Val: "tomorrow"},
+	} {
+		if _, err := Compile(rule); err == nil {
+			t.Errorf("Expected rule %+v to be rejected", rule)
+		}
+	}
+}
+
+func TestCompileNonASCIICalendarNames(t *testing.T) {
+	rule, err := Parse(`event.date weekday "ı"`)
+	if err != nil {
+		t.Fatalf("Parse failed with error: %v", err)
+	}
+	if _, err := Compile(rule); err == nil {
+		t.Errorf("Expected rule %+v to be rejected", rule)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for name, expected := range map[string]time.Weekday{"FRI": time.Friday, "friday": time.Friday, "Sun": time.Sunday} {
+		if day, ok := ParseWeekday(name); !ok || day != expected {
+			t.Errorf("Expected %q to be %v, got %v", name, expected, day)
+		}
+	}
+	for _, name := range []string{"", "FR", "FUNDAY", "ı"} {
+		if _, ok := ParseWeekday(name); ok {
+			t.Errorf("Expected %q not to be a weekday", name)
+		}
+	}
+}
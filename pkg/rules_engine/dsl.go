@@ -0,0 +1,627 @@
+package rule_engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Position is a place in a rule expression. Lines and columns start at 1, columns count characters.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// SyntaxError reports an expression that is not valid in the rule language, with the position of the problem.
+type SyntaxError struct {
+	Position
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Position.String() + ": " + e.Message
+}
+
+// Expression is a rule parsed from the rule language. It remembers where each part of the rule was written, so the
+// problems of a CompileError can be reported at their position in the expression.
+type Expression struct {
+	Rule      Rule
+	source    string
+	positions map[string]int
+}
+
+// Parse reads a rule written in the rule language, for example:
+//
+//	triggerData.amount >= 100 and userData.tier in ["gold", "platinum"] and any(triggerData.items, sku matches "^SHOE")
+//
+// Conditions are combined with "and", "or", "not" and parentheses. Each condition is a field, an operator of the JSON
+// form and its value. any, all, none and count apply conditions to the elements of an array, where "_" stands for the
+// element itself, and timezone("Asia/Dubai", ...) sets the timezone of the conditions it wraps. It returns a *SyntaxError.
+func Parse(expression string) (Rule, error) {
+	parsed, err := ParseExpression(expression)
+	if err != nil {
+		return Rule{}, err
+	}
+	return parsed.Rule, nil
+}
+
+// ParseExpression reads a rule written in the rule language, keeping the positions of its parts. It returns a *SyntaxError.
+func ParseExpression(expression string) (*Expression, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{source: expression, tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.errorAt(next, "unexpected %s", next)
+	}
+	parsed := &Expression{Rule: node.rule, source: expression, positions: make(map[string]int)}
+	parsed.record("", node)
+	return parsed, nil
+}
+
+// Position returns where the part of the rule at the path of a CompileError (e.g. "rules[1].value") was written,
+// falling back to the start of its rule.
+func (e *Expression) Position(path string) Position {
+	for {
+		if offset, ok := e.positions[path]; ok {
+			return positionOf(e.source, offset)
+		}
+		if path == "" {
+			return positionOf(e.source, 0)
+		}
+		// Drop the last part of the path, e.g. "rules[1].value" becomes "rules[1]." and then ""
+		path = strings.TrimSuffix(path, ".")
+		if i := strings.LastIndex(path, "."); i >= 0 {
+			path = path[:i+1]
+		} else {
+			path = ""
+		}
+	}
+}
+
+// record stores the positions of a parsed rule and its nested rules under their paths.
+func (e *Expression) record(path string, node parsedRule) {
+	for part, offset := range node.offsets {
+		e.positions[path+part] = offset
+	}
+	for i, child := range node.children {
+		e.record(fmt.Sprintf("%srules[%d].", path, i), child)
+	}
+}
+
+func positionOf(source string, offset int) Position {
+	before := source[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+	return Position{Offset: offset, Line: line, Column: column}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenQuotedField
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lex splits an expression into words (fields, operators and keywords), quoted fields, strings, numbers and symbols.
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == '"':
+			end, err := scanString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokenString, text: source[start:i], offset: start})
+		case r == '`':
+			end := strings.IndexByte(source[i+1:], '`')
+			if end < 0 {
+				return nil, &SyntaxError{Position: positionOf(source, start), Message: "unterminated quoted field"}
+			}
+			i += end + 2
+			tokens = append(tokens, token{kind: tokenQuotedField, text: source[start+1 : i-1], offset: start})
+		case isDigit(r) || (r == '-' && i+1 < len(source) && isDigit(rune(source[i+1]))):
+			i++
+			for i < len(source) && (isWordByte(source[i]) || source[i] == '.' || ((source[i] == '+' || source[i] == '-') && (source[i-1] == 'e' || source[i-1] == 'E'))) {
+				i++
+			}
+			text := source[start:i]
+			kind := tokenNumber
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				// Words starting with a digit such as the window "30d" of an aggregate
+				kind = tokenWord
+			}
+			tokens = append(tokens, token{kind: kind, text: text, offset: start})
+		case r == '_' || (r < utf8.RuneSelf && unicode.IsLetter(r)):
+			for i < len(source) && (isWordByte(source[i]) || (source[i] == '.' && i+1 < len(source) && isWordByte(source[i+1]))) {
+				i++
+			}
+			// The case-insensitive comparisons i== and i!= are written without spaces
+			if source[start:i] == "i" && (strings.HasPrefix(source[i:], "==") || strings.HasPrefix(source[i:], "!=")) {
+				i += 2
+				tokens = append(tokens, token{kind: tokenSymbol, text: source[start:i], offset: start})
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: source[start:i], offset: start})
+		default:
+			text := ""
+			for _, symbol := range []string{"==", "!=", ">=", "<=", ">", "<", "(", ")", "[", "]", "{", "}", ",", ":"} {
+				if strings.HasPrefix(source[i:], symbol) {
+					text = symbol
+					break
+				}
+			}
+			if text == "" {
+				return nil, &SyntaxError{Position: positionOf(source, start), Message: fmt.Sprintf("unexpected character %q", r)}
+			}
+			i += len(text)
+			tokens = append(tokens, token{kind: tokenSymbol, text: text, offset: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(source)}), nil
+}
+
+// scanString returns the end of the JSON string starting at the offset.
+func scanString(source string, offset int) (int, error) {
+	for i := offset + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n':
+			i = len(source)
+		}
+	}
+	return 0, &SyntaxError{Position: positionOf(source, offset), Message: "unterminated string"}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// parsedRule is a rule with the offsets its parts were written at, keyed like the paths of a CompileError.
+type parsedRule struct {
+	rule     Rule
+	offsets  map[string]int
+	children []parsedRule
+}
+
+func logicRule(logic string, offset int, children []parsedRule) parsedRule {
+	rules := make([]Rule, len(children))
+	for i, child := range children {
+		rules[i] = child.rule
+	}
+	return parsedRule{
+		rule:     Rule{Logic: logic, Rules: rules},
+		offsets:  map[string]int{"": children[0].offsets[""], "logic": offset},
+		children: children,
+	}
+}
+
+type parser struct {
+	source string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorAt(t token, format string, args ...interface{}) error {
+	return &SyntaxError{Position: positionOf(p.source, t.offset), Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(symbol string) (token, error) {
+	t := p.next()
+	if t.kind != tokenSymbol || t.text != symbol {
+		return t, p.errorAt(t, "expected %q but found %s", symbol, t)
+	}
+	return t, nil
+}
+
+// isKeyword reports whether the token is one of the logic keywords, which are case-insensitive.
+func isKeyword(t token, keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// isFunction reports whether the token is the name of a function followed by its opening parenthesis.
+func (p *parser) isFunction(name string) bool {
+	t, open := p.peek(), p.peekAt(1)
+	return t.kind == tokenWord && t.text == name && open.kind == tokenSymbol && open.text == "("
+}
+
+// isComparison reports whether the token compares a number, as the size of an array, a count or a calendar part do.
+// Calendar parts can also be compared with a list.
+func isComparison(t token, calendar bool) bool {
+	if t.kind == tokenSymbol {
+		return operatorKinds[t.text] == operandOrdered || t.text == "==" || t.text == "!="
+	}
+	return calendar && t.kind == tokenWord && (t.text == "in" || t.text == "notin" || t.text == "between")
+}
+
+// parseOr reads conditions joined by "or", the lowest precedence.
+func (p *parser) parseOr() (parsedRule, error) {
+	return p.parseJoined("or", "OR", p.parseAnd)
+}
+
+// parseAnd reads conditions joined by "and", which binds tighter than "or".
+func (p *parser) parseAnd() (parsedRule, error) {
+	return p.parseJoined("and", "AND", p.parseNot)
+}
+
+func (p *parser) parseJoined(keyword, logic string, parseOperand func() (parsedRule, error)) (parsedRule, error) {
+	first, err := parseOperand()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	children := []parsedRule{first}
+	offset := -1
+	for isKeyword(p.peek(), keyword) {
+		t := p.next()
+		if offset < 0 {
+			offset = t.offset
+		}
+		operand, err := parseOperand()
+		if err != nil {
+			return parsedRule{}, err
+		}
+		children = append(children, operand)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return logicRule(logic, offset, children), nil
+}
+
+func (p *parser) parseNot() (parsedRule, error) {
+	if !isKeyword(p.peek(), "not") {
+		return p.parsePrimary()
+	}
+	t := p.next()
+	operand, err := p.parseNot()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	node := logicRule("NOT", t.offset, []parsedRule{operand})
+	node.offsets[""] = t.offset
+	return node, nil
+}
+
+// parsePrimary reads a parenthesized expression, a quantifier, a timezone or a condition.
+func (p *parser) parsePrimary() (parsedRule, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenSymbol && t.text == "(":
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return parsedRule{}, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return parsedRule{}, err
+		}
+		return node, nil
+	case p.isFunction("timezone"):
+		return p.parseTimezone()
+	case p.isFunction("any"), p.isFunction("all"), p.isFunction("none"):
+		return p.parseQuantifier()
+	case p.isFunction("count") && !p.isAggregate():
+		return p.parseQuantifier()
+	}
+	return p.parseCondition()
+}
+
+// isAggregate tells the aggregate count(trigger, window) apart from the quantifier count(array, conditions).
+func (p *parser) isAggregate() bool {
+	window, closing := p.peekAt(4), p.peekAt(5)
+	return p.peekAt(3).text == "," && window.kind == tokenWord && closing.kind == tokenSymbol && closing.text == ")"
+}
+
+// parseTimezone reads timezone("Asia/Dubai", conditions).
+func (p *parser) parseTimezone() (parsedRule, error) {
+	start := p.next()
+	p.next()
+	t := p.next()
+	if t.kind != tokenString {
+		return parsedRule{}, p.errorAt(t, "expected a timezone such as \"Asia/Dubai\" but found %s", t)
+	}
+	var timezone string
+	if err := json.Unmarshal([]byte(t.text), &timezone); err != nil {
+		return parsedRule{}, p.errorAt(t, "invalid string: %v", err)
+	}
+	if _, err := p.expect(","); err != nil {
+		return parsedRule{}, err
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return parsedRule{}, err
+	}
+	if node.rule.Timezone != "" {
+		node = logicRule("AND", start.offset, []parsedRule{node})
+	}
+	node.rule.Timezone = timezone
+	node.offsets[""] = start.offset
+	node.offsets["timezone"] = t.offset
+	return node, nil
+}
+
+// parseQuantifier reads any(array, conditions), all(...), none(...) or count(array, conditions) followed by a comparison.
+func (p *parser) parseQuantifier() (parsedRule, error) {
+	start := p.next()
+	p.next()
+	field, err := p.parseField()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	if _, err := p.expect(","); err != nil {
+		return parsedRule{}, err
+	}
+	conditions, err := p.parseOr()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return parsedRule{}, err
+	}
+	// The conditions of a quantifier are already combined with AND
+	children := []parsedRule{conditions}
+	if conditions.rule.Logic == "AND" && conditions.rule.Timezone == "" {
+		children = conditions.children
+	}
+	node := parsedRule{
+		rule:     Rule{Field: field.rule.Field, Operator: start.text, Rules: make([]Rule, len(children))},
+		offsets:  map[string]int{"": start.offset, "operator": start.offset, "field": field.offsets["field"], "rules": conditions.offsets[""]},
+		children: children,
+	}
+	for i, child := range children {
+		node.rule.Rules[i] = child.rule
+	}
+	if start.text != "count" {
+		return node, nil
+	}
+	t := p.next()
+	if !isComparison(t, false) {
+		return parsedRule{}, p.errorAt(t, "expected a comparison such as \">= 2\" after count but found %s", t)
+	}
+	node.offsets["value"] = p.peek().offset
+	value, err := p.parseValue()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	// At least n matching elements is written as a plain number
+	node.rule.Val = map[string]interface{}{t.text: value}
+	if t.text == ">=" {
+		node.rule.Val = value
+	}
+	return node, nil
+}
+
+// parseField reads a field path, a quoted field, "_" for the element of an array or an aggregate.
+func (p *parser) parseField() (parsedRule, error) {
+	t := p.peek()
+	node := parsedRule{offsets: map[string]int{"": t.offset, "field": t.offset}}
+	switch {
+	case t.kind == tokenQuotedField:
+		p.next()
+		node.rule.Field = t.text
+	case p.isFunction("count") || p.isFunction("sum"):
+		p.next()
+		p.next()
+		var args []string
+		for {
+			arg := p.next()
+			if arg.kind != tokenWord {
+				return parsedRule{}, p.errorAt(arg, "expected an argument of %s but found %s", t.text, arg)
+			}
+			args = append(args, arg.text)
+			separator := p.next()
+			if separator.kind == tokenSymbol && separator.text == ")" {
+				break
+			}
+			if separator.kind != tokenSymbol || separator.text != "," {
+				return parsedRule{}, p.errorAt(separator, "expected \",\" or \")\" but found %s", separator)
+			}
+		}
+		node.rule.Field = t.text + "(" + strings.Join(args, ", ") + ")"
+	case t.kind == tokenWord && t.text == "_":
+		p.next()
+	case t.kind == tokenWord && !isDigit(rune(t.text[0])) && !isKeyword(t, "and") && !isKeyword(t, "or") && !isKeyword(t, "not"):
+		p.next()
+		node.rule.Field = t.text
+	default:
+		return parsedRule{}, p.errorAt(t, "expected a field but found %s", t)
+	}
+	return node, nil
+}
+
+// parseCondition reads a field, an operator and the value the operator expects.
+func (p *parser) parseCondition() (parsedRule, error) {
+	node, err := p.parseField()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	t := p.next()
+	kind, ok := operatorKinds[t.text]
+	switch {
+	case t.kind == tokenWord && (!ok || kind == operandRules):
+		return parsedRule{}, p.errorAt(t, "unknown operator %s", t)
+	case !ok:
+		return parsedRule{}, p.errorAt(t, "expected an operator after %s but found %s", formatField(node.rule.Field), t)
+	}
+	node.rule.Operator = t.text
+	node.offsets["operator"] = t.offset
+	node.offsets["value"] = p.peek().offset
+
+	switch kind {
+	case operandNone:
+		return node, nil
+	case operandDate:
+		// sameday and anniversary compare with now unless a date is given
+		if !p.startsValue() {
+			return node, nil
+		}
+	case operandSize, operandCalendar:
+		// e.g. "items size >= 2" or "event.date hour between [18, 22]"
+		if comparison := p.peek(); isComparison(comparison, kind == operandCalendar) {
+			p.next()
+			value, err := p.parseValue()
+			if err != nil {
+				return parsedRule{}, err
+			}
+			node.rule.Val = map[string]interface{}{comparison.text: value}
+			return node, nil
+		}
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return parsedRule{}, err
+	}
+	node.rule.Val = value
+	return node, nil
+}
+
+// startsValue reports whether the next token can start a value.
+func (p *parser) startsValue() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return true
+	case tokenSymbol:
+		return t.text == "[" || t.text == "{"
+	case tokenWord:
+		return t.text == "true" || t.text == "false" || t.text == "null"
+	}
+	return false
+}
+
+// parseValue reads a JSON value: a string, a number, true, false, null, an array or an object.
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenString:
+		var value string
+		if err := json.Unmarshal([]byte(t.text), &value); err != nil {
+			return nil, p.errorAt(t, "invalid string: %v", err)
+		}
+		return value, nil
+	case t.kind == tokenNumber:
+		value, _ := strconv.ParseFloat(t.text, 64)
+		return value, nil
+	case t.kind == tokenWord && t.text == "true":
+		return true, nil
+	case t.kind == tokenWord && t.text == "false":
+		return false, nil
+	case t.kind == tokenWord && t.text == "null":
+		return nil, nil
+	case t.kind == tokenSymbol && t.text == "[":
+		values := []interface{}{}
+		if p.peek().text == "]" {
+			p.next()
+			return values, nil
+		}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			separator := p.next()
+			if separator.kind == tokenSymbol && separator.text == "]" {
+				return values, nil
+			}
+			if separator.kind != tokenSymbol || separator.text != "," {
+				return nil, p.errorAt(separator, "expected \",\" or \"]\" but found %s", separator)
+			}
+		}
+	case t.kind == tokenSymbol && t.text == "{":
+		object := map[string]interface{}{}
+		if p.peek().text == "}" {
+			p.next()
+			return object, nil
+		}
+		for {
+			key := p.next()
+			var name string
+			if key.kind != tokenString || json.Unmarshal([]byte(key.text), &name) != nil {
+				return nil, p.errorAt(key, "expected a string key but found %s", key)
+			}
+			if _, err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			object[name] = value
+			separator := p.next()
+			if separator.kind == tokenSymbol && separator.text == "}" {
+				return object, nil
+			}
+			if separator.kind != tokenSymbol || separator.text != "," {
+				return nil, p.errorAt(separator, "expected \",\" or \"}\" but found %s", separator)
+			}
+		}
+	}
+	return nil, p.errorAt(t, "expected a value but found %s", t)
+}
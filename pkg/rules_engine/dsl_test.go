@@ -0,0 +1,176 @@
+package rule_engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	rule, err := Parse(`triggerData.amount >= 100 and userData.tier in ["gold","platinum"] and any(triggerData.items, sku matches "^SHOE")`)
+	if err != nil {
+		t.Fatalf("Parse failed with error: %v", err)
+	}
+	expected := Rule{
+		Logic: "AND",
+		Rules: []Rule{
+			{Field: "triggerData.amount", Operator: ">=", Val: float64(100)},
+			{Field: "userData.tier", Operator: "in", Val: []interface{}{"gold", "platinum"}},
+			{Field: "triggerData.items", Operator: "any", Rules: []Rule{{Field: "sku", Operator: "matches", Val: "^SHOE"}}},
+		},
+	}
+	if !reflect.DeepEqual(rule, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, rule)
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	rule, err := Parse(`a == 1 or not b == 2 and c == 3`)
+	if err != nil {
+		t.Fatalf("Parse failed with error: %v", err)
+	}
+	expected := Rule{
+		Logic: "OR",
+		Rules: []Rule{
+			{Field: "a", Operator: "==", Val: float64(1)},
+			{Logic: "AND", Rules: []Rule{
+				{Logic: "NOT", Rules: []Rule{{Field: "b", Operator: "==", Val: float64(2)}}},
+				{Field: "c", Operator: "==", Val: float64(3)},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(rule, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, rule)
+	}
+}
+
+func TestParseOperatorForms(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   Rule
+	}{
+		{`count(purchase, month) == 3`, Rule{Field: "count(purchase, month)", Operator: "==", Val: float64(3)}},
+		{`sum(amount, purchase, 30d) > 1000`, Rule{Field: "sum(amount, purchase, 30d)", Operator: ">", Val: float64(1000)}},
+		{`count(items, price > 50) >= 2`, Rule{Field: "items", Operator: "count", Val: float64(2), Rules: []Rule{{Field: "price", Operator: ">", Val: float64(50)}}}},
+		{`count(items, price > 50) == 1`, Rule{Field: "items", Operator: "count", Val: map[string]interface{}{"==": float64(1)}, Rules: []Rule{{Field: "price", Operator: ">", Val: float64(50)}}}},
+		{`any(tags, _ istartswith "vip")`, Rule{Field: "tags", Operator: "any", Rules: []Rule{{Operator: "istartswith", Val: "vip"}}}},
+		{`items size >= 2`, Rule{Field: "items", Operator: "size", Val: map[string]interface{}{">=": float64(2)}}},
+		{`event.date hour between [18, 22]`, Rule{Field: "event.date", Operator: "hour", Val: map[string]interface{}{"between": []interface{}{float64(18), float64(22)}}}},
+		{`event.date weekday ["SAT", "SUN"]`, Rule{Field: "event.date", Operator: "weekday", Val: []interface{}{"SAT", "SUN"}}},
+		{`user.birthday anniversary`, Rule{Field: "user.birthday", Operator: "anniversary"}},
+		{`user.name i== "john"`, Rule{Field: "user.name", Operator: "i==", Val: "john"}},
+		{"`first name` exists", Rule{Field: "first name", Operator: "exists"}},
+		{`timezone("Asia/Dubai", event.date hour 20)`, Rule{Field: "event.date", Operator: "hour", Val: float64(20), Timezone: "Asia/Dubai"}},
+		{`user.deletedAt == null`, Rule{Field: "user.deletedAt", Operator: "==", Val: nil}},
+	}
+	for _, test := range tests {
+		rule, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("Parse(%s) failed with error: %v", test.expression, err)
+			continue
+		}
+		if !reflect.DeepEqual(rule, test.expected) {
+			t.Errorf("Parse(%s): expected %+v but got %+v", test.expression, test.expected, rule)
+		}
+	}
+}
+
+func TestParseReportsPositions(t *testing.T) {
+	tests := []struct {
+		expression   string
+		line, column int
+	}{
+		{`amount >=`, 1, 10},
+		{`amount ~ 5`, 1, 8},
+		{`amount over 5`, 1, 8},
+		{"amount > 5 and\n  (tier == \"gold\"", 2, 18},
+		{"amount > 5 and\n  tier == \"gold", 2, 11},
+		{`amount > 5 tier == "gold"`, 1, 12},
+		{`any(items price > 5)`, 1, 11},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.expression)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Expected a syntax error for %q, got %v", test.expression, err)
+			continue
+		}
+		if syntaxErr.Line != test.line || syntaxErr.Column != test.column {
+			t.Errorf("Expected the error of %q at line %d, column %d, got %v", test.expression, test.line, test.column, syntaxErr)
+		}
+	}
+}
+
+func TestExpressionPositionOfCompileProblems(t *testing.T) {
+	parsed, err := ParseExpression("amount > 5 and\n  any(items, price between [1])")
+	if err != nil {
+		t.Fatalf("Parse failed with error: %v", err)
+	}
+	_, err = Compile(parsed.Rule)
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("Expected a compile error, got %v", err)
+	}
+	for path := range compileErr.Fields {
+		position := parsed.Position(path)
+		if position.Line != 2 || position.Column != 28 {
+			t.Errorf("Expected %s at line 2, column 28, got %v", path, position)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	rule := Rule{
+		Logic: "AND",
+		Rules: []Rule{
+			{Field: "amount", Operator: ">=", Val: 100},
+			{Logic: "OR", Rules: []Rule{
+				{Field: "tier", Operator: "in", Val: []string{"gold", "platinum"}},
+				{Logic: "NOT", Rules: []Rule{{Field: "first order", Operator: "exists"}}},
+			}},
+		},
+	}
+	expected := "amount >= 100 and (tier in [\"gold\", \"platinum\"] or not `first order` exists)"
+	if text := Format(rule); text != expected {
+		t.Fatalf("Expected %s but got %s", expected, text)
+	}
+}
+
+func TestFormatBreaksLongRules(t *testing.T) {
+	rule, err := Parse(`triggerData.amount >= 100 and userData.tier in ["gold", "platinum"] and (any(triggerData.items, sku matches "^SHOE") or triggerData.channel == "flagship-store")`)
+	if err != nil {
+		t.Fatalf("Parse failed with error: %v", err)
+	}
+	expected := `triggerData.amount >= 100
+and userData.tier in ["gold", "platinum"]
+and (
+  any(triggerData.items, sku matches "^SHOE")
+  or triggerData.channel == "flagship-store"
+)`
+	if text := Format(rule); text != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, text)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, expression := range []string{
+		`not (a == 1 or b i!= "x") and count(items, price > 50 and _ != null) >= 2`,
+		`count(purchase, month) == 3 or sum(amount, purchase, 30d) > 1000.5`,
+		`timezone("Asia/Dubai", event.date hour between [18, 22] and event.date weekday ["SAT", "SUN"])`,
+		`user.birthday anniversary "2024-02-29" and items size 3 and date within "7d"`,
+		`any(orders, all(items, price > 0 and (category == "food" or category == "drinks")))`,
+		`email matches "^\\S+@example\\.com$" and note == "say \"hi\""`,
+	} {
+		rule, err := Parse(expression)
+		if err != nil {
+			t.Errorf("Parse(%s) failed with error: %v", expression, err)
+			continue
+		}
+		if text := Format(rule); text != expression {
+			t.Errorf("Expected %s to be formatted back, got %s", expression, text)
+		}
+		if _, err := Compile(rule); err != nil {
+			t.Errorf("Compile(%s) failed with error: %v", expression, err)
+		}
+	}
+}
@@ -1,88 +1,190 @@
 package rule_engine
 
 import (
+	"errors"
 	"fmt"
 	"github.com/abdelrahman146/digital-wallet/pkg/utils"
 )
 
-// EvaluateRule recursively evaluates rules, including logical combinations (AND, OR, NOT).
+// EvaluateRule compiles the rule and evaluates it against the data. Rules evaluated repeatedly should be compiled once
+// with Compile or a Cache instead.
 func EvaluateRule(rule Rule, data map[string]interface{}) (bool, error) {
-	if rule.Logic != "" {
-		return evaluateLogic(rule, data)
+	compiled, err := Compile(rule)
+	if err != nil {
+		return false, err
 	}
+	return compiled.Evaluate(data)
+}
 
-	fieldValue, exists := utils.GetField(data, rule.Field)
-	if !exists {
-		return false, fmt.Errorf("field %s not found", rule.Field)
+// evaluation holds the state of a single evaluation of a rule.
+type evaluation struct {
+	aggregator Aggregator
+	// resolved keeps the aggregates already resolved, so each is only computed once
+	resolved map[string]float64
+}
+
+// Evaluate evaluates the rule against the data. Rules with aggregate fields need EvaluateWith.
+func (c CompiledRule) Evaluate(data map[string]interface{}) (bool, error) {
+	return c.evaluate(data, &evaluation{resolved: make(map[string]float64)})
+}
+
+// EvaluateWith evaluates the rule against the data, resolving aggregate fields with the aggregator when they are reached.
+func (c CompiledRule) EvaluateWith(data map[string]interface{}, aggregator Aggregator) (bool, error) {
+	return c.evaluate(data, &evaluation{aggregator: aggregator, resolved: make(map[string]float64)})
+}
+
+// evaluate recursively evaluates the rule, including logical combinations (AND, OR, NOT).
+func (c CompiledRule) evaluate(data map[string]interface{}, eval *evaluation) (bool, error) {
+	if c.logic != "" {
+		return c.evaluateLogic(data, eval)
 	}
 
-	// Handle array fields - Check if it's an array and operator is related to array handling (e.g., "any", "all")
-	if array, ok := fieldValue.([]interface{}); ok && (rule.Operator == "any" || rule.Operator == "all") {
-		return evaluateArray(rule, array, rule.Operator == "all")
+	if c.aggregate != nil {
+		value, err := c.aggregate.resolve(eval.aggregator, eval.resolved, c.env)
+		if err != nil {
+			return false, err
+		}
+		return evaluateNumeric(c.operator, value, c.value)
 	}
 
-	// Evaluate the operator
-	return evaluateOperator(rule.Operator, fieldValue, rule.Val)
-}
+	fieldValue, exists := utils.GetField(data, c.field)
+
+	// Presence operators are the only ones that accept a missing field
+	switch c.operator {
+	case "exists":
+		return exists, nil
+	case "notexists":
+		return !exists, nil
+	case "isnull":
+		return fieldValue == nil, nil
+	}
+
+	if !exists {
+		return false, missingFieldError{field: c.field}
+	}
 
-// evaluateArray applies a rule to each element in an array (supports "all" or "any").
-func evaluateArray(rule Rule, dataArray []interface{}, matchAll bool) (bool, error) {
-	for _, element := range dataArray {
-		jsonObject, ok := element.(map[string]interface{})
+	// Quantifiers apply their rules to each element of an array
+	if operatorKinds[c.operator] == operandRules {
+		if fieldValue == nil {
+			return c.evaluateQuantifier(nil, eval)
+		}
+		array, ok := toSlice(fieldValue)
 		if !ok {
-			return false, fmt.Errorf("array element is not a JSON object")
+			return false, fmt.Errorf("field %s is not an array", c.field)
 		}
+		return c.evaluateQuantifier(array, eval)
+	}
 
-		// Iterate through all sub-rules and evaluate them for each array element
-		for _, subRule := range rule.Rules {
-			result, err := EvaluateRule(subRule, jsonObject)
-			if err != nil {
-				return false, err
-			}
+	// Regexes are precompiled, so "matches" does not go through evaluateString
+	if c.pattern != nil && fieldValue != nil {
+		str, ok := fieldValue.(string)
+		if !ok {
+			return false, fmt.Errorf("field %s is not a string", c.field)
+		}
+		return c.pattern.MatchString(str), nil
+	}
 
-			// For "all" case, return false if any rule fails for any element
-			if matchAll && !result {
-				return false, nil
-			}
+	// Relative dates such as "now-7d" are resolved against the clock at every evaluation
+	ruleValue := resolveValue(c.value, c.env)
+	if fieldValue != nil {
+		if result, ok, err := evaluateTimeOperator(c.operator, fieldValue, ruleValue, c.env); ok {
+			return result, err
+		}
+	}
 
-			// For "any" case, return true if any rule passes for at least one element
-			if !matchAll && result {
-				return true, nil
-			}
+	// Evaluate the operator
+	return evaluateOperator(c.operator, fieldValue, ruleValue, c.env.location)
+}
+
+// evaluateQuantifier applies the rules of a quantifier to each element of an array. An element matches when it passes
+// every rule. "any" needs one matching element, "all" needs every element to match, "none" needs no element to match
+// and "count" compares the number of matching elements with its value. A missing or null array has no elements.
+func (c CompiledRule) evaluateQuantifier(array []interface{}, eval *evaluation) (bool, error) {
+	matches := 0
+	for _, element := range array {
+		matched, err := c.matchElement(element, eval)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case matched && c.operator == "any":
+			return true, nil
+		case matched && c.operator == "none":
+			return false, nil
+		case !matched && c.operator == "all":
+			return false, nil
+		case matched:
+			matches++
 		}
 	}
+	switch c.operator {
+	case "any":
+		return false, nil
+	case "all", "none":
+		return true, nil
+	}
+	if comparison, ok := c.value.(map[string]interface{}); ok {
+		for operator, count := range comparison {
+			return evaluateNumeric(operator, float64(matches), count)
+		}
+	}
+	return evaluateNumeric(">=", float64(matches), c.value)
+}
 
-	// If it's an "all" rule, return true because all elements passed all rules
-	// If it's an "any" rule, return false because no element satisfied any rule
-	return matchAll, nil
+// matchElement reports whether an array element passes every rule of the quantifier. Objects are evaluated as the
+// data of the rules, and rules without a field compare the element itself. Elements may have different shapes, so an
+// element missing a field of the rules does not match rather than failing the whole quantifier.
+func (c CompiledRule) matchElement(element interface{}, eval *evaluation) (bool, error) {
+	data, ok := element.(map[string]interface{})
+	if !ok {
+		// An empty field looks up the "" key, so scalar elements are stored under it
+		data = map[string]interface{}{"": element}
+	}
+	for _, subRule := range c.rules {
+		result, err := subRule.evaluate(data, eval)
+		var missing missingFieldError
+		if errors.As(err, &missing) {
+			return false, nil
+		}
+		if err != nil || !result {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// missingFieldError is returned when a rule compares a field the data does not have
+type missingFieldError struct {
+	field string
+}
+
+func (e missingFieldError) Error() string {
+	return fmt.Sprintf("field %s not found", e.field)
 }
 
 // evaluateLogic handles AND, OR, and NOT operations between rules.
-func evaluateLogic(rule Rule, data map[string]interface{}) (bool, error) {
-	switch rule.Logic {
+func (c CompiledRule) evaluateLogic(data map[string]interface{}, eval *evaluation) (bool, error) {
+	switch c.logic {
 	case "AND":
-		for _, subRule := range rule.Rules {
-			result, err := EvaluateRule(subRule, data)
+		for _, subRule := range c.rules {
+			result, err := subRule.evaluate(data, eval)
 			if err != nil || !result {
 				return false, err
 			}
 		}
 		return true, nil
 	case "OR":
-		for _, subRule := range rule.Rules {
-			result, err := EvaluateRule(subRule, data)
+		for _, subRule := range c.rules {
+			result, err := subRule.evaluate(data, eval)
 			if result && err == nil {
 				return true, nil
 			}
 		}
 		return false, nil
 	case "NOT":
-		if len(rule.Rules) != 1 {
-			return false, fmt.Errorf("NOT logic must have exactly one sub-rule")
-		}
-		result, err := EvaluateRule(rule.Rules[0], data)
+		result, err := c.rules[0].evaluate(data, eval)
 		return !result, err
 	default:
-		return false, fmt.Errorf("unsupported logic operator: %s", rule.Logic)
+		return false, fmt.Errorf("unsupported logic operator: %s", c.logic)
 	}
 }
@@ -0,0 +1,192 @@
+package rule_engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// formatWidth is the length past which Format breaks "and"/"or" conditions over several lines.
+const formatWidth = 80
+
+// Format writes a rule in the rule language, the reverse of Parse. Short rules fit on one line, while longer ones put
+// each condition of an "and"/"or" on its own line, indented by nesting.
+func Format(rule Rule) string {
+	return formatRule(rule, 0)
+}
+
+// Precedence of the forms of the rule language, from the loosest to the tightest.
+const (
+	precedenceOr = iota
+	precedenceAnd
+	precedenceNot
+	precedenceCondition
+)
+
+func precedence(rule Rule) int {
+	switch {
+	case rule.Timezone != "":
+		return precedenceCondition
+	case rule.Logic == "OR":
+		return precedenceOr
+	case rule.Logic == "AND":
+		return precedenceAnd
+	case rule.Logic == "NOT":
+		return precedenceNot
+	default:
+		return precedenceCondition
+	}
+}
+
+func formatRule(rule Rule, depth int) string {
+	if rule.Timezone != "" {
+		inner := rule
+		inner.Timezone = ""
+		return fmt.Sprintf("timezone(%s, %s)", formatValue(rule.Timezone), formatRule(inner, depth))
+	}
+	switch rule.Logic {
+	case "AND", "OR":
+		return formatJoined(strings.ToLower(rule.Logic), precedence(rule), rule.Rules, depth)
+	case "NOT":
+		if len(rule.Rules) != 1 {
+			return "not (" + formatJoined("and", precedenceAnd, rule.Rules, depth+1) + ")"
+		}
+		return "not " + formatOperand(rule.Rules[0], precedenceNot, depth+1)
+	case "":
+	default:
+		return formatJoined(strings.ToLower(rule.Logic), precedenceOr, rule.Rules, depth)
+	}
+
+	switch operatorKinds[rule.Operator] {
+	case operandRules:
+		conditions := formatJoined("and", precedenceAnd, rule.Rules, depth+1)
+		text := fmt.Sprintf("%s(%s, %s)", rule.Operator, formatField(rule.Field), conditions)
+		if rule.Operator != "count" {
+			return text
+		}
+		return text + " " + formatComparison(rule.Val, ">=")
+	case operandSize, operandCalendar:
+		return formatField(rule.Field) + " " + rule.Operator + " " + formatComparison(rule.Val, "")
+	}
+	text := formatField(rule.Field) + " " + rule.Operator
+	if rule.Val == nil && (operatorKinds[rule.Operator] == operandNone || operatorKinds[rule.Operator] == operandDate) {
+		return text
+	}
+	return text + " " + formatValue(rule.Val)
+}
+
+// formatJoined writes rules joined by "and" or "or", breaking them over lines when they don't fit on one.
+func formatJoined(keyword string, level int, rules []Rule, depth int) string {
+	if len(rules) == 0 {
+		return "()"
+	}
+	rules = flatten(strings.ToUpper(keyword), rules)
+	// Operands are indented one level deeper, so their own lines stand out once these are broken
+	parts := make([]string, len(rules))
+	multiline := false
+	for i, rule := range rules {
+		parts[i] = formatOperand(rule, level, depth+1)
+		multiline = multiline || strings.Contains(parts[i], "\n")
+	}
+	text := strings.Join(parts, " "+keyword+" ")
+	if !multiline && len(text)+2*depth <= formatWidth {
+		return text
+	}
+	indent := "\n" + strings.Repeat("  ", depth)
+	return strings.Join(parts, indent+keyword+" ")
+}
+
+// flatten lifts the rules of nested combinations with the same logic, as "a and (b and c)" is written "a and b and c".
+func flatten(logic string, rules []Rule) []Rule {
+	var flat []Rule
+	for _, rule := range rules {
+		if rule.Logic == logic && rule.Timezone == "" && len(rule.Rules) > 0 {
+			flat = append(flat, flatten(logic, rule.Rules)...)
+		} else {
+			flat = append(flat, rule)
+		}
+	}
+	return flat
+}
+
+// formatOperand writes a rule within a form of the given precedence, in parentheses when it binds looser.
+func formatOperand(rule Rule, level int, depth int) string {
+	if precedence(rule) >= level {
+		return formatRule(rule, depth)
+	}
+	inner := formatRule(rule, depth)
+	if !strings.Contains(inner, "\n") {
+		return "(" + inner + ")"
+	}
+	return "(\n" + strings.Repeat("  ", depth) + inner + "\n" + strings.Repeat("  ", depth-1) + ")"
+}
+
+// formatComparison writes the value of size, count and calendar operators, e.g. ">= 2" or "between [18, 22]". Plain
+// values are written with the default operator when there is one.
+func formatComparison(value interface{}, defaultOperator string) string {
+	if comparison, ok := value.(map[string]interface{}); ok && len(comparison) == 1 {
+		for operator, operand := range comparison {
+			return operator + " " + formatValue(operand)
+		}
+	}
+	if defaultOperator != "" {
+		return defaultOperator + " " + formatValue(value)
+	}
+	return formatValue(value)
+}
+
+var fieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z0-9_]+)*$`)
+
+// formatField writes a field as a path, "_" for the element of an array, or in backquotes when it is not a plain path.
+func formatField(field string) string {
+	if field == "" {
+		return "_"
+	}
+	if _, isAggregate, err := parseAggregateField(field); isAggregate && err == nil {
+		return field
+	}
+	switch strings.ToLower(field) {
+	case "_", "and", "or", "not", "true", "false", "null":
+		return "`" + field + "`"
+	}
+	if fieldPattern.MatchString(field) {
+		return field
+	}
+	return "`" + field + "`"
+}
+
+// formatValue writes a value as JSON, with the keys of objects sorted.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = formatValue(key) + ": " + formatValue(v[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	if list, ok := toSlice(value); ok {
+		return formatValue(list)
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%q", fmt.Sprint(value))
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
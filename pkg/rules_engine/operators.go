@@ -6,33 +6,101 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 )
 
-// evaluateOperator handles all comparisons: numeric, string, and date, based on value types.
-func evaluateOperator(operator string, fieldValue, ruleValue interface{}) (bool, error) {
+// evaluateOperator handles all comparisons: numeric, string, date, boolean, null and array, based on value types.
+// Dates without an offset are read in the given location.
+func evaluateOperator(operator string, fieldValue, ruleValue interface{}, location *time.Location) (bool, error) {
+	if fieldValue == nil {
+		return evaluateNull(operator, ruleValue)
+	}
+	if ruleValue == nil && (operator == "==" || operator == "!=") {
+		return operator == "!=", nil
+	}
+	if array, ok := toSlice(fieldValue); ok {
+		return evaluateArrayOperator(operator, array, ruleValue)
+	}
 	switch fieldVal := fieldValue.(type) {
 	case string:
-		if utils.IsDate(fieldValue) || utils.IsDate(ruleValue) {
-			return evaluateDateOperator(operator, fieldVal, ruleValue)
-		}
-		return evaluateString(operator, fieldVal, ruleValue.(string))
-	case float64:
-		return evaluateNumeric(operator, fieldVal, utils.ToFloat64(ruleValue))
-	case int, int64, int32:
-		return evaluateNumeric(operator, float64(reflect.ValueOf(fieldValue).Int()), utils.ToFloat64(ruleValue))
+		if isDateOperator(operator) && (utils.IsDate(fieldValue) || utils.IsDate(ruleValue)) {
+			return evaluateDateOperator(operator, fieldVal, ruleValue, location)
+		}
+		return evaluateString(operator, fieldVal, ruleValue)
+	case bool:
+		return evaluateBool(operator, fieldVal, ruleValue)
 	default:
+		if number, ok := utils.AsFloat64(fieldValue); ok {
+			return evaluateNumeric(operator, number, ruleValue)
+		}
 		return false, fmt.Errorf("unsupported value type for comparison: %v", fieldValue)
 	}
 }
 
+// isDateOperator reports whether the operator can compare dates.
+func isDateOperator(operator string) bool {
+	switch operator {
+	case "==", "!=", ">", "<", ">=", "<=", "between":
+		return true
+	default:
+		return false
+	}
+}
+
+// evaluateNull handles comparisons against a field holding null. Only equality operators match a null field.
+func evaluateNull(operator string, ruleValue interface{}) (bool, error) {
+	switch operator {
+	case "==":
+		return ruleValue == nil, nil
+	case "!=":
+		return ruleValue != nil, nil
+	case "notin", "inotin":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// evaluateBool handles boolean comparisons for "==" and "!=".
+func evaluateBool(operator string, fieldValue bool, ruleValue interface{}) (bool, error) {
+	ruleBool, ok := ruleValue.(bool)
+	if !ok {
+		return false, fmt.Errorf("rule value for boolean field must be a boolean, got %v", ruleValue)
+	}
+	switch operator {
+	case "==":
+		return fieldValue == ruleBool, nil
+	case "!=":
+		return fieldValue != ruleBool, nil
+	default:
+		return false, fmt.Errorf("unsupported boolean operator: %s", operator)
+	}
+}
+
 // evaluateDateOperator compares date values using operators like "before", "after", "on".
-func evaluateDateOperator(operator string, fieldValue, ruleValue interface{}) (bool, error) {
-	fieldDate, err := utils.ParseDate(fieldValue)
+func evaluateDateOperator(operator string, fieldValue, ruleValue interface{}, location *time.Location) (bool, error) {
+	fieldDate, err := utils.ParseDateIn(fieldValue, location)
 	if err != nil {
 		return false, fmt.Errorf("field value is not a valid date: %v", err)
 	}
 
-	ruleDate, err := utils.ParseDate(ruleValue)
+	if operator == "between" {
+		bounds, err := ruleRange(ruleValue)
+		if err != nil {
+			return false, err
+		}
+		from, err := utils.ParseDateIn(bounds[0], location)
+		if err != nil {
+			return false, fmt.Errorf("rule value is not a valid date: %v", err)
+		}
+		to, err := utils.ParseDateIn(bounds[1], location)
+		if err != nil {
+			return false, fmt.Errorf("rule value is not a valid date: %v", err)
+		}
+		return !fieldDate.Before(from) && !fieldDate.After(to), nil
+	}
+
+	ruleDate, err := utils.ParseDateIn(ruleValue, location)
 	if err != nil {
 		return false, fmt.Errorf("rule value is not a valid date: %v", err)
 	}
@@ -55,54 +123,231 @@ func evaluateDateOperator(operator string, fieldValue, ruleValue interface{}) (b
 	}
 }
 
-// evaluateString handles string comparisons for "==", "!=", ">", "<", ">=", "<="
-func evaluateString(operator string, fieldValue string, ruleValue string) (bool, error) {
+// evaluateString handles string comparisons. Operators prefixed with "i" compare case-insensitively.
+func evaluateString(operator string, fieldValue string, ruleValue interface{}) (bool, error) {
+	if base, ok := strings.CutPrefix(operator, "i"); ok && caseInsensitiveOperators[base] {
+		return evaluateString(base, strings.ToLower(fieldValue), lowerStrings(ruleValue))
+	}
+
 	switch operator {
-	case "==":
-		return fieldValue == ruleValue, nil
-	case "!=":
-		return fieldValue != ruleValue, nil
-	case "contains":
-		return strings.Contains(fieldValue, ruleValue), nil
-	case "in":
-		values := strings.Split(ruleValue, ",")
-		for _, value := range values {
-			if value == fieldValue {
-				return true, nil
-			}
+	case "in", "notin":
+		values, err := ruleList(ruleValue)
+		if err != nil {
+			return false, err
 		}
-		return false, nil
-	case "notin":
-		values := strings.Split(ruleValue, ",")
+		found := false
 		for _, value := range values {
-			if value == fieldValue {
-				return false, nil
+			if str, ok := value.(string); ok && str == fieldValue {
+				found = true
+				break
 			}
 		}
-		return true, nil
+		return found == (operator == "in"), nil
+	}
+
+	ruleString, ok := ruleValue.(string)
+	if !ok {
+		return false, fmt.Errorf("rule value for string operator %s must be a string, got %v", operator, ruleValue)
+	}
+	switch operator {
+	case "==":
+		return fieldValue == ruleString, nil
+	case "!=":
+		return fieldValue != ruleString, nil
+	case "contains":
+		return strings.Contains(fieldValue, ruleString), nil
+	case "startswith":
+		return strings.HasPrefix(fieldValue, ruleString), nil
+	case "endswith":
+		return strings.HasSuffix(fieldValue, ruleString), nil
 	case "matches":
-		return regexp.MatchString(ruleValue, fieldValue)
+		return regexp.MatchString(ruleString, fieldValue)
 	default:
 		return false, fmt.Errorf("unsupported string operator: %s", operator)
 	}
 }
 
-// evaluateNumeric handles numeric comparisons for "==", "!=", ">", "<", ">=", "<="
-func evaluateNumeric(operator string, fieldValue, ruleValue float64) (bool, error) {
+// caseInsensitiveOperators lists the string operators that have an "i" prefixed case-insensitive variant.
+var caseInsensitiveOperators = map[string]bool{
+	"==":         true,
+	"!=":         true,
+	"contains":   true,
+	"startswith": true,
+	"endswith":   true,
+	"in":         true,
+	"notin":      true,
+}
+
+// lowerStrings lower-cases a string rule value or every string in a list rule value.
+func lowerStrings(value interface{}) interface{} {
+	if str, ok := value.(string); ok {
+		return strings.ToLower(str)
+	}
+	if list, ok := toSlice(value); ok {
+		lowered := make([]interface{}, len(list))
+		for i, item := range list {
+			lowered[i] = lowerStrings(item)
+		}
+		return lowered
+	}
+	return value
+}
+
+// evaluateNumeric handles numeric comparisons for "==", "!=", ">", "<", ">=", "<=", "in", "notin" and "between"
+func evaluateNumeric(operator string, fieldValue float64, ruleValue interface{}) (bool, error) {
+	switch operator {
+	case "in", "notin":
+		values, err := ruleList(ruleValue)
+		if err != nil {
+			return false, err
+		}
+		found := false
+		for _, value := range values {
+			number, ok := utils.ParseFloat64(value)
+			if !ok {
+				return false, fmt.Errorf("rule value for numeric operator %s must contain numbers, got %v", operator, value)
+			}
+			if number == fieldValue {
+				found = true
+				break
+			}
+		}
+		return found == (operator == "in"), nil
+	case "between":
+		bounds, err := ruleRange(ruleValue)
+		if err != nil {
+			return false, err
+		}
+		from, fromOk := utils.AsFloat64(bounds[0])
+		to, toOk := utils.AsFloat64(bounds[1])
+		if !fromOk || !toOk {
+			return false, fmt.Errorf("rule value for between must contain numbers, got %v", ruleValue)
+		}
+		return fieldValue >= from && fieldValue <= to, nil
+	}
+
+	ruleNumber, ok := utils.AsFloat64(ruleValue)
+	if !ok {
+		return false, fmt.Errorf("rule value for numeric operator %s must be a number, got %v", operator, ruleValue)
+	}
 	switch operator {
 	case "==":
-		return fieldValue == ruleValue, nil
+		return fieldValue == ruleNumber, nil
 	case "!=":
-		return fieldValue != ruleValue, nil
+		return fieldValue != ruleNumber, nil
 	case ">":
-		return fieldValue > ruleValue, nil
+		return fieldValue > ruleNumber, nil
 	case "<":
-		return fieldValue < ruleValue, nil
+		return fieldValue < ruleNumber, nil
 	case ">=":
-		return fieldValue >= ruleValue, nil
+		return fieldValue >= ruleNumber, nil
 	case "<=":
-		return fieldValue <= ruleValue, nil
+		return fieldValue <= ruleNumber, nil
 	default:
 		return false, fmt.Errorf("unsupported numeric operator: %s", operator)
 	}
 }
+
+// evaluateArrayOperator handles operators applied to an array of scalars: "contains", "containsAny", "containsAll" and "size".
+func evaluateArrayOperator(operator string, fieldValue []interface{}, ruleValue interface{}) (bool, error) {
+	switch operator {
+	case "contains":
+		return containsValue(fieldValue, ruleValue), nil
+	case "containsAny", "containsAll":
+		values, ok := toSlice(ruleValue)
+		if !ok {
+			return false, fmt.Errorf("rule value for %s must be an array, got %v", operator, ruleValue)
+		}
+		matchAll := operator == "containsAll"
+		for _, value := range values {
+			if containsValue(fieldValue, value) != matchAll {
+				return !matchAll, nil
+			}
+		}
+		return matchAll, nil
+	case "size":
+		// The rule value is either the exact size or a single comparison such as {">=": 2}
+		if comparison, ok := ruleValue.(map[string]interface{}); ok {
+			if len(comparison) != 1 {
+				return false, fmt.Errorf("rule value for size must hold exactly one comparison, got %v", ruleValue)
+			}
+			for sizeOperator, size := range comparison {
+				return evaluateNumeric(sizeOperator, float64(len(fieldValue)), size)
+			}
+		}
+		return evaluateNumeric("==", float64(len(fieldValue)), ruleValue)
+	default:
+		return false, fmt.Errorf("unsupported array operator: %s", operator)
+	}
+}
+
+// containsValue reports whether the array holds a scalar equal to the value. Numbers compare by value regardless of type.
+func containsValue(array []interface{}, value interface{}) bool {
+	for _, element := range array {
+		if scalarEqual(element, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func scalarEqual(a, b interface{}) bool {
+	if x, ok := utils.AsFloat64(a); ok {
+		y, ok := utils.AsFloat64(b)
+		return ok && x == y
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case nil:
+		return b == nil
+	default:
+		return false
+	}
+}
+
+// ruleList returns the members of an "in"/"notin" rule value. Comma separated strings are still accepted, with the
+// spaces around their members trimmed so "gold, platinum" lists "platinum" rather than " platinum".
+func ruleList(ruleValue interface{}) ([]interface{}, error) {
+	if str, ok := ruleValue.(string); ok {
+		parts := strings.Split(str, ",")
+		values := make([]interface{}, len(parts))
+		for i, part := range parts {
+			values[i] = strings.TrimSpace(part)
+		}
+		return values, nil
+	}
+	if values, ok := toSlice(ruleValue); ok {
+		return values, nil
+	}
+	return nil, fmt.Errorf("rule value must be an array or a comma separated string, got %v", ruleValue)
+}
+
+// ruleRange returns the [min, max] bounds of a "between" rule value.
+func ruleRange(ruleValue interface{}) ([]interface{}, error) {
+	bounds, ok := toSlice(ruleValue)
+	if !ok || len(bounds) != 2 {
+		return nil, fmt.Errorf("rule value for between must be an array of two values, got %v", ruleValue)
+	}
+	return bounds, nil
+}
+
+// toSlice converts any Go slice or array into []interface{} so rules built in code ([]string, []int) behave like decoded JSON.
+func toSlice(value interface{}) ([]interface{}, bool) {
+	if values, ok := value.([]interface{}); ok {
+		return values, true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
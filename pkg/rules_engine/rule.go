@@ -13,6 +13,7 @@ type Rule struct {
 	Val      interface{} `json:"value,omitempty"`    // Val to compare the field with
 	Logic    string      `json:"logic,omitempty"`    // Logical operator for combining rules ("AND", "OR", "NOT")
 	Rules    []Rule      `json:"rules,omitempty"`    // Nested rules (used with logic operators)
+	Timezone string      `json:"timezone,omitempty"` // IANA timezone dates of the rule and its nested rules are evaluated in (e.g. "Asia/Dubai")
 }
 
 // Value Marshal
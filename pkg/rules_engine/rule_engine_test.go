@@ -58,6 +58,13 @@ func TestEvaluateStringIn(t *testing.T) {
 
 	data = `{"status": "archived"}`
 	testEvaluateRule(t, rule, data, false)
+
+	// The members of a comma separated list are trimmed
+	rule.Val = "pending, approved , rejected"
+	testEvaluateRule(t, rule, `{"status": "approved"}`, true)
+	testEvaluateRule(t, rule, `{"status": "rejected"}`, true)
+	testEvaluateRule(t, rule, `{"status": " approved"}`, false)
+	testEvaluateRule(t, Rule{Field: "status", Operator: "notin", Val: "pending, approved"}, `{"status": "approved"}`, false)
 }
 
 func TestEvaluateStringNotIn(t *testing.T) {
@@ -261,16 +268,134 @@ func TestEvaluateArrayMultipleRulesAny(t *testing.T) {
 		},
 	}
 
-	data := `{"family": [{"name": "John", "age": 15}, {"name": "Jane", "age": 25}]}`
-	testEvaluateRule(t, rule, data, true) // One rule (name == "John") is satisfied
+	data := `{"family": [{"name": "John", "age": 15}, {"name": "John", "age": 25}]}`
+	testEvaluateRule(t, rule, data, true) // The second element satisfies both rules
 
-	data = `{"family": [{"name": "John", "age": 15}, {"name": "Jane", "age": 17}]}`
-	testEvaluateRule(t, rule, data, true) // One rule (name == "John") is satisfied
+	data = `{"family": [{"name": "John", "age": 15}, {"name": "Jane", "age": 25}]}`
+	testEvaluateRule(t, rule, data, false) // Each rule is satisfied, but by different elements
 
 	data = `{"family": [{"name": "Doe", "age": 15}, {"name": "Jane", "age": 17}]}`
 	testEvaluateRule(t, rule, data, false) // No rule is satisfied
 }
 
+func TestEvaluateArrayNone(t *testing.T) {
+	rule := Rule{
+		Field:    "items",
+		Operator: "none",
+		Rules: []Rule{
+			{Field: "category", Operator: "==", Val: "alcohol"},
+			{Field: "price", Operator: ">", Val: 50},
+		},
+	}
+	testEvaluateRule(t, rule, `{"items": [{"category": "alcohol", "price": 20}, {"category": "food", "price": 80}]}`, true)
+	testEvaluateRule(t, rule, `{"items": [{"category": "alcohol", "price": 80}]}`, false)
+	testEvaluateRule(t, rule, `{"items": []}`, true)
+}
+
+func TestEvaluateArrayCount(t *testing.T) {
+	rule := Rule{
+		Field:    "items",
+		Operator: "count",
+		Val:      2,
+		Rules:    []Rule{{Field: "price", Operator: ">", Val: 50}},
+	}
+	testEvaluateRule(t, rule, `{"items": [{"price": 60}, {"price": 20}, {"price": 90}]}`, true)
+	testEvaluateRule(t, rule, `{"items": [{"price": 60}, {"price": 20}]}`, false)
+
+	rule.Val = map[string]interface{}{"==": 1}
+	testEvaluateRule(t, rule, `{"items": [{"price": 60}, {"price": 20}]}`, true)
+	testEvaluateRule(t, rule, `{"items": [{"price": 60}, {"price": 90}]}`, false)
+}
+
+func TestEvaluateArrayOfScalars(t *testing.T) {
+	rule := Rule{
+		Field:    "tags",
+		Operator: "any",
+		Rules:    []Rule{{Operator: "istartswith", Val: "vip"}},
+	}
+	testEvaluateRule(t, rule, `{"tags": ["new", "VIP-gold"]}`, true)
+	testEvaluateRule(t, rule, `{"tags": ["new", "regular"]}`, false)
+
+	rule = Rule{
+		Field:    "scores",
+		Operator: "all",
+		Rules: []Rule{{
+			Logic: "OR",
+			Rules: []Rule{
+				{Operator: ">=", Val: 90},
+				{Operator: "==", Val: 0},
+			},
+		}},
+	}
+	testEvaluateRule(t, rule, `{"scores": [95, 0, 90]}`, true)
+	testEvaluateRule(t, rule, `{"scores": [95, 50]}`, false)
+}
+
+func TestEvaluateNestedQuantifiers(t *testing.T) {
+	// Any order that has an electronics item worth more than 100
+	rule := Rule{
+		Field:    "orders",
+		Operator: "any",
+		Rules: []Rule{{
+			Field:    "items",
+			Operator: "any",
+			Rules: []Rule{
+				{Field: "category", Operator: "==", Val: "electronics"},
+				{Field: "price", Operator: ">", Val: 100},
+			},
+		}},
+	}
+	data := `{"orders": [
+		{"items": [{"category": "books", "price": 150}]},
+		{"items": [{"category": "electronics", "price": 50}, {"category": "electronics", "price": 300}]}
+	]}`
+	testEvaluateRule(t, rule, data, true)
+
+	data = `{"orders": [
+		{"items": [{"category": "books", "price": 150}, {"category": "electronics", "price": 50}]},
+		{"items": []}
+	]}`
+	testEvaluateRule(t, rule, data, false)
+}
+
+func TestEvaluateQuantifiersOnEmptyArrays(t *testing.T) {
+	matchAdult := []Rule{{Field: "age", Operator: ">", Val: 18}}
+	for operator, expected := range map[string]bool{"any": false, "all": true, "none": true} {
+		rule := Rule{Field: "family", Operator: operator, Rules: matchAdult}
+		testEvaluateRule(t, rule, `{"family": []}`, expected)
+		testEvaluateRule(t, rule, `{"family": null}`, expected)
+	}
+
+	testEvaluateRuleError(t, Rule{Field: "family", Operator: "any", Rules: matchAdult}, `{"family": "John"}`)
+}
+
+func TestEvaluateQuantifiersOnMixedElements(t *testing.T) {
+	// The element without an age does not match, it does not fail the quantifier
+	matchAdult := []Rule{{Field: "age", Operator: ">", Val: 18}}
+	data := `{"family": [{"name": "John"}, {"name": "Jane", "age": 25}]}`
+	testEvaluateRule(t, Rule{Field: "family", Operator: "any", Rules: matchAdult}, data, true)
+	testEvaluateRule(t, Rule{Field: "family", Operator: "all", Rules: matchAdult}, data, false)
+	testEvaluateRule(t, Rule{Field: "family", Operator: "none", Rules: matchAdult}, data, false)
+	testEvaluateRule(t, Rule{Field: "family", Operator: "count", Val: 1, Rules: matchAdult}, data, true)
+	testEvaluateRule(t, Rule{Field: "family", Operator: "count", Val: map[string]interface{}{"==": 2}, Rules: matchAdult}, data, false)
+
+	data = `{"family": [{"name": "John"}, {"name": "Jane", "age": 12}]}`
+	testEvaluateRule(t, Rule{Field: "family", Operator: "any", Rules: matchAdult}, data, false)
+	testEvaluateRule(t, Rule{Field: "family", Operator: "none", Rules: matchAdult}, data, true)
+	testEvaluateRule(t, Rule{Field: "family", Operator: "count", Val: map[string]interface{}{"==": 0}, Rules: matchAdult}, data, true)
+
+	// An element missing the field of a nested rule does not match either
+	rule := Rule{Field: "items", Operator: "all", Rules: []Rule{{Logic: "AND", Rules: []Rule{
+		{Field: "price", Operator: ">", Val: 0},
+		{Field: "category", Operator: "!=", Val: "alcohol"},
+	}}}}
+	testEvaluateRule(t, rule, `{"items": [{"price": 5, "category": "food"}, {"price": 8}]}`, false)
+	testEvaluateRule(t, rule, `{"items": [{"price": 5, "category": "food"}, {"price": 8, "category": "drinks"}]}`, true)
+
+	// The array itself missing is still an error
+	testEvaluateRuleError(t, Rule{Field: "family", Operator: "any", Rules: matchAdult}, `{}`)
+}
+
 func TestEvaluateArrayMultipleRulesAll(t *testing.T) {
 	rule := Rule{
 		Field:    "family",
@@ -509,3 +634,141 @@ func TestComplexDateAndStringOperations(t *testing.T) {
 	}`
 	testEvaluateRule(t, rule, data, false) // Fails "status in" rule
 }
+
+// Utility function to assert that a rule is rejected with an error instead of panicking
+func testEvaluateRuleError(t *testing.T, rule Rule, dataJson string) {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(dataJson), &data)
+	if _, err := EvaluateRule(rule, data); err == nil {
+		t.Fatalf("Expected an error for rule: %+v and data: %s", rule, dataJson)
+	}
+}
+
+func TestEvaluateExistsAndNull(t *testing.T) {
+	rule := Rule{Field: "user.email", Operator: "exists"}
+	testEvaluateRule(t, rule, `{"user": {"email": "john@example.com"}}`, true)
+	testEvaluateRule(t, rule, `{"user": {"email": null}}`, true)
+	testEvaluateRule(t, rule, `{"user": {}}`, false)
+	testEvaluateRule(t, rule, `{}`, false)
+
+	rule = Rule{Field: "user.email", Operator: "notexists"}
+	testEvaluateRule(t, rule, `{"user": {}}`, true)
+	testEvaluateRule(t, rule, `{"user": {"email": "john@example.com"}}`, false)
+
+	rule = Rule{Field: "user.email", Operator: "isnull"}
+	testEvaluateRule(t, rule, `{"user": {"email": null}}`, true)
+	testEvaluateRule(t, rule, `{"user": {}}`, true)
+	testEvaluateRule(t, rule, `{"user": {"email": "john@example.com"}}`, false)
+}
+
+func TestEvaluateNullField(t *testing.T) {
+	testEvaluateRule(t, Rule{Field: "email", Operator: "==", Val: nil}, `{"email": null}`, true)
+	testEvaluateRule(t, Rule{Field: "email", Operator: "!=", Val: "john@example.com"}, `{"email": null}`, true)
+	testEvaluateRule(t, Rule{Field: "age", Operator: ">", Val: 18}, `{"age": null}`, false)
+	testEvaluateRule(t, Rule{Field: "email", Operator: "==", Val: nil}, `{"email": "john@example.com"}`, false)
+}
+
+func TestEvaluateMissingFieldReturnsError(t *testing.T) {
+	testEvaluateRuleError(t, Rule{Field: "user.age", Operator: ">", Val: 18}, `{"user": {}}`)
+}
+
+func TestEvaluateBooleanEquality(t *testing.T) {
+	rule := Rule{Field: "user.verified", Operator: "==", Val: true}
+	testEvaluateRule(t, rule, `{"user": {"verified": true}}`, true)
+	testEvaluateRule(t, rule, `{"user": {"verified": false}}`, false)
+
+	rule = Rule{Field: "user.verified", Operator: "!=", Val: true}
+	testEvaluateRule(t, rule, `{"user": {"verified": false}}`, true)
+
+	testEvaluateRuleError(t, Rule{Field: "user.verified", Operator: "==", Val: "true"}, `{"user": {"verified": true}}`)
+	testEvaluateRuleError(t, Rule{Field: "user.verified", Operator: ">", Val: true}, `{"user": {"verified": true}}`)
+}
+
+func TestEvaluateStringInArray(t *testing.T) {
+	rule := Rule{Field: "status", Operator: "in", Val: []interface{}{"pending", "approved"}}
+	testEvaluateRule(t, rule, `{"status": "approved"}`, true)
+	testEvaluateRule(t, rule, `{"status": "archived"}`, false)
+
+	rule = Rule{Field: "status", Operator: "notin", Val: []string{"pending", "approved"}}
+	testEvaluateRule(t, rule, `{"status": "archived"}`, true)
+	testEvaluateRule(t, rule, `{"status": "pending"}`, false)
+}
+
+func TestEvaluateStringPrefixAndSuffix(t *testing.T) {
+	testEvaluateRule(t, Rule{Field: "sku", Operator: "startswith", Val: "GIFT-"}, `{"sku": "GIFT-100"}`, true)
+	testEvaluateRule(t, Rule{Field: "sku", Operator: "startswith", Val: "GIFT-"}, `{"sku": "CARD-100"}`, false)
+	testEvaluateRule(t, Rule{Field: "email", Operator: "endswith", Val: "@example.com"}, `{"email": "john@example.com"}`, true)
+}
+
+func TestEvaluateCaseInsensitiveString(t *testing.T) {
+	testEvaluateRule(t, Rule{Field: "country", Operator: "i==", Val: "egypt"}, `{"country": "Egypt"}`, true)
+	testEvaluateRule(t, Rule{Field: "country", Operator: "i!=", Val: "EGYPT"}, `{"country": "Egypt"}`, false)
+	testEvaluateRule(t, Rule{Field: "description", Operator: "icontains", Val: "WORLD"}, `{"description": "Hello world"}`, true)
+	testEvaluateRule(t, Rule{Field: "sku", Operator: "istartswith", Val: "gift-"}, `{"sku": "GIFT-100"}`, true)
+	testEvaluateRule(t, Rule{Field: "email", Operator: "iendswith", Val: "@EXAMPLE.COM"}, `{"email": "john@example.com"}`, true)
+	testEvaluateRule(t, Rule{Field: "status", Operator: "iin", Val: []interface{}{"PENDING", "APPROVED"}}, `{"status": "approved"}`, true)
+	testEvaluateRule(t, Rule{Field: "status", Operator: "inotin", Val: "PENDING,APPROVED"}, `{"status": "approved"}`, false)
+}
+
+func TestEvaluateStringOperatorWithInvalidRuleValue(t *testing.T) {
+	testEvaluateRuleError(t, Rule{Field: "name", Operator: "==", Val: 10}, `{"name": "John"}`)
+	testEvaluateRuleError(t, Rule{Field: "name", Operator: "contains", Val: true}, `{"name": "John"}`)
+	testEvaluateRuleError(t, Rule{Field: "name", Operator: "in", Val: 10}, `{"name": "John"}`)
+	testEvaluateRuleError(t, Rule{Field: "name", Operator: "matches", Val: "("}, `{"name": "John"}`)
+}
+
+func TestEvaluateNumericInAndBetween(t *testing.T) {
+	rule := Rule{Field: "tier", Operator: "in", Val: []interface{}{1, 2, 3}}
+	testEvaluateRule(t, rule, `{"tier": 2}`, true)
+	testEvaluateRule(t, rule, `{"tier": 4}`, false)
+
+	rule = Rule{Field: "tier", Operator: "notin", Val: "1,2,3"}
+	testEvaluateRule(t, rule, `{"tier": 4}`, true)
+
+	rule = Rule{Field: "amount", Operator: "between", Val: []interface{}{10, 100}}
+	testEvaluateRule(t, rule, `{"amount": 10}`, true)
+	testEvaluateRule(t, rule, `{"amount": 55.5}`, true)
+	testEvaluateRule(t, rule, `{"amount": 100.01}`, false)
+
+	testEvaluateRuleError(t, Rule{Field: "amount", Operator: "between", Val: []interface{}{10}}, `{"amount": 10}`)
+	testEvaluateRuleError(t, Rule{Field: "amount", Operator: ">", Val: "ten"}, `{"amount": 10}`)
+	testEvaluateRuleError(t, Rule{Field: "tier", Operator: "in", Val: []interface{}{"gold"}}, `{"tier": 1}`)
+}
+
+func TestEvaluateDateBetween(t *testing.T) {
+	rule := Rule{Field: "created_at", Operator: "between", Val: []interface{}{"2024-01-01", "2024-12-31"}}
+	testEvaluateRule(t, rule, `{"created_at": "2024-06-15"}`, true)
+	testEvaluateRule(t, rule, `{"created_at": "2025-01-01"}`, false)
+}
+
+func TestEvaluateScalarArrayOperators(t *testing.T) {
+	rule := Rule{Field: "tags", Operator: "contains", Val: "vip"}
+	testEvaluateRule(t, rule, `{"tags": ["new", "vip"]}`, true)
+	testEvaluateRule(t, rule, `{"tags": ["new"]}`, false)
+
+	rule = Rule{Field: "categories", Operator: "contains", Val: 3}
+	testEvaluateRule(t, rule, `{"categories": [1, 3, 5]}`, true)
+
+	rule = Rule{Field: "tags", Operator: "containsAny", Val: []string{"vip", "staff"}}
+	testEvaluateRule(t, rule, `{"tags": ["new", "staff"]}`, true)
+	testEvaluateRule(t, rule, `{"tags": ["new"]}`, false)
+
+	rule = Rule{Field: "tags", Operator: "containsAll", Val: []interface{}{"vip", "staff"}}
+	testEvaluateRule(t, rule, `{"tags": ["vip", "new", "staff"]}`, true)
+	testEvaluateRule(t, rule, `{"tags": ["vip", "new"]}`, false)
+
+	testEvaluateRuleError(t, Rule{Field: "tags", Operator: "containsAny", Val: 5}, `{"tags": ["vip"]}`)
+	testEvaluateRuleError(t, Rule{Field: "tags", Operator: ">", Val: 1}, `{"tags": ["vip"]}`)
+}
+
+func TestEvaluateArraySize(t *testing.T) {
+	rule := Rule{Field: "items", Operator: "size", Val: 2}
+	testEvaluateRule(t, rule, `{"items": ["a", "b"]}`, true)
+	testEvaluateRule(t, rule, `{"items": ["a"]}`, false)
+
+	rule = Rule{Field: "items", Operator: "size", Val: map[string]interface{}{">=": 3}}
+	testEvaluateRule(t, rule, `{"items": ["a", "b", "c"]}`, true)
+	testEvaluateRule(t, rule, `{"items": []}`, false)
+
+	testEvaluateRuleError(t, Rule{Field: "items", Operator: "size", Val: "two"}, `{"items": ["a", "b"]}`)
+}
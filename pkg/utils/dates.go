@@ -9,22 +9,38 @@ func GetDateAfter(interval time.Duration) time.Time {
 	return time.Now().Add(interval)
 }
 
+// dateLayouts are the supported date formats. Layouts carrying an offset keep it, the others are read in the location given to ParseDateIn.
+var dateLayouts = []string{
+	time.RFC3339Nano,            // "2006-01-02T15:04:05.999999999Z07:00"
+	"2006-01-02T15:04:05",       // "2006-01-02T15:04:05"
+	"2006-01-02T15:04Z07:00",    // "2006-01-02T15:04+04:00"
+	"2006-01-02T15:04",          // "2006-01-02T15:04"
+	"2006-01-02 15:04:05Z07:00", // "2006-01-02 15:04:05+04:00"
+	"2006-01-02 15:04:05",       // "2006-01-02 15:04:05"
+	"2006-01-02 15:04Z07:00",    // "2006-01-02 15:04+04:00"
+	"2006-01-02 15:04",          // "2006-01-02 15:04"
+	"2006-01-02",                // "2006-01-02"
+	"02-01-2006",                // "02-01-2006" (European format)
+}
+
 // ParseDate converts a date string into a time.Time object. Supports multiple date formats.
+// Dates without an offset are read as UTC.
 func ParseDate(value interface{}) (time.Time, error) {
+	return ParseDateIn(value, time.UTC)
+}
+
+// ParseDateIn converts a date string into a time.Time object, reading dates without an offset in the given location.
+func ParseDateIn(value interface{}, location *time.Location) (time.Time, error) {
+	if date, ok := value.(time.Time); ok {
+		return date, nil
+	}
 	strValue, ok := value.(string)
 	if !ok {
 		return time.Time{}, errors.New("invalid date format")
 	}
 
-	layouts := []string{
-		time.RFC3339,       // "2006-01-02T15:04:05Z07:00"
-		"2006-01-02",       // "2006-01-02"
-		"2006-01-02 15:04", // "2006-01-02 15:04"
-		"02-01-2006",       // "02-01-2006" (European format)
-	}
-
-	for _, layout := range layouts {
-		parsed, err := time.Parse(layout, strValue)
+	for _, layout := range dateLayouts {
+		parsed, err := time.ParseInLocation(layout, strValue, location)
 		if err == nil {
 			return parsed, nil
 		}
@@ -20,15 +20,26 @@ func Coalesce[T Nullable](options ...T) T {
 }
 
 // GetField extracts the value from a nested field in the JSON-like map using dot notation.
+// The boolean result is false when any part of the path is missing; an explicit null is reported as found.
 func GetField(data map[string]interface{}, field string) (interface{}, bool) {
 	parts := strings.Split(field, ".")
 	var current interface{} = data
 	for _, part := range parts {
-		if objMap, ok := current.(map[string]interface{}); ok {
-			current = objMap[part]
-		} else {
+		objMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = objMap[part]; !ok {
 			return nil, false
 		}
 	}
 	return current, true
 }
+
+// EqualPtr reports whether two optional values are both unset or both set to the same value
+func EqualPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -1,16 +1,52 @@
 package utils
 
+import "strconv"
+
 func ToFloat64(value interface{}) float64 {
+	f, _ := AsFloat64(value)
+	return f
+}
+
+// AsFloat64 converts any Go numeric type into a float64. The boolean result is false when the value is not a number.
+func AsFloat64(value interface{}) (float64, bool) {
 	switch v := value.(type) {
 	case float64:
-		return v
+		return v, true
+	case float32:
+		return float64(v), true
 	case int:
-		return float64(v)
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
 	case int64:
-		return float64(v)
-	case float32:
-		return float64(v)
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
 	default:
-		return 0
+		return 0, false
+	}
+}
+
+// ParseFloat64 converts a number or a numeric string into a float64.
+func ParseFloat64(value interface{}) (float64, bool) {
+	if f, ok := AsFloat64(value); ok {
+		return f, true
+	}
+	if s, ok := value.(string); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
 	}
+	return 0, false
 }